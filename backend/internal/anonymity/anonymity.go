@@ -0,0 +1,87 @@
+// Package anonymity implements the timing protections applied to group posts:
+// randomized posting delays, fixed posting windows and timestamp rounding.
+package anonymity
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Window is a daily posting time, in minutes after midnight.
+type Window int
+
+// ParseWindows parses a comma-separated list of "HH:MM" times.
+// An empty string means no windows (post as soon as the delay allows).
+func ParseWindows(s string) ([]Window, error) {
+	var windows []Window
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		t, err := time.Parse("15:04", part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid posting window %q, expected HH:MM", part)
+		}
+		windows = append(windows, Window(t.Hour()*60+t.Minute()))
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i] < windows[j] })
+	return windows, nil
+}
+
+// RandomDelay returns a uniformly random delay between min and max minutes.
+func RandomDelay(minMinutes, maxMinutes int) time.Duration {
+	if maxMinutes <= minMinutes {
+		return time.Duration(minMinutes) * time.Minute
+	}
+	span := time.Duration(maxMinutes-minMinutes) * time.Minute
+	return time.Duration(minMinutes)*time.Minute + time.Duration(rand.Int63n(int64(span)+1))
+}
+
+// NextWindow returns the first window time at or after t in loc.
+// With no windows it returns t unchanged.
+func NextWindow(t time.Time, windows []Window, loc *time.Location) time.Time {
+	if len(windows) == 0 {
+		return t
+	}
+	local := t.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	for day := 0; day < 2; day++ {
+		base := midnight.AddDate(0, 0, day)
+		for _, w := range windows {
+			at := base.Add(time.Duration(w) * time.Minute)
+			if !at.Before(t) {
+				return at
+			}
+		}
+	}
+	// Unreachable with at least one window, kept for safety
+	return t
+}
+
+// Round truncates t to a multiple of minutes in loc, so that e.g. rounding
+// to 1440 minutes yields local midnight. Zero minutes returns t unchanged.
+func Round(t time.Time, minutes int, loc *time.Location) time.Time {
+	if minutes <= 0 || t.IsZero() {
+		return t
+	}
+	local := t.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	step := time.Duration(minutes) * time.Minute
+	return midnight.Add(local.Sub(midnight) / step * step)
+}
+
+// Location loads a tenant timezone, falling back to UTC if it is unknown.
+func Location(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,54 @@
+package anonymity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWindows(t *testing.T) {
+	w, err := ParseWindows("17:00, 09:30")
+	assert.NoError(t, err)
+	assert.Equal(t, []Window{9*60 + 30, 17 * 60}, w)
+
+	w, err = ParseWindows("")
+	assert.NoError(t, err)
+	assert.Empty(t, w)
+
+	_, err = ParseWindows("25:00")
+	assert.Error(t, err)
+}
+
+func TestNextWindow(t *testing.T) {
+	loc := time.FixedZone("UTC+5", 5*3600)
+	windows, _ := ParseWindows("09:00,17:00")
+
+	at := time.Date(2026, 3, 2, 10, 15, 0, 0, loc)
+	assert.Equal(t, time.Date(2026, 3, 2, 17, 0, 0, 0, loc), NextWindow(at, windows, loc))
+
+	late := time.Date(2026, 3, 2, 18, 0, 0, 0, loc)
+	assert.Equal(t, time.Date(2026, 3, 3, 9, 0, 0, 0, loc), NextWindow(late, windows, loc))
+
+	exact := time.Date(2026, 3, 2, 9, 0, 0, 0, loc)
+	assert.Equal(t, exact, NextWindow(exact, windows, loc))
+
+	assert.Equal(t, at, NextWindow(at, nil, loc))
+}
+
+func TestRandomDelay(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := RandomDelay(5, 10)
+		assert.GreaterOrEqual(t, d, 5*time.Minute)
+		assert.LessOrEqual(t, d, 10*time.Minute)
+	}
+	assert.Equal(t, 3*time.Minute, RandomDelay(3, 0))
+}
+
+func TestRound(t *testing.T) {
+	loc := time.FixedZone("UTC+5", 5*3600)
+	at := time.Date(2026, 3, 2, 10, 47, 31, 0, loc)
+	assert.Equal(t, time.Date(2026, 3, 2, 10, 30, 0, 0, loc), Round(at, 30, loc))
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, loc), Round(at, 1440, loc))
+	assert.Equal(t, at, Round(at, 0, loc))
+}
@@ -1,6 +1,10 @@
 package config
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/Lavina-Tech-LLC/lavinagopackage/v2/conf"
 )
 
@@ -8,8 +12,11 @@ var Confs Conf
 
 type (
 	Conf struct {
-		DB       gormDB
-		Settings Settings
+		DB        gormDB
+		Settings  Settings
+		Identity  Identity
+		BotTokens BotTokens
+		Mail      Mail
 	}
 
 	gormDB struct {
@@ -23,9 +30,66 @@ type (
 	Settings struct {
 		SrvAddress string
 		JWTSecret  string
+		DataDir    string // Generated files such as exports; defaults to "data"
+	}
+
+	// Identity holds the sender pseudonym keys as "<version>:<secret>".
+	// They must never be stored in the database.
+	Identity struct {
+		KeyFile string   // One key per line
+		Keys    []string // Inline alternative to KeyFile
+	}
+
+	// BotTokens holds the master keys that wrap bot token data keys, in the
+	// same "<version>:<secret>" form as Identity.
+	BotTokens struct {
+		MasterKeyFile string
+		MasterKeys    []string
+	}
+
+	// Mail configures outgoing email such as scheduled reports. With no
+	// Driver, messages are only logged.
+	Mail struct {
+		Driver   string // "smtp" or "log"
+		Host     string
+		Port     string
+		Username string
+		Password string
+		From     string
 	}
 )
 
 func Init() {
 	Confs = conf.Get[Conf]("conf/")
 }
+
+// DataDir returns the directory for generated files.
+func DataDir() string {
+	if Confs.Settings.DataDir == "" {
+		return "data"
+	}
+	return Confs.Settings.DataDir
+}
+
+// IdentityKeys returns the configured pseudonym keys from the key file and
+// the inline list combined.
+func IdentityKeys() ([]string, error) {
+	return readKeys(Confs.Identity.Keys, Confs.Identity.KeyFile, "identity.keyfile")
+}
+
+// BotTokenKeys returns the configured bot token master keys.
+func BotTokenKeys() ([]string, error) {
+	return readKeys(Confs.BotTokens.MasterKeys, Confs.BotTokens.MasterKeyFile, "bottokens.masterkeyfile")
+}
+
+func readKeys(inline []string, file, setting string) ([]string, error) {
+	keys := append([]string{}, inline...)
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("config: %s: %w", setting, err)
+		}
+		keys = append(keys, strings.Split(string(data), "\n")...)
+	}
+	return keys, nil
+}
@@ -0,0 +1,152 @@
+// Package cron parses standard five-field cron expressions
+// ("minute hour day-of-month month day-of-week") and finds their next run.
+//
+// Fields take *, numbers, ranges (1-5), lists (1,3,5) and steps (*/15,
+// 9-17/2). Day of week runs 0-6 from Sunday, and 7 is Sunday too. As in
+// classic cron, when both day fields are restricted a day matching either one
+// runs. The shorthands @hourly, @daily, @weekly and @monthly are accepted.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // Bit n set when value n matches
+	domAny, dowAny                bool
+}
+
+var shorthands = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Parse parses expr.
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if s, ok := shorthands[expr]; ok {
+		expr = s
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	var s Schedule
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return s, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return s, fmt.Errorf("hour: %w", err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return s, fmt.Errorf("day of month: %w", err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12); err != nil {
+		return s, fmt.Errorf("month: %w", err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return s, fmt.Errorf("day of week: %w", err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return s, nil
+}
+
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rng, step = part[:i], n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var errA, errB error
+			lo, errA = strconv.Atoi(a)
+			hi, errB = strconv.Atoi(b)
+			if errA != nil || errB != nil || lo > hi {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			n, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rng)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (s Schedule) dayMatches(d time.Time) bool {
+	if s.month&(1<<uint(d.Month())) == 0 {
+		return false
+	}
+	dom := s.dom&(1<<uint(d.Day())) != 0
+	dow := s.dow&(1<<uint(d.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// Next returns the first time after after that s runs, with the fields read
+// in loc. It returns the zero time if s never runs, e.g. "0 0 30 2 *".
+func (s Schedule) Next(after time.Time, loc *time.Location) time.Time {
+	local := after.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	// Every valid month/day combination occurs within 8 years (29 February)
+	for i := 0; i < 8*366; i++ {
+		d := day.AddDate(0, 0, i)
+		if !s.dayMatches(d) {
+			continue
+		}
+		for h := 0; h < 24; h++ {
+			if s.hour&(1<<uint(h)) == 0 {
+				continue
+			}
+			for m := 0; m < 60; m++ {
+				if s.minute&(1<<uint(m)) == 0 {
+					continue
+				}
+				if t := time.Date(d.Year(), d.Month(), d.Day(), h, m, 0, 0, loc); t.After(after) {
+					return t
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,48 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Errors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	loc := time.FixedZone("UTC+5", 5*60*60)
+	// Sunday 18 Oct 2026, 10:30 local
+	after := time.Date(2026, 10, 18, 10, 30, 0, 0, loc)
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 10, 18, 10, 45, 0, 0, loc)},
+		{"30 10 * * *", time.Date(2026, 10, 19, 10, 30, 0, 0, loc)},
+		{"0 17 * * 5", time.Date(2026, 10, 23, 17, 0, 0, 0, loc)},
+		{"0 9 * * 1-5", time.Date(2026, 10, 19, 9, 0, 0, 0, loc)},
+		{"0 9 * * 7", time.Date(2026, 10, 25, 9, 0, 0, 0, loc)},
+		{"0 12 1,15 * *", time.Date(2026, 11, 1, 12, 0, 0, 0, loc)},
+		{"0 9-17/4 * * *", time.Date(2026, 10, 18, 13, 0, 0, 0, loc)},
+		{"@monthly", time.Date(2026, 11, 1, 0, 0, 0, 0, loc)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, loc)},
+		// Both day fields restricted: either matches (the 20th, or a Monday)
+		{"0 8 20 * 1", time.Date(2026, 10, 19, 8, 0, 0, 0, loc)},
+	}
+	for _, c := range cases {
+		s, err := Parse(c.expr)
+		require.NoError(t, err, c.expr)
+		assert.True(t, c.want.Equal(s.Next(after, loc)), "%s: got %v", c.expr, s.Next(after, loc))
+	}
+
+	never, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, never.Next(after, loc).IsZero())
+}
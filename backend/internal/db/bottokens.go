@@ -0,0 +1,90 @@
+package db
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/envelope"
+)
+
+// migrateBotTokens encrypts the plaintext token column of databases created
+// before envelope encryption, then drops it. Like migrateIdentities it runs
+// before AutoMigrate so the new NOT NULL/unique columns start out filled.
+func migrateBotTokens() error {
+	migrator := models.DB.Migrator()
+	if !migrator.HasTable("bots") || !migrator.HasColumn("bots", "token") {
+		return nil
+	}
+	for _, column := range []string{"token_ciphertext", "token_data_key", "token_hash"} {
+		if !migrator.HasColumn("bots", column) {
+			if err := models.DB.Exec(fmt.Sprintf("ALTER TABLE bots ADD COLUMN %s text", column)).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	var rows []struct {
+		ID    uint
+		Token string
+	}
+	if err := models.DB.Table("bots").Select("id", "token").Find(&rows).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := sealBotToken(row.ID, row.Token); err != nil {
+			return err
+		}
+	}
+
+	models.DB.Exec("DROP INDEX IF EXISTS idx_bots_token")
+	if err := models.DB.Exec("ALTER TABLE bots DROP COLUMN token").Error; err != nil {
+		return err
+	}
+	log.Printf("[db] Encrypted %d bot token(s)", len(rows))
+	return nil
+}
+
+// sealBotToken re-encrypts one bot's token under a fresh data key and the
+// current master key. It bypasses hooks since it writes the columns directly.
+func sealBotToken(id uint, token string) error {
+	ciphertext, dataKey, err := envelope.Seal(token)
+	if err != nil {
+		return err
+	}
+	return models.DB.Table("bots").Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"token_ciphertext": ciphertext,
+		"token_data_key":   dataKey,
+		"token_hash":       envelope.LookupHash(token),
+	}).Error
+}
+
+// RotateBotTokenKeys re-encrypts every bot token (including deleted bots)
+// that is not yet on the current master key.
+//
+// Rotation procedure: add a new master key version to the config (keeping
+// the old one), restart, and run this command. The old version can be
+// removed once it reports zero remaining rows.
+func RotateBotTokenKeys() error {
+	version := envelope.CurrentVersion()
+	prefix := fmt.Sprintf("v%d:%%", version)
+
+	var bots []models.Bot
+	if err := models.DB.Unscoped().
+		Where("token_data_key NOT LIKE ? OR token_hash NOT LIKE ?", prefix, prefix).
+		Find(&bots).Error; err != nil {
+		return err
+	}
+	for _, bot := range bots {
+		if err := sealBotToken(bot.ID, bot.Token); err != nil {
+			return fmt.Errorf("bot %d: %w", bot.ID, err)
+		}
+	}
+	log.Printf("[db] Re-encrypted %d bot token(s) with master key v%d", len(bots), version)
+
+	var remaining int64
+	models.DB.Unscoped().Model(&models.Bot{}).
+		Where("token_data_key NOT LIKE ? OR token_hash NOT LIKE ?", prefix, prefix).Count(&remaining)
+	log.Printf("[db] bots: %d row(s) still on an older master key", remaining)
+	return nil
+}
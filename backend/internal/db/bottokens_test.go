@@ -0,0 +1,61 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/envelope"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupKeys(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	models.DB = db
+	require.NoError(t, identity.Configure([]string{"1:test-identity-key-0123456789"}))
+	require.NoError(t, envelope.Configure([]string{"1:test-master-key-0123456789"}))
+	return db
+}
+
+func TestMigrate_EncryptsLegacyBotTokens(t *testing.T) {
+	db := setupKeys(t)
+
+	// Schema as created by versions that stored plaintext tokens
+	require.NoError(t, db.Exec(`CREATE TABLE bots (id integer PRIMARY KEY, tenant_id integer NOT NULL, token text NOT NULL,
+		bot_username text, bot_name text, verified numeric, created_at datetime, updated_at datetime, deleted_at datetime)`).Error)
+	require.NoError(t, db.Exec(`CREATE UNIQUE INDEX idx_bots_token ON bots(token)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO bots (id, tenant_id, token) VALUES (1, 1, '123:legacy')`).Error)
+
+	Migrate()
+
+	assert.False(t, db.Migrator().HasColumn("bots", "token"))
+	var bot models.Bot
+	require.NoError(t, db.First(&bot, 1).Error)
+	assert.Equal(t, "123:legacy", bot.Token)
+	assert.Equal(t, envelope.LookupHash("123:legacy"), bot.TokenHash)
+}
+
+func TestRotateBotTokenKeys_ReencryptsAllRows(t *testing.T) {
+	db := setupKeys(t)
+	Migrate()
+
+	bot := models.Bot{TenantID: 1, Token: "123:rotate-me"}
+	require.NoError(t, db.Create(&bot).Error)
+	db.Delete(&bot)
+
+	require.NoError(t, envelope.Configure([]string{"1:test-master-key-0123456789", "2:next-master-key-0123456789"}))
+	require.NoError(t, RotateBotTokenKeys())
+
+	// The old master key is no longer needed
+	require.NoError(t, envelope.Configure([]string{"2:next-master-key-0123456789"}))
+	var rotated models.Bot
+	require.NoError(t, db.Unscoped().First(&rotated, bot.ID).Error)
+	assert.Equal(t, "123:rotate-me", rotated.Token)
+	assert.True(t, envelope.IsCurrent(rotated.TokenDataKey))
+	assert.Equal(t, envelope.LookupHash("123:rotate-me"), rotated.TokenHash)
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"gorm.io/gorm"
+)
+
+// Cursors are opaque keyset pagination tokens. They carry only the ID of the
+// last row returned; the ordering columns are read back from that row, so a
+// cursor reveals nothing the listing doesn't (feedback timestamps may be
+// rounded in responses).
+const cursorPrefix = "1:"
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+func EncodeCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatUint(uint64(id), 10)))
+}
+
+func DecodeCursor(s string) (uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(string(raw), cursorPrefix), 10, 64)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidCursor
+	}
+	return uint(id), nil
+}
+
+// NewestFirst orders table by created_at then id, descending, starting after
+// the row with ID after (0 for the first page).
+func NewestFirst(table string, after uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Order(table + ".created_at DESC").Order(table + ".id DESC")
+		if after == 0 {
+			return db
+		}
+		createdAt := "(SELECT created_at FROM " + table + " WHERE id = ?)"
+		return db.Where(table+".created_at < "+createdAt+" OR ("+table+".created_at = "+createdAt+" AND "+table+".id < ?)",
+			after, after, after)
+	}
+}
+
+// ByID orders table by id, starting after the row with ID after.
+func ByID(table string, after uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Order(table + ".id")
+		if after == 0 {
+			return db
+		}
+		return db.Where(table+".id > ?", after)
+	}
+}
+
+// EstimateCount returns the planner's row estimate for query on PostgreSQL,
+// which is far cheaper than COUNT(*) on large tables. Other databases get
+// an exact count.
+func EstimateCount(query *gorm.DB, model interface{}) int64 {
+	var count int64
+	if query.Dialector.Name() != "postgres" {
+		query.Model(model).Count(&count)
+		return count
+	}
+
+	stmt := query.Session(&gorm.Session{DryRun: true}).Model(model).Select("1").Find(&[]map[string]interface{}{}).Statement
+	sqlDB, err := models.DB.DB()
+	if err != nil {
+		return 0
+	}
+	var plan string
+	if err := sqlDB.QueryRow("EXPLAIN (FORMAT JSON) "+stmt.SQL.String(), stmt.Vars...).Scan(&plan); err != nil {
+		return 0
+	}
+	var parsed []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &parsed); err != nil || len(parsed) == 0 {
+		return 0
+	}
+	return int64(parsed[0].Plan.Rows)
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	id, err := DecodeCursor(EncodeCursor(42))
+	require.NoError(t, err)
+	assert.Equal(t, uint(42), id)
+
+	for _, bad := range []string{"", "!!", EncodeCursor(0), "eDo0Mg"} {
+		_, err := DecodeCursor(bad)
+		assert.ErrorIs(t, err, ErrInvalidCursor, bad)
+	}
+}
+
+func TestNewestFirst_WalksTies(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	models.DB = db
+	require.NoError(t, db.AutoMigrate(&models.Feedback{}))
+	require.NoError(t, migrateIndexes())
+
+	// Rows 2-4 share a timestamp; row 5 is backdated (as imports are)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, at := range []time.Time{base, base.Add(time.Hour), base.Add(time.Hour), base.Add(time.Hour), base.Add(-time.Hour)} {
+		fb := models.Feedback{TenantID: 1, GroupID: 1, Message: string(rune('a' + i)), Model: gorm.Model{CreatedAt: at}}
+		require.NoError(t, db.Create(&fb).Error)
+	}
+
+	var seen []string
+	after := uint(0)
+	for {
+		var page []models.Feedback
+		db.Scopes(NewestFirst("feedbacks", after)).Limit(2).Find(&page)
+		if len(page) == 0 {
+			break
+		}
+		for _, fb := range page {
+			seen = append(seen, fb.Message)
+		}
+		after = page[len(page)-1].ID
+	}
+	assert.Equal(t, []string{"d", "c", "b", "a", "e"}, seen)
+}
@@ -1,14 +1,44 @@
 package db
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/envelope"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// ConfigureKeys loads the sender pseudonym and bot token keys. Both are
+// required; a missing one is reported by its config setting.
+func ConfigureKeys() error {
+	identityKeys, err := config.IdentityKeys()
+	if err != nil {
+		return err
+	}
+	if err := identity.Configure(identityKeys); err != nil {
+		return keyError("identity.keys (or identity.keyfile)", "sender pseudonym", err, identity.ErrNoKeys)
+	}
+	masterKeys, err := config.BotTokenKeys()
+	if err != nil {
+		return err
+	}
+	if err := envelope.Configure(masterKeys); err != nil {
+		return keyError("bottokens.masterkeys (or bottokens.masterkeyfile)", "bot token master", err, envelope.ErrNoKeys)
+	}
+	return nil
+}
+
+func keyError(setting, kind string, err, errNoKeys error) error {
+	if errors.Is(err, errNoKeys) {
+		return fmt.Errorf("config: %s is missing; add a %s key like \"1:<long random secret>\", see conf/config.example.yaml and the upgrade notes in README.md", setting, kind)
+	}
+	return fmt.Errorf("config: %s: %w", setting, err)
+}
+
 func Init() {
 	cfg := config.Confs.DB
 	dsn := fmt.Sprintf(
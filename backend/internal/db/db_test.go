@@ -0,0 +1,32 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureKeys_NamesMissingSetting(t *testing.T) {
+	saved := config.Confs
+	t.Cleanup(func() { config.Confs = saved })
+
+	config.Confs.Identity = config.Identity{}
+	config.Confs.BotTokens = config.BotTokens{MasterKeys: []string{"1:test-master-key-0123456789"}}
+	assert.ErrorContains(t, ConfigureKeys(), "identity.keys (or identity.keyfile) is missing")
+
+	config.Confs.Identity = config.Identity{Keys: []string{"1:short"}}
+	assert.ErrorContains(t, ConfigureKeys(), "identity.keys (or identity.keyfile): identity: key version 1 is shorter")
+
+	config.Confs.Identity = config.Identity{Keys: []string{"1:test-identity-key-0123456789"}}
+	config.Confs.BotTokens = config.BotTokens{MasterKeyFile: filepath.Join(t.TempDir(), "missing")}
+	assert.ErrorContains(t, ConfigureKeys(), "bottokens.masterkeyfile")
+
+	config.Confs.BotTokens = config.BotTokens{}
+	assert.ErrorContains(t, ConfigureKeys(), "bottokens.masterkeys (or bottokens.masterkeyfile) is missing")
+
+	config.Confs.BotTokens = config.BotTokens{MasterKeys: []string{"1:test-master-key-0123456789"}}
+	require.NoError(t, ConfigureKeys())
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+)
+
+// pseudonymizedTables previously stored the raw telegram_user_id.
+var pseudonymizedTables = []string{"group_users", "pending_feedbacks", "sender_preferences"}
+
+var legacyIdentityIndexes = map[string][]string{
+	"pending_feedbacks":  {"idx_pending_feedbacks_telegram_user_id"},
+	"sender_preferences": {"idx_sender_pref"},
+}
+
+// migrateIdentities replaces the raw telegram_user_id column of databases
+// created before pseudonymization with sender_hash, then drops it. It runs
+// before AutoMigrate so the new NOT NULL/unique constraints apply to filled rows.
+func migrateIdentities() error {
+	migrator := models.DB.Migrator()
+	for _, table := range pseudonymizedTables {
+		if !migrator.HasTable(table) || !migrator.HasColumn(table, "telegram_user_id") {
+			continue
+		}
+		if !migrator.HasColumn(table, "sender_hash") {
+			if err := models.DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN sender_hash text", table)).Error; err != nil {
+				return err
+			}
+		}
+
+		var rows []struct {
+			ID             uint
+			TelegramUserID int64
+		}
+		if err := models.DB.Table(table).Select("id", "telegram_user_id").Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := models.DB.Table(table).Where("id = ?", row.ID).
+				Update("sender_hash", identity.Pseudonym(row.TelegramUserID)).Error; err != nil {
+				return err
+			}
+		}
+
+		// Indexes on the old column must go first for SQLite
+		for _, index := range legacyIdentityIndexes[table] {
+			models.DB.Exec("DROP INDEX IF EXISTS " + index)
+		}
+		if err := models.DB.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN telegram_user_id", table)).Error; err != nil {
+			return err
+		}
+		log.Printf("[db] Pseudonymized %d row(s) in %s", len(rows), table)
+	}
+	return nil
+}
+
+// RotateIdentityKeys moves stored identities to the current pseudonym key.
+//
+// Rotation procedure: add a new key version to the config (keeping the old
+// one), restart, and run this command. It re-encrypts pending reply
+// addresses and upgrades the pseudonyms of their senders. Other pseudonyms
+// can't be recomputed without the raw ID, so they are upgraded as senders
+// write to the bot again; the old key can be removed once the reported
+// counts reach zero (or once the remaining senders may be treated as new).
+func RotateIdentityKeys() error {
+	version := identity.CurrentVersion()
+	prefix := fmt.Sprintf("v%d:%%", version)
+
+	var feedbacks []models.Feedback
+	models.DB.Unscoped().Where("reply_to <> '' AND reply_to NOT LIKE ?", prefix).Find(&feedbacks)
+	for _, fb := range feedbacks {
+		telegramUserID, err := identity.Decrypt(fb.ReplyTo)
+		if err != nil {
+			return fmt.Errorf("feedback %d: %w", fb.ID, err)
+		}
+		sealed, err := identity.Encrypt(telegramUserID)
+		if err != nil {
+			return err
+		}
+		models.DB.Unscoped().Model(&fb).Update("reply_to", sealed)
+		models.DB.Model(&models.GroupUser{}).Where("id = ? AND sender_hash NOT LIKE ?", fb.SenderID, prefix).
+			Update("sender_hash", identity.Pseudonym(telegramUserID))
+	}
+	log.Printf("[db] Re-encrypted %d reply address(es) with key v%d", len(feedbacks), version)
+
+	for _, table := range pseudonymizedTables {
+		var remaining int64
+		models.DB.Table(table).Where("sender_hash NOT LIKE ?", prefix).Count(&remaining)
+		log.Printf("[db] %s: %d row(s) still on an older key", table, remaining)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestMigrate_PseudonymizesLegacyRows(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	models.DB = db
+	require.NoError(t, identity.Configure([]string{"1:test-identity-key-0123456789"}))
+
+	// Schema as created by versions that stored raw Telegram IDs
+	require.NoError(t, db.Exec(`CREATE TABLE group_users (id integer PRIMARY KEY, tenant_id integer, group_id integer,
+		telegram_user_id integer NOT NULL, created_at datetime, updated_at datetime, deleted_at datetime)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO group_users (id, tenant_id, group_id, telegram_user_id) VALUES (1, 1, 1, 777)`).Error)
+
+	Migrate()
+
+	assert.False(t, db.Migrator().HasColumn("group_users", "telegram_user_id"))
+	var gu models.GroupUser
+	require.NoError(t, db.First(&gu, 1).Error)
+	assert.Equal(t, identity.Pseudonym(777), gu.SenderHash)
+}
+
+func TestRotateIdentityKeys_ReencryptsReplyAddresses(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	models.DB = db
+	require.NoError(t, identity.Configure([]string{"1:test-identity-key-0123456789"}))
+	Migrate()
+
+	gu := models.GroupUser{TenantID: 1, GroupID: 1, SenderHash: identity.Pseudonym(888)}
+	db.Create(&gu)
+	replyTo, _ := identity.Encrypt(888)
+	fb := models.Feedback{TenantID: 1, GroupID: 1, SenderID: gu.ID, Message: "m", ReplyTo: replyTo}
+	db.Create(&fb)
+
+	require.NoError(t, identity.Configure([]string{"1:test-identity-key-0123456789", "2:next-identity-key-0123456789"}))
+	require.NoError(t, RotateIdentityKeys())
+
+	db.First(&fb, fb.ID)
+	db.First(&gu, gu.ID)
+	assert.True(t, identity.IsCurrent(fb.ReplyTo))
+	assert.Equal(t, identity.Pseudonym(888), gu.SenderHash)
+}
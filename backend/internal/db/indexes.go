@@ -0,0 +1,20 @@
+package db
+
+import "github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+
+// indexes are composite indexes for the listing queries. They span the
+// embedded gorm.Model columns, which struct tags can't reach.
+var indexes = []string{
+	"CREATE INDEX IF NOT EXISTS idx_feedbacks_tenant_created ON feedbacks (tenant_id, created_at, id)",
+	"CREATE INDEX IF NOT EXISTS idx_feedbacks_group_created ON feedbacks (group_id, created_at, id)",
+	"CREATE INDEX IF NOT EXISTS idx_feedbacks_tenant_admin_only_created ON feedbacks (tenant_id, admin_only, created_at, id)",
+}
+
+func migrateIndexes() error {
+	for _, stmt := range indexes {
+		if err := models.DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,20 +1,57 @@
 package db
 
-import "github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+import (
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/search"
+)
+
+// Models lists every table the app owns, in migration order.
+var Models = []interface{}{
+	&models.Tenant{},
+	&models.Bot{},
+	&models.Group{},
+	&models.FeedbackConfig{},
+	&models.GroupUser{},
+	&models.Feedback{},
+	&models.User{},
+	&models.UserTenant{},
+	&models.PendingFeedback{},
+	&models.SenderPreference{},
+	&models.MessageTemplate{},
+	&models.PurgeLog{},
+	&models.ExportJob{},
+	&models.ImportJob{},
+	&models.Notification{},
+	&models.ReportSchedule{},
+	&models.Survey{},
+	&models.SurveyQuestion{},
+	&models.SurveyRound{},
+	&models.SurveyPoll{},
+	&models.SurveyAnswer{},
+	&models.IdeaVote{},
+	&models.PendingComment{},
+	&models.PublicResponse{},
+	&models.RetroSession{},
+	&models.RetroItem{},
+	&models.RetroDot{},
+}
 
 func Migrate() {
-	err := models.DB.AutoMigrate(
-		&models.Tenant{},
-		&models.Bot{},
-		&models.Group{},
-		&models.FeedbackConfig{},
-		&models.GroupUser{},
-		&models.Feedback{},
-		&models.User{},
-		&models.UserTenant{},
-		&models.PendingFeedback{},
-	)
+	if err := migrateIdentities(); err != nil {
+		panic(err)
+	}
+	if err := migrateBotTokens(); err != nil {
+		panic(err)
+	}
+
+	err := models.DB.AutoMigrate(Models...)
 	if err != nil {
 		panic(err)
 	}
+	if err := migrateIndexes(); err != nil {
+		panic(err)
+	}
+	if err := search.Migrate(models.DB); err != nil {
+		panic(err)
+	}
 }
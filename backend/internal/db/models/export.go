@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExportJob.Status values.
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// ExportJob.Kind values.
+const (
+	ExportKindTenantArchive = "tenant_archive"
+	ExportKindFeedbacks     = "feedbacks" // A filtered feedback listing, see exporter.StartJob
+)
+
+// ExportJob is a file built in the background for the tenant to download.
+type ExportJob struct {
+	TenantID    uint       `gorm:"not null;index" json:"tenant_id"`
+	UserID      uint       `json:"user_id"` // Requester, notified when done
+	Kind        string     `gorm:"not null" json:"kind"`
+	Status      string     `gorm:"not null;default:pending" json:"status"`
+	Format      string     `json:"format,omitempty"`
+	Params      string     `json:"params,omitempty"` // Listing filters, as a query string
+	Total       int64      `json:"total"`
+	Processed   int64      `json:"processed"`
+	FileName    string     `json:"file_name,omitempty"` // Name to download as
+	FilePath    string     `json:"-"`
+	FileSize    int64      `json:"file_size"`
+	Error       string     `json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"` // The file is removed after this
+	gorm.Model
+}
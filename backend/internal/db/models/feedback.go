@@ -1,33 +1,152 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Feedback.Moderation states for groups that require approval before posting.
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
+// Rating scales for FeedbackConfig.RatingScale and Feedback.RatingScale.
+const (
+	RatingENPS = "enps" // 0-10, "how likely are you to recommend working here"
+	RatingCSAT = "csat" // 1-5 satisfaction
+)
+
+// Feedback types, chosen by the sender with a DM command such as /idea.
+const (
+	FeedbackGeneral  = "general"
+	FeedbackIdea     = "idea"
+	FeedbackIssue    = "issue"
+	FeedbackPraise   = "praise"
+	FeedbackQuestion = "question"
+)
+
+// ImportedSender is the SenderHash of the GroupUser that imported feedback is
+// attributed to, one per group. It can't collide with a pseudonym, which is
+// hex.
+const ImportedSender = "imported"
+
+// FeedbackTypes lists every feedback type, in display order.
+var FeedbackTypes = []string{FeedbackGeneral, FeedbackIdea, FeedbackIssue, FeedbackPraise, FeedbackQuestion}
+
+// ValidFeedbackType reports whether t is one of FeedbackTypes.
+func ValidFeedbackType(t string) bool {
+	return slices.Contains(FeedbackTypes, t)
+}
+
+// AllowsType reports whether the group accepts feedback of type t. General
+// feedback is always allowed.
+func (c FeedbackConfig) AllowsType(t string) bool {
+	if t == FeedbackGeneral || c.AllowedTypes == "" {
+		return true
+	}
+	return slices.Contains(strings.Split(c.AllowedTypes, ","), t)
+}
 
 type (
 	GroupUser struct {
-		TenantID       uint  `gorm:"not null" json:"tenant_id"`
-		GroupID        uint  `gorm:"not null" json:"group_id"`
-		TelegramUserID int64 `gorm:"not null" json:"telegram_user_id"`
-		Group          Group `gorm:"foreignKey:GroupID" json:"group,omitempty"`
+		TenantID   uint   `gorm:"not null" json:"tenant_id"`
+		GroupID    uint   `gorm:"not null" json:"group_id"`
+		SenderHash string `gorm:"not null;index" json:"-"` // identity.Pseudonym of the Telegram user, or ImportedSender
+		Group      Group  `gorm:"foreignKey:GroupID" json:"group,omitempty"`
 		gorm.Model
 	}
 
 	Feedback struct {
-		TenantID  uint      `gorm:"not null" json:"tenant_id"`
-		GroupID   uint      `gorm:"not null" json:"group_id"`
-		SenderID  uint      `gorm:"not null" json:"-"` // Never exposed via API
-		Message   string    `gorm:"not null" json:"message"`
-		AdminOnly bool      `gorm:"default:false" json:"admin_only"`
-		Posted    bool      `gorm:"default:false" json:"posted"`
-		Group     Group     `gorm:"foreignKey:GroupID" json:"group,omitempty"`
-		Sender    GroupUser `gorm:"foreignKey:SenderID" json:"-"` // Never exposed
+		TenantID      uint       `gorm:"not null" json:"tenant_id"`
+		GroupID       uint       `gorm:"not null" json:"group_id"`
+		SenderID      uint       `gorm:"not null" json:"-"` // Never exposed via API
+		Message       string     `gorm:"not null" json:"message"`
+		Type          string     `gorm:"default:general;index" json:"type"` // FeedbackTypes
+		AdminOnly     bool       `gorm:"default:false" json:"admin_only"`
+		Sealed        bool       `gorm:"default:false" json:"sealed"` // Message is a sealed box for the admin key
+		SealedKey     string     `json:"sealed_key,omitempty"`        // sealed.Fingerprint of that key
+		Posted        bool       `gorm:"default:false" json:"posted"`
+		ScheduledAt   *time.Time `gorm:"index" json:"scheduled_at"`                // Queued group post, see FeedbackConfig anonymity settings
+		DigestQueued  bool       `gorm:"default:false;index" json:"digest_queued"` // Waiting for the group's next digest
+		InDigest      bool       `gorm:"default:false" json:"in_digest"`           // Posted in a digest message shared with other feedback
+		PostChatID    int64      `json:"post_chat_id,omitempty"`
+		PostThreadID  int        `json:"post_thread_id,omitempty"`
+		PostMessageID int64      `json:"post_message_id,omitempty"`
+		Pinned        bool       `gorm:"default:false" json:"pinned"`
+		EditedAt      *time.Time `json:"edited_at"`
+		TrackingCode  string     `gorm:"index" json:"-"`          // Shown only to the sender
+		Moderation    string     `gorm:"index" json:"moderation"` // "", pending, approved, rejected
+		ModeratedAt   *time.Time `json:"moderated_at"`
+		RejectReason  string     `json:"reject_reason,omitempty"`
+		RetractedAt   *time.Time `json:"-"`
+		ReplyTo       string     `json:"-"`                                    // Encrypted sender chat, kept only while a reply to the sender is due
+		ImportJobID   *uint      `gorm:"index" json:"import_job_id,omitempty"` // Imported feedback's sender is the group's ImportedSender
+		Rating        *int       `json:"-"`                                    // Optional score given after sending, only exposed in aggregate
+		RatingScale   string     `gorm:"index" json:"-"`                       // Scale of Rating
+		Upvotes       int        `gorm:"default:0" json:"upvotes"`             // Idea votes from the group post, see IdeaVote
+		Downvotes     int        `gorm:"default:0" json:"downvotes"`
+		ParentID      *uint      `gorm:"index" json:"parent_id,omitempty"` // Set on anonymous comments, posted as replies to the parent's post
+		AnsweredAt    *time.Time `gorm:"index" json:"answered_at"`         // When a PublicResponse was published
+		Group         Group      `gorm:"foreignKey:GroupID" json:"group,omitempty"`
+		Sender        GroupUser  `gorm:"foreignKey:SenderID" json:"-"` // Never exposed
 		gorm.Model
 	}
 
 	PendingFeedback struct {
-		TelegramUserID int64  `gorm:"uniqueIndex;not null" json:"telegram_user_id"`
-		BotID          uint   `gorm:"not null" json:"bot_id"`
-		Text           string `gorm:"not null" json:"text"`
-		AdminOnly      bool   `gorm:"default:false" json:"admin_only"`
+		SenderHash string `gorm:"uniqueIndex;not null" json:"-"`
+		BotID      uint   `gorm:"not null" json:"bot_id"`
+		Text       string `gorm:"not null" json:"text"`
+		AdminOnly  bool   `gorm:"default:false" json:"admin_only"`
+		SealedKey  string `json:"sealed_key,omitempty"` // Set when Text is a sealed box for the admin key with this fingerprint
+		Type       string `gorm:"default:general" json:"type"`
+		gorm.Model
+	}
+
+	// PublicResponse is an official answer to a feedback, published in its
+	// group from the dashboard ("you said, we did").
+	PublicResponse struct {
+		TenantID      uint     `gorm:"not null;index" json:"tenant_id"`
+		FeedbackID    uint     `gorm:"not null;uniqueIndex" json:"feedback_id"`
+		GroupID       uint     `gorm:"not null" json:"group_id"`
+		AuthorID      uint     `gorm:"not null" json:"author_id"` // Dashboard user who published it
+		Message       string   `gorm:"not null" json:"message"`
+		PostChatID    int64    `json:"post_chat_id"`
+		PostMessageID int64    `json:"post_message_id"`
+		Feedback      Feedback `gorm:"foreignKey:FeedbackID" json:"feedback,omitempty"`
+		Group         Group    `gorm:"foreignKey:GroupID" json:"group,omitempty"`
+		gorm.Model
+	}
+
+	// PendingComment marks a sender as writing a comment on a posted
+	// feedback; their next DM message becomes the comment.
+	PendingComment struct {
+		SenderHash string `gorm:"uniqueIndex;not null" json:"-"`
+		BotID      uint   `gorm:"not null" json:"bot_id"`
+		FeedbackID uint   `gorm:"not null" json:"feedback_id"`
+		gorm.Model
+	}
+
+	// IdeaVote is one group member's vote on a posted idea. VoterHash is
+	// derived from the feedback and the voter's pseudonym, so votes can't be
+	// linked across ideas or to the voter's own feedback.
+	IdeaVote struct {
+		TenantID   uint   `gorm:"not null;index" json:"tenant_id"`
+		FeedbackID uint   `gorm:"not null;uniqueIndex:idx_idea_vote" json:"feedback_id"`
+		VoterHash  string `gorm:"not null;uniqueIndex:idx_idea_vote" json:"-"`
+		Value      int    `gorm:"not null" json:"value"` // 1 or -1
+		gorm.Model
+	}
+
+	// SenderPreference holds per-sender bot settings chosen via DM commands.
+	SenderPreference struct {
+		BotID      uint   `gorm:"not null;uniqueIndex:idx_sender_pref" json:"bot_id"`
+		SenderHash string `gorm:"not null;uniqueIndex:idx_sender_pref" json:"-"`
+		Language   string `json:"language"`
 		gorm.Model
 	}
 )
@@ -1,10 +1,14 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type (
 	Group struct {
-		TenantID uint   `gorm:"not null" json:"tenant_id"`
+		TenantID uint   `gorm:"not null;index" json:"tenant_id"`
 		BotID    uint   `gorm:"not null" json:"bot_id"`
 		ChatID   int64  `gorm:"not null;uniqueIndex" json:"chat_id"`
 		Title    string `json:"title"`
@@ -16,10 +20,35 @@ type (
 	}
 
 	FeedbackConfig struct {
-		GroupID      uint  `gorm:"not null;uniqueIndex" json:"group_id"`
-		PostToGroup  bool  `gorm:"default:false" json:"post_to_group"`
-		ForumTopicID *int  `json:"forum_topic_id"`
-		Group        Group `gorm:"foreignKey:GroupID" json:"group,omitempty"`
+		GroupID              uint   `gorm:"not null;uniqueIndex" json:"group_id"`
+		PostToGroup          bool   `gorm:"default:false" json:"post_to_group"`
+		ForumTopicID         *int   `json:"forum_topic_id"`
+		RetractWindowMinutes int    `gorm:"default:60" json:"retract_window_minutes"` // 0 disables /retract
+		RequireApproval      bool   `gorm:"default:false" json:"require_approval"`
+		ModerationChatID     *int64 `json:"moderation_chat_id"` // Receives approve/reject buttons
+
+		// Anonymity: delay posts by a random PostDelayMin..MaxMinutes, then hold
+		// them until the next PostingWindows time ("HH:MM,HH:MM" in the tenant
+		// timezone) and until MinDistinctSenders people have feedback queued.
+		PostDelayMinMinutes      int    `gorm:"default:0" json:"post_delay_min_minutes"`
+		PostDelayMaxMinutes      int    `gorm:"default:0" json:"post_delay_max_minutes"`
+		PostingWindows           string `json:"posting_windows"`
+		MinDistinctSenders       int    `gorm:"default:1" json:"min_distinct_senders"`
+		TimestampRoundingMinutes int    `gorm:"default:0" json:"timestamp_rounding_minutes"` // Applied to API/CSV output
+		RetentionMonths          *int   `json:"retention_months"`                            // Overrides the tenant setting when set
+
+		// Digest mode: public feedback is queued and posted together as one
+		// message (split at Telegram's length limit) on DigestSchedule, a cron
+		// expression in the tenant timezone. Replaces the delays and windows.
+		DigestMode     bool       `gorm:"default:false" json:"digest_mode"`
+		DigestSchedule string     `json:"digest_schedule"`
+		DigestPin      bool       `gorm:"default:false" json:"digest_pin"` // Pin each digest, unpinning the last
+		DigestNextAt   *time.Time `json:"digest_next_at"`
+
+		RatingScale  string `json:"rating_scale"`  // Ask senders for a rating after feedback: "", enps or csat
+		AllowedTypes string `json:"allowed_types"` // Comma-separated feedback types, "" allows all. General is always allowed
+
+		Group Group `gorm:"foreignKey:GroupID" json:"group,omitempty"`
 		gorm.Model
 	}
 )
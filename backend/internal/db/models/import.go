@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImportJob.Status values.
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// ImportJob.Format values.
+const (
+	ImportFormatExportCSV = "export_csv" // The layout GET /feedbacks/export writes
+	ImportFormatCSV       = "csv"        // Any CSV, with a column mapping
+	ImportFormatArchive   = "archive"    // A POST /tenant/export zip
+)
+
+// ImportJob brings feedback from a file into the tenant in the background.
+// Processed is a row cursor committed with each batch, so an interrupted job
+// resumes where it stopped.
+type ImportJob struct {
+	TenantID    uint       `gorm:"not null;index" json:"tenant_id"`
+	Format      string     `gorm:"not null" json:"format"`
+	DryRun      bool       `json:"dry_run"`
+	Options     string     `json:"-"` // importer.Options as JSON
+	FileName    string     `json:"file_name"`
+	FilePath    string     `json:"-"`
+	Status      string     `gorm:"not null;default:pending" json:"status"`
+	Total       int        `json:"total"`
+	Processed   int        `json:"processed"`
+	Imported    int        `json:"imported"` // Or, for a dry run, would be imported
+	Skipped     int        `json:"skipped"`
+	Errors      string     `json:"-"` // []importer.RowError as JSON
+	Error       string     `json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at"`
+	gorm.Model
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification.Kind values.
+const (
+	NotificationExportReady  = "export_ready"
+	NotificationExportFailed = "export_failed"
+)
+
+// Notification is an in-app message for a dashboard user.
+type Notification struct {
+	TenantID uint       `gorm:"not null;index" json:"tenant_id"`
+	UserID   uint       `gorm:"not null;index" json:"user_id"`
+	Kind     string     `gorm:"not null" json:"kind"`
+	Message  string     `json:"message"`
+	Link     string     `json:"link,omitempty"` // API path of the subject, e.g. /exports/12
+	ReadAt   *time.Time `json:"read_at"`
+	gorm.Model
+}
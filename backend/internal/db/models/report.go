@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReportSchedule.Frequency values.
+const (
+	ReportDaily   = "daily"
+	ReportWeekly  = "weekly"
+	ReportMonthly = "monthly"
+)
+
+// ReportSchedule emails a user a feedback digest on a schedule in the
+// tenant's timezone, see package reports.
+type ReportSchedule struct {
+	TenantID   uint       `gorm:"not null;index" json:"tenant_id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"` // Recipient
+	Frequency  string     `gorm:"not null" json:"frequency"`
+	Hour       int        `gorm:"default:9" json:"hour"`         // Local hour to send at, 0-23
+	Weekday    int        `gorm:"default:1" json:"weekday"`      // Weekly: 0 is Sunday
+	DayOfMonth int        `gorm:"default:1" json:"day_of_month"` // Monthly: 1-28
+	GroupID    *uint      `json:"group_id"`                      // Only this group, or all
+	Enabled    bool       `gorm:"default:true" json:"enabled"`
+	NextRunAt  time.Time  `gorm:"index" json:"next_run_at"`
+	LastSentAt *time.Time `json:"last_sent_at"`
+	LastError  string     `json:"last_error,omitempty"`
+	gorm.Model
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurgeLog is the audit trail of the retention purge: one row per group and
+// run that deleted anything.
+type PurgeLog struct {
+	TenantID        uint      `gorm:"not null;index" json:"tenant_id"`
+	GroupID         uint      `gorm:"not null" json:"group_id"`
+	RetentionMonths int       `json:"retention_months"`
+	Cutoff          time.Time `json:"cutoff"` // Feedback created before this was deleted
+	Feedbacks       int64     `json:"feedbacks"`
+	GroupUsers      int64     `json:"group_users"`
+	RetroItems      int64     `json:"retro_items"`
+	gorm.Model
+}
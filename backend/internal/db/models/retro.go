@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RetroSession is a time-boxed retrospective in a group. Between StartsAt
+// and EndsAt, members' DMs to the bot are collected into its Columns
+// without being posted; at the end they are revealed in the group at once.
+type RetroSession struct {
+	TenantID          uint       `gorm:"not null;index" json:"tenant_id"`
+	GroupID           uint       `gorm:"not null;index" json:"group_id"`
+	Title             string     `gorm:"not null" json:"title"`
+	Columns           []string   `gorm:"serializer:json" json:"columns"` // e.g. went well, to improve, action items
+	StartsAt          time.Time  `gorm:"index" json:"starts_at"`
+	EndsAt            time.Time  `gorm:"index" json:"ends_at"`
+	DotVoting         bool       `gorm:"default:false" json:"dot_voting"`
+	DotsPerMember     int        `gorm:"default:3" json:"dots_per_member"`
+	AnnouncedAt       *time.Time `json:"announced_at"`
+	ClosedAt          *time.Time `json:"closed_at"`   // No longer taking notes
+	RevealedAt        *time.Time `json:"revealed_at"` // Posted in the group; retried until it is
+	AnnounceMessageID int64      `json:"-"`
+	gorm.Model
+}
+
+// RetroItem is one anonymous note of a session. Position is its number in
+// the column once revealed, in random order.
+type RetroItem struct {
+	TenantID      uint   `gorm:"not null;index" json:"tenant_id"`
+	SessionID     uint   `gorm:"not null;index" json:"session_id"`
+	Column        int    `gorm:"column:column_index" json:"column"` // Index into the session's Columns
+	Message       string `gorm:"not null" json:"message"`
+	Position      int    `json:"position"`
+	PostMessageID int64  `json:"-"` // The revealed column message carrying its dot button
+	Dots          int    `gorm:"default:0" json:"dots"`
+	gorm.Model
+}
+
+// RetroDot is one dot on a revealed item. VoterHash is keyed by session, so
+// a member's dots can be counted against DotsPerMember but not linked to
+// them or across sessions.
+type RetroDot struct {
+	TenantID  uint   `gorm:"not null;index" json:"tenant_id"`
+	SessionID uint   `gorm:"not null;index" json:"session_id"`
+	ItemID    uint   `gorm:"not null;uniqueIndex:idx_retro_dot" json:"item_id"`
+	VoterHash string `gorm:"not null;uniqueIndex:idx_retro_dot;index" json:"-"`
+	gorm.Model
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Survey.Delivery values.
+const (
+	SurveyDeliveryPoll = "poll" // Native anonymous polls in the group, one per question
+	SurveyDeliveryDM   = "dm"   // Questionnaire in the bot's private chat, announced in the group
+)
+
+// SurveyQuestion.Kind values.
+const (
+	QuestionScale  = "scale"  // 1..ScaleMax
+	QuestionChoice = "choice" // One of Options
+)
+
+// Survey is a set of questions the bot sends to a group in rounds, once at
+// StartsAt or on Schedule, a cron expression in the tenant timezone.
+type Survey struct {
+	TenantID  uint             `gorm:"not null;index" json:"tenant_id"`
+	GroupID   uint             `gorm:"not null;index" json:"group_id"`
+	Title     string           `gorm:"not null" json:"title"`
+	Delivery  string           `gorm:"not null;default:poll" json:"delivery"`
+	Schedule  string           `json:"schedule"`
+	StartsAt  *time.Time       `json:"starts_at"`
+	OpenHours int              `gorm:"default:24" json:"open_hours"` // How long each round takes answers
+	Active    bool             `gorm:"default:true" json:"active"`
+	NextRunAt *time.Time       `gorm:"index" json:"next_run_at"`
+	Questions []SurveyQuestion `gorm:"foreignKey:SurveyID" json:"questions,omitempty"`
+	gorm.Model
+}
+
+type SurveyQuestion struct {
+	SurveyID uint     `gorm:"not null;index" json:"survey_id"`
+	Position int      `json:"position"`
+	Text     string   `gorm:"not null" json:"text"`
+	Kind     string   `gorm:"not null" json:"kind"`
+	ScaleMax int      `json:"scale_max,omitempty"`
+	Options  []string `gorm:"serializer:json" json:"options,omitempty"`
+	gorm.Model
+}
+
+// Choices returns the answer labels of q, in option order.
+func (q SurveyQuestion) Choices() []string {
+	if q.Kind != QuestionScale {
+		return q.Options
+	}
+	labels := make([]string, q.ScaleMax)
+	for i := range labels {
+		labels[i] = strconv.Itoa(i + 1)
+	}
+	return labels
+}
+
+// SurveyRound is one sending of a survey. It takes answers until ClosesAt.
+type SurveyRound struct {
+	TenantID          uint       `gorm:"not null;index" json:"tenant_id"`
+	SurveyID          uint       `gorm:"not null;index" json:"survey_id"`
+	GroupID           uint       `gorm:"not null" json:"group_id"`
+	ClosesAt          time.Time  `gorm:"index" json:"closes_at"`
+	ClosedAt          *time.Time `json:"closed_at"`
+	AnnounceMessageID int64      `json:"-"` // DM delivery: the group message linking to the bot
+	gorm.Model
+}
+
+// SurveyPoll is the Telegram poll for one question of a round. Counts holds
+// the latest vote count per option, as reported by poll updates.
+type SurveyPoll struct {
+	RoundID    uint   `gorm:"not null;index" json:"round_id"`
+	QuestionID uint   `gorm:"not null" json:"question_id"`
+	ChatID     int64  `json:"chat_id"`
+	MessageID  int64  `json:"message_id"`
+	PollID     string `gorm:"not null;index" json:"poll_id"`
+	Counts     []int  `gorm:"serializer:json" json:"counts"`
+	Voters     int    `json:"voters"`
+	gorm.Model
+}
+
+// SurveyAnswer is one DM answer. RespondentHash is keyed by round and
+// question, so answers can't be linked to a person or across questions;
+// it only lets people change their answer.
+type SurveyAnswer struct {
+	RoundID        uint   `gorm:"not null;uniqueIndex:idx_survey_answer" json:"round_id"`
+	QuestionID     uint   `gorm:"not null;uniqueIndex:idx_survey_answer" json:"question_id"`
+	RespondentHash string `gorm:"not null;uniqueIndex:idx_survey_answer" json:"-"`
+	Choice         int    `json:"choice"` // Index into the question's Choices
+	gorm.Model
+}
@@ -0,0 +1,17 @@
+package models
+
+import "gorm.io/gorm"
+
+type (
+	// MessageTemplate overrides a built-in bot message for a tenant, or for a
+	// single group when GroupID is set. An empty Language applies to all languages.
+	MessageTemplate struct {
+		TenantID uint   `gorm:"not null;index" json:"tenant_id"`
+		GroupID  *uint  `gorm:"index" json:"group_id"`
+		Key      string `gorm:"not null" json:"key"`
+		Language string `json:"language"`
+		Body     string `gorm:"not null" json:"body"`
+		Group    *Group `gorm:"foreignKey:GroupID" json:"group,omitempty"`
+		gorm.Model
+	}
+)
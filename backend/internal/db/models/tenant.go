@@ -1,22 +1,40 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/envelope"
+	"gorm.io/gorm"
+)
 
 type (
 	Tenant struct {
-		Name string `gorm:"not null" json:"name"`
-		Slug string `gorm:"uniqueIndex;not null" json:"slug"`
-		Bots []Bot  `gorm:"foreignKey:TenantID" json:"bots,omitempty"`
+		Name            string `gorm:"not null" json:"name"`
+		Slug            string `gorm:"uniqueIndex;not null" json:"slug"`
+		DefaultLanguage string `gorm:"default:en" json:"default_language"`
+		Timezone        string `gorm:"default:Asia/Tashkent" json:"timezone"`
+		AdminPublicKey  string `json:"admin_public_key"`                  // X25519, base64; seals admin-only feedback
+		RetentionMonths int    `gorm:"default:0" json:"retention_months"` // 0 keeps feedback forever
+		// Rating analytics hide any figure based on fewer responses than this
+		MinRatingResponses int `gorm:"default:5" json:"min_rating_responses"`
+		// Set while deletion is pending; all tenant data is removed at this time
+		DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+		Bots                []Bot      `gorm:"foreignKey:TenantID" json:"bots,omitempty"`
 		gorm.Model
 	}
 
 	Bot struct {
-		TenantID    uint   `gorm:"not null" json:"tenant_id"`
-		Token       string `gorm:"uniqueIndex;not null" json:"-"`
-		BotUsername string `json:"bot_username"`
-		BotName     string `json:"bot_name"`
-		Verified    bool   `gorm:"default:false" json:"verified"`
-		Tenant      Tenant `gorm:"foreignKey:TenantID" json:"tenant,omitempty"`
+		TenantID uint `gorm:"not null;index" json:"tenant_id"`
+		// Token is only held in memory; it is stored envelope-encrypted, with
+		// TokenHash standing in for it in the unique index and duplicate check.
+		Token           string `gorm:"-" json:"-"`
+		TokenCiphertext string `gorm:"not null" json:"-"`
+		TokenDataKey    string `gorm:"not null" json:"-"`
+		TokenHash       string `gorm:"uniqueIndex;not null" json:"-"`
+		BotUsername     string `json:"bot_username"`
+		BotName         string `json:"bot_name"`
+		Verified        bool   `gorm:"default:false" json:"verified"`
+		Tenant          Tenant `gorm:"foreignKey:TenantID" json:"tenant,omitempty"`
 		gorm.Model
 	}
 
@@ -36,3 +54,29 @@ type (
 		gorm.Model
 	}
 )
+
+// BeforeSave seals the in-memory token into its encrypted columns.
+func (b *Bot) BeforeSave(tx *gorm.DB) error {
+	if b.Token == "" {
+		return nil
+	}
+	ciphertext, dataKey, err := envelope.Seal(b.Token)
+	if err != nil {
+		return err
+	}
+	b.TokenCiphertext, b.TokenDataKey, b.TokenHash = ciphertext, dataKey, envelope.LookupHash(b.Token)
+	return nil
+}
+
+// AfterFind decrypts the token so callers can use bot.Token directly.
+func (b *Bot) AfterFind(tx *gorm.DB) error {
+	if b.TokenCiphertext == "" {
+		return nil
+	}
+	token, err := envelope.Open(b.TokenCiphertext, b.TokenDataKey)
+	if err != nil {
+		return err
+	}
+	b.Token = token
+	return nil
+}
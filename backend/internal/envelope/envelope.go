@@ -0,0 +1,212 @@
+// Package envelope encrypts secrets such as bot tokens before they are stored.
+//
+// Each value is sealed with its own random data key (AES-256-GCM), and the
+// data key is in turn sealed ("wrapped") with a master key from the server
+// config. Master keys are versioned like identity keys ("<version>:<secret>",
+// highest version current), so rotating the master key only requires
+// re-sealing rows, never a coordinated flag day.
+//
+// Because ciphertexts are randomized, equality lookups use LookupHash, a
+// keyed HMAC of the plaintext under the current master key.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoKeys    = errors.New("envelope: no master keys configured")
+	ErrMalformed = errors.New("envelope: malformed ciphertext")
+)
+
+type masterKey struct {
+	version int
+	lookup  []byte
+	wrap    cipher.AEAD
+}
+
+// keys are sorted by version, newest first.
+var keys []masterKey
+
+// Configure installs the master keys, each given as "<version>:<secret>".
+func Configure(specs []string) error {
+	var parsed []masterKey
+	seen := map[int]bool{}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" || strings.HasPrefix(spec, "#") {
+			continue
+		}
+		v, secret, ok := strings.Cut(spec, ":")
+		version, err := strconv.Atoi(v)
+		if !ok || err != nil || version < 1 {
+			return fmt.Errorf("envelope: key must look like \"<version>:<secret>\"")
+		}
+		if len(secret) < 16 {
+			return fmt.Errorf("envelope: key version %d is shorter than 16 characters", version)
+		}
+		if seen[version] {
+			return fmt.Errorf("envelope: duplicate key version %d", version)
+		}
+		seen[version] = true
+
+		derive := func(label string) []byte {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write([]byte("feedbackbot/envelope/" + label))
+			return mac.Sum(nil)
+		}
+		wrap, err := newAEAD(derive("wrap"))
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, masterKey{version: version, lookup: derive("lookup"), wrap: wrap})
+	}
+	if len(parsed) == 0 {
+		return ErrNoKeys
+	}
+	sort.Slice(parsed, func(i, j int) bool { return parsed[i].version > parsed[j].version })
+	keys = parsed
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func current() masterKey {
+	if len(keys) == 0 {
+		panic(ErrNoKeys)
+	}
+	return keys[0]
+}
+
+func find(version int) (masterKey, error) {
+	for _, k := range keys {
+		if k.version == version {
+			return k, nil
+		}
+	}
+	return masterKey{}, fmt.Errorf("envelope: key version %d is not configured", version)
+}
+
+// CurrentVersion returns the version of the current master key.
+func CurrentVersion() int {
+	return current().version
+}
+
+// IsCurrent reports whether a wrapped key or lookup hash uses the current
+// master key.
+func IsCurrent(value string) bool {
+	return strings.HasPrefix(value, fmt.Sprintf("v%d:", CurrentVersion()))
+}
+
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+}
+
+// Seal encrypts plaintext under a fresh data key and returns the ciphertext
+// together with the data key wrapped by the current master key.
+func Seal(plaintext string) (ciphertext, wrappedKey string, err error) {
+	k := current()
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", "", err
+	}
+	sealed, err := seal(aead, []byte(plaintext))
+	if err != nil {
+		return "", "", err
+	}
+	wrapped, err := seal(k.wrap, dataKey)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.RawStdEncoding.EncodeToString(sealed),
+		fmt.Sprintf("v%d:%s", k.version, base64.RawStdEncoding.EncodeToString(wrapped)), nil
+}
+
+// Open reverses Seal using whichever configured master key wrapped the data key.
+func Open(ciphertext, wrappedKey string) (string, error) {
+	v, data, ok := strings.Cut(strings.TrimPrefix(wrappedKey, "v"), ":")
+	version, err := strconv.Atoi(v)
+	if !ok || err != nil {
+		return "", ErrMalformed
+	}
+	k, err := find(version)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	dataKey, err := open(k.wrap, wrapped)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	plain, err := open(aead, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func (k masterKey) lookupHash(value string) string {
+	mac := hmac.New(sha256.New, k.lookup)
+	mac.Write([]byte(value))
+	return fmt.Sprintf("v%d:%s", k.version, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// LookupHash returns a deterministic hash of value under the current master
+// key, for unique indexes and equality lookups.
+func LookupHash(value string) string {
+	return current().lookupHash(value)
+}
+
+// LookupHashes returns the lookup hash under every configured master key,
+// current first, so rows not yet rotated still match.
+func LookupHashes(value string) []string {
+	current()
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		out[i] = k.lookupHash(value)
+	}
+	return out
+}
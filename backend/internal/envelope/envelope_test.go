@@ -0,0 +1,61 @@
+package envelope
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen_RoundTrip(t *testing.T) {
+	require.NoError(t, Configure([]string{"1:first-master-0123456789"}))
+	c1, k1, err := Seal("123:ABC")
+	require.NoError(t, err)
+	c2, k2, err := Seal("123:ABC")
+	require.NoError(t, err)
+
+	// Fresh data key per value
+	assert.NotEqual(t, c1, c2)
+	assert.NotEqual(t, k1, k2)
+	assert.NotContains(t, c1, "ABC")
+
+	plain, err := Open(c1, k1)
+	require.NoError(t, err)
+	assert.Equal(t, "123:ABC", plain)
+
+	_, err = Open(c1, k2)
+	assert.Error(t, err)
+}
+
+func TestLookupHash_Deterministic(t *testing.T) {
+	require.NoError(t, Configure([]string{"1:first-master-0123456789"}))
+	h := LookupHash("123:ABC")
+	assert.Equal(t, h, LookupHash("123:ABC"))
+	assert.NotEqual(t, h, LookupHash("123:ABD"))
+	assert.NotContains(t, h, "ABC")
+}
+
+func TestRotation_OldKeysStillOpen(t *testing.T) {
+	require.NoError(t, Configure([]string{"1:first-master-0123456789"}))
+	ciphertext, wrapped, err := Seal("token")
+	require.NoError(t, err)
+	oldHash := LookupHash("token")
+
+	require.NoError(t, Configure([]string{"1:first-master-0123456789", "2:second-master-0123456789"}))
+	assert.False(t, IsCurrent(wrapped))
+	assert.Equal(t, []string{LookupHash("token"), oldHash}, LookupHashes("token"))
+	plain, err := Open(ciphertext, wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, "token", plain)
+
+	require.NoError(t, Configure([]string{"2:second-master-0123456789"}))
+	_, err = Open(ciphertext, wrapped)
+	assert.Error(t, err)
+}
+
+func TestConfigure_RejectsBadKeys(t *testing.T) {
+	assert.ErrorIs(t, Configure(nil), ErrNoKeys)
+	assert.Error(t, Configure([]string{"short"}))
+	assert.Error(t, Configure([]string{"1:too-short"}))
+	assert.Error(t, Configure([]string{"1:first-master-0123456789", "1:other-master-0123456789"}))
+}
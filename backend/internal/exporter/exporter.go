@@ -0,0 +1,288 @@
+// Package exporter streams feedback listings as CSV, JSON, NDJSON or XLSX.
+//
+// Rows are read in keyset batches (db.NewestFirst) and written as they
+// arrive, so exports have no row cap and hold one batch in memory.
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"gorm.io/gorm"
+)
+
+// Formats.
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+var contentTypes = map[string]string{
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatJSON:   "application/json",
+	FormatNDJSON: "application/x-ndjson",
+	FormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+const batchSize = 500
+
+// Column is one field of the export. Value returns a string, bool, uint or
+// time.Time.
+type Column struct {
+	Key    string // Name in ?columns= and in JSON output
+	Header string // CSV/XLSX header
+	Value  func(fb models.Feedback) interface{}
+}
+
+// Columns are all exportable fields. The first seven are the layout
+// models.ImportFormatExportCSV reads, and stay in that order.
+var Columns = []Column{
+	{"id", "ID", func(fb models.Feedback) interface{} { return fb.ID }},
+	{"group", "Group", func(fb models.Feedback) interface{} { return fb.Group.Title }},
+	{"message", "Message", func(fb models.Feedback) interface{} { return fb.Message }},
+	{"admin_only", "Admin Only", func(fb models.Feedback) interface{} { return fb.AdminOnly }},
+	{"posted", "Posted to Group", func(fb models.Feedback) interface{} { return fb.Posted }},
+	{"created_at", "Created At", func(fb models.Feedback) interface{} { return fb.CreatedAt }},
+	{"sealed", "Sealed", func(fb models.Feedback) interface{} { return fb.Sealed }},
+	{"status", "Status", func(fb models.Feedback) interface{} { return Status(fb) }},
+	{"type", "Type", func(fb models.Feedback) interface{} { return fb.Type }},
+	{"group_id", "Group ID", func(fb models.Feedback) interface{} { return fb.GroupID }},
+	{"pinned", "Pinned", func(fb models.Feedback) interface{} { return fb.Pinned }},
+	{"edited_at", "Edited At", func(fb models.Feedback) interface{} { return fb.EditedAt }},
+	{"reject_reason", "Reject Reason", func(fb models.Feedback) interface{} { return fb.RejectReason }},
+	{"parent_id", "Comment On", func(fb models.Feedback) interface{} { return fb.ParentID }},
+}
+
+// Status sums up where a feedback item is in its lifecycle.
+func Status(fb models.Feedback) string {
+	switch {
+	case fb.Moderation == models.ModerationPending:
+		return "pending_approval"
+	case fb.Moderation == models.ModerationRejected:
+		return "rejected"
+	case fb.Posted:
+		return "posted"
+	case fb.ScheduledAt != nil, fb.DigestQueued:
+		return "scheduled"
+	}
+	return "received"
+}
+
+// Options select what an export writes.
+type Options struct {
+	Format  string
+	Columns []string // Column keys; empty for all
+	BOM     bool     // Prefix CSV with a UTF-8 byte order mark, for Excel
+	// Prepare, if set, is called on each batch before it is written
+	Prepare func([]models.Feedback)
+}
+
+// Validate checks opts and returns the columns to write.
+func (opts Options) Validate() ([]Column, error) {
+	if _, ok := contentTypes[opts.Format]; !ok {
+		return nil, fmt.Errorf("format must be one of %s, %s, %s, %s", FormatCSV, FormatJSON, FormatNDJSON, FormatXLSX)
+	}
+	if len(opts.Columns) == 0 {
+		return Columns, nil
+	}
+	byKey := make(map[string]Column, len(Columns))
+	for _, col := range Columns {
+		byKey[col.Key] = col
+	}
+	cols := make([]Column, 0, len(opts.Columns))
+	for _, key := range opts.Columns {
+		col, ok := byKey[strings.TrimSpace(key)]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", key)
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// Write streams the feedbacks selected by query to w, newest first.
+func Write(w io.Writer, query *gorm.DB, opts Options) error {
+	cols, err := opts.Validate()
+	if err != nil {
+		return err
+	}
+	rw, err := newRowWriter(w, opts, cols)
+	if err != nil {
+		return err
+	}
+
+	base := query.Session(&gorm.Session{}).Preload("Group", func(tx *gorm.DB) *gorm.DB {
+		return tx.Select("id", "title")
+	})
+	var after uint
+	for {
+		var batch []models.Feedback
+		if err := base.Scopes(db.NewestFirst("feedbacks", after)).Limit(batchSize).Find(&batch).Error; err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		after = batch[len(batch)-1].ID
+		if opts.Prepare != nil {
+			opts.Prepare(batch)
+		}
+		for _, fb := range batch {
+			values := make([]interface{}, len(cols))
+			for i, col := range cols {
+				values[i] = col.Value(fb)
+			}
+			if err := rw.row(values); err != nil {
+				return err
+			}
+		}
+		if len(batch) < batchSize {
+			break
+		}
+	}
+	return rw.close()
+}
+
+type rowWriter interface {
+	row(values []interface{}) error
+	close() error
+}
+
+func newRowWriter(w io.Writer, opts Options, cols []Column) (rowWriter, error) {
+	switch opts.Format {
+	case FormatCSV:
+		return newCSVWriter(w, cols, opts.BOM)
+	case FormatJSON, FormatNDJSON:
+		return newJSONWriter(w, cols, opts.Format == FormatJSON)
+	case FormatXLSX:
+		return newXLSXWriter(w, cols)
+	}
+	return nil, fmt.Errorf("unknown format %q", opts.Format)
+}
+
+// text renders a value for CSV and XLSX.
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case *uint:
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*v), 10)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, cols []Column, bom bool) (*csvWriter, error) {
+	if bom {
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return nil, err
+		}
+	}
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	header := make([]string, len(cols))
+	for i, col := range cols {
+		header[i] = col.Header
+	}
+	return cw, cw.w.Write(header)
+}
+
+func (cw *csvWriter) row(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = text(v)
+	}
+	return cw.w.Write(record)
+}
+
+func (cw *csvWriter) close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// jsonWriter writes objects with keys in column order, either as one array
+// or one per line.
+type jsonWriter struct {
+	w     *bufio.Writer
+	keys  [][]byte
+	array bool
+	n     int
+}
+
+func newJSONWriter(w io.Writer, cols []Column, array bool) (*jsonWriter, error) {
+	jw := &jsonWriter{w: bufio.NewWriter(w), array: array}
+	for _, col := range cols {
+		key, _ := json.Marshal(col.Key)
+		jw.keys = append(jw.keys, key)
+	}
+	if array {
+		jw.w.WriteString("[")
+	}
+	return jw, nil
+}
+
+func (jw *jsonWriter) row(values []interface{}) error {
+	if jw.array && jw.n > 0 {
+		jw.w.WriteString(",")
+	}
+	jw.n++
+	jw.w.WriteString("{")
+	for i, v := range values {
+		if i > 0 {
+			jw.w.WriteString(",")
+		}
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		jw.w.Write(jw.keys[i])
+		jw.w.WriteString(":")
+		jw.w.Write(encoded)
+	}
+	jw.w.WriteString("}")
+	if !jw.array {
+		jw.w.WriteString("\n")
+	}
+	if jw.w.Buffered() > 32*1024 {
+		return jw.w.Flush()
+	}
+	return nil
+}
+
+func (jw *jsonWriter) close() error {
+	if jw.array {
+		jw.w.WriteString("]")
+	}
+	return jw.w.Flush()
+}
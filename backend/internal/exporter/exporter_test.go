@@ -0,0 +1,115 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// seed creates n feedbacks, one minute apart, oldest first.
+func seed(t *testing.T, n int) models.Group {
+	t.Helper()
+	testutil.SetupTestDB(t)
+	group := models.Group{TenantID: 1, BotID: 1, ChatID: -100, Title: "Team", IsActive: true}
+	require.NoError(t, models.DB.Create(&group).Error)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	batch := make([]models.Feedback, n)
+	for i := range batch {
+		at := base.Add(time.Duration(i) * time.Minute)
+		batch[i] = models.Feedback{TenantID: 1, GroupID: group.ID, Message: "m", Model: gorm.Model{CreatedAt: at, UpdatedAt: at}}
+	}
+	require.NoError(t, models.DB.CreateInBatches(&batch, 200).Error)
+	return group
+}
+
+func TestWrite_CSVStreamsAllRowsNewestFirst(t *testing.T) {
+	seed(t, batchSize*2+3)
+
+	var buf bytes.Buffer
+	prepared := 0
+	opts := Options{Format: FormatCSV, BOM: true, Prepare: func(b []models.Feedback) { prepared += len(b) }}
+	require.NoError(t, Write(&buf, models.DB.Model(&models.Feedback{}), opts))
+
+	assert.True(t, strings.HasPrefix(buf.String(), "\ufeffID,Group,Message,Admin Only,Posted to Group,Created At,Sealed,Status"))
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\ufeff"))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, batchSize*2+4)
+	assert.Equal(t, batchSize*2+3, prepared)
+	assert.Equal(t, "2026-01-01T00:00:00Z", records[len(records)-1][5])
+	assert.Equal(t, "Team", records[1][1])
+	assert.Equal(t, "received", records[1][7])
+	for i := 2; i < len(records); i++ {
+		assert.Greater(t, records[i-1][5], records[i][5])
+	}
+}
+
+func TestWrite_JSONAndNDJSON(t *testing.T) {
+	seed(t, 2)
+	models.DB.Model(&models.Feedback{}).Where("id = 1").Updates(map[string]interface{}{"message": `say "hi"`, "moderation": models.ModerationPending})
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, models.DB.Model(&models.Feedback{}), Options{Format: FormatJSON, Columns: []string{"message", "status", "admin_only", "id"}}))
+	assert.True(t, strings.HasPrefix(buf.String(), `[{"message":`))
+	var rows []map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rows))
+	require.Len(t, rows, 2)
+	assert.Equal(t, map[string]interface{}{"message": `say "hi"`, "status": "pending_approval", "admin_only": false, "id": float64(1)}, rows[1])
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, models.DB.Model(&models.Feedback{}).Where("id = 2"), Options{Format: FormatNDJSON, Columns: []string{"id"}}))
+	assert.Equal(t, "{\"id\":2}\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, models.DB.Model(&models.Feedback{}).Where("id = 0"), Options{Format: FormatJSON}))
+	assert.Equal(t, "[]", buf.String())
+}
+
+func TestWrite_XLSX(t *testing.T) {
+	seed(t, 1)
+	models.DB.Model(&models.Feedback{}).Where("id = 1").Update("message", "a < b & \x01c")
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, models.DB.Model(&models.Feedback{}), Options{Format: FormatXLSX, Columns: []string{"message", "sealed"}}))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			data, _ := io.ReadAll(rc)
+			sheet = string(data)
+		}
+	}
+	assert.Contains(t, sheet, `<c r="A1" t="inlineStr"><is><t xml:space="preserve">Message</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">a &lt; b &amp; c</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B2" t="b"><v>0</v></c>`)
+}
+
+func TestValidate(t *testing.T) {
+	_, err := Options{Format: "pdf"}.Validate()
+	assert.Error(t, err)
+	_, err = Options{Format: FormatCSV, Columns: []string{"message", "sender_id"}}.Validate()
+	assert.EqualError(t, err, `unknown column "sender_id"`)
+	cols, err := Options{Format: FormatCSV, Columns: []string{" status", "id"}}.Validate()
+	require.NoError(t, err)
+	assert.Equal(t, "Status", cols[0].Header)
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "BA", columnName(52))
+}
@@ -0,0 +1,171 @@
+package exporter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// JobTTL is how long a finished export stays downloadable. Expired
+	// files are removed by tenantdata.Run.
+	JobTTL = 24 * time.Hour
+	// DownloadURLTTL is how long a signed download link works.
+	DownloadURLTTL = time.Hour
+)
+
+// StartJob queues an export of the feedbacks selected by query to a file and
+// builds it in the background. params are the listing filters, recorded on
+// the job; userID is notified when it finishes.
+func StartJob(tenantID, userID uint, params string, query *gorm.DB, opts Options, fileName string) (models.ExportJob, error) {
+	if _, err := opts.Validate(); err != nil {
+		return models.ExportJob{}, err
+	}
+	job := models.ExportJob{
+		TenantID: tenantID,
+		UserID:   userID,
+		Kind:     models.ExportKindFeedbacks,
+		Status:   models.ExportPending,
+		Format:   opts.Format,
+		Params:   params,
+		FileName: fileName,
+	}
+	if err := models.DB.Create(&job).Error; err != nil {
+		return job, err
+	}
+	go runJob(job, query, opts)
+	return job, nil
+}
+
+// Rebuild recreates the query and options of a feedbacks export from the
+// params recorded on its job.
+type Rebuild func(job models.ExportJob) (*gorm.DB, Options, error)
+
+// ResumeAll restarts the feedbacks exports a restart left pending or running,
+// from the beginning.
+func ResumeAll(rebuild Rebuild) {
+	var jobs []models.ExportJob
+	models.DB.Where("kind = ? AND status IN ?", models.ExportKindFeedbacks, []string{models.ExportPending, models.ExportRunning}).Find(&jobs)
+	for _, job := range jobs {
+		query, opts, err := rebuild(job)
+		if err != nil {
+			log.Printf("[exporter] Can't resume export %d: %v", job.ID, err)
+			models.DB.Model(&job).Updates(map[string]interface{}{"status": models.ExportFailed, "error": err.Error()})
+			continue
+		}
+		log.Printf("[exporter] Resuming export %d", job.ID)
+		go runJob(job, query, opts)
+	}
+}
+
+func jobPath(job models.ExportJob) string {
+	return filepath.Join(config.DataDir(), "exports", fmt.Sprintf("feedbacks-%d-%d.%s", job.TenantID, job.ID, job.Format))
+}
+
+func runJob(job models.ExportJob, query *gorm.DB, opts Options) {
+	var total int64
+	query.Session(&gorm.Session{}).Model(&models.Feedback{}).Count(&total)
+	models.DB.Model(&job).Updates(map[string]interface{}{"status": models.ExportRunning, "total": total})
+
+	var processed int64
+	prepare := opts.Prepare
+	opts.Prepare = func(batch []models.Feedback) {
+		if prepare != nil {
+			prepare(batch)
+		}
+		processed += int64(len(batch))
+		models.DB.Model(&job).Update("processed", processed)
+	}
+
+	path := jobPath(job)
+	size, err := writeFile(path, query, opts)
+	if err != nil {
+		log.Printf("[exporter] Export %d for tenant %d failed: %v", job.ID, job.TenantID, err)
+		os.Remove(path)
+		models.DB.Model(&job).Updates(map[string]interface{}{"status": models.ExportFailed, "error": err.Error()})
+		notify(job, models.NotificationExportFailed, "Your export failed: "+err.Error())
+		return
+	}
+
+	now := time.Now()
+	models.DB.Model(&job).Updates(map[string]interface{}{
+		"status":       models.ExportReady,
+		"file_path":    path,
+		"file_size":    size,
+		"completed_at": now,
+		"expires_at":   now.Add(JobTTL),
+	})
+	notify(job, models.NotificationExportReady, fmt.Sprintf("Your export %s is ready to download.", job.FileName))
+}
+
+func writeFile(path string, query *gorm.DB, opts Options) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	if err := Write(f, query, opts); err != nil {
+		f.Close()
+		return 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+	return info.Size(), f.Close()
+}
+
+func notify(job models.ExportJob, kind, message string) {
+	if job.UserID == 0 {
+		return
+	}
+	models.DB.Create(&models.Notification{
+		TenantID: job.TenantID,
+		UserID:   job.UserID,
+		Kind:     kind,
+		Message:  message,
+		Link:     fmt.Sprintf("/exports/%d", job.ID),
+	})
+}
+
+func signature(jobID uint, expires int64) string {
+	mac := hmac.New(sha256.New, []byte("export-download:"+config.Confs.Settings.JWTSecret))
+	fmt.Fprintf(mac, "%d:%d", jobID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DownloadURL returns a signed link to a ready job's file, valid for
+// DownloadURLTTL or until the file expires. It needs no other auth, so it can
+// be opened directly by the browser.
+func DownloadURL(job models.ExportJob, now time.Time) string {
+	if job.Status != models.ExportReady || job.FilePath == "" {
+		return ""
+	}
+	expires := now.Add(DownloadURLTTL)
+	if job.ExpiresAt != nil && job.ExpiresAt.Before(expires) {
+		expires = *job.ExpiresAt
+	}
+	return fmt.Sprintf("/exports/%d/download?expires=%d&signature=%s", job.ID, expires.Unix(), signature(job.ID, expires.Unix()))
+}
+
+// VerifyDownload checks the expires and signature params of a download link.
+func VerifyDownload(jobID uint, expires, sig string, now time.Time) bool {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(signature(jobID, unix)))
+}
@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartJob_WritesFileAndNotifies(t *testing.T) {
+	seed(t, batchSize+1)
+	config.Confs.Settings.DataDir = t.TempDir()
+
+	job, err := StartJob(1, 7, "format=ndjson", models.DB.Model(&models.Feedback{}), Options{Format: FormatNDJSON, Columns: []string{"id"}}, "feedbacks.ndjson")
+	require.NoError(t, err)
+	// The notification is the job's last step
+	var n models.Notification
+	require.Eventually(t, func() bool {
+		return models.DB.Where("user_id = ?", 7).First(&n).Error == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, models.NotificationExportReady, n.Kind)
+	assert.Equal(t, "/exports/1", n.Link)
+
+	models.DB.First(&job, job.ID)
+	assert.Equal(t, models.ExportReady, job.Status)
+
+	assert.Equal(t, int64(batchSize+1), job.Total)
+	assert.Equal(t, job.Total, job.Processed)
+	require.NotNil(t, job.ExpiresAt)
+	data, err := os.ReadFile(job.FilePath)
+	require.NoError(t, err)
+	assert.Equal(t, batchSize+1, strings.Count(string(data), "\n"))
+	assert.Equal(t, int64(len(data)), job.FileSize)
+}
+
+func TestDownloadURL(t *testing.T) {
+	config.Confs.Settings.JWTSecret = "test-secret"
+	now := time.Now()
+	expires := now.Add(10 * time.Minute)
+	job := models.ExportJob{Status: models.ExportReady, FilePath: "/tmp/x", ExpiresAt: &expires}
+	job.ID = 3
+
+	link := DownloadURL(job, now)
+	require.True(t, strings.HasPrefix(link, "/exports/3/download?expires="))
+	params := strings.Split(strings.SplitN(link, "?", 2)[1], "&")
+	exp, sig := strings.TrimPrefix(params[0], "expires="), strings.TrimPrefix(params[1], "signature=")
+
+	// Capped at the file's expiry
+	assert.Equal(t, strconv.FormatInt(expires.Unix(), 10), exp)
+	assert.True(t, VerifyDownload(3, exp, sig, now))
+	assert.False(t, VerifyDownload(4, exp, sig, now))
+	assert.False(t, VerifyDownload(3, exp, sig, expires.Add(time.Second)))
+	assert.False(t, VerifyDownload(3, strconv.FormatInt(expires.Unix()+3600, 10), sig, now))
+
+	job.Status = models.ExportRunning
+	assert.Empty(t, DownloadURL(job, now))
+}
@@ -0,0 +1,114 @@
+package i18n
+
+var en = map[string]string{
+	"start.welcome": "👋 Welcome to FeedbackBot!\n\nSend me a message and I'll deliver it anonymously to your team admin.\n\nUse /adminOnly before your message to keep it visible only to the admin.\nStart with /idea, /issue, /praise or /question to say what kind of feedback it is.\nUse /history to see what you sent, /status <code> to check one item and /retract to withdraw your latest feedback.\nUse /language to change the language.",
+
+	"feedback.admin_only_empty": "Please write your feedback after /adminOnly.\n\nExample: /adminOnly I think we should improve our standup meetings.",
+	"feedback.type_empty":       "Please write your message after /{type}.",
+	"feedback.type_not_allowed": "❌ /{type} isn't enabled for your group. Send your message without the command to submit it as general feedback.",
+	"feedback.empty":            "Please send a text message with your feedback.",
+	"feedback.too_long.one":     "Your message is too long. Please keep it under {count} character.",
+	"feedback.too_long.other":   "Your message is too long. Please keep it under {count} characters.",
+	"feedback.no_groups":        "❌ No active groups found. The bot needs to be added to a group first.",
+	"feedback.pick_group":       "📋 Which group is this feedback for?",
+	"feedback.sent_admin_only":  "✅ Your feedback has been sent privately to the admin. It will NOT be posted in the group.",
+	"feedback.sent":             "✅ Your feedback has been submitted anonymously. Thank you!",
+	"feedback.session_expired":  "⏳ Session expired. Please send your feedback again.",
+	"feedback.group_not_found":  "❌ Group not found.",
+
+	"feedback.tracking_code": "🔖 Tracking code: {code}\nUse /status {code} to check it, /edit {code} <text> to change it or /retract {code} to withdraw it.",
+
+	"history.empty":        "You haven't sent any feedback yet.",
+	"history.header.one":   "🗂 Your last {count} feedback:",
+	"history.header.other": "🗂 Your last {count} feedbacks:",
+
+	"status.usage":      "Please add the tracking code.\n\nExample: /status ABCD2345",
+	"status.not_found":  "❌ No feedback with code {code} found.",
+	"status.details":    "🔖 {code}\nSent: {date}\nStatus: {status}\n\n{message}",
+	"status.received":   "📥 received",
+	"status.posted":     "📢 posted in the group",
+	"status.admin_only": "🔒 delivered to the admin only",
+	"status.retracted":  "↩️ retracted",
+
+	"retract.done":          "↩️ Feedback {code} has been withdrawn.",
+	"retract.already":       "Feedback {code} was already withdrawn.",
+	"retract.disabled":      "❌ Withdrawing feedback is turned off for this group.",
+	"retract.expired.one":   "❌ Feedback can only be withdrawn within {count} minute of sending.",
+	"retract.expired.other": "❌ Feedback can only be withdrawn within {count} minutes of sending.",
+
+	"edit.usage":     "Please add the tracking code and the new text.\n\nExample: /edit ABCD2345 Our standups run too long.",
+	"edit.done":      "✏️ Feedback {code} has been updated.",
+	"edit.moderated": "❌ Feedback {code} has already been reviewed by a moderator and can't be edited.",
+	"edit.failed":    "❌ Feedback {code} could not be updated. Please try again later.",
+
+	"feedback.awaiting_approval": "⏳ It will be posted in the group once a moderator approves it.",
+
+	"status.pending":  "⏳ awaiting moderation",
+	"status.rejected": "🚫 not approved for posting",
+
+	"moderation.request":         "🛡 New feedback for {group} is awaiting approval:\n\n{message}",
+	"moderation.approve":         "✅ Approve",
+	"moderation.reject":          "🚫 Reject",
+	"moderation.approved_by":     "✅ Approved by {name}",
+	"moderation.rejected_by":     "🚫 Rejected by {name}",
+	"moderation.already":         "This feedback has already been moderated.",
+	"moderation.not_allowed":     "❌ Only group admins can moderate feedback.",
+	"moderation.sender_approved": "✅ Your feedback {code} was approved and posted in the group.",
+	"moderation.sender_rejected": "🚫 Your feedback {code} was not approved for posting in the group.{reason}",
+
+	"feedback.scheduled":     "🕒 To protect your anonymity it will appear in the group with a delay.",
+	"status.scheduled":       "🕒 queued for posting in the group",
+	"feedback.digest_queued": "🗂 It will be posted together with other feedback in the group's next digest.",
+	"status.digest_queued":   "🗂 queued for the group's next digest",
+
+	"feedback.sealed_preview": "🔐 End-to-end encrypted, readable only by the admin.",
+	"feedback.seal_failed":    "❌ Your feedback could not be encrypted for the admin, so it was not saved. Please try again later.",
+
+	"group.post_header":          "📬 Anonymous Feedback:",
+	"group.post_header_idea":     "💡 Anonymous Idea:",
+	"group.post_header_issue":    "⚠️ Anonymous Issue:",
+	"group.post_header_praise":   "🙌 Anonymous Praise:",
+	"group.post_header_question": "❓ Anonymous Question:",
+	"group.digest_header":        "📋 Feedback digest:",
+	"group.comment_header":       "💬 Anonymous comment:",
+	"group.response_header":      "📣 You said, we did:",
+	"group.response_quote":       "💬 Feedback: «{message}»",
+
+	"comment.button":            "💬 Comment anonymously",
+	"comment.prompt":            "💬 Write your comment on this feedback. It will be posted anonymously as a reply:\n\n{preview}\n\nSend /cancel to stop.",
+	"comment.sent":              "✅ Your comment has been submitted anonymously. Thank you!",
+	"comment.not_found":         "❌ This feedback can no longer be commented on.",
+	"comment.not_member":        "❌ Only members of {group} can comment on its feedback.",
+	"comment.cancelled":         "Your comment was cancelled.",
+	"comment.nothing_to_cancel": "There is nothing to cancel.",
+
+	"survey.announce":           "📊 {title}\n\nAnswer anonymously in a private chat with the bot until {closes}.",
+	"survey.open_button":        "Answer the survey",
+	"survey.question":           "📊 {title}\n\nQuestion {number} of {total}:\n{text}",
+	"survey.thanks":             "✅ Thank you! Your answers are anonymous. You can change them until the survey closes.",
+	"survey.closed":             "⏳ This survey has closed.",
+	"survey.not_found":          "❌ Survey not found.",
+	"survey.not_member":         "❌ Only members of {group} can answer this survey.",
+	"retro.column_went_well":    "Went well",
+	"retro.column_to_improve":   "To improve",
+	"retro.column_action_items": "Action items",
+	"retro.announce":            "🔁 {title}\n\nSend your notes to the bot in a private chat until {ends}. Notes are anonymous, and nothing is shown until the retro ends.\n\nColumns: {columns}",
+	"retro.open_button":         "Add notes",
+	"retro.pick_column":         "🔁 A retro is collecting notes. Which column is this for?",
+	"retro.added":               "✅ Added to «{column}». All notes are revealed in the group when the retro ends, at {ends}.",
+	"retro.closed":              "⏳ This retro has already ended.",
+	"retro.not_member":          "❌ Only members of {group} can add notes to this retro.",
+	"retro.reveal":              "🔁 {title}\n\nThe retro has ended. Here are all the notes, anonymously.",
+	"retro.vote_hint":           "Tap a note's number to give it a dot. Dots per person: {dots}.",
+	"retro.column_empty":        "No notes.",
+
+	"rating.ask_enps":  "📈 One more optional question: how likely are you to recommend this team as a place to work? (0 = not at all, 10 = extremely)",
+	"rating.ask_csat":  "📈 One more optional question: how satisfied are you overall? (1 = very unsatisfied, 5 = very satisfied)",
+	"rating.skip":      "Skip",
+	"rating.thanks":    "✅ Thanks! Your rating of {score} is only ever shown as part of team averages.",
+	"rating.not_found": "❌ This feedback can no longer be rated.",
+
+	"language.choose":      "🌐 Choose your language:",
+	"language.set":         "✅ Language set to {language}.",
+	"language.unsupported": "❌ Unsupported language. Available: {languages}.",
+}
@@ -0,0 +1,117 @@
+package i18n
+
+var ru = map[string]string{
+	"start.welcome": "👋 Добро пожаловать в FeedbackBot!\n\nОтправьте мне сообщение, и я анонимно передам его администратору вашей команды.\n\nНапишите /adminOnly перед сообщением, чтобы его видел только администратор.\nНачните с /idea, /issue, /praise или /question, чтобы указать тип отзыва: идея, проблема, благодарность или вопрос.\nКоманда /history покажет ваши отзывы, /status <код> — статус одного отзыва, /retract отзовёт последний отзыв.\nКоманда /language меняет язык.",
+
+	"feedback.admin_only_empty": "Напишите отзыв после /adminOnly.\n\nПример: /adminOnly Думаю, нам стоит улучшить наши стендапы.",
+	"feedback.type_empty":       "Напишите сообщение после /{type}.",
+	"feedback.type_not_allowed": "❌ Команда /{type} не включена для вашей группы. Отправьте сообщение без команды, чтобы оно ушло как обычный отзыв.",
+	"feedback.empty":            "Пожалуйста, отправьте отзыв текстовым сообщением.",
+	"feedback.too_long.one":     "Сообщение слишком длинное. Пожалуйста, уложитесь в {count} символ.",
+	"feedback.too_long.few":     "Сообщение слишком длинное. Пожалуйста, уложитесь в {count} символа.",
+	"feedback.too_long.many":    "Сообщение слишком длинное. Пожалуйста, уложитесь в {count} символов.",
+	"feedback.no_groups":        "❌ Активные группы не найдены. Сначала добавьте бота в группу.",
+	"feedback.pick_group":       "📋 Для какой группы этот отзыв?",
+	"feedback.sent_admin_only":  "✅ Ваш отзыв отправлен лично администратору. Он НЕ будет опубликован в группе.",
+	"feedback.sent":             "✅ Ваш отзыв анонимно отправлен. Спасибо!",
+	"feedback.session_expired":  "⏳ Сессия истекла. Отправьте отзыв ещё раз.",
+	"feedback.group_not_found":  "❌ Группа не найдена.",
+
+	"feedback.tracking_code": "🔖 Код отслеживания: {code}\nКоманда /status {code} покажет статус, /edit {code} <текст> изменит отзыв, /retract {code} отзовёт его.",
+
+	"history.empty":       "Вы ещё не отправляли отзывов.",
+	"history.header.one":  "🗂 Ваш последний {count} отзыв:",
+	"history.header.few":  "🗂 Ваши последние {count} отзыва:",
+	"history.header.many": "🗂 Ваши последние {count} отзывов:",
+
+	"status.usage":      "Укажите код отслеживания.\n\nПример: /status ABCD2345",
+	"status.not_found":  "❌ Отзыв с кодом {code} не найден.",
+	"status.details":    "🔖 {code}\nОтправлен: {date}\nСтатус: {status}\n\n{message}",
+	"status.received":   "📥 получен",
+	"status.posted":     "📢 опубликован в группе",
+	"status.admin_only": "🔒 доставлен только администратору",
+	"status.retracted":  "↩️ отозван",
+
+	"retract.done":         "↩️ Отзыв {code} отозван.",
+	"retract.already":      "Отзыв {code} уже отозван.",
+	"retract.disabled":     "❌ В этой группе отзывать отзывы нельзя.",
+	"retract.expired.one":  "❌ Отозвать отзыв можно только в течение {count} минуты после отправки.",
+	"retract.expired.few":  "❌ Отозвать отзыв можно только в течение {count} минут после отправки.",
+	"retract.expired.many": "❌ Отозвать отзыв можно только в течение {count} минут после отправки.",
+
+	"edit.usage":     "Укажите код отслеживания и новый текст.\n\nПример: /edit ABCD2345 Наши стендапы слишком длинные.",
+	"edit.done":      "✏️ Отзыв {code} обновлён.",
+	"edit.moderated": "❌ Отзыв {code} уже проверен модератором, его нельзя изменить.",
+	"edit.failed":    "❌ Не удалось обновить отзыв {code}. Попробуйте позже.",
+
+	"feedback.awaiting_approval": "⏳ Он появится в группе после одобрения модератором.",
+
+	"status.pending":  "⏳ ожидает модерации",
+	"status.rejected": "🚫 не одобрен к публикации",
+
+	"moderation.request":         "🛡 Новый отзыв для {group} ожидает одобрения:\n\n{message}",
+	"moderation.approve":         "✅ Одобрить",
+	"moderation.reject":          "🚫 Отклонить",
+	"moderation.approved_by":     "✅ Одобрено: {name}",
+	"moderation.rejected_by":     "🚫 Отклонено: {name}",
+	"moderation.already":         "Этот отзыв уже прошёл модерацию.",
+	"moderation.not_allowed":     "❌ Модерировать отзывы могут только администраторы группы.",
+	"moderation.sender_approved": "✅ Ваш отзыв {code} одобрен и опубликован в группе.",
+	"moderation.sender_rejected": "🚫 Ваш отзыв {code} не одобрен к публикации в группе.{reason}",
+
+	"feedback.scheduled":     "🕒 Для защиты анонимности он появится в группе с задержкой.",
+	"status.scheduled":       "🕒 в очереди на публикацию в группе",
+	"feedback.digest_queued": "🗂 Он будет опубликован вместе с другими отзывами в ближайшей сводке группы.",
+	"status.digest_queued":   "🗂 в очереди на ближайшую сводку группы",
+
+	"feedback.sealed_preview": "🔐 Зашифровано сквозным шифрованием, прочитать может только администратор.",
+	"feedback.seal_failed":    "❌ Не удалось зашифровать отзыв для администратора, поэтому он не сохранён. Попробуйте позже.",
+
+	"group.post_header":          "📬 Анонимный отзыв:",
+	"group.post_header_idea":     "💡 Анонимная идея:",
+	"group.post_header_issue":    "⚠️ Анонимная проблема:",
+	"group.post_header_praise":   "🙌 Анонимная благодарность:",
+	"group.post_header_question": "❓ Анонимный вопрос:",
+	"group.digest_header":        "📋 Сводка отзывов:",
+	"group.comment_header":       "💬 Анонимный комментарий:",
+	"group.response_header":      "📣 Вы сказали — мы сделали:",
+	"group.response_quote":       "💬 Отзыв: «{message}»",
+
+	"comment.button":            "💬 Комментировать анонимно",
+	"comment.prompt":            "💬 Напишите комментарий к этому отзыву. Он будет анонимно опубликован как ответ:\n\n{preview}\n\nОтправьте /cancel, чтобы отменить.",
+	"comment.sent":              "✅ Ваш комментарий отправлен анонимно. Спасибо!",
+	"comment.not_found":         "❌ Этот отзыв больше нельзя комментировать.",
+	"comment.not_member":        "❌ Комментировать отзывы группы {group} могут только её участники.",
+	"comment.cancelled":         "Комментарий отменён.",
+	"comment.nothing_to_cancel": "Нечего отменять.",
+
+	"survey.announce":           "📊 {title}\n\nОтветьте анонимно в личном чате с ботом до {closes}.",
+	"survey.open_button":        "Пройти опрос",
+	"survey.question":           "📊 {title}\n\nВопрос {number} из {total}:\n{text}",
+	"survey.thanks":             "✅ Спасибо! Ваши ответы анонимны. Их можно изменить, пока опрос открыт.",
+	"survey.closed":             "⏳ Этот опрос уже закрыт.",
+	"survey.not_found":          "❌ Опрос не найден.",
+	"survey.not_member":         "❌ Отвечать на этот опрос могут только участники группы {group}.",
+	"retro.column_went_well":    "Что было хорошо",
+	"retro.column_to_improve":   "Что улучшить",
+	"retro.column_action_items": "Действия",
+	"retro.announce":            "🔁 {title}\n\nОтправьте заметки боту в личном чате до {ends}. Заметки анонимны, и ничего не будет показано до конца ретро.\n\nКолонки: {columns}",
+	"retro.open_button":         "Добавить заметки",
+	"retro.pick_column":         "🔁 Идёт сбор заметок для ретро. В какую колонку добавить?",
+	"retro.added":               "✅ Добавлено в «{column}». Все заметки появятся в группе, когда ретро закончится, в {ends}.",
+	"retro.closed":              "⏳ Это ретро уже закончилось.",
+	"retro.not_member":          "❌ Добавлять заметки в это ретро могут только участники группы {group}.",
+	"retro.reveal":              "🔁 {title}\n\nРетро закончилось. Вот все заметки, анонимно.",
+	"retro.vote_hint":           "Нажмите на номер заметки, чтобы отдать ей точку. Точек на человека: {dots}.",
+	"retro.column_empty":        "Заметок нет.",
+
+	"rating.ask_enps":  "📈 Ещё один необязательный вопрос: насколько вероятно, что вы порекомендуете эту команду как место работы? (0 — точно нет, 10 — обязательно)",
+	"rating.ask_csat":  "📈 Ещё один необязательный вопрос: насколько вы довольны в целом? (1 — совсем недоволен, 5 — очень доволен)",
+	"rating.skip":      "Пропустить",
+	"rating.thanks":    "✅ Спасибо! Ваша оценка {score} показывается только в составе средних по команде.",
+	"rating.not_found": "❌ Этот отзыв больше нельзя оценить.",
+
+	"language.choose":      "🌐 Выберите язык:",
+	"language.set":         "✅ Язык изменён: {language}.",
+	"language.unsupported": "❌ Язык не поддерживается. Доступны: {languages}.",
+}
@@ -0,0 +1,114 @@
+package i18n
+
+var uz = map[string]string{
+	"start.welcome": "👋 FeedbackBot ga xush kelibsiz!\n\nMenga xabar yuboring, men uni jamoangiz administratoriga anonim tarzda yetkazaman.\n\nXabar faqat administratorga ko'rinishi uchun uning oldiga /adminOnly yozing.\nFikr turini ko'rsatish uchun /idea, /issue, /praise yoki /question bilan boshlang: g'oya, muammo, minnatdorchilik yoki savol.\nYuborganlaringizni ko'rish uchun /history, bitta fikr holati uchun /status <kod>, oxirgi fikrni qaytarib olish uchun /retract dan foydalaning.\nTilni o'zgartirish uchun /language dan foydalaning.",
+
+	"feedback.admin_only_empty": "Iltimos, fikringizni /adminOnly dan keyin yozing.\n\nMisol: /adminOnly Menimcha, standup yig'ilishlarimizni yaxshilashimiz kerak.",
+	"feedback.type_empty":       "Iltimos, xabaringizni /{type} dan keyin yozing.",
+	"feedback.type_not_allowed": "❌ /{type} guruhingiz uchun yoqilmagan. Umumiy fikr sifatida yuborish uchun xabarni buyruqsiz yuboring.",
+	"feedback.empty":            "Iltimos, fikringizni matnli xabar sifatida yuboring.",
+	"feedback.too_long.one":     "Xabaringiz juda uzun. Iltimos, {count} belgidan oshirmang.",
+	"feedback.too_long.other":   "Xabaringiz juda uzun. Iltimos, {count} belgidan oshirmang.",
+	"feedback.no_groups":        "❌ Faol guruhlar topilmadi. Avval botni guruhga qo'shing.",
+	"feedback.pick_group":       "📋 Bu fikr qaysi guruh uchun?",
+	"feedback.sent_admin_only":  "✅ Fikringiz administratorga shaxsan yuborildi. U guruhda e'lon qilinMAYDI.",
+	"feedback.sent":             "✅ Fikringiz anonim tarzda yuborildi. Rahmat!",
+	"feedback.session_expired":  "⏳ Sessiya muddati tugadi. Iltimos, fikringizni qayta yuboring.",
+	"feedback.group_not_found":  "❌ Guruh topilmadi.",
+
+	"feedback.tracking_code": "🔖 Kuzatuv kodi: {code}\nHolatini bilish uchun /status {code}, o'zgartirish uchun /edit {code} <matn>, qaytarib olish uchun /retract {code} dan foydalaning.",
+
+	"history.empty":        "Siz hali fikr yubormagansiz.",
+	"history.header.one":   "🗂 Oxirgi {count} ta fikringiz:",
+	"history.header.other": "🗂 Oxirgi {count} ta fikringiz:",
+
+	"status.usage":      "Iltimos, kuzatuv kodini kiriting.\n\nMisol: /status ABCD2345",
+	"status.not_found":  "❌ {code} kodli fikr topilmadi.",
+	"status.details":    "🔖 {code}\nYuborilgan: {date}\nHolati: {status}\n\n{message}",
+	"status.received":   "📥 qabul qilindi",
+	"status.posted":     "📢 guruhda e'lon qilindi",
+	"status.admin_only": "🔒 faqat administratorga yetkazildi",
+	"status.retracted":  "↩️ qaytarib olindi",
+
+	"retract.done":          "↩️ {code} fikri qaytarib olindi.",
+	"retract.already":       "{code} fikri allaqachon qaytarib olingan.",
+	"retract.disabled":      "❌ Bu guruhda fikrni qaytarib olish o'chirilgan.",
+	"retract.expired.one":   "❌ Fikrni faqat yuborilgandan keyin {count} daqiqa ichida qaytarib olish mumkin.",
+	"retract.expired.other": "❌ Fikrni faqat yuborilgandan keyin {count} daqiqa ichida qaytarib olish mumkin.",
+
+	"edit.usage":     "Iltimos, kuzatuv kodi va yangi matnni kiriting.\n\nMisol: /edit ABCD2345 Standuplarimiz juda uzoq davom etadi.",
+	"edit.done":      "✏️ {code} fikri yangilandi.",
+	"edit.moderated": "❌ {code} fikri moderator tomonidan ko'rib chiqilgan, uni tahrirlab bo'lmaydi.",
+	"edit.failed":    "❌ {code} fikrini yangilab bo'lmadi. Keyinroq qayta urinib ko'ring.",
+
+	"feedback.awaiting_approval": "⏳ Moderator tasdiqlagach, u guruhda e'lon qilinadi.",
+
+	"status.pending":  "⏳ moderatsiyani kutmoqda",
+	"status.rejected": "🚫 e'lon qilish uchun tasdiqlanmadi",
+
+	"moderation.request":         "🛡 {group} uchun yangi fikr tasdiqlashni kutmoqda:\n\n{message}",
+	"moderation.approve":         "✅ Tasdiqlash",
+	"moderation.reject":          "🚫 Rad etish",
+	"moderation.approved_by":     "✅ Tasdiqladi: {name}",
+	"moderation.rejected_by":     "🚫 Rad etdi: {name}",
+	"moderation.already":         "Bu fikr allaqachon ko'rib chiqilgan.",
+	"moderation.not_allowed":     "❌ Fikrlarni faqat guruh administratorlari ko'rib chiqishi mumkin.",
+	"moderation.sender_approved": "✅ {code} fikringiz tasdiqlandi va guruhda e'lon qilindi.",
+	"moderation.sender_rejected": "🚫 {code} fikringiz guruhda e'lon qilish uchun tasdiqlanmadi.{reason}",
+
+	"feedback.scheduled":     "🕒 Anonimligingizni himoya qilish uchun u guruhda biroz kechikib paydo bo'ladi.",
+	"status.scheduled":       "🕒 guruhda e'lon qilish navbatida",
+	"feedback.digest_queued": "🗂 U boshqa fikrlar bilan birga guruhning navbatdagi jamlanmasida e'lon qilinadi.",
+	"status.digest_queued":   "🗂 guruhning navbatdagi jamlanmasi navbatida",
+
+	"feedback.sealed_preview": "🔐 Uchdan-uchgacha shifrlangan, faqat administrator o'qiy oladi.",
+	"feedback.seal_failed":    "❌ Fikringizni administrator uchun shifrlab bo'lmadi, shuning uchun u saqlanmadi. Keyinroq qayta urinib ko'ring.",
+
+	"group.post_header":          "📬 Anonim fikr:",
+	"group.post_header_idea":     "💡 Anonim g'oya:",
+	"group.post_header_issue":    "⚠️ Anonim muammo:",
+	"group.post_header_praise":   "🙌 Anonim minnatdorchilik:",
+	"group.post_header_question": "❓ Anonim savol:",
+	"group.digest_header":        "📋 Fikrlar jamlanmasi:",
+	"group.comment_header":       "💬 Anonim izoh:",
+	"group.response_header":      "📣 Siz aytdingiz, biz qildik:",
+	"group.response_quote":       "💬 Fikr: «{message}»",
+
+	"comment.button":            "💬 Anonim izoh qoldirish",
+	"comment.prompt":            "💬 Ushbu fikrga izohingizni yozing. U javob sifatida anonim tarzda e'lon qilinadi:\n\n{preview}\n\nBekor qilish uchun /cancel yuboring.",
+	"comment.sent":              "✅ Izohingiz anonim tarzda yuborildi. Rahmat!",
+	"comment.not_found":         "❌ Bu fikrga endi izoh qoldirib bo'lmaydi.",
+	"comment.not_member":        "❌ {group} fikrlariga faqat uning a'zolari izoh qoldira oladi.",
+	"comment.cancelled":         "Izoh bekor qilindi.",
+	"comment.nothing_to_cancel": "Bekor qilinadigan narsa yo'q.",
+
+	"survey.announce":           "📊 {title}\n\n{closes} gacha bot bilan shaxsiy chatda anonim javob bering.",
+	"survey.open_button":        "So'rovnomaga javob berish",
+	"survey.question":           "📊 {title}\n\n{number}/{total}-savol:\n{text}",
+	"survey.thanks":             "✅ Rahmat! Javoblaringiz anonim. So'rovnoma yopilguncha ularni o'zgartirishingiz mumkin.",
+	"survey.closed":             "⏳ Bu so'rovnoma yopilgan.",
+	"survey.not_found":          "❌ So'rovnoma topilmadi.",
+	"survey.not_member":         "❌ Bu so'rovnomaga faqat {group} guruhi a'zolari javob bera oladi.",
+	"retro.column_went_well":    "Yaxshi bo'ldi",
+	"retro.column_to_improve":   "Yaxshilash kerak",
+	"retro.column_action_items": "Vazifalar",
+	"retro.announce":            "🔁 {title}\n\nEslatmalaringizni {ends} gacha bot bilan shaxsiy chatda yuboring. Eslatmalar anonim va retro tugaguncha hech narsa ko'rsatilmaydi.\n\nUstunlar: {columns}",
+	"retro.open_button":         "Eslatma qo'shish",
+	"retro.pick_column":         "🔁 Retro uchun eslatmalar yig'ilmoqda. Bu qaysi ustun uchun?",
+	"retro.added":               "✅ «{column}» ga qo'shildi. Barcha eslatmalar retro tugaganda, {ends} da guruhda ko'rsatiladi.",
+	"retro.closed":              "⏳ Bu retro allaqachon tugagan.",
+	"retro.not_member":          "❌ Bu retroga faqat {group} guruhi a'zolari eslatma qo'sha oladi.",
+	"retro.reveal":              "🔁 {title}\n\nRetro tugadi. Mana barcha eslatmalar, anonim tarzda.",
+	"retro.vote_hint":           "Eslatmaga nuqta berish uchun uning raqamini bosing. Har bir kishiga nuqtalar: {dots}.",
+	"retro.column_empty":        "Eslatmalar yo'q.",
+
+	"rating.ask_enps":  "📈 Yana bitta ixtiyoriy savol: bu jamoani ish joyi sifatida tavsiya qilishingiz ehtimoli qanday? (0 — umuman yo'q, 10 — albatta)",
+	"rating.ask_csat":  "📈 Yana bitta ixtiyoriy savol: umuman olganda qanchalik mamnunsiz? (1 — umuman mamnun emasman, 5 — juda mamnunman)",
+	"rating.skip":      "O'tkazib yuborish",
+	"rating.thanks":    "✅ Rahmat! Sizning {score} bahoyingiz faqat jamoa o'rtachasi tarkibida ko'rsatiladi.",
+	"rating.not_found": "❌ Bu fikrni endi baholab bo'lmaydi.",
+
+	"language.choose":      "🌐 Tilni tanlang:",
+	"language.set":         "✅ Til o'rnatildi: {language}.",
+	"language.unsupported": "❌ Bu til qo'llab-quvvatlanmaydi. Mavjud tillar: {languages}.",
+}
@@ -0,0 +1,132 @@
+// Package i18n holds the message catalog for everything the bot says to users.
+//
+// Messages are looked up by key for a language ("en", "ru", "uz"). Text may
+// contain {placeholders} that are filled from Args, and pluralized messages are
+// stored under "<key>.<category>" where category is one of one/few/many/other
+// as defined by the CLDR plural rules of the language.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+const DefaultLanguage = "en"
+
+// Args holds placeholder values for a message.
+type Args map[string]interface{}
+
+var catalogs = map[string]map[string]string{
+	"en": en,
+	"ru": ru,
+	"uz": uz,
+}
+
+// Supported lists the language codes with a catalog, in display order.
+var Supported = []string{"en", "ru", "uz"}
+
+// Names maps language codes to their native display names.
+var Names = map[string]string{
+	"en": "English",
+	"ru": "Русский",
+	"uz": "O'zbekcha",
+}
+
+// Normalize maps a Telegram/IETF language code (e.g. "ru-RU") to a supported
+// catalog language. It returns "" if the language is not supported.
+func Normalize(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if _, ok := catalogs[code]; ok {
+		return code
+	}
+	return ""
+}
+
+// IsSupported reports whether lang has a catalog.
+func IsSupported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// T returns the message for key in lang with placeholders filled in.
+// Missing translations fall back to English, then to the key itself.
+func T(lang, key string, args ...Args) string {
+	text, ok := lookup(lang, key)
+	if !ok {
+		return key
+	}
+	return format(text, merge(args))
+}
+
+// N returns the plural form of key for count n. The count is available to the
+// message as the {count} placeholder.
+func N(lang, key string, n int, args ...Args) string {
+	a := merge(args)
+	a["count"] = n
+
+	if text, ok := lookup(lang, key+"."+PluralCategory(lang, n)); ok {
+		return format(text, a)
+	}
+	if text, ok := lookup(lang, key+".other"); ok {
+		return format(text, a)
+	}
+	return key
+}
+
+// PluralCategory returns the CLDR plural category of n for lang.
+func PluralCategory(lang string, n int) string {
+	if n < 0 {
+		n = -n
+	}
+	switch lang {
+	case "ru":
+		mod10, mod100 := n%10, n%100
+		switch {
+		case mod10 == 1 && mod100 != 11:
+			return "one"
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	default: // en, uz
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
+
+func lookup(lang, key string) (string, bool) {
+	if cat, ok := catalogs[lang]; ok {
+		if text, ok := cat[key]; ok {
+			return text, true
+		}
+	}
+	text, ok := catalogs[DefaultLanguage][key]
+	return text, ok
+}
+
+func merge(args []Args) Args {
+	out := Args{}
+	for _, a := range args {
+		for k, v := range a {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func format(text string, args Args) string {
+	if len(args) == 0 || !strings.Contains(text, "{") {
+		return text
+	}
+	pairs := make([]string, 0, len(args)*2)
+	for k, v := range args {
+		pairs = append(pairs, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
@@ -0,0 +1,71 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "ru", Normalize("ru"))
+	assert.Equal(t, "ru", Normalize("ru-RU"))
+	assert.Equal(t, "uz", Normalize("UZ"))
+	assert.Equal(t, "", Normalize("de"))
+	assert.Equal(t, "", Normalize(""))
+}
+
+func TestT_Placeholders(t *testing.T) {
+	assert.Equal(t, "✅ Language set to English.", T("en", "language.set", Args{"language": "English"}))
+}
+
+func TestT_FallsBackToEnglish(t *testing.T) {
+	assert.Equal(t, T("en", "feedback.sent"), T("de", "feedback.sent"))
+	assert.Equal(t, "missing.key", T("ru", "missing.key"))
+}
+
+func TestPluralCategory_Russian(t *testing.T) {
+	cases := map[int]string{1: "one", 21: "one", 2: "few", 24: "few", 5: "many", 11: "many", 12: "many", 111: "many", 0: "many"}
+	for n, want := range cases {
+		assert.Equal(t, want, PluralCategory("ru", n), "n=%d", n)
+	}
+}
+
+func TestN(t *testing.T) {
+	assert.Contains(t, N("en", "feedback.too_long", 1), "1 character.")
+	assert.Contains(t, N("en", "feedback.too_long", 4000), "4000 characters.")
+	assert.Contains(t, N("ru", "feedback.too_long", 4000), "4000 символов.")
+	assert.Contains(t, N("ru", "feedback.too_long", 3), "3 символа.")
+}
+
+func TestCatalogsHaveEnglishKeys(t *testing.T) {
+	for lang, cat := range catalogs {
+		for key := range en {
+			if _, ok := cat[key]; ok {
+				continue
+			}
+			// Plural keys may use a different set of categories per language.
+			if base, ok := pluralBase(key); ok && hasPlural(cat, base) {
+				continue
+			}
+			t.Errorf("%s catalog is missing %q", lang, key)
+		}
+	}
+}
+
+func pluralBase(key string) (string, bool) {
+	for _, cat := range []string{".one", ".few", ".many", ".other"} {
+		if len(key) > len(cat) && key[len(key)-len(cat):] == cat {
+			return key[:len(key)-len(cat)], true
+		}
+	}
+	return "", false
+}
+
+func hasPlural(cat map[string]string, base string) bool {
+	for _, c := range []string{".one", ".few", ".many", ".other"} {
+		if _, ok := cat[base+c]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+// Package identity pseudonymizes Telegram user IDs before they are stored.
+//
+// Senders are stored as a keyed HMAC pseudonym ("v<version>:<hex>") so the
+// database alone can't be used to de-anonymize feedback. Where the bot must
+// still message a sender later (e.g. to report a moderation outcome) the ID
+// is kept encrypted with AES-GCM, only for as long as it is needed.
+//
+// Keys come from the server config, never from the DB. Each key has a
+// version; the highest version is current. Lookups try every configured
+// version so old pseudonyms keep matching, and callers upgrade rows to the
+// current version as senders are seen again.
+package identity
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrNoKeys = errors.New("identity: no pseudonym keys configured")
+
+type key struct {
+	version int
+	hmac    []byte
+	aead    cipher.AEAD
+}
+
+// keys are sorted by version, newest first.
+var keys []key
+
+// Configure installs the pseudonym keys, each given as "<version>:<secret>".
+func Configure(specs []string) error {
+	var parsed []key
+	seen := map[int]bool{}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" || strings.HasPrefix(spec, "#") {
+			continue
+		}
+		v, secret, ok := strings.Cut(spec, ":")
+		version, err := strconv.Atoi(v)
+		if !ok || err != nil || version < 1 {
+			return fmt.Errorf("identity: key must look like \"<version>:<secret>\"")
+		}
+		if len(secret) < 16 {
+			return fmt.Errorf("identity: key version %d is shorter than 16 characters", version)
+		}
+		if seen[version] {
+			return fmt.Errorf("identity: duplicate key version %d", version)
+		}
+		seen[version] = true
+
+		k, err := newKey(version, secret)
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, k)
+	}
+	if len(parsed) == 0 {
+		return ErrNoKeys
+	}
+	sort.Slice(parsed, func(i, j int) bool { return parsed[i].version > parsed[j].version })
+	keys = parsed
+	return nil
+}
+
+// newKey derives separate HMAC and encryption keys from one secret.
+func newKey(version int, secret string) (key, error) {
+	derive := func(label string) []byte {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte("feedbackbot/identity/" + label))
+		return mac.Sum(nil)
+	}
+	block, err := aes.NewCipher(derive("encrypt"))
+	if err != nil {
+		return key{}, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return key{}, err
+	}
+	return key{version: version, hmac: derive("pseudonym"), aead: aead}, nil
+}
+
+func (k key) pseudonym(telegramUserID int64) string {
+	mac := hmac.New(sha256.New, k.hmac)
+	mac.Write([]byte(strconv.FormatInt(telegramUserID, 10)))
+	return fmt.Sprintf("v%d:%s", k.version, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func current() key {
+	if len(keys) == 0 {
+		panic(ErrNoKeys)
+	}
+	return keys[0]
+}
+
+// CurrentVersion returns the version of the current key.
+func CurrentVersion() int {
+	return current().version
+}
+
+// Pseudonym returns the pseudonym of a Telegram user under the current key.
+func Pseudonym(telegramUserID int64) string {
+	return current().pseudonym(telegramUserID)
+}
+
+// Pseudonyms returns the pseudonym under every configured key, current first,
+// for matching rows that have not been upgraded yet.
+func Pseudonyms(telegramUserID int64) []string {
+	current()
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		out[i] = k.pseudonym(telegramUserID)
+	}
+	return out
+}
+
+// IsCurrent reports whether a pseudonym or ciphertext uses the current key.
+func IsCurrent(value string) bool {
+	return strings.HasPrefix(value, fmt.Sprintf("v%d:", CurrentVersion()))
+}
+
+// Encrypt seals a Telegram user ID with the current key.
+func Encrypt(telegramUserID int64) (string, error) {
+	k := current()
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := k.aead.Seal(nonce, nonce, []byte(strconv.FormatInt(telegramUserID, 10)), nil)
+	return fmt.Sprintf("v%d:%s", k.version, base64.RawStdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt opens a value produced by Encrypt with any configured key.
+func Decrypt(value string) (int64, error) {
+	v, data, ok := strings.Cut(strings.TrimPrefix(value, "v"), ":")
+	version, err := strconv.Atoi(v)
+	if !ok || err != nil {
+		return 0, errors.New("identity: malformed ciphertext")
+	}
+	for _, k := range keys {
+		if k.version != version {
+			continue
+		}
+		sealed, err := base64.RawStdEncoding.DecodeString(data)
+		if err != nil || len(sealed) < k.aead.NonceSize() {
+			return 0, errors.New("identity: malformed ciphertext")
+		}
+		plain, err := k.aead.Open(nil, sealed[:k.aead.NonceSize()], sealed[k.aead.NonceSize():], nil)
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseInt(string(plain), 10, 64)
+	}
+	return 0, fmt.Errorf("identity: key version %d is not configured", version)
+}
@@ -0,0 +1,48 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPseudonym_StableAndKeyed(t *testing.T) {
+	require.NoError(t, Configure([]string{"1:first-secret-0123456789"}))
+	p1 := Pseudonym(12345)
+	assert.Equal(t, p1, Pseudonym(12345))
+	assert.NotEqual(t, p1, Pseudonym(12346))
+	assert.Contains(t, p1, "v1:")
+	assert.NotContains(t, p1, "12345")
+
+	require.NoError(t, Configure([]string{"1:other-secret-0123456789"}))
+	assert.NotEqual(t, p1, Pseudonym(12345))
+}
+
+func TestRotation_OldPseudonymsStillMatch(t *testing.T) {
+	require.NoError(t, Configure([]string{"1:first-secret-0123456789"}))
+	old := Pseudonym(42)
+	sealed, err := Encrypt(42)
+	require.NoError(t, err)
+
+	require.NoError(t, Configure([]string{"1:first-secret-0123456789", "2:second-secret-0123456789"}))
+	assert.Equal(t, 2, CurrentVersion())
+	assert.False(t, IsCurrent(old))
+	assert.Equal(t, []string{Pseudonym(42), old}, Pseudonyms(42))
+
+	id, err := Decrypt(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+
+	// Once the old key is removed its ciphertexts can no longer be opened
+	require.NoError(t, Configure([]string{"2:second-secret-0123456789"}))
+	_, err = Decrypt(sealed)
+	assert.Error(t, err)
+}
+
+func TestConfigure_Invalid(t *testing.T) {
+	assert.ErrorIs(t, Configure(nil), ErrNoKeys)
+	assert.Error(t, Configure([]string{"nokey"}))
+	assert.Error(t, Configure([]string{"1:short"}))
+	assert.Error(t, Configure([]string{"1:first-secret-0123456789", "1:again-secret-0123456789"}))
+}
@@ -0,0 +1,297 @@
+// Package importer brings feedback history into a tenant from files: the
+// CSV that GET /feedbacks/export writes, any CSV with a column mapping, or a
+// tenant archive from POST /tenant/export.
+//
+// Imports run as background jobs (models.ImportJob). Rows are validated and
+// matched to the tenant's existing groups; invalid rows are skipped and
+// reported. Each batch of rows is committed together with the job's row
+// cursor, so a job interrupted by a restart resumes without duplicates.
+// Imported feedback has no sender identity: it belongs to a placeholder
+// GroupUser per group (models.ImportedSender). It is never posted.
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"gorm.io/gorm"
+)
+
+// Fields that Options.Columns can map.
+const (
+	FieldMessage   = "message" // Required
+	FieldGroup     = "group"
+	FieldAdminOnly = "admin_only"
+	FieldCreatedAt = "created_at"
+	FieldSealed    = "sealed"
+)
+
+const (
+	MaxMessageLen = 4000
+	maxRowErrors  = 100
+	batchSize     = 200
+)
+
+// Options are the per-job settings.
+type Options struct {
+	Format string `json:"format"`
+	// Columns maps fields to CSV header names, for the csv format
+	Columns map[string]string `json:"columns,omitempty"`
+	// GroupMap maps group names in the file to group IDs. Unmapped names are
+	// matched against group titles, case-insensitively.
+	GroupMap map[string]uint `json:"group_map,omitempty"`
+	// DefaultGroupID receives rows with no group, or an unknown one
+	DefaultGroupID uint `json:"default_group_id,omitempty"`
+}
+
+// RowError reports a skipped row.
+type RowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Validate checks opts before a job is created.
+func (opts Options) Validate(tenantID uint) error {
+	switch opts.Format {
+	case models.ImportFormatExportCSV, models.ImportFormatArchive:
+	case models.ImportFormatCSV:
+		if strings.TrimSpace(opts.Columns[FieldMessage]) == "" {
+			return errors.New("columns.message is required for the csv format")
+		}
+		for field := range opts.Columns {
+			switch field {
+			case FieldMessage, FieldGroup, FieldAdminOnly, FieldCreatedAt, FieldSealed:
+			default:
+				return fmt.Errorf("unknown column field %q", field)
+			}
+		}
+	default:
+		return fmt.Errorf("format must be one of %s, %s, %s",
+			models.ImportFormatExportCSV, models.ImportFormatCSV, models.ImportFormatArchive)
+	}
+
+	ids := make([]uint, 0, len(opts.GroupMap)+1)
+	for _, id := range opts.GroupMap {
+		ids = append(ids, id)
+	}
+	if opts.DefaultGroupID != 0 {
+		ids = append(ids, opts.DefaultGroupID)
+	}
+	for _, id := range ids {
+		var count int64
+		models.DB.Model(&models.Group{}).Where("id = ? AND tenant_id = ?", id, tenantID).Count(&count)
+		if count == 0 {
+			return fmt.Errorf("group %d not found", id)
+		}
+	}
+	return nil
+}
+
+// groupResolver maps group names from a file to the tenant's group IDs.
+type groupResolver struct {
+	opts    Options
+	byTitle map[string]uint
+}
+
+func newGroupResolver(tenantID uint, opts Options) groupResolver {
+	var groups []models.Group
+	models.DB.Where("tenant_id = ?", tenantID).Order("id").Find(&groups)
+	byTitle := map[string]uint{}
+	for _, g := range groups {
+		key := strings.ToLower(strings.TrimSpace(g.Title))
+		if _, taken := byTitle[key]; !taken {
+			byTitle[key] = g.ID
+		}
+	}
+	return groupResolver{opts: opts, byTitle: byTitle}
+}
+
+func (r groupResolver) resolve(name string) (uint, error) {
+	if id, ok := r.opts.GroupMap[name]; ok {
+		return id, nil
+	}
+	if id, ok := r.byTitle[strings.ToLower(strings.TrimSpace(name))]; ok && name != "" {
+		return id, nil
+	}
+	if r.opts.DefaultGroupID != 0 {
+		return r.opts.DefaultGroupID, nil
+	}
+	if name == "" {
+		return 0, errors.New("no group given and no default_group_id set")
+	}
+	return 0, fmt.Errorf("no group matches %q", name)
+}
+
+// toFeedback validates a row and builds the feedback to import.
+func toFeedback(job models.ImportJob, groups groupResolver, row Row) (models.Feedback, error) {
+	if row.Err != nil {
+		return models.Feedback{}, row.Err
+	}
+	message := strings.TrimSpace(row.Message)
+	if message == "" {
+		return models.Feedback{}, errors.New("message is empty")
+	}
+	if len(message) > MaxMessageLen && !row.Sealed {
+		return models.Feedback{}, fmt.Errorf("message is longer than %d characters", MaxMessageLen)
+	}
+	groupID, err := groups.resolve(row.Group)
+	if err != nil {
+		return models.Feedback{}, err
+	}
+
+	fb := models.Feedback{
+		TenantID:    job.TenantID,
+		GroupID:     groupID,
+		Message:     message,
+		AdminOnly:   row.AdminOnly || row.Sealed,
+		Sealed:      row.Sealed,
+		SealedKey:   row.SealedKey,
+		ImportJobID: &job.ID,
+	}
+	if !row.CreatedAt.IsZero() {
+		fb.CreatedAt, fb.UpdatedAt = row.CreatedAt, row.CreatedAt
+	}
+	return fb, nil
+}
+
+// importedSender returns the ID of the group's placeholder sender for
+// imported feedback, creating it the first time.
+func importedSender(tx *gorm.DB, tenantID, groupID uint) (uint, error) {
+	sender := models.GroupUser{TenantID: tenantID, GroupID: groupID, SenderHash: models.ImportedSender}
+	err := tx.Where("group_id = ? AND sender_hash = ?", groupID, models.ImportedSender).FirstOrCreate(&sender).Error
+	return sender.ID, err
+}
+
+// Start creates an import job for a file already saved at path, and runs it
+// in the background.
+func Start(tenantID uint, opts Options, fileName, path string, dryRun bool) (models.ImportJob, error) {
+	if err := opts.Validate(tenantID); err != nil {
+		return models.ImportJob{}, err
+	}
+	encoded, _ := json.Marshal(opts)
+	job := models.ImportJob{
+		TenantID: tenantID,
+		Format:   opts.Format,
+		DryRun:   dryRun,
+		Options:  string(encoded),
+		FileName: fileName,
+		FilePath: path,
+		Status:   models.ImportPending,
+	}
+	if err := models.DB.Create(&job).Error; err != nil {
+		return job, err
+	}
+	go Run(job.ID)
+	return job, nil
+}
+
+// ResumeAll restarts jobs that were interrupted, e.g. by a restart.
+func ResumeAll() {
+	var ids []uint
+	models.DB.Model(&models.ImportJob{}).Where("status IN ?", []string{models.ImportPending, models.ImportRunning}).Pluck("id", &ids)
+	for _, id := range ids {
+		log.Printf("[importer] Resuming import %d", id)
+		go Run(id)
+	}
+}
+
+// Run processes an import job from its row cursor to the end.
+func Run(jobID uint) {
+	var job models.ImportJob
+	if err := models.DB.First(&job, jobID).Error; err != nil {
+		return
+	}
+	if err := run(&job); err != nil {
+		log.Printf("[importer] Import %d failed: %v", job.ID, err)
+		models.DB.Model(&job).Updates(map[string]interface{}{"status": models.ImportFailed, "error": err.Error()})
+		return
+	}
+
+	os.Remove(job.FilePath)
+	now := time.Now()
+	models.DB.Model(&job).Updates(map[string]interface{}{"status": models.ImportCompleted, "completed_at": now, "file_path": ""})
+}
+
+func run(job *models.ImportJob) error {
+	var opts Options
+	if err := json.Unmarshal([]byte(job.Options), &opts); err != nil {
+		return err
+	}
+	rows, err := ReadRows(job.FilePath, opts)
+	if err != nil {
+		return err
+	}
+	groups := newGroupResolver(job.TenantID, opts)
+
+	var rowErrors []RowError
+	if job.Errors != "" {
+		json.Unmarshal([]byte(job.Errors), &rowErrors)
+	}
+	models.DB.Model(job).Updates(map[string]interface{}{"status": models.ImportRunning, "total": len(rows), "error": ""})
+
+	for start := job.Processed; start < len(rows); start += batchSize {
+		end := min(start+batchSize, len(rows))
+
+		var batch []models.Feedback
+		skipped := 0
+		for _, row := range rows[start:end] {
+			fb, err := toFeedback(*job, groups, row)
+			if err != nil {
+				skipped++
+				if len(rowErrors) < maxRowErrors {
+					rowErrors = append(rowErrors, RowError{Line: row.Line, Error: err.Error()})
+				}
+				continue
+			}
+			batch = append(batch, fb)
+		}
+
+		encoded, _ := json.Marshal(rowErrors)
+		err := models.DB.Transaction(func(tx *gorm.DB) error {
+			if len(batch) > 0 && !job.DryRun {
+				for i := range batch {
+					senderID, err := importedSender(tx, batch[i].TenantID, batch[i].GroupID)
+					if err != nil {
+						return err
+					}
+					batch[i].SenderID = senderID
+				}
+				if err := tx.Create(&batch).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Model(job).Updates(map[string]interface{}{
+				"processed": end,
+				"imported":  job.Imported + len(batch),
+				"skipped":   job.Skipped + skipped,
+				"errors":    string(encoded),
+			}).Error
+		})
+		if err != nil {
+			return err
+		}
+		job.Processed, job.Imported, job.Skipped = end, job.Imported+len(batch), job.Skipped+skipped
+	}
+	return nil
+}
+
+// Report is the job summary with its row errors, for the API.
+type Report struct {
+	models.ImportJob
+	RowErrors []RowError `json:"row_errors"`
+}
+
+// NewReport decodes the row errors stored on job.
+func NewReport(job models.ImportJob) Report {
+	r := Report{ImportJob: job, RowErrors: []RowError{}}
+	if job.Errors != "" {
+		json.Unmarshal([]byte(job.Errors), &r.RowErrors)
+	}
+	return r
+}
@@ -0,0 +1,190 @@
+package importer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/tenantdata"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setup(t *testing.T) (models.Tenant, models.Group) {
+	t.Helper()
+	// Foreign keys are enforced, as on PostgreSQL
+	testutil.SetupTestDBWithForeignKeys(t)
+	tenant := models.Tenant{Name: "I", Slug: "i"}
+	require.NoError(t, models.DB.Create(&tenant).Error)
+	bot := models.Bot{TenantID: tenant.ID, Token: "i-token", BotUsername: "ibot"}
+	require.NoError(t, models.DB.Create(&bot).Error)
+	group := models.Group{TenantID: tenant.ID, BotID: bot.ID, ChatID: -100, Title: "Engineering", IsActive: true}
+	require.NoError(t, models.DB.Create(&group).Error)
+	return tenant, group
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// newJob starts an import and waits for it to finish.
+func newJob(t *testing.T, tenantID uint, opts Options, path string, dryRun bool) models.ImportJob {
+	t.Helper()
+	require.NoError(t, opts.Validate(tenantID))
+	job, err := Start(tenantID, opts, filepath.Base(path), path, dryRun)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		models.DB.First(&job, job.ID)
+		return job.Status == models.ImportCompleted || job.Status == models.ImportFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestImport_ExportCSV(t *testing.T) {
+	tenant, group := setup(t)
+	path := writeFile(t, "export.csv", "\ufeffID,Group,Message,Admin Only,Posted to Group,Created At,Sealed\n"+
+		"7,engineering,Hello,false,true,2025-03-01T10:00:00Z,false\n"+
+		"8,Engineering,Private,true,false,2025-03-02T10:00:00Z,false\n")
+
+	job := newJob(t, tenant.ID, Options{Format: models.ImportFormatExportCSV}, path, false)
+	require.Equal(t, models.ImportCompleted, job.Status, job.Error)
+	assert.Equal(t, 2, job.Total)
+	assert.Equal(t, 2, job.Imported)
+	assert.Empty(t, job.FilePath)
+	assert.NoFileExists(t, path)
+
+	var feedbacks []models.Feedback
+	models.DB.Order("id").Find(&feedbacks)
+	require.Len(t, feedbacks, 2)
+	assert.Equal(t, group.ID, feedbacks[0].GroupID)
+	assert.Equal(t, "Hello", feedbacks[0].Message)
+	// Both rows belong to the group's one placeholder sender
+	var sender models.GroupUser
+	require.NoError(t, models.DB.First(&sender, feedbacks[0].SenderID).Error)
+	assert.Equal(t, models.ImportedSender, sender.SenderHash)
+	assert.Equal(t, group.ID, sender.GroupID)
+	assert.Equal(t, sender.ID, feedbacks[1].SenderID)
+	assert.Equal(t, 2025, feedbacks[0].CreatedAt.Year())
+	assert.True(t, feedbacks[1].AdminOnly)
+	require.NotNil(t, feedbacks[1].ImportJobID)
+	assert.Equal(t, job.ID, *feedbacks[1].ImportJobID)
+}
+
+func TestImport_CSVMappingReportsBadRows(t *testing.T) {
+	tenant, group := setup(t)
+	other := models.Group{TenantID: tenant.ID, BotID: group.BotID, ChatID: -101, Title: "Sales", IsActive: true}
+	models.DB.Create(&other)
+	path := writeFile(t, "forms.csv", "Timestamp,Team,Your feedback\n"+
+		"3/15/2025 9:30:00,Ops,Too many meetings\n"+
+		"3/16/2025,Unknown,Needs a default\n"+
+		"not a date,Ops,Bad date\n"+
+		"3/17/2025,Ops,\n")
+
+	opts := Options{
+		Format:   models.ImportFormatCSV,
+		Columns:  map[string]string{FieldMessage: "Your feedback", FieldGroup: "Team", FieldCreatedAt: "Timestamp"},
+		GroupMap: map[string]uint{"Ops": other.ID},
+	}
+	job := newJob(t, tenant.ID, opts, path, false)
+	require.Equal(t, models.ImportCompleted, job.Status, job.Error)
+	assert.Equal(t, 1, job.Imported)
+	assert.Equal(t, 3, job.Skipped)
+
+	report := NewReport(job)
+	require.Len(t, report.RowErrors, 3)
+	assert.Equal(t, 3, report.RowErrors[0].Line)
+	assert.Contains(t, report.RowErrors[0].Error, `"Unknown"`)
+	assert.Equal(t, 4, report.RowErrors[1].Line)
+	assert.Equal(t, 5, report.RowErrors[2].Line)
+
+	var fb models.Feedback
+	require.NoError(t, models.DB.First(&fb).Error)
+	assert.Equal(t, other.ID, fb.GroupID)
+	assert.NotEqual(t, group.ID, fb.GroupID)
+}
+
+func TestImport_DryRunInsertsNothing(t *testing.T) {
+	tenant, group := setup(t)
+	path := writeFile(t, "forms.csv", "text\nOne\nTwo\n")
+
+	opts := Options{Format: models.ImportFormatCSV, Columns: map[string]string{FieldMessage: "text"}, DefaultGroupID: group.ID}
+	job := newJob(t, tenant.ID, opts, path, true)
+	require.Equal(t, models.ImportCompleted, job.Status, job.Error)
+	assert.Equal(t, 2, job.Imported)
+
+	var count int64
+	models.DB.Model(&models.Feedback{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestImport_Archive(t *testing.T) {
+	source, group := setup(t)
+	sourceGroup := models.Group{TenantID: source.ID, BotID: group.BotID, ChatID: -102, Title: "Design", IsActive: true}
+	require.NoError(t, models.DB.Create(&sourceGroup).Error)
+	sender := models.GroupUser{TenantID: source.ID, GroupID: sourceGroup.ID, SenderHash: "abc"}
+	require.NoError(t, models.DB.Create(&sender).Error)
+	models.DB.Create(&models.Feedback{TenantID: source.ID, GroupID: sourceGroup.ID, SenderID: sender.ID, Message: "Kept"})
+	retracted := models.Feedback{TenantID: source.ID, GroupID: sourceGroup.ID, SenderID: sender.ID, Message: "Retracted"}
+	models.DB.Create(&retracted)
+	models.DB.Delete(&retracted)
+
+	path := filepath.Join(t.TempDir(), "archive.zip")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, tenantdata.WriteArchive(f, source.ID))
+	f.Close()
+
+	target := models.Tenant{Name: "T", Slug: "t"}
+	models.DB.Create(&target)
+	targetBot := models.Bot{TenantID: target.ID, Token: "t-token", BotUsername: "tbot"}
+	require.NoError(t, models.DB.Create(&targetBot).Error)
+	targetGroup := models.Group{TenantID: target.ID, BotID: targetBot.ID, ChatID: -200, Title: "design", IsActive: true}
+	require.NoError(t, models.DB.Create(&targetGroup).Error)
+
+	job := newJob(t, target.ID, Options{Format: models.ImportFormatArchive}, path, false)
+	require.Equal(t, models.ImportCompleted, job.Status, job.Error)
+	assert.Equal(t, 1, job.Imported)
+
+	var fb models.Feedback
+	require.NoError(t, models.DB.Where("tenant_id = ?", target.ID).First(&fb).Error)
+	assert.Equal(t, targetGroup.ID, fb.GroupID)
+	assert.Equal(t, "Kept", fb.Message)
+	assert.NotEqual(t, sender.ID, fb.SenderID, "the source sender isn't carried over")
+}
+
+func TestRun_ResumesFromCursor(t *testing.T) {
+	tenant, group := setup(t)
+	path := writeFile(t, "forms.csv", "text\nOne\nTwo\nThree\n")
+	opts := Options{Format: models.ImportFormatCSV, Columns: map[string]string{FieldMessage: "text"}, DefaultGroupID: group.ID}
+
+	// A job interrupted after committing the first row
+	encoded, _ := json.Marshal(opts)
+	job := models.ImportJob{TenantID: tenant.ID, Format: opts.Format, Options: string(encoded), FilePath: path,
+		Status: models.ImportRunning, Processed: 1, Imported: 1}
+	require.NoError(t, models.DB.Create(&job).Error)
+
+	Run(job.ID)
+
+	models.DB.First(&job, job.ID)
+	assert.Equal(t, models.ImportCompleted, job.Status)
+	assert.Equal(t, 3, job.Processed)
+	assert.Equal(t, 3, job.Imported)
+	var messages []string
+	models.DB.Model(&models.Feedback{}).Order("id").Pluck("message", &messages)
+	assert.Equal(t, []string{"Two", "Three"}, messages)
+}
+
+func TestValidate(t *testing.T) {
+	tenant, _ := setup(t)
+	assert.Error(t, Options{Format: "xml"}.Validate(tenant.ID))
+	assert.Error(t, Options{Format: models.ImportFormatCSV}.Validate(tenant.ID))
+	assert.Error(t, Options{Format: models.ImportFormatCSV, Columns: map[string]string{FieldMessage: "m", "sender": "s"}}.Validate(tenant.ID))
+	assert.Error(t, Options{Format: models.ImportFormatArchive, DefaultGroupID: 999}.Validate(tenant.ID))
+}
@@ -0,0 +1,208 @@
+package importer
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+)
+
+// Row is one feedback item read from an import file.
+type Row struct {
+	Line      int // 1-based line (CSV) or record number (archive), for error reports
+	Group     string
+	Message   string
+	AdminOnly bool
+	Sealed    bool
+	SealedKey string
+	CreatedAt time.Time // Zero when the file has none
+	Err       error     // Set when the row can't be parsed
+}
+
+// timeLayouts are tried in order for created_at values. The last ones cover
+// spreadsheet exports such as Google Forms.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"1/2/2006 15:04:05",
+	"1/2/2006",
+	"02.01.2006 15:04:05",
+	"02.01.2006",
+}
+
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", s)
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "false", "no", "0":
+		return false, nil
+	case "true", "yes", "1":
+		return true, nil
+	}
+	return false, fmt.Errorf("expected true or false, got %q", s)
+}
+
+// exportCSVColumns maps the header GET /feedbacks/export writes to
+// Options.Columns fields.
+var exportCSVColumns = map[string]string{
+	FieldMessage:   "Message",
+	FieldGroup:     "Group",
+	FieldAdminOnly: "Admin Only",
+	FieldCreatedAt: "Created At",
+	FieldSealed:    "Sealed", // Absent from exports made before sealing existed
+}
+
+// ReadRows parses the whole file at path according to opts.
+func ReadRows(path string, opts Options) ([]Row, error) {
+	switch opts.Format {
+	case models.ImportFormatExportCSV:
+		return readCSV(path, exportCSVColumns)
+	case models.ImportFormatCSV:
+		return readCSV(path, opts.Columns)
+	case models.ImportFormatArchive:
+		return readArchive(path)
+	}
+	return nil, fmt.Errorf("unknown format %q", opts.Format)
+}
+
+func readCSV(path string, columns map[string]string) ([]Row, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(bufio.NewReader(f))
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff") // Excel's BOM
+	}
+
+	index := map[string]int{}
+	for field, name := range columns {
+		index[field] = -1
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(name)) {
+				index[field] = i
+			}
+		}
+		if index[field] < 0 && field == FieldMessage {
+			return nil, fmt.Errorf("column %q not found in header", name)
+		}
+	}
+	cell := func(record []string, field string) string {
+		i, ok := index[field]
+		if !ok || i < 0 || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	var rows []Row
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		row := Row{Line: line, Group: strings.TrimSpace(cell(record, FieldGroup)), Message: cell(record, FieldMessage)}
+		if v := cell(record, FieldAdminOnly); row.Err == nil {
+			row.AdminOnly, row.Err = parseBool(v)
+		}
+		if v := cell(record, FieldSealed); row.Err == nil {
+			row.Sealed, row.Err = parseBool(v)
+		}
+		if v := cell(record, FieldCreatedAt); row.Err == nil && strings.TrimSpace(v) != "" {
+			row.CreatedAt, row.Err = parseTime(v)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// readArchive reads the feedback of a tenant archive, naming each item's
+// group by its title in the archive. Retracted feedback is left out.
+func readArchive(path string) ([]Row, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	groups := map[uint]string{}
+	err = eachRecord(&zr.Reader, "groups.ndjson", func(n int, data []byte) error {
+		var g models.Group
+		if err := json.Unmarshal(data, &g); err != nil {
+			return fmt.Errorf("groups.ndjson record %d: %w", n, err)
+		}
+		groups[g.ID] = g.Title
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	err = eachRecord(&zr.Reader, "feedbacks.ndjson", func(n int, data []byte) error {
+		var fb models.Feedback
+		if err := json.Unmarshal(data, &fb); err != nil {
+			rows = append(rows, Row{Line: n, Err: err})
+			return nil
+		}
+		if fb.DeletedAt.Valid {
+			return nil
+		}
+		rows = append(rows, Row{
+			Line:      n,
+			Group:     groups[fb.GroupID],
+			Message:   fb.Message,
+			AdminOnly: fb.AdminOnly,
+			Sealed:    fb.Sealed,
+			SealedKey: fb.SealedKey,
+			CreatedAt: fb.CreatedAt,
+		})
+		return nil
+	})
+	return rows, err
+}
+
+func eachRecord(zr *zip.Reader, name string, fn func(n int, data []byte) error) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return fmt.Errorf("archive has no %s", name)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if err := fn(n, scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
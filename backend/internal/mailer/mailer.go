@@ -0,0 +1,88 @@
+// Package mailer sends email through a pluggable Sender. The sender is chosen
+// by config.Mail at startup; tests swap it with SetSender.
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
+)
+
+// Attachment is a file sent along with a Message.
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Message is one email. HTML is optional; Text is always sent as the plain
+// alternative.
+type Message struct {
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Sender delivers messages.
+type Sender interface {
+	Send(msg Message) error
+}
+
+var (
+	mu     sync.RWMutex
+	sender Sender = LogSender{}
+)
+
+// Configure selects the sender for c.Driver.
+func Configure(c config.Mail) error {
+	switch strings.ToLower(c.Driver) {
+	case "", "log":
+		SetSender(LogSender{})
+	case "smtp":
+		if c.Host == "" || c.From == "" {
+			return fmt.Errorf("mail: smtp needs a host and a from address")
+		}
+		port := c.Port
+		if port == "" {
+			port = "587"
+		}
+		SetSender(&SMTPSender{Addr: c.Host + ":" + port, Username: c.Username, Password: c.Password, From: c.From})
+	default:
+		return fmt.Errorf("mail: unknown driver %q", c.Driver)
+	}
+	return nil
+}
+
+// SetSender replaces the sender and returns the previous one.
+func SetSender(s Sender) Sender {
+	mu.Lock()
+	defer mu.Unlock()
+	prev := sender
+	sender = s
+	return prev
+}
+
+// Send delivers msg with the configured sender.
+func Send(msg Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("mail: no recipients")
+	}
+	mu.RLock()
+	s := sender
+	mu.RUnlock()
+	return s.Send(msg)
+}
+
+// LogSender only logs what would have been sent.
+type LogSender struct{}
+
+func (LogSender) Send(msg Message) error {
+	log.Printf("[mailer] Not sending %q to %s (%d attachments): no mail driver configured",
+		msg.Subject, strings.Join(msg.To, ", "), len(msg.Attachments))
+	return nil
+}
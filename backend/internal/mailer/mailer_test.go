@@ -0,0 +1,88 @@
+package mailer
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild_MultipartWithAttachment(t *testing.T) {
+	msg := Message{
+		To:          []string{"a@example.com", "b@example.com"},
+		Subject:     "Отчёт за неделю",
+		Text:        "plain body",
+		HTML:        "<p>html body</p>",
+		Attachments: []Attachment{{Name: "report.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4 data")}},
+	}
+	data, err := Build("Bot <bot@example.com>", msg, time.Now())
+	require.NoError(t, err)
+
+	m, err := mail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Отчёт за неделю", subject)
+	assert.Equal(t, "a@example.com, b@example.com", m.Header.Get("To"))
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	body, err := mr.NextPart()
+	require.NoError(t, err)
+	mediaType, params, _ = mime.ParseMediaType(body.Header.Get("Content-Type"))
+	assert.Equal(t, "multipart/alternative", mediaType)
+	alt := multipart.NewReader(body, params["boundary"])
+	var texts []string
+	for {
+		p, err := alt.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		b, _ := io.ReadAll(p) // NextPart decodes quoted-printable
+		texts = append(texts, string(b))
+	}
+	assert.Equal(t, []string{"plain body", "<p>html body</p>"}, texts)
+
+	att, err := mr.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "report.pdf", att.FileName())
+	raw, _ := io.ReadAll(att)
+	assert.Contains(t, string(raw), "JVBERi0xLjQgZGF0YQ==")
+}
+
+type recorder struct{ sent []Message }
+
+func (r *recorder) Send(msg Message) error {
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func TestConfigureAndSend(t *testing.T) {
+	rec := &recorder{}
+	prev := SetSender(rec)
+	defer SetSender(prev)
+
+	assert.Error(t, Send(Message{Subject: "nobody"}))
+	require.NoError(t, Send(Message{To: []string{"a@example.com"}, Subject: "hi"}))
+	assert.Len(t, rec.sent, 1)
+
+	assert.Error(t, Configure(config.Mail{Driver: "smtp"}))
+	assert.Error(t, Configure(config.Mail{Driver: "pigeon"}))
+	require.NoError(t, Configure(config.Mail{Driver: "smtp", Host: "mail.example.com", From: "bot@example.com"}))
+	mu.RLock()
+	s, ok := sender.(*SMTPSender)
+	mu.RUnlock()
+	require.True(t, ok)
+	assert.Equal(t, "mail.example.com:587", s.Addr)
+}
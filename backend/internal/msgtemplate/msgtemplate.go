@@ -0,0 +1,259 @@
+// Package msgtemplate renders tenant-customizable bot messages.
+//
+// Tenants may override selected messages with Go text/template bodies. Only
+// the fields of Vars are available to a template, and any template that fails
+// to render falls back to the built-in i18n message. Loops, nested templates
+// and printf widths are rejected, so rendering time and memory stay
+// proportional to the body.
+package msgtemplate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
+)
+
+const (
+	KeyWelcome               = "welcome"
+	KeyConfirmation          = "confirmation"
+	KeyConfirmationAdminOnly = "confirmation_admin_only"
+	KeyPostHeader            = "post_header"
+	KeyPostHeaderIdea        = "post_header_idea"
+	KeyPostHeaderIssue       = "post_header_issue"
+	KeyPostHeaderPraise      = "post_header_praise"
+	KeyPostHeaderQuestion    = "post_header_question"
+	KeyDigestHeader          = "digest_header"
+	KeyCommentHeader         = "comment_header"
+	KeyResponseHeader        = "response_header"
+
+	MaxBodyLen   = 2000
+	maxOutputLen = 4000
+)
+
+// Keys maps each customizable template key to its built-in i18n message.
+var Keys = map[string]string{
+	KeyWelcome:               "start.welcome",
+	KeyConfirmation:          "feedback.sent",
+	KeyConfirmationAdminOnly: "feedback.sent_admin_only",
+	KeyPostHeader:            "group.post_header",
+	KeyPostHeaderIdea:        "group.post_header_idea",
+	KeyPostHeaderIssue:       "group.post_header_issue",
+	KeyPostHeaderPraise:      "group.post_header_praise",
+	KeyPostHeaderQuestion:    "group.post_header_question",
+	KeyDigestHeader:          "group.digest_header",
+	KeyCommentHeader:         "group.comment_header",
+	KeyResponseHeader:        "group.response_header",
+}
+
+// PostHeaderKey returns the group post header key for a feedback type.
+// General feedback uses KeyPostHeader.
+func PostHeaderKey(feedbackType string) string {
+	if key := KeyPostHeader + "_" + feedbackType; Keys[key] != "" {
+		return key
+	}
+	return KeyPostHeader
+}
+
+// Vars are the values a template may reference, e.g. {{.GroupTitle}}.
+type Vars struct {
+	TenantName   string
+	GroupTitle   string
+	BotName      string
+	BotUsername  string
+	FeedbackType string
+}
+
+// SampleVars is used to validate and preview templates.
+var SampleVars = Vars{
+	TenantName:   "Acme",
+	GroupTitle:   "Team Chat",
+	BotName:      "Feedback Bot",
+	BotUsername:  "acme_feedback_bot",
+	FeedbackType: "feedback",
+}
+
+// Default returns the built-in message for key.
+func Default(key, lang string) string {
+	return i18n.T(lang, Keys[key])
+}
+
+// Validate checks that body parses and renders against SampleVars.
+func Validate(body string) error {
+	if body == "" {
+		return fmt.Errorf("body must not be empty")
+	}
+	if len(body) > MaxBodyLen {
+		return fmt.Errorf("body must be at most %d bytes", MaxBodyLen)
+	}
+	_, err := Execute(body, SampleVars)
+	return err
+}
+
+var errTooLong = fmt.Errorf("rendered message exceeds %d bytes", maxOutputLen)
+
+// limitedWriter fails the render as soon as it outgrows maxOutputLen.
+type limitedWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.buf.Len()+len(p) > maxOutputLen {
+		return 0, errTooLong
+	}
+	return w.buf.Write(p)
+}
+
+// Execute renders body with vars.
+func Execute(body string, vars Vars) (string, error) {
+	tmpl, err := template.New("msg").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", err
+	}
+	if len(tmpl.Templates()) > 1 {
+		return "", errors.New("templates can't define other templates")
+	}
+	if err := check(tmpl.Tree.Root); err != nil {
+		return "", err
+	}
+	var w limitedWriter
+	if err := tmpl.Execute(&w, vars); err != nil {
+		return "", err
+	}
+	return w.buf.String(), nil
+}
+
+// check rejects the parts of the template language that let a short body
+// run long or render large: range and template calls, and printf with a
+// width, a precision or a format that isn't a literal.
+func check(node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := check(child); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		return check(n.Pipe)
+	case *parse.IfNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		return errors.New("range is not allowed in templates")
+	case *parse.TemplateNode:
+		return errors.New("templates can't call other templates")
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, cmd := range n.Cmds {
+			if err := check(cmd); err != nil {
+				return err
+			}
+		}
+	case *parse.CommandNode:
+		if id, ok := n.Args[0].(*parse.IdentifierNode); ok && id.Ident == "printf" {
+			if err := checkFormat(n.Args[1:]); err != nil {
+				return err
+			}
+		}
+		for _, arg := range n.Args {
+			if err := check(arg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkBranch(n *parse.BranchNode) error {
+	for _, child := range []parse.Node{n.Pipe, n.List, n.ElseList} {
+		if err := check(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFormat accepts a printf format only if it is a string literal whose
+// verbs have no width or precision.
+func checkFormat(args []parse.Node) error {
+	var format *parse.StringNode
+	if len(args) > 0 {
+		format, _ = args[0].(*parse.StringNode)
+	}
+	if format == nil {
+		return errors.New("printf format must be a string literal")
+	}
+	text := format.Text
+	for i := 0; i < len(text); i++ {
+		if text[i] != '%' {
+			continue
+		}
+		i++
+		for i < len(text) && strings.IndexByte("+-# 0", text[i]) >= 0 {
+			i++
+		}
+		if i < len(text) && text[i] == '[' {
+			if end := strings.IndexByte(text[i:], ']'); end >= 0 {
+				i += end + 1
+			}
+		}
+		if i < len(text) && (text[i] >= '1' && text[i] <= '9' || text[i] == '*' || text[i] == '.') {
+			return errors.New("printf widths and precisions are not allowed in templates")
+		}
+	}
+	return nil
+}
+
+// Find returns the most specific template for key: group and language first,
+// then group for any language, then tenant-wide with and without language.
+func Find(tenantID uint, groupID uint, key, lang string) (models.MessageTemplate, bool) {
+	var candidates []models.MessageTemplate
+	q := models.DB.Where("tenant_id = ? AND key = ? AND (language = ? OR language = '')", tenantID, key, lang)
+	if groupID > 0 {
+		q = q.Where("group_id IS NULL OR group_id = ?", groupID)
+	} else {
+		q = q.Where("group_id IS NULL")
+	}
+	q.Find(&candidates)
+
+	best, bestScore := models.MessageTemplate{}, -1
+	for _, t := range candidates {
+		score := 0
+		if t.GroupID != nil {
+			score += 2
+		}
+		if t.Language != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = t, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// Render returns the tenant's customized message for key, falling back to the
+// built-in default when there is no template or it fails to render.
+func Render(tenantID uint, groupID uint, key, lang string, vars Vars) string {
+	if t, ok := Find(tenantID, groupID, key, lang); ok {
+		out, err := Execute(t.Body, vars)
+		if err == nil {
+			return out
+		}
+		log.Printf("[msgtemplate] Template %d (%s) failed to render: %v", t.ID, key, err)
+	}
+	return Default(key, lang)
+}
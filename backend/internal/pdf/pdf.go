@@ -0,0 +1,339 @@
+// Package pdf writes simple flowing text documents (headings, paragraphs,
+// table rows) as PDF without external tools. Text is set in the embedded Go
+// Regular TrueType font, which covers Latin and Cyrillic.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// A4 portrait, in points.
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+	Margin     = 50.0
+
+	lineSpacing = 1.4
+)
+
+// Document is a PDF being laid out top to bottom.
+type Document struct {
+	font   *sfnt.Font
+	buf    sfnt.Buffer
+	upem   float64
+	widths map[sfnt.GlyphIndex]float64 // Advance per 1000 em of every glyph used
+	runes  map[sfnt.GlyphIndex]rune
+	pages  []*bytes.Buffer
+	y      float64
+}
+
+// New starts a document with one empty page.
+func New() (*Document, error) {
+	f, err := sfnt.Parse(goregular.TTF)
+	if err != nil {
+		return nil, err
+	}
+	d := &Document{
+		font:   f,
+		upem:   float64(f.UnitsPerEm()),
+		widths: map[sfnt.GlyphIndex]float64{},
+		runes:  map[sfnt.GlyphIndex]rune{},
+	}
+	d.NewPage()
+	return d, nil
+}
+
+// NewPage starts a new page.
+func (d *Document) NewPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = PageHeight - Margin
+}
+
+func (d *Document) page() *bytes.Buffer { return d.pages[len(d.pages)-1] }
+
+// glyph returns the glyph for r and its advance per 1000 em.
+func (d *Document) glyph(r rune) (sfnt.GlyphIndex, float64) {
+	gid, err := d.font.GlyphIndex(&d.buf, r)
+	if err != nil {
+		gid = 0
+	}
+	if w, ok := d.widths[gid]; ok {
+		return gid, w
+	}
+	adv, err := d.font.GlyphAdvance(&d.buf, gid, fixed.I(int(d.upem)), font.HintingNone)
+	w := 0.0
+	if err == nil {
+		w = float64(adv) / 64 * 1000 / d.upem
+	}
+	d.widths[gid] = w
+	if _, ok := d.runes[gid]; !ok && gid != 0 {
+		d.runes[gid] = r
+	}
+	return gid, w
+}
+
+// Width returns the width of s in points at size.
+func (d *Document) Width(s string, size float64) float64 {
+	total := 0.0
+	for _, r := range s {
+		_, w := d.glyph(r)
+		total += w
+	}
+	return total * size / 1000
+}
+
+// ensure starts a new page unless height fits above the bottom margin.
+func (d *Document) ensure(height float64) {
+	if d.y-height < Margin {
+		d.NewPage()
+	}
+}
+
+// show draws s with its baseline at x, y.
+func (d *Document) show(s string, x, y, size float64, gray float64) {
+	var hex strings.Builder
+	for _, r := range s {
+		gid, _ := d.glyph(r)
+		fmt.Fprintf(&hex, "%04X", uint16(gid))
+	}
+	fmt.Fprintf(d.page(), "BT %.3g g /F1 %.4g Tf %.2f %.2f Td <%s> Tj ET\n", gray, size, x, y, hex.String())
+}
+
+// Text writes a paragraph wrapped to the page width.
+func (d *Document) Text(s string, size float64) {
+	d.TextGray(s, size, 0)
+}
+
+// TextGray is Text in a shade of gray, 0 black to 1 white.
+func (d *Document) TextGray(s string, size, gray float64) {
+	lineHeight := size * lineSpacing
+	for _, line := range d.Wrap(s, size, PageWidth-2*Margin) {
+		d.ensure(lineHeight)
+		d.y -= lineHeight
+		d.show(line, Margin, d.y+size*0.3, size, gray)
+	}
+}
+
+// Row writes one line of cells starting at the given x offsets from the
+// left margin. Cells that don't fit before the next offset are cut short.
+func (d *Document) Row(cells []string, offsets []float64, size float64) {
+	lineHeight := size * lineSpacing
+	d.ensure(lineHeight)
+	d.y -= lineHeight
+	for i, cell := range cells {
+		limit := PageWidth - 2*Margin - offsets[i]
+		if i+1 < len(offsets) {
+			limit = offsets[i+1] - offsets[i] - size/2
+		}
+		d.show(d.fit(cell, size, limit), Margin+offsets[i], d.y+size*0.3, size, 0)
+	}
+}
+
+// Rule draws a thin horizontal line across the page.
+func (d *Document) Rule() {
+	d.ensure(6)
+	d.y -= 3
+	fmt.Fprintf(d.page(), "0.8 G 0.5 w %.2f %.2f m %.2f %.2f l S\n", Margin, d.y, PageWidth-Margin, d.y)
+	d.y -= 3
+}
+
+// Gap leaves vertical space.
+func (d *Document) Gap(points float64) {
+	d.y -= points
+	if d.y < Margin {
+		d.NewPage()
+	}
+}
+
+// fit cuts s with an ellipsis so it is at most width wide.
+func (d *Document) fit(s string, size, width float64) string {
+	if d.Width(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && d.Width(string(runes)+"…", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// Wrap splits s into lines at most width wide, breaking at spaces where it
+// can and inside words where it must.
+func (d *Document) Wrap(s string, size, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if d.Width(candidate, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = ""
+			for _, r := range word {
+				if line != "" && d.Width(line+string(r), size) > width {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// WriteTo writes the finished PDF.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	out := &pdfWriter{}
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-7 are fixed, then a page and its content per page
+	const (
+		catalog = iota + 1
+		pagesObj
+		type0
+		cidFont
+		descriptor
+		fontFile
+		toUnicode
+		firstPage
+	)
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	out.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+	out.object(pagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	out.object(type0, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /GoRegular /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", cidFont, toUnicode))
+	out.object(cidFont, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /GoRegular /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /DW 500 /W [%s] >>", descriptor, d.widthArray()))
+	out.object(descriptor, d.descriptor(fontFile))
+	out.stream(fontFile, fmt.Sprintf("/Length1 %d", len(goregular.TTF)), goregular.TTF)
+	out.stream(toUnicode, "", []byte(d.cmap()))
+	for i, content := range d.pages {
+		page := firstPage + 2*i
+		out.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %g %g] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pagesObj, PageWidth, PageHeight, type0, page+1))
+		out.stream(page+1, "", content.Bytes())
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(out.offsets)+1)
+	for _, off := range out.offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(out.offsets)+1, catalog, xref)
+	return out.WriteTo(w)
+}
+
+// Bytes returns the finished PDF.
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	d.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+func (d *Document) widthArray() string {
+	gids := make([]int, 0, len(d.widths))
+	for gid := range d.widths {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+	parts := make([]string, len(gids))
+	for i, gid := range gids {
+		parts[i] = fmt.Sprintf("%d [%.0f]", gid, d.widths[sfnt.GlyphIndex(gid)])
+	}
+	return strings.Join(parts, " ")
+}
+
+func (d *Document) descriptor(fontFile int) string {
+	ppem := fixed.I(int(d.upem))
+	scale := func(v fixed.Int26_6) int { return int(float64(v) / 64 * 1000 / d.upem) }
+	m, _ := d.font.Metrics(&d.buf, ppem, font.HintingNone)
+	b, _ := d.font.Bounds(&d.buf, ppem, font.HintingNone)
+	// sfnt's y axis points down
+	return fmt.Sprintf("<< /Type /FontDescriptor /FontName /GoRegular /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		scale(b.Min.X), -scale(b.Max.Y), scale(b.Max.X), -scale(b.Min.Y), scale(m.Ascent), -scale(m.Descent), scale(m.CapHeight), fontFile)
+}
+
+// cmap maps the used glyphs back to text, so it can be searched and copied.
+func (d *Document) cmap() string {
+	gids := make([]int, 0, len(d.runes))
+	for gid := range d.runes {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(gids); start += 100 {
+		chunk := gids[start:min(start+100, len(gids))]
+		fmt.Fprintf(&b, "%d beginbfchar\n", len(chunk))
+		for _, gid := range chunk {
+			fmt.Fprintf(&b, "<%04X> <", gid)
+			for _, u := range utf16.Encode([]rune{d.runes[sfnt.GlyphIndex(gid)]}) {
+				fmt.Fprintf(&b, "%04X", u)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.String()
+}
+
+// pdfWriter records where each object starts for the xref table.
+type pdfWriter struct {
+	bytes.Buffer
+	offsets []int
+}
+
+func (w *pdfWriter) object(n int, body string) {
+	w.begin(n)
+	fmt.Fprintf(w, "%s\nendobj\n", body)
+}
+
+// stream writes a flate-compressed stream object; dict holds extra entries.
+func (w *pdfWriter) stream(n int, dict string, data []byte) {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(data)
+	zw.Close()
+
+	if dict != "" {
+		dict = " " + dict
+	}
+	w.begin(n)
+	fmt.Fprintf(w, "<< /Length %d /Filter /FlateDecode%s >>\nstream\n", z.Len(), dict)
+	w.Write(z.Bytes())
+	w.WriteString("\nendstream\nendobj\n")
+}
+
+func (w *pdfWriter) begin(n int) {
+	if n != len(w.offsets)+1 {
+		panic(fmt.Sprintf("pdf: object %d written out of order", n))
+	}
+	w.offsets = append(w.offsets, w.Len())
+	fmt.Fprintf(w, "%d 0 obj\n", n)
+}
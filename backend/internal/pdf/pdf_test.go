@@ -0,0 +1,79 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streams returns every stream in the PDF, decompressed.
+func streams(t *testing.T, data []byte) []string {
+	var out []string
+	re := regexp.MustCompile(`(?s)/Length (\d+)[^>]*>>\nstream\n`)
+	for _, m := range re.FindAllSubmatchIndex(data, -1) {
+		n, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		zr, err := zlib.NewReader(bytes.NewReader(data[m[1] : m[1]+n]))
+		require.NoError(t, err)
+		b, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		out = append(out, string(b))
+	}
+	return out
+}
+
+func TestDocument_Structure(t *testing.T) {
+	d, err := New()
+	require.NoError(t, err)
+	d.Text("Weekly report", 18)
+	d.Rule()
+	d.Row([]string{"Группа", "Count"}, []float64{0, 300}, 10)
+	for i := 0; i < 80; i++ {
+		d.Text(fmt.Sprintf("Line %d of a paragraph long enough to need wrapping across the width of the page, twice over if possible.", i), 10)
+	}
+	data := d.Bytes()
+
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(data, []byte("%%EOF\n")))
+	assert.Greater(t, len(d.pages), 1)
+	assert.Contains(t, string(data), fmt.Sprintf("/Count %d", len(d.pages)))
+
+	// Every xref entry points at its object
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	require.NotNil(t, m)
+	xref, _ := strconv.Atoi(string(m[1]))
+	lines := strings.Split(string(data[xref:]), "\n")
+	require.Equal(t, "xref", lines[0])
+	var count int
+	fmt.Sscanf(lines[1], "0 %d", &count)
+	for n := 1; n < count; n++ {
+		off, _ := strconv.Atoi(lines[2+n][:10])
+		assert.True(t, bytes.HasPrefix(data[off:], []byte(fmt.Sprintf("%d 0 obj", n))), "object %d", n)
+	}
+
+	all := strings.Join(streams(t, data), "")
+	assert.Contains(t, all, "<0413>") // Г in the ToUnicode map
+	assert.Contains(t, all, "/F1 18 Tf")
+}
+
+func TestWrap(t *testing.T) {
+	d, err := New()
+	require.NoError(t, err)
+
+	lines := d.Wrap("one two three four five six", 10, d.Width("one two three", 10))
+	assert.Equal(t, []string{"one two three", "four five six"}, lines)
+
+	long := strings.Repeat("x", 200)
+	for _, line := range d.Wrap(long, 10, 100) {
+		assert.LessOrEqual(t, d.Width(line, 10), 100.0)
+	}
+	assert.Equal(t, []string{"a", "", "b"}, d.Wrap("a\n\nb", 10, 100))
+	assert.Equal(t, "abc…", d.fit("abcdefghijklmnop", 10, d.Width("abc…", 10)))
+}
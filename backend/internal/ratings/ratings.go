@@ -0,0 +1,137 @@
+// Package ratings computes eNPS and satisfaction figures from the scores
+// senders give their feedback.
+//
+// Figures from small groups or short periods would let people work out
+// individual scores. Scores are therefore pooled in cells, one group in one
+// week or month, and only cells with at least a tenant's minimum responses
+// count towards any figure, see Publishable. Every figure is then a sum of
+// whole cells, so comparing two of them can't isolate fewer responses than
+// the minimum.
+package ratings
+
+import (
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+)
+
+// MinResponsesFloor is the lowest minimum a tenant may configure.
+const MinResponsesFloor = 3
+
+// Scale is the range of scores of a rating scale.
+type Scale struct {
+	Min int
+	Max int
+}
+
+var scales = map[string]Scale{
+	models.RatingENPS: {0, 10},
+	models.RatingCSAT: {1, 5},
+}
+
+// Lookup returns the scale with the given name.
+func Lookup(name string) (Scale, bool) {
+	s, ok := scales[name]
+	return s, ok
+}
+
+// Valid reports whether score is on the named scale.
+func Valid(name string, score int) bool {
+	s, ok := scales[name]
+	return ok && score >= s.Min && score <= s.Max
+}
+
+// Stats summarizes the scores of one group or period. Suppressed stats
+// carry only the number of responses.
+type Stats struct {
+	Responses    int      `json:"responses"`
+	Suppressed   bool     `json:"suppressed,omitempty"`
+	Average      *float64 `json:"average,omitempty"`
+	Distribution []int    `json:"distribution,omitempty"` // Responses per score, from the scale's minimum
+
+	// eNPS only: promoters score 9-10, detractors 0-6. ENPS is the share of
+	// promoters minus the share of detractors, -100 to 100.
+	Promoters  *int     `json:"promoters,omitempty"`
+	Passives   *int     `json:"passives,omitempty"`
+	Detractors *int     `json:"detractors,omitempty"`
+	ENPS       *float64 `json:"enps,omitempty"`
+}
+
+// Compute summarizes scores on the named scale. Scores off the scale are
+// ignored.
+func Compute(name string, scores []int) Stats {
+	scale := scales[name]
+	stats := Stats{Distribution: make([]int, scale.Max-scale.Min+1)}
+	sum := 0
+	for _, score := range scores {
+		if !Valid(name, score) {
+			continue
+		}
+		stats.Responses++
+		stats.Distribution[score-scale.Min]++
+		sum += score
+	}
+	if stats.Responses == 0 {
+		return stats
+	}
+	average := float64(sum) / float64(stats.Responses)
+	stats.Average = &average
+
+	if name == models.RatingENPS {
+		var promoters, passives, detractors int
+		for score, n := range stats.Distribution {
+			switch {
+			case score >= 9:
+				promoters += n
+			case score >= 7:
+				passives += n
+			default:
+				detractors += n
+			}
+		}
+		enps := float64(promoters-detractors) * 100 / float64(stats.Responses)
+		stats.Promoters, stats.Passives, stats.Detractors, stats.ENPS = &promoters, &passives, &detractors, &enps
+	}
+	return stats
+}
+
+// Cell holds the scores of one group in the period starting at Start.
+type Cell struct {
+	GroupID uint
+	Start   time.Time
+}
+
+// Publishable keeps the cells with at least min scores valid on the named
+// scale.
+func Publishable(name string, cells map[Cell][]int, min int) map[Cell][]int {
+	kept := make(map[Cell][]int, len(cells))
+	for cell, scores := range cells {
+		if Compute(name, scores).Responses >= min {
+			kept[cell] = scores
+		}
+	}
+	return kept
+}
+
+// Withheld stands in for the stats of responses that are all in cells
+// below the minimum. It carries only their number.
+func Withheld(responses int) Stats {
+	return Stats{Responses: responses, Suppressed: true}
+}
+
+// Interval values for PeriodStart.
+const (
+	Week  = "week"
+	Month = "month"
+)
+
+// PeriodStart returns the start of the week (from Monday) or month holding
+// t, in loc.
+func PeriodStart(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	if interval == Month {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
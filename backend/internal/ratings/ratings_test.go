@@ -0,0 +1,72 @@
+package ratings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompute_ENPS(t *testing.T) {
+	stats := Compute(models.RatingENPS, []int{10, 9, 8, 7, 6, 0, 11, -1})
+
+	assert.Equal(t, 6, stats.Responses)
+	require.NotNil(t, stats.ENPS)
+	assert.Equal(t, 2, *stats.Promoters)
+	assert.Equal(t, 2, *stats.Passives)
+	assert.Equal(t, 2, *stats.Detractors)
+	assert.InDelta(t, 0, *stats.ENPS, 0.001)
+	assert.InDelta(t, 40.0/6, *stats.Average, 0.001)
+	assert.Equal(t, []int{1, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1}, stats.Distribution)
+
+	stats = Compute(models.RatingENPS, []int{10, 10, 3})
+	assert.InDelta(t, 100.0/3, *stats.ENPS, 0.001)
+}
+
+func TestCompute_CSAT(t *testing.T) {
+	stats := Compute(models.RatingCSAT, []int{5, 4, 0})
+
+	assert.Equal(t, 2, stats.Responses)
+	assert.InDelta(t, 4.5, *stats.Average, 0.001)
+	assert.Equal(t, []int{0, 0, 0, 1, 1}, stats.Distribution)
+	assert.Nil(t, stats.ENPS)
+
+	empty := Compute(models.RatingCSAT, nil)
+	assert.Nil(t, empty.Average)
+}
+
+func TestPublishable(t *testing.T) {
+	week := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	big := Cell{GroupID: 1, Start: week}
+	small := Cell{GroupID: 2, Start: week}
+	offScale := Cell{GroupID: 1, Start: week.AddDate(0, 0, 7)}
+	cells := map[Cell][]int{
+		big:      repeat(3, 5),
+		small:    repeat(3, 4),
+		offScale: append(repeat(3, 4), 9),
+	}
+
+	kept := Publishable(models.RatingCSAT, cells, 5)
+	assert.Len(t, kept, 1)
+	assert.Contains(t, kept, big)
+}
+
+func repeat(score, n int) []int {
+	scores := make([]int, n)
+	for i := range scores {
+		scores[i] = score
+	}
+	return scores
+}
+
+func TestPeriodStart(t *testing.T) {
+	loc := time.FixedZone("UTC+5", 5*3600)
+	// Sunday 22:00 UTC is already Monday in UTC+5
+	sunday := time.Date(2026, 10, 18, 22, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2026, 10, 19, 0, 0, 0, 0, loc), PeriodStart(sunday, Week, loc))
+	assert.Equal(t, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), PeriodStart(sunday, Week, time.UTC))
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, loc), PeriodStart(sunday, Month, loc))
+}
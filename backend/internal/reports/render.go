@@ -0,0 +1,218 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/mailer"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/pdf"
+)
+
+var titles = map[string]string{
+	models.ReportDaily:   "Daily feedback report",
+	models.ReportWeekly:  "Weekly feedback report",
+	models.ReportMonthly: "Monthly feedback report",
+}
+
+// Title names the report, e.g. "Weekly feedback report".
+func (r Report) Title() string {
+	return titles[r.Frequency]
+}
+
+// PeriodLabel describes the covered days, e.g. "12 Oct 2026 - 18 Oct 2026".
+func (r Report) PeriodLabel() string {
+	last := r.To.AddDate(0, 0, -1)
+	if last.Equal(r.From) {
+		return r.From.Format("Mon, 2 Jan 2006")
+	}
+	return r.From.Format("2 Jan 2006") + " - " + last.Format("2 Jan 2006")
+}
+
+// Subject is the email subject line.
+func (r Report) Subject() string {
+	subject := fmt.Sprintf("%s: %s", r.Title(), r.TenantName)
+	if r.GroupTitle != "" {
+		subject += " / " + r.GroupTitle
+	}
+	return subject + ", " + r.PeriodLabel()
+}
+
+type summaryRow struct {
+	Label string
+	Count
+}
+
+func (r Report) summary() []summaryRow {
+	return []summaryRow{
+		{"Received", r.Received},
+		{"Public", r.Public},
+		{"Admin-only", r.AdminOnly},
+		{"Posted to groups", r.Posted},
+		{"Rejected", r.Rejected},
+	}
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, Helvetica, sans-serif; color: #222; max-width: 640px;">
+<h2 style="margin-bottom: 4px;">{{.R.Title}}</h2>
+<p style="color: #666; margin-top: 0;">{{.R.TenantName}}{{if .R.GroupTitle}} / {{.R.GroupTitle}}{{end}} &middot; {{.R.PeriodLabel}}</p>
+
+<table cellpadding="6" style="border-collapse: collapse; width: 100%;">
+<tr style="background: #f2f2f2; text-align: left;"><th></th><th>This period</th><th>Previous</th><th>Trend</th></tr>
+{{range .Summary}}<tr style="border-bottom: 1px solid #eee;"><td>{{.Label}}</td><td><b>{{.Current}}</b></td><td>{{.Previous}}</td><td>{{.Change}}</td></tr>
+{{end}}</table>
+
+{{if .R.Groups}}<h3>By group</h3>
+<table cellpadding="6" style="border-collapse: collapse; width: 100%;">
+<tr style="background: #f2f2f2; text-align: left;"><th>Group</th><th>This period</th><th>Previous</th><th>Trend</th></tr>
+{{range .R.Groups}}<tr style="border-bottom: 1px solid #eee;"><td>{{.Title}}</td><td><b>{{.Current}}</b></td><td>{{.Previous}}</td><td>{{.Change}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .R.Types}}<h3>By type</h3>
+<table cellpadding="6" style="border-collapse: collapse; width: 100%;">
+<tr style="background: #f2f2f2; text-align: left;"><th>Type</th><th>This period</th><th>Previous</th><th>Trend</th></tr>
+{{range .R.Types}}<tr style="border-bottom: 1px solid #eee;"><td>{{.Label}}</td><td><b>{{.Current}}</b></td><td>{{.Previous}}</td><td>{{.Change}}</td></tr>
+{{end}}</table>
+{{end}}
+<h3>Awaiting moderation ({{.R.UnresolvedCount}})</h3>
+{{if .R.Unresolved}}<ul style="padding-left: 18px;">
+{{range .R.Unresolved}}<li style="margin-bottom: 8px;"><span style="color: #666;">{{.CreatedOn.Format "2 Jan"}} &middot; {{.Group}}</span><br>{{.Message}}</li>
+{{end}}</ul>
+{{if gt .R.UnresolvedCount (len .R.Unresolved)}}<p style="color: #666;">and {{.More}} more.</p>{{end}}
+{{else}}<p style="color: #666;">Nothing is waiting for moderation.</p>
+{{end}}
+<p style="color: #999; font-size: 12px;">A PDF copy of this report is attached. You receive it because of a report schedule in your dashboard.</p>
+</body>
+</html>
+`))
+
+// HTML renders the report as an email body.
+func (r Report) HTML() (string, error) {
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, map[string]interface{}{
+		"R":       r,
+		"Summary": r.summary(),
+		"More":    r.UnresolvedCount - int64(len(r.Unresolved)),
+	})
+	return buf.String(), err
+}
+
+// Text renders the report as plain text.
+func (r Report) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s\n\n", r.Subject(), strings.Repeat("=", 40))
+	for _, row := range r.summary() {
+		fmt.Fprintf(&b, "%-18s %6d  (previous %d, %s)\n", row.Label+":", row.Current, row.Previous, row.Change())
+	}
+	if len(r.Groups) > 0 {
+		b.WriteString("\nBy group:\n")
+		for _, g := range r.Groups {
+			fmt.Fprintf(&b, "  %s: %d (previous %d, %s)\n", g.Title, g.Current, g.Previous, g.Change())
+		}
+	}
+	if len(r.Types) > 0 {
+		b.WriteString("\nBy type:\n")
+		for _, t := range r.Types {
+			fmt.Fprintf(&b, "  %s: %d (previous %d, %s)\n", t.Label, t.Current, t.Previous, t.Change())
+		}
+	}
+	fmt.Fprintf(&b, "\nAwaiting moderation: %d\n", r.UnresolvedCount)
+	for _, item := range r.Unresolved {
+		fmt.Fprintf(&b, "- %s, %s: %s\n", item.CreatedOn.Format("2 Jan"), item.Group, item.Message)
+	}
+	if more := r.UnresolvedCount - int64(len(r.Unresolved)); more > 0 {
+		fmt.Fprintf(&b, "and %d more.\n", more)
+	}
+	return b.String()
+}
+
+// PDF renders the report as a PDF document.
+func (r Report) PDF() ([]byte, error) {
+	d, err := pdf.New()
+	if err != nil {
+		return nil, err
+	}
+	scope := r.TenantName
+	if r.GroupTitle != "" {
+		scope += " / " + r.GroupTitle
+	}
+	d.Text(r.Title(), 18)
+	d.TextGray(scope+" · "+r.PeriodLabel(), 10, 0.4)
+	d.Gap(12)
+
+	columns := []float64{0, 200, 290, 380}
+	row := func(label string, c Count) {
+		d.Row([]string{label, fmt.Sprint(c.Current), fmt.Sprint(c.Previous), c.Change()}, columns, 10)
+	}
+	d.Row([]string{"", "This period", "Previous", "Trend"}, columns, 10)
+	d.Rule()
+	for _, s := range r.summary() {
+		row(s.Label, s.Count)
+	}
+
+	if len(r.Groups) > 0 {
+		d.Gap(16)
+		d.Text("By group", 13)
+		d.Rule()
+		for _, g := range r.Groups {
+			row(g.Title, g.Count)
+		}
+	}
+	if len(r.Types) > 0 {
+		d.Gap(16)
+		d.Text("By type", 13)
+		d.Rule()
+		for _, t := range r.Types {
+			row(t.Label, t.Count)
+		}
+	}
+
+	d.Gap(16)
+	d.Text(fmt.Sprintf("Awaiting moderation (%d)", r.UnresolvedCount), 13)
+	d.Rule()
+	if len(r.Unresolved) == 0 {
+		d.TextGray("Nothing is waiting for moderation.", 10, 0.4)
+	}
+	for _, item := range r.Unresolved {
+		d.TextGray(item.CreatedOn.Format("2 Jan")+" · "+item.Group, 9, 0.4)
+		d.Text(item.Message, 10)
+		d.Gap(4)
+	}
+	if more := r.UnresolvedCount - int64(len(r.Unresolved)); more > 0 {
+		d.TextGray(fmt.Sprintf("and %d more.", more), 10, 0.4)
+	}
+	return d.Bytes(), nil
+}
+
+// Message renders the report as an email with the PDF attached.
+func (r Report) Message(to string) (mailer.Message, error) {
+	html, err := r.HTML()
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	doc, err := r.PDF()
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	return mailer.Message{
+		To:      []string{to},
+		Subject: r.Subject(),
+		Text:    r.Text(),
+		HTML:    html,
+		Attachments: []mailer.Attachment{{
+			Name:        r.FileName(),
+			ContentType: "application/pdf",
+			Data:        doc,
+		}},
+	}, nil
+}
+
+// FileName names the PDF, e.g. "feedback-report-weekly_2026-10-12.pdf".
+func (r Report) FileName() string {
+	return fmt.Sprintf("feedback-report-%s_%s.pdf", r.Frequency, r.From.Format(time.DateOnly))
+}
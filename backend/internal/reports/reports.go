@@ -0,0 +1,293 @@
+// Package reports emails users scheduled feedback digests.
+//
+// A models.ReportSchedule runs daily, weekly or monthly at a local hour in
+// the tenant's timezone. Each run covers the whole days before it (the
+// previous day, the previous 7 days or the previous month) and compares them
+// with the period before. The report is selected with the same filters as the
+// feedback listing (svc_feedback.FilterQuery) and sent through the mailer as
+// an HTML email with a PDF copy attached.
+package reports
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/anonymity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_feedback"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxUnresolved is how many unresolved items a report lists.
+	MaxUnresolved = 20
+
+	previewRunes = 280
+)
+
+// Validate checks a schedule's frequency and timing fields.
+func Validate(s models.ReportSchedule) error {
+	switch s.Frequency {
+	case models.ReportDaily, models.ReportWeekly, models.ReportMonthly:
+	default:
+		return fmt.Errorf("frequency must be daily, weekly or monthly")
+	}
+	if s.Hour < 0 || s.Hour > 23 {
+		return fmt.Errorf("hour must be between 0 and 23")
+	}
+	if s.Weekday < 0 || s.Weekday > 6 {
+		return fmt.Errorf("weekday must be between 0 (Sunday) and 6")
+	}
+	// Later days don't exist in every month
+	if s.DayOfMonth < 1 || s.DayOfMonth > 28 {
+		return fmt.Errorf("day_of_month must be between 1 and 28")
+	}
+	return nil
+}
+
+func runsOn(s models.ReportSchedule, day time.Time) bool {
+	switch s.Frequency {
+	case models.ReportWeekly:
+		return int(day.Weekday()) == s.Weekday
+	case models.ReportMonthly:
+		return day.Day() == s.DayOfMonth
+	}
+	return true
+}
+
+// NextRun returns the first time after after that s is due, in loc.
+func NextRun(s models.ReportSchedule, after time.Time, loc *time.Location) time.Time {
+	local := after.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	for i := 0; i <= 62; i++ {
+		d := day.AddDate(0, 0, i)
+		if !runsOn(s, d) {
+			continue
+		}
+		if at := time.Date(d.Year(), d.Month(), d.Day(), s.Hour, 0, 0, 0, loc); at.After(after) {
+			return at
+		}
+	}
+	// Unreachable for a valid schedule
+	return after.Add(24 * time.Hour)
+}
+
+// back returns the start of the period of frequency that ends at t.
+func back(frequency string, t time.Time) time.Time {
+	switch frequency {
+	case models.ReportWeekly:
+		return t.AddDate(0, 0, -7)
+	case models.ReportMonthly:
+		return t.AddDate(0, -1, 0)
+	}
+	return t.AddDate(0, 0, -1)
+}
+
+// Period returns the whole local days a run at runAt covers: [from, to).
+func Period(frequency string, runAt time.Time, loc *time.Location) (from, to time.Time) {
+	local := runAt.In(loc)
+	to = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	return back(frequency, to), to
+}
+
+// Count is a figure for a period and the one before it.
+type Count struct {
+	Current  int64 `json:"current"`
+	Previous int64 `json:"previous"`
+}
+
+// Change describes the trend, e.g. "+25%", "-10%", "no change" or "new".
+func (c Count) Change() string {
+	switch {
+	case c.Current == c.Previous:
+		return "no change"
+	case c.Previous == 0:
+		return "new"
+	}
+	pct := float64(c.Current-c.Previous) * 100 / float64(c.Previous)
+	return fmt.Sprintf("%+.0f%%", pct)
+}
+
+// GroupCount is one group's received feedback.
+type GroupCount struct {
+	GroupID uint   `json:"group_id"`
+	Title   string `json:"title"`
+	Count
+}
+
+// TypeCount is the received feedback of one type.
+type TypeCount struct {
+	Type  string `json:"type"`
+	Label string `json:"label"`
+	Count
+}
+
+var typeLabels = map[string]string{
+	models.FeedbackGeneral:  "General",
+	models.FeedbackIdea:     "Ideas",
+	models.FeedbackIssue:    "Issues",
+	models.FeedbackPraise:   "Praise",
+	models.FeedbackQuestion: "Questions",
+}
+
+// Item is an unresolved feedback.
+type Item struct {
+	ID        uint      `json:"id"`
+	Group     string    `json:"group"`
+	Message   string    `json:"message"`
+	CreatedOn time.Time `json:"created_on"` // Local date only, so it can't be matched to a sender
+}
+
+// Report is a digest of one period.
+type Report struct {
+	TenantName   string       `json:"tenant_name"`
+	GroupTitle   string       `json:"group_title,omitempty"` // Set when limited to one group
+	Frequency    string       `json:"frequency"`
+	From         time.Time    `json:"from"`
+	To           time.Time    `json:"to"` // Exclusive
+	PreviousFrom time.Time    `json:"previous_from"`
+	Received     Count        `json:"received"`
+	Public       Count        `json:"public"`
+	AdminOnly    Count        `json:"admin_only"`
+	Posted       Count        `json:"posted"`
+	Rejected     Count        `json:"rejected"`
+	Groups       []GroupCount `json:"groups"`
+	Types        []TypeCount  `json:"types,omitempty"` // Omitted when all feedback is general
+	// Feedback from the period still waiting for moderation
+	UnresolvedCount int64  `json:"unresolved_count"`
+	Unresolved      []Item `json:"unresolved"`
+}
+
+// Build gathers the report for a schedule run at runAt.
+func Build(s models.ReportSchedule, tenant models.Tenant, runAt time.Time) Report {
+	loc := anonymity.Location(tenant.Timezone)
+	from, to := Period(s.Frequency, runAt, loc)
+	prevFrom := back(s.Frequency, from)
+
+	params := url.Values{}
+	if s.GroupID != nil {
+		params.Set("group_id", strconv.FormatUint(uint64(*s.GroupID), 10))
+	}
+	// A fresh query per use, since gorm chains can't be shared
+	between := func(from, to time.Time) *gorm.DB {
+		return svc_feedback.FilterQuery(tenant.ID, params).Model(&models.Feedback{}).
+			Where("feedbacks.created_at >= ? AND feedbacks.created_at < ?", from.UTC(), to.UTC())
+	}
+	count := func(where string, args ...interface{}) Count {
+		var c Count
+		cur, prev := between(from, to), between(prevFrom, from)
+		if where != "" {
+			cur, prev = cur.Where(where, args...), prev.Where(where, args...)
+		}
+		cur.Count(&c.Current)
+		prev.Count(&c.Previous)
+		return c
+	}
+
+	r := Report{
+		TenantName:   tenant.Name,
+		Frequency:    s.Frequency,
+		From:         from,
+		To:           to,
+		PreviousFrom: prevFrom,
+		Received:     count(""),
+		Public:       count("admin_only = ?", false),
+		AdminOnly:    count("admin_only = ?", true),
+		Posted:       count("posted = ?", true),
+		Rejected:     count("moderation = ?", models.ModerationRejected),
+	}
+
+	r.Groups = groupCounts(between(from, to), between(prevFrom, from))
+	for _, t := range models.FeedbackTypes {
+		if c := count("type = ?", t); c.Current > 0 || c.Previous > 0 {
+			r.Types = append(r.Types, TypeCount{Type: t, Label: typeLabels[t], Count: c})
+		}
+	}
+	if len(r.Types) == 1 && r.Types[0].Type == models.FeedbackGeneral {
+		r.Types = nil
+	}
+	if s.GroupID != nil {
+		var group models.Group
+		models.DB.Select("id", "title").First(&group, *s.GroupID)
+		r.GroupTitle = group.Title
+	}
+
+	unresolved := func() *gorm.DB {
+		return between(from, to).Where("moderation = ?", models.ModerationPending)
+	}
+	unresolved().Count(&r.UnresolvedCount)
+	var feedbacks []models.Feedback
+	unresolved().Preload("Group").Order("feedbacks.created_at ASC, feedbacks.id ASC").Limit(MaxUnresolved).Find(&feedbacks)
+	for _, fb := range feedbacks {
+		created := fb.CreatedAt.In(loc)
+		r.Unresolved = append(r.Unresolved, Item{
+			ID:        fb.ID,
+			Group:     fb.Group.Title,
+			Message:   preview(fb),
+			CreatedOn: time.Date(created.Year(), created.Month(), created.Day(), 0, 0, 0, 0, loc),
+		})
+	}
+	return r
+}
+
+func groupCounts(current, previous *gorm.DB) []GroupCount {
+	type row struct {
+		GroupID uint
+		N       int64
+	}
+	var cur, prev []row
+	current.Select("group_id, COUNT(*) AS n").Group("group_id").Scan(&cur)
+	previous.Select("group_id, COUNT(*) AS n").Group("group_id").Scan(&prev)
+
+	byGroup := map[uint]*GroupCount{}
+	var ids []uint
+	get := func(id uint) *GroupCount {
+		if g, ok := byGroup[id]; ok {
+			return g
+		}
+		byGroup[id] = &GroupCount{GroupID: id}
+		ids = append(ids, id)
+		return byGroup[id]
+	}
+	for _, r := range cur {
+		get(r.GroupID).Current = r.N
+	}
+	for _, r := range prev {
+		get(r.GroupID).Previous = r.N
+	}
+
+	var groups []models.Group
+	if len(ids) > 0 {
+		models.DB.Unscoped().Select("id", "title").Where("id IN ?", ids).Find(&groups)
+	}
+	for _, g := range groups {
+		byGroup[g.ID].Title = g.Title
+	}
+
+	out := make([]GroupCount, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, *byGroup[id])
+	}
+	// Busiest groups first
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Current != out[j].Current {
+			return out[i].Current > out[j].Current
+		}
+		return out[i].Title < out[j].Title
+	})
+	return out
+}
+
+func preview(fb models.Feedback) string {
+	if fb.Sealed {
+		return "(sealed for the admin key)"
+	}
+	if utf8.RuneCountInString(fb.Message) <= previewRunes {
+		return fb.Message
+	}
+	return string([]rune(fb.Message)[:previewRunes]) + "…"
+}
@@ -0,0 +1,203 @@
+package reports
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/mailer"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tashkent = time.FixedZone("UTC+5", 5*60*60)
+
+func TestNextRun(t *testing.T) {
+	// Sunday 18 Oct 2026, 10:00 local
+	after := time.Date(2026, 10, 18, 10, 0, 0, 0, tashkent)
+
+	daily := models.ReportSchedule{Frequency: models.ReportDaily, Hour: 9}
+	assert.Equal(t, time.Date(2026, 10, 19, 9, 0, 0, 0, tashkent), NextRun(daily, after, tashkent))
+	daily.Hour = 11
+	assert.Equal(t, time.Date(2026, 10, 18, 11, 0, 0, 0, tashkent), NextRun(daily, after, tashkent))
+
+	weekly := models.ReportSchedule{Frequency: models.ReportWeekly, Hour: 9, Weekday: int(time.Monday)}
+	assert.Equal(t, time.Date(2026, 10, 19, 9, 0, 0, 0, tashkent), NextRun(weekly, after, tashkent))
+	weekly.Weekday = int(time.Sunday)
+	weekly.Hour = 10
+	assert.Equal(t, time.Date(2026, 10, 25, 10, 0, 0, 0, tashkent), NextRun(weekly, after, tashkent), "strictly after")
+
+	monthly := models.ReportSchedule{Frequency: models.ReportMonthly, Hour: 8, DayOfMonth: 1}
+	assert.Equal(t, time.Date(2026, 11, 1, 8, 0, 0, 0, tashkent), NextRun(monthly, after, tashkent))
+
+	// Days are counted in the tenant's timezone: 20:00 UTC is already the 19th
+	daily.Hour = 0
+	late := time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC)
+	assert.True(t, NextRun(daily, late, tashkent).Equal(time.Date(2026, 10, 20, 0, 0, 0, 0, tashkent)))
+}
+
+func TestPeriod(t *testing.T) {
+	runAt := time.Date(2026, 10, 19, 9, 0, 0, 0, tashkent)
+
+	from, to := Period(models.ReportWeekly, runAt, tashkent)
+	assert.Equal(t, time.Date(2026, 10, 12, 0, 0, 0, 0, tashkent), from)
+	assert.Equal(t, time.Date(2026, 10, 19, 0, 0, 0, 0, tashkent), to)
+
+	from, _ = Period(models.ReportDaily, runAt, tashkent)
+	assert.Equal(t, time.Date(2026, 10, 18, 0, 0, 0, 0, tashkent), from)
+
+	from, to = Period(models.ReportMonthly, time.Date(2026, 11, 1, 8, 0, 0, 0, tashkent), tashkent)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, tashkent), from)
+	assert.Equal(t, time.Date(2026, 11, 1, 0, 0, 0, 0, tashkent), to)
+}
+
+func TestValidate(t *testing.T) {
+	valid := models.ReportSchedule{Frequency: models.ReportWeekly, Hour: 9, Weekday: 1, DayOfMonth: 1}
+	assert.NoError(t, Validate(valid))
+
+	for _, mutate := range []func(*models.ReportSchedule){
+		func(s *models.ReportSchedule) { s.Frequency = "hourly" },
+		func(s *models.ReportSchedule) { s.Hour = 24 },
+		func(s *models.ReportSchedule) { s.Weekday = 7 },
+		func(s *models.ReportSchedule) { s.DayOfMonth = 31 },
+	} {
+		s := valid
+		mutate(&s)
+		assert.Error(t, Validate(s))
+	}
+}
+
+func TestCount_Change(t *testing.T) {
+	assert.Equal(t, "+100%", Count{4, 2}.Change())
+	assert.Equal(t, "-25%", Count{3, 4}.Change())
+	assert.Equal(t, "new", Count{1, 0}.Change())
+	assert.Equal(t, "no change", Count{0, 0}.Change())
+}
+
+// seedReport creates a Tashkent tenant with feedback in the week of 12-18
+// Oct 2026 and the week before.
+func seedReport(t *testing.T) (models.User, models.Tenant, models.Group) {
+	t.Helper()
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "boss@example.com", "Boss")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Acme", "acme")
+	require.Equal(t, "Asia/Tashkent", tenant.Timezone)
+
+	a := models.Group{TenantID: tenant.ID, ChatID: -1, Title: "Backend"}
+	b := models.Group{TenantID: tenant.ID, ChatID: -2, Title: "Frontend"}
+	models.DB.Create(&a)
+	models.DB.Create(&b)
+
+	at := func(day, hour int) time.Time { return time.Date(2026, 10, day, hour, 0, 0, 0, time.UTC) }
+	create := func(fb models.Feedback, created time.Time) {
+		fb.TenantID, fb.CreatedAt = tenant.ID, created
+		if fb.GroupID == 0 {
+			fb.GroupID = a.ID
+		}
+		require.NoError(t, models.DB.Create(&fb).Error)
+	}
+	create(models.Feedback{Message: "public", Type: models.FeedbackIdea}, at(13, 10))
+	create(models.Feedback{Message: "secret", AdminOnly: true}, at(14, 10))
+	create(models.Feedback{Message: "posted", Posted: true}, at(15, 10))
+	create(models.Feedback{Message: "Нужно обсудить отпуск", Moderation: models.ModerationPending}, at(16, 10))
+	create(models.Feedback{Message: "frontend", GroupID: b.ID, Type: models.FeedbackPraise}, at(11, 19)) // 00:00 on the 12th in Tashkent
+	create(models.Feedback{Message: "last week"}, at(6, 10))
+	create(models.Feedback{Message: "last week too"}, at(10, 10))
+	create(models.Feedback{Message: "too late"}, at(18, 20)) // 01:00 on the 19th in Tashkent
+
+	other := testutil.CreateTestUser(t, "other@example.com", "Other")
+	otherTenant := testutil.CreateTestTenant(t, other.ID, "Other", "other")
+	models.DB.Create(&models.Feedback{TenantID: otherTenant.ID, GroupID: 99, Message: "elsewhere"})
+	return user, tenant, a
+}
+
+func TestBuild(t *testing.T) {
+	_, tenant, backend := seedReport(t)
+	runAt := time.Date(2026, 10, 19, 4, 0, 0, 0, time.UTC) // 09:00 in Tashkent
+
+	r := Build(models.ReportSchedule{Frequency: models.ReportWeekly}, tenant, runAt)
+	assert.Equal(t, Count{5, 2}, r.Received)
+	assert.Equal(t, Count{4, 2}, r.Public)
+	assert.Equal(t, Count{1, 0}, r.AdminOnly)
+	assert.Equal(t, Count{1, 0}, r.Posted)
+	require.Len(t, r.Groups, 2)
+	assert.Equal(t, GroupCount{GroupID: backend.ID, Title: "Backend", Count: Count{4, 2}}, r.Groups[0])
+	assert.Equal(t, "Frontend", r.Groups[1].Title)
+	assert.Equal(t, []TypeCount{
+		{Type: models.FeedbackGeneral, Label: "General", Count: Count{3, 2}},
+		{Type: models.FeedbackIdea, Label: "Ideas", Count: Count{1, 0}},
+		{Type: models.FeedbackPraise, Label: "Praise", Count: Count{1, 0}},
+	}, r.Types)
+	assert.Equal(t, int64(1), r.UnresolvedCount)
+	require.Len(t, r.Unresolved, 1)
+	assert.Equal(t, "Нужно обсудить отпуск", r.Unresolved[0].Message)
+	assert.Equal(t, "Backend", r.Unresolved[0].Group)
+	assert.Equal(t, "12 Oct 2026 - 18 Oct 2026", r.PeriodLabel())
+
+	gid := backend.ID
+	r = Build(models.ReportSchedule{Frequency: models.ReportWeekly, GroupID: &gid}, tenant, runAt)
+	assert.Equal(t, Count{4, 2}, r.Received)
+	assert.Equal(t, "Backend", r.GroupTitle)
+
+	html, err := r.HTML()
+	require.NoError(t, err)
+	assert.Contains(t, html, "Weekly feedback report")
+	assert.Contains(t, html, "Нужно обсудить отпуск")
+	assert.Contains(t, r.Text(), "Received:               4  (previous 2, +100%)")
+	assert.Contains(t, r.Text(), "By type:\n  General: 3 (previous 2, +50%)\n  Ideas: 1 (previous 0, new)\n")
+
+	doc, err := r.PDF()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(doc), "%PDF-"))
+}
+
+type recorder struct{ sent []mailer.Message }
+
+func (r *recorder) Send(msg mailer.Message) error {
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func TestRun_SendsDueReports(t *testing.T) {
+	user, tenant, _ := seedReport(t)
+	rec := &recorder{}
+	prev := mailer.SetSender(rec)
+	defer mailer.SetSender(prev)
+
+	due := time.Date(2026, 10, 19, 4, 0, 0, 0, time.UTC)
+	s := models.ReportSchedule{TenantID: tenant.ID, UserID: user.ID, Frequency: models.ReportWeekly, Hour: 9, Weekday: 1, DayOfMonth: 1, Enabled: true, NextRunAt: due}
+	models.DB.Create(&s)
+	disabled := models.ReportSchedule{TenantID: tenant.ID, UserID: user.ID, Frequency: models.ReportDaily, DayOfMonth: 1, NextRunAt: due}
+	models.DB.Create(&disabled)
+	models.DB.Model(&disabled).Update("enabled", false)
+
+	Run(due.Add(-time.Minute))
+	assert.Empty(t, rec.sent)
+
+	// Running late still reports the scheduled week
+	now := due.Add(3 * time.Hour)
+	Run(now)
+	require.Len(t, rec.sent, 1)
+	msg := rec.sent[0]
+	assert.Equal(t, []string{"boss@example.com"}, msg.To)
+	assert.Contains(t, msg.Subject, "12 Oct 2026 - 18 Oct 2026")
+	require.Len(t, msg.Attachments, 1)
+	assert.Equal(t, "feedback-report-weekly_2026-10-12.pdf", msg.Attachments[0].Name)
+
+	var reloaded models.ReportSchedule
+	models.DB.First(&reloaded, s.ID)
+	require.NotNil(t, reloaded.LastSentAt)
+	assert.True(t, reloaded.NextRunAt.Equal(due.AddDate(0, 0, 7)), reloaded.NextRunAt)
+
+	Run(now.Add(time.Minute))
+	assert.Len(t, rec.sent, 1)
+
+	// A recipient who left the tenant gets nothing and the error is kept
+	models.DB.Where("user_id = ?", user.ID).Delete(&models.UserTenant{})
+	Run(due.AddDate(0, 0, 7))
+	assert.Len(t, rec.sent, 1)
+	models.DB.First(&reloaded, s.ID)
+	assert.Contains(t, reloaded.LastError, "no longer a member")
+}
@@ -0,0 +1,81 @@
+package reports
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/anonymity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/mailer"
+)
+
+const checkInterval = time.Minute
+
+var stopCh = make(chan struct{})
+
+// Start sends due reports every minute, until Stop.
+func Start() {
+	log.Printf("[reports] Starting report scheduler")
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			log.Printf("[reports] Stopping report scheduler")
+			return
+		case now := <-ticker.C:
+			Run(now)
+		}
+	}
+}
+
+func Stop() {
+	close(stopCh)
+}
+
+// Run sends every enabled report that is due at now and schedules its next
+// run. Runs missed while the server was down are sent once, not repeated.
+func Run(now time.Time) {
+	var due []models.ReportSchedule
+	models.DB.Where("enabled = ? AND next_run_at <= ?", true, now).Find(&due)
+	for _, s := range due {
+		var tenant models.Tenant
+		if err := models.DB.First(&tenant, s.TenantID).Error; err != nil {
+			continue
+		}
+
+		updates := map[string]interface{}{
+			"next_run_at": NextRun(s, now, anonymity.Location(tenant.Timezone)).UTC(),
+			"last_error":  "",
+		}
+		if err := Send(s, tenant, s.NextRunAt); err != nil {
+			log.Printf("[reports] Report schedule %d for tenant %d failed: %v", s.ID, s.TenantID, err)
+			updates["last_error"] = err.Error()
+		} else {
+			updates["last_sent_at"] = now
+		}
+		models.DB.Model(&s).Updates(updates)
+	}
+}
+
+// Send builds the report for a run at runAt and emails it to the schedule's
+// user.
+func Send(s models.ReportSchedule, tenant models.Tenant, runAt time.Time) error {
+	if tenant.DeletionScheduledAt != nil {
+		return errors.New("tenant is scheduled for deletion")
+	}
+	var user models.User
+	err := models.DB.Joins("JOIN user_tenants ON user_tenants.user_id = users.id AND user_tenants.deleted_at IS NULL").
+		Where("user_tenants.tenant_id = ?", s.TenantID).First(&user, s.UserID).Error
+	if err != nil {
+		return errors.New("recipient is no longer a member of the tenant")
+	}
+
+	msg, err := Build(s, tenant, runAt).Message(user.Email)
+	if err != nil {
+		return err
+	}
+	return mailer.Send(msg)
+}
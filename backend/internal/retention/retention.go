@@ -0,0 +1,248 @@
+// Package retention enforces how long feedback is kept.
+//
+// Each tenant sets RetentionMonths (0 keeps feedback forever) and a group's
+// FeedbackConfig.RetentionMonths overrides it. The purge job hard-deletes
+// feedback older than the cutoff together with the comments on it, the sender
+// rows (GroupUser) left without any feedback, and the retro notes (RetroItem)
+// of the group's sessions older than the same cutoff. It logs the counts to
+// models.PurgeLog. Unfinished group choices (PendingFeedback) expire after
+// PendingFeedbackTTL regardless.
+package retention
+
+import (
+	"log"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/tenantdata"
+	"gorm.io/gorm"
+)
+
+const (
+	PendingFeedbackTTL = 24 * time.Hour
+	MaxRetentionMonths = 120
+
+	purgeInterval = time.Hour
+)
+
+// GroupPurge describes what a purge removes (or removed) from one group.
+type GroupPurge struct {
+	GroupID         uint      `json:"group_id"`
+	GroupTitle      string    `json:"group_title"`
+	RetentionMonths int       `json:"retention_months"` // 0 keeps feedback forever
+	Cutoff          time.Time `json:"cutoff"`
+	Feedbacks       int64     `json:"feedbacks"`
+	GroupUsers      int64     `json:"group_users"`
+	RetroItems      int64     `json:"retro_items"`
+}
+
+// Report sums up a tenant purge or dry run.
+type Report struct {
+	DryRun     bool         `json:"dry_run"`
+	Groups     []GroupPurge `json:"groups"`
+	Feedbacks  int64        `json:"feedbacks"`
+	GroupUsers int64        `json:"group_users"`
+	RetroItems int64        `json:"retro_items"`
+}
+
+// EffectiveMonths returns the retention that applies to a group.
+func EffectiveMonths(tenant models.Tenant, config models.FeedbackConfig) int {
+	if config.RetentionMonths != nil {
+		return *config.RetentionMonths
+	}
+	return tenant.RetentionMonths
+}
+
+// Cutoff returns the time before which feedback has expired, or the zero
+// time when it is kept forever.
+func Cutoff(months int, now time.Time) time.Time {
+	if months <= 0 {
+		return time.Time{}
+	}
+	return now.AddDate(0, -months, 0)
+}
+
+// expiredFeedback selects a group's feedback created before cutoff,
+// including retracted (soft-deleted) rows, along with the comments on it,
+// which would otherwise point at a parent that is gone.
+func expiredFeedback(tx *gorm.DB, groupID uint, cutoff time.Time) *gorm.DB {
+	return tx.Unscoped().Model(&models.Feedback{}).
+		Where("group_id = ? AND (created_at < ? OR parent_id IN (SELECT id FROM feedbacks WHERE group_id = ? AND created_at < ?))",
+			groupID, cutoff, groupID, cutoff)
+}
+
+// expiredRetroItems selects the notes of a group's retro sessions written
+// before cutoff, including soft-deleted rows.
+func expiredRetroItems(tx *gorm.DB, groupID uint, cutoff time.Time) *gorm.DB {
+	return tx.Unscoped().Model(&models.RetroItem{}).
+		Where("session_id IN (SELECT id FROM retro_sessions WHERE group_id = ?) AND created_at < ?", groupID, cutoff)
+}
+
+// orphanedGroupUsers selects a group's senders with no feedback from cutoff
+// on. New senders are spared for PendingFeedbackTTL, since their GroupUser is
+// created just before their feedback.
+func orphanedGroupUsers(tx *gorm.DB, groupID uint, cutoff, now time.Time) *gorm.DB {
+	return tx.Unscoped().Model(&models.GroupUser{}).
+		Where("group_id = ? AND created_at < ?", groupID, now.Add(-PendingFeedbackTTL)).
+		Where("NOT EXISTS (SELECT 1 FROM feedbacks WHERE feedbacks.sender_id = group_users.id AND feedbacks.created_at >= ?)", cutoff)
+}
+
+func plan(tenantID uint, now time.Time) []GroupPurge {
+	var tenant models.Tenant
+	models.DB.First(&tenant, tenantID)
+
+	var groups []models.Group
+	models.DB.Unscoped().Where("tenant_id = ?", tenantID).Order("id").Find(&groups)
+
+	var out []GroupPurge
+	for _, group := range groups {
+		var config models.FeedbackConfig
+		models.DB.Where("group_id = ?", group.ID).First(&config)
+
+		months := EffectiveMonths(tenant, config)
+		p := GroupPurge{GroupID: group.ID, GroupTitle: group.Title, RetentionMonths: months, Cutoff: Cutoff(months, now)}
+		expiredFeedback(models.DB, group.ID, p.Cutoff).Count(&p.Feedbacks)
+		orphanedGroupUsers(models.DB, group.ID, p.Cutoff, now).Count(&p.GroupUsers)
+		expiredRetroItems(models.DB, group.ID, p.Cutoff).Count(&p.RetroItems)
+		out = append(out, p)
+	}
+	return out
+}
+
+func summarize(groups []GroupPurge, dryRun bool) Report {
+	r := Report{DryRun: dryRun, Groups: []GroupPurge{}}
+	for _, g := range groups {
+		r.Groups = append(r.Groups, g)
+		r.Feedbacks += g.Feedbacks
+		r.GroupUsers += g.GroupUsers
+		r.RetroItems += g.RetroItems
+	}
+	return r
+}
+
+// Preview reports what PurgeTenant would delete at now, without deleting.
+func Preview(tenantID uint, now time.Time) Report {
+	return summarize(plan(tenantID, now), true)
+}
+
+// PurgeTenant hard-deletes a tenant's expired feedback, orphaned senders
+// and expired retro notes, and records each affected group in the purge log.
+func PurgeTenant(tenantID uint, now time.Time) (Report, error) {
+	var purged []GroupPurge
+	for _, p := range plan(tenantID, now) {
+		if p.Feedbacks == 0 && p.GroupUsers == 0 && p.RetroItems == 0 {
+			continue
+		}
+		err := models.DB.Transaction(func(tx *gorm.DB) error {
+			for _, child := range []interface{}{&models.IdeaVote{}, &models.PublicResponse{}} {
+				err := tx.Unscoped().Where("feedback_id IN (?)", expiredFeedback(tx, p.GroupID, p.Cutoff).Select("id")).
+					Delete(child).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			res := expiredFeedback(tx, p.GroupID, p.Cutoff).Delete(&models.Feedback{})
+			if res.Error != nil {
+				return res.Error
+			}
+			p.Feedbacks = res.RowsAffected
+
+			res = orphanedGroupUsers(tx, p.GroupID, p.Cutoff, now).Delete(&models.GroupUser{})
+			if res.Error != nil {
+				return res.Error
+			}
+			p.GroupUsers = res.RowsAffected
+
+			err := tx.Unscoped().Where("item_id IN (?)", expiredRetroItems(tx, p.GroupID, p.Cutoff).Select("id")).
+				Delete(&models.RetroDot{}).Error
+			if err != nil {
+				return err
+			}
+			res = expiredRetroItems(tx, p.GroupID, p.Cutoff).Delete(&models.RetroItem{})
+			if res.Error != nil {
+				return res.Error
+			}
+			p.RetroItems = res.RowsAffected
+
+			return tx.Create(&models.PurgeLog{
+				TenantID:        tenantID,
+				GroupID:         p.GroupID,
+				RetentionMonths: p.RetentionMonths,
+				Cutoff:          p.Cutoff,
+				Feedbacks:       p.Feedbacks,
+				GroupUsers:      p.GroupUsers,
+				RetroItems:      p.RetroItems,
+			}).Error
+		})
+		if err != nil {
+			return summarize(purged, false), err
+		}
+		purged = append(purged, p)
+	}
+	return summarize(purged, false), nil
+}
+
+// PurgePending hard-deletes group choices and comment prompts that were
+// never completed, along with ones already used (soft-deleted).
+func PurgePending(now time.Time) (int64, error) {
+	var n int64
+	for _, model := range []interface{}{&models.PendingFeedback{}, &models.PendingComment{}} {
+		res := models.DB.Unscoped().
+			Where("updated_at < ? OR deleted_at IS NOT NULL", now.Add(-PendingFeedbackTTL)).
+			Delete(model)
+		if res.Error != nil {
+			return n, res.Error
+		}
+		n += res.RowsAffected
+	}
+	return n, nil
+}
+
+// Run purges every tenant once, and completes due tenant deletions.
+func Run(now time.Time) {
+	tenantdata.Run(now)
+
+	if n, err := PurgePending(now); err != nil {
+		log.Printf("[retention] Failed to purge pending feedback: %v", err)
+	} else if n > 0 {
+		log.Printf("[retention] Purged %d pending feedback(s)", n)
+	}
+
+	var tenantIDs []uint
+	models.DB.Model(&models.Tenant{}).Pluck("id", &tenantIDs)
+	for _, tenantID := range tenantIDs {
+		report, err := PurgeTenant(tenantID, now)
+		if err != nil {
+			log.Printf("[retention] Failed to purge tenant %d: %v", tenantID, err)
+			continue
+		}
+		if report.Feedbacks > 0 || report.GroupUsers > 0 || report.RetroItems > 0 {
+			log.Printf("[retention] Tenant %d: purged %d feedback(s), %d sender(s), %d retro note(s)", tenantID, report.Feedbacks, report.GroupUsers, report.RetroItems)
+		}
+	}
+}
+
+var stopCh = make(chan struct{})
+
+// Start runs the purge job at startup and then hourly, until Stop.
+func Start() {
+	log.Printf("[retention] Starting purge job")
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	Run(time.Now())
+	for {
+		select {
+		case <-stopCh:
+			log.Printf("[retention] Stopping purge job")
+			return
+		case now := <-ticker.C:
+			Run(now)
+		}
+	}
+}
+
+func Stop() {
+	close(stopCh)
+}
@@ -0,0 +1,152 @@
+package retention
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func at(t time.Time) gorm.Model {
+	return gorm.Model{CreatedAt: t, UpdatedAt: t}
+}
+
+// setup creates a tenant with 6-month retention and two groups, the first
+// of which overrides it to keep feedback forever. Each group has one sender
+// with 7-month-old feedback and one with recent feedback.
+func setup(t *testing.T, now time.Time) (models.Tenant, []models.Group) {
+	t.Helper()
+	testutil.SetupTestDB(t)
+	tenant := models.Tenant{Name: "R", Slug: "r", RetentionMonths: 6}
+	require.NoError(t, models.DB.Create(&tenant).Error)
+
+	forever := 0
+	old := now.AddDate(0, -7, 0)
+	groups := make([]models.Group, 2)
+	for i := range groups {
+		groups[i] = models.Group{TenantID: tenant.ID, BotID: 1, ChatID: int64(-100 - i), Title: "G", IsActive: true}
+		models.DB.Create(&groups[i])
+		config := models.FeedbackConfig{GroupID: groups[i].ID}
+		if i == 0 {
+			config.RetentionMonths = &forever
+		}
+		models.DB.Create(&config)
+
+		oldSender := models.GroupUser{TenantID: tenant.ID, GroupID: groups[i].ID, SenderHash: identity.Pseudonym(int64(i)), Model: at(old)}
+		recentSender := models.GroupUser{TenantID: tenant.ID, GroupID: groups[i].ID, SenderHash: identity.Pseudonym(int64(10 + i)), Model: at(old)}
+		models.DB.Create(&oldSender)
+		models.DB.Create(&recentSender)
+		models.DB.Create(&models.Feedback{TenantID: tenant.ID, GroupID: groups[i].ID, SenderID: oldSender.ID, Message: "old", Model: at(old)})
+		models.DB.Create(&models.Feedback{TenantID: tenant.ID, GroupID: groups[i].ID, SenderID: recentSender.ID, Message: "new", Model: at(now.AddDate(0, -1, 0))})
+	}
+	return tenant, groups
+}
+
+func TestPreview_DoesNotDelete(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	tenant, groups := setup(t, now)
+
+	report := Preview(tenant.ID, now)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, int64(1), report.Feedbacks)
+	assert.Equal(t, int64(1), report.GroupUsers)
+	require.Len(t, report.Groups, 2)
+	assert.Equal(t, 0, report.Groups[0].RetentionMonths)
+	assert.Equal(t, groups[1].ID, report.Groups[1].GroupID)
+	assert.Equal(t, 6, report.Groups[1].RetentionMonths)
+
+	var count int64
+	models.DB.Model(&models.Feedback{}).Count(&count)
+	assert.Equal(t, int64(4), count)
+}
+
+func TestPurgeTenant_DeletesExpiredAndLogs(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	tenant, groups := setup(t, now)
+
+	// Retracted feedback is purged too, and for good
+	var retracted models.Feedback
+	models.DB.Where("group_id = ? AND message = ?", groups[1].ID, "old").First(&retracted)
+	models.DB.Delete(&retracted)
+	// Votes and responses go with their feedback
+	models.DB.Create(&models.IdeaVote{TenantID: tenant.ID, FeedbackID: retracted.ID, VoterHash: "v", Value: 1})
+	models.DB.Create(&models.PublicResponse{TenantID: tenant.ID, FeedbackID: retracted.ID, GroupID: groups[1].ID, Message: "Fixed"})
+	// A recent comment goes with its expired parent, and so do its votes
+	comment := models.Feedback{TenantID: tenant.ID, GroupID: groups[1].ID, SenderID: retracted.SenderID, Message: "comment", ParentID: &retracted.ID}
+	models.DB.Create(&comment)
+	models.DB.Create(&models.IdeaVote{TenantID: tenant.ID, FeedbackID: comment.ID, VoterHash: "w", Value: 1})
+	models.DB.Create(&models.PublicResponse{TenantID: tenant.ID, FeedbackID: comment.ID, GroupID: groups[1].ID, Message: "Noted"})
+	// Retro notes follow their session's group, with their dots
+	for _, group := range groups {
+		retro := models.RetroSession{TenantID: tenant.ID, GroupID: group.ID, Title: "Retro", Model: at(now.AddDate(0, -7, 0))}
+		models.DB.Create(&retro)
+		item := models.RetroItem{TenantID: tenant.ID, SessionID: retro.ID, Message: "old note", Model: at(now.AddDate(0, -7, 0))}
+		models.DB.Create(&item)
+		models.DB.Create(&models.RetroDot{TenantID: tenant.ID, SessionID: retro.ID, ItemID: item.ID, VoterHash: "v"})
+		models.DB.Create(&models.RetroItem{TenantID: tenant.ID, SessionID: retro.ID, Message: "new note"})
+	}
+
+	report, err := PurgeTenant(tenant.ID, now)
+	require.NoError(t, err)
+	assert.False(t, report.DryRun)
+	assert.Equal(t, int64(2), report.Feedbacks)
+	assert.Equal(t, int64(1), report.GroupUsers)
+	assert.Equal(t, int64(1), report.RetroItems)
+
+	var messages []string
+	models.DB.Unscoped().Model(&models.Feedback{}).Where("group_id = ?", groups[1].ID).Pluck("message", &messages)
+	assert.Equal(t, []string{"new"}, messages)
+	var kept int64
+	models.DB.Model(&models.Feedback{}).Where("group_id = ?", groups[0].ID).Count(&kept)
+	assert.Equal(t, int64(2), kept)
+	var senders int64
+	models.DB.Unscoped().Model(&models.GroupUser{}).Where("group_id = ?", groups[1].ID).Count(&senders)
+	assert.Equal(t, int64(1), senders)
+	var votes int64
+	models.DB.Unscoped().Model(&models.IdeaVote{}).Count(&votes)
+	assert.Zero(t, votes)
+	var responses int64
+	models.DB.Unscoped().Model(&models.PublicResponse{}).Count(&responses)
+	assert.Zero(t, responses)
+	var notes []string
+	models.DB.Unscoped().Model(&models.RetroItem{}).Order("id").Pluck("message", &notes)
+	assert.Equal(t, []string{"old note", "new note", "new note"}, notes)
+	var dots int64
+	models.DB.Unscoped().Model(&models.RetroDot{}).Count(&dots)
+	assert.Equal(t, int64(1), dots, "only the kept group's dot is left")
+
+	var logs []models.PurgeLog
+	models.DB.Find(&logs)
+	require.Len(t, logs, 1)
+	assert.Equal(t, groups[1].ID, logs[0].GroupID)
+	assert.Equal(t, int64(2), logs[0].Feedbacks)
+	assert.Equal(t, int64(1), logs[0].RetroItems)
+
+	// Nothing left to do on the next run
+	report, err = PurgeTenant(tenant.ID, now)
+	require.NoError(t, err)
+	assert.Zero(t, report.Feedbacks)
+}
+
+func TestPurgePending_ExpiresOldAndUsed(t *testing.T) {
+	testutil.SetupTestDB(t)
+	now := time.Now()
+	models.DB.Create(&models.PendingFeedback{SenderHash: "a", BotID: 1, Text: "stale", Model: at(now.Add(-2 * PendingFeedbackTTL))})
+	models.DB.Create(&models.PendingFeedback{SenderHash: "b", BotID: 1, Text: "fresh"})
+	used := models.PendingFeedback{SenderHash: "c", BotID: 1, Text: "used"}
+	models.DB.Create(&used)
+	models.DB.Delete(&used)
+
+	n, err := PurgePending(now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	var texts []string
+	models.DB.Unscoped().Model(&models.PendingFeedback{}).Pluck("text", &texts)
+	assert.Equal(t, []string{"fresh"}, texts)
+}
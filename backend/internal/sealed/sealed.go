@@ -0,0 +1,50 @@
+// Package sealed encrypts admin-only feedback to a tenant's admin public key.
+//
+// Messages are NaCl anonymous sealed boxes (X25519 + XSalsa20-Poly1305): the
+// server can seal but never open them. Only the admin's private key opens
+// them, outside the app, e.g. with libsodium's crypto_box_seal_open. The API
+// and exports return the base64 box, and the dashboard shows a placeholder.
+package sealed
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+var ErrInvalidKey = errors.New("sealed: public key must be 32 bytes, base64-encoded")
+
+// ParsePublicKey decodes a base64 X25519 public key.
+func ParsePublicKey(s string) (*[32]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(raw) != 32 {
+		return nil, ErrInvalidKey
+	}
+	var key [32]byte
+	copy(key[:], raw)
+	return &key, nil
+}
+
+// Fingerprint identifies a public key so clients know which private key
+// opens a message after the admin key has been replaced.
+func Fingerprint(publicKey string) string {
+	sum := sha256.Sum256([]byte(publicKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Seal encrypts text to publicKey and returns the base64 sealed box.
+func Seal(publicKey string, text string) (string, error) {
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	out, err := box.SealAnonymous(nil, []byte(text), key, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(out), nil
+}
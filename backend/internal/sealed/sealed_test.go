@@ -0,0 +1,43 @@
+package sealed
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/box"
+)
+
+func TestSeal_OpensWithPrivateKey(t *testing.T) {
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	publicKey := base64.StdEncoding.EncodeToString(pub[:])
+
+	ciphertext, err := Seal(publicKey, "only for the admin")
+	require.NoError(t, err)
+	assert.NotContains(t, ciphertext, "admin")
+
+	raw, err := base64.StdEncoding.DecodeString(ciphertext)
+	require.NoError(t, err)
+	plain, ok := box.OpenAnonymous(nil, raw, pub, priv)
+	require.True(t, ok)
+	assert.Equal(t, "only for the admin", string(plain))
+}
+
+func TestParsePublicKey_RejectsInvalid(t *testing.T) {
+	_, err := ParsePublicKey("not base64!")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = ParsePublicKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = Seal("", "text")
+	assert.Error(t, err)
+}
+
+func TestFingerprint_Stable(t *testing.T) {
+	assert.Equal(t, Fingerprint("a"), Fingerprint("a"))
+	assert.NotEqual(t, Fingerprint("a"), Fingerprint("b"))
+	assert.Len(t, Fingerprint("a"), 16)
+}
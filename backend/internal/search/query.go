@@ -0,0 +1,127 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Term is one element of a search query: a word, or a phrase of words that
+// must appear in order.
+type Term struct {
+	Words  []string
+	Prefix bool // The last word matches as a prefix ("deploy*")
+	Negate bool // Excludes matches ("-salary")
+}
+
+// Query is a parsed search. All terms must match, and no negated one may.
+type Query struct {
+	Terms []Term
+}
+
+// Parse reads the search syntax:
+//
+//	word            matches word forms ("meetings" finds "meeting")
+//	"two words"     matches the phrase
+//	deploy*         matches words starting with "deploy"
+//	-word, -"a b"   excludes matches
+//
+// Punctuation inside a word splits it into a phrase ("e-mail" is "e mail").
+// Parse never fails; a query without any positive term matches nothing.
+func Parse(s string) Query {
+	var q Query
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var t Term
+		if runes[i] == '-' {
+			t.Negate = true
+			i++
+		}
+		var text string
+		if i < len(runes) && runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			text = string(runes[i+1 : end])
+			i = min(end+1, len(runes)) // An unclosed quote runs to the end
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+				end++
+			}
+			text = string(runes[i:end])
+			i = end
+		}
+		if i < len(runes) && runes[i] == '*' {
+			i++
+			text += "*"
+		}
+		t.Prefix = strings.HasSuffix(text, "*")
+
+		t.Words = strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(t.Words) > 0 {
+			q.Terms = append(q.Terms, t)
+		}
+	}
+	return q
+}
+
+// Empty reports whether q has no positive term, and so matches nothing.
+func (q Query) Empty() bool {
+	for _, t := range q.Terms {
+		if !t.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+// TSQuery renders q in PostgreSQL to_tsquery syntax. Words only contain
+// letters and digits, so they need no quoting.
+func (q Query) TSQuery() string {
+	parts := make([]string, 0, len(q.Terms))
+	for _, t := range q.Terms {
+		words := append([]string(nil), t.Words...)
+		if t.Prefix {
+			words[len(words)-1] += ":*"
+		}
+		part := strings.Join(words, " <-> ")
+		if len(words) > 1 {
+			part = "(" + part + ")"
+		}
+		if t.Negate {
+			part = "!" + part
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " & ")
+}
+
+// FTS5 renders q in SQLite FTS5 query syntax. FTS5's NOT is binary, so the
+// negated terms follow the positive ones.
+func (q Query) FTS5() string {
+	var positive, negative []string
+	for _, t := range q.Terms {
+		part := `"` + strings.Join(t.Words, " ") + `"`
+		if t.Prefix {
+			part += "*"
+		}
+		if t.Negate {
+			negative = append(negative, part)
+		} else {
+			positive = append(positive, part)
+		}
+	}
+	s := strings.Join(positive, " AND ")
+	for _, n := range negative {
+		s += " NOT " + n
+	}
+	return s
+}
@@ -0,0 +1,40 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	q := Parse(`Meetings "too long" deploy* -salary -"e-mail" 'quoted'`)
+	assert.Equal(t, []Term{
+		{Words: []string{"meetings"}},
+		{Words: []string{"too", "long"}},
+		{Words: []string{"deploy"}, Prefix: true},
+		{Words: []string{"salary"}, Negate: true},
+		{Words: []string{"e", "mail"}, Negate: true},
+		{Words: []string{"quoted"}},
+	}, q.Terms)
+	assert.False(t, q.Empty())
+
+	assert.Equal(t, []Term{{Words: []string{"unclosed", "phrase"}}}, Parse(`"unclosed phrase`).Terms)
+	assert.Equal(t, []Term{{Words: []string{"привет", "мир"}, Prefix: true}}, Parse(`"Привет мир"*`).Terms)
+	assert.True(t, Parse(`-only -negated`).Empty())
+	assert.True(t, Parse(`!!! ""`).Empty())
+}
+
+func TestQuerySyntax(t *testing.T) {
+	q := Parse(`meetings "too long" deploy* -salary`)
+	assert.Equal(t, `meetings & (too <-> long) & deploy:* & !salary`, q.TSQuery())
+	assert.Equal(t, `"meetings" AND "too long" AND "deploy"* NOT "salary"`, q.FTS5())
+
+	// Words can't carry operators into either syntax
+	q = Parse(`a&b|c:*d`)
+	assert.Equal(t, `(a <-> b <-> c <-> d)`, q.TSQuery())
+	assert.Equal(t, `"a b c d"`, q.FTS5())
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "&lt;b&gt; <mark>late</mark> again", Highlight("<b> "+markStart+"late"+markEnd+" again"))
+}
@@ -0,0 +1,216 @@
+// Package search implements full-text search over feedback messages.
+//
+// On PostgreSQL, feedbacks has a generated tsvector column, search_vector,
+// built with the russian and english configurations (russian also stems
+// Latin-script words as English) and indexed with GIN. Elsewhere, i.e. in
+// tests on SQLite, an FTS5 table kept in sync by triggers stands in for it,
+// without stemming; the tests' pure-Go driver (glebarez/sqlite) has FTS5
+// built in. SQLite builds without FTS5 fall back to unranked substring
+// matching. Sealed feedback is never indexed.
+package search
+
+import (
+	"html"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type backend int
+
+const (
+	substring backend = iota
+	postgres
+	fts5
+)
+
+// current is set by Migrate for the database in models.DB.
+var current = substring
+
+// Snippet highlight markers, from the Unicode private use area so they
+// can't occur in messages. Highlight turns them into <mark> tags.
+const (
+	markStart = "\ue000"
+	markEnd   = "\ue001"
+)
+
+// Configs are the PostgreSQL text search configurations messages are
+// indexed with.
+var Configs = []string{"russian", "english"}
+
+// FullText reports whether searches are ranked full-text matches.
+func FullText() bool {
+	return current != substring
+}
+
+// Migrate creates the search index for the database's dialect.
+func Migrate(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case "postgres":
+		current = postgres
+		return migratePostgres(db)
+	case "sqlite":
+		current = fts5
+		if err := migrateFTS5(db); err != nil {
+			if !strings.Contains(err.Error(), "no such module") {
+				return err
+			}
+			log.Printf("[search] SQLite has no FTS5; using substring search")
+			current = substring
+		}
+		return nil
+	}
+	current = substring
+	return nil
+}
+
+func migratePostgres(db *gorm.DB) error {
+	vectors := make([]string, len(Configs))
+	for i, config := range Configs {
+		vectors[i] = "to_tsvector('" + config + "'::regconfig, coalesce(message, ''))"
+	}
+	stmts := []string{
+		`ALTER TABLE feedbacks ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			CASE WHEN sealed THEN ''::tsvector ELSE ` + strings.Join(vectors, " || ") + ` END
+		) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_feedbacks_search_vector ON feedbacks USING GIN (search_vector)`,
+	}
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateFTS5(db *gorm.DB) error {
+	var exists int64
+	db.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'feedbacks_fts'").Scan(&exists)
+
+	stmts := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS feedbacks_fts USING fts5(message, tokenize = 'unicode61 remove_diacritics 2')`,
+		`CREATE TRIGGER IF NOT EXISTS feedbacks_fts_insert AFTER INSERT ON feedbacks WHEN NOT new.sealed BEGIN
+			INSERT INTO feedbacks_fts(rowid, message) VALUES (new.id, new.message);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS feedbacks_fts_update AFTER UPDATE OF message, sealed ON feedbacks BEGIN
+			DELETE FROM feedbacks_fts WHERE rowid = old.id;
+			INSERT INTO feedbacks_fts(rowid, message) SELECT new.id, new.message WHERE NOT new.sealed;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS feedbacks_fts_delete AFTER DELETE ON feedbacks BEGIN
+			DELETE FROM feedbacks_fts WHERE rowid = old.id;
+		END`,
+	}
+	if exists == 0 {
+		stmts = append(stmts, `INSERT INTO feedbacks_fts(rowid, message) SELECT id, message FROM feedbacks WHERE NOT sealed`)
+	}
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tsquery is the PostgreSQL query expression, matching any configuration.
+// It takes one argument per config.
+func tsquery() string {
+	parts := make([]string, len(Configs))
+	for i, config := range Configs {
+		parts[i] = "to_tsquery('" + config + "'::regconfig, ?)"
+	}
+	return "(" + strings.Join(parts, " || ") + ")"
+}
+
+func tsqueryArgs(q Query) []interface{} {
+	args := make([]interface{}, len(Configs))
+	for i := range Configs {
+		args[i] = q.TSQuery()
+	}
+	return args
+}
+
+// Filter restricts feedbacks to those matching q.
+func Filter(q Query) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if q.Empty() {
+			return db.Where("1 = 0")
+		}
+		switch current {
+		case postgres:
+			return db.Where("feedbacks.search_vector @@ "+tsquery(), tsqueryArgs(q)...)
+		case fts5:
+			return db.Where("feedbacks.id IN (SELECT rowid FROM feedbacks_fts WHERE feedbacks_fts MATCH ?)", q.FTS5())
+		}
+		db = db.Where("feedbacks.sealed = ?", false) // As if unindexed
+		for _, t := range q.Terms {
+			pattern := "%" + strings.Join(t.Words, " ") + "%"
+			if t.Negate {
+				db = db.Where("LOWER(feedbacks.message) NOT LIKE ?", pattern)
+			} else {
+				db = db.Where("LOWER(feedbacks.message) LIKE ?", pattern)
+			}
+		}
+		return db
+	}
+}
+
+// OrderByRank sorts matches best first. Substring search has no ranking and
+// leaves the order alone.
+func OrderByRank(q Query) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if q.Empty() {
+			return db
+		}
+		switch current {
+		case postgres:
+			return db.Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:  "ts_rank(feedbacks.search_vector, " + tsquery() + ") DESC",
+				Vars: tsqueryArgs(q),
+			}})
+		case fts5:
+			// bm25 is lower for better matches
+			return db.Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:  "(SELECT bm25(feedbacks_fts) FROM feedbacks_fts WHERE feedbacks_fts MATCH ? AND rowid = feedbacks.id)",
+				Vars: []interface{}{q.FTS5()},
+			}})
+		}
+		return db
+	}
+}
+
+// Snippets returns an excerpt of each matching feedback in ids, with the
+// matched words highlighted as HTML (see Highlight).
+func Snippets(db *gorm.DB, ids []uint, q Query) map[uint]string {
+	out := map[uint]string{}
+	if len(ids) == 0 || q.Empty() {
+		return out
+	}
+
+	var rows []struct {
+		ID      uint
+		Snippet string
+	}
+	switch current {
+	case postgres:
+		args := append(tsqueryArgs(q), ids)
+		db.Raw("SELECT id, ts_headline('"+Configs[0]+"'::regconfig, message, "+tsquery()+
+			", 'StartSel="+markStart+", StopSel="+markEnd+", MaxWords=30, MinWords=10, MaxFragments=2') AS snippet"+
+			" FROM feedbacks WHERE id IN ?", args...).Scan(&rows)
+	case fts5:
+		db.Raw("SELECT rowid AS id, snippet(feedbacks_fts, 0, ?, ?, '…', 24) AS snippet FROM feedbacks_fts"+
+			" WHERE feedbacks_fts MATCH ? AND rowid IN ?", markStart, markEnd, q.FTS5(), ids).Scan(&rows)
+	}
+	for _, r := range rows {
+		out[r.ID] = Highlight(r.Snippet)
+	}
+	return out
+}
+
+// Highlight escapes a raw snippet as HTML and wraps matches in <mark>.
+func Highlight(raw string) string {
+	s := html.EscapeString(raw)
+	s = strings.ReplaceAll(s, markStart, "<mark>")
+	return strings.ReplaceAll(s, markEnd, "</mark>")
+}
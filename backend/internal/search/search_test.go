@@ -0,0 +1,64 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/search"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func find(t *testing.T, s string) []string {
+	t.Helper()
+	q := search.Parse(s)
+	var messages []string
+	models.DB.Model(&models.Feedback{}).Scopes(search.Filter(q), search.OrderByRank(q)).Order("id").Pluck("message", &messages)
+	return messages
+}
+
+func TestFilter(t *testing.T) {
+	testutil.SetupTestDB(t)
+	for _, m := range []string{
+		"Meetings run too long every week",
+		"The deployment broke on Friday",
+		"Salary reviews are too long overdue",
+		"Совещания слишком длинные",
+	} {
+		require.NoError(t, models.DB.Create(&models.Feedback{TenantID: 1, GroupID: 1, Message: m}).Error)
+	}
+	sealed := models.Feedback{TenantID: 1, GroupID: 1, Message: "meetings", Sealed: true}
+	models.DB.Create(&sealed)
+
+	assert.Equal(t, []string{"Meetings run too long every week"}, find(t, "MEETINGS"))
+	assert.Equal(t, []string{"Meetings run too long every week"}, find(t, `"too long" -salary`))
+	assert.Equal(t, []string{"The deployment broke on Friday"}, find(t, "deploy*"))
+	assert.Empty(t, find(t, "-meetings"))
+
+	require.True(t, search.FullText(), "the test database must have FTS5")
+	// Case folding beyond ASCII, and the index follows edits and deletes
+	assert.Equal(t, []string{"Совещания слишком длинные"}, find(t, "СОВЕЩАНИЯ"))
+	models.DB.Model(&models.Feedback{}).Where("message LIKE ?", "The deployment%").Update("message", "Deploys are fine now")
+	assert.Equal(t, []string{"Deploys are fine now"}, find(t, "deploy*"))
+	models.DB.Unscoped().Where("message LIKE ?", "Deploys%").Delete(&models.Feedback{})
+	assert.Empty(t, find(t, "deploy*"))
+}
+
+func TestOrderByRankAndSnippets(t *testing.T) {
+	testutil.SetupTestDB(t)
+	require.True(t, search.FullText(), "the test database must have FTS5")
+	weak := models.Feedback{TenantID: 1, GroupID: 1, Message: "Lunch is fine, but the office is cold and the coffee machine is broken again"}
+	strong := models.Feedback{TenantID: 1, GroupID: 1, Message: "Coffee, coffee, coffee"}
+	models.DB.Create(&weak)
+	models.DB.Create(&strong)
+	for _, m := range []string{"Too many meetings", "Parking is full", "More plants please"} {
+		models.DB.Create(&models.Feedback{TenantID: 1, GroupID: 1, Message: m})
+	}
+
+	assert.Equal(t, []string{strong.Message, weak.Message}, find(t, "coffee"))
+
+	snippets := search.Snippets(models.DB, []uint{weak.ID, strong.ID}, search.Parse("coffee"))
+	assert.Contains(t, snippets[weak.ID], "<mark>coffee</mark>")
+	assert.Equal(t, "<mark>Coffee</mark>, <mark>coffee</mark>, <mark>coffee</mark>", snippets[strong.ID])
+}
@@ -54,3 +54,15 @@ func GetTenantID(c *gin.Context) uint {
 	}
 	return 0
 }
+
+// GetUserID extracts user_id from Gin context
+func GetUserID(c *gin.Context) uint {
+	userID, _ := c.Get("user_id")
+	switch v := userID.(type) {
+	case float64:
+		return uint(v)
+	case uint:
+		return v
+	}
+	return 0
+}
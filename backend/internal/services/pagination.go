@@ -0,0 +1,44 @@
+package services
+
+import (
+	"strconv"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+)
+
+const MaxPageSize = 100
+
+// CursorPage is a keyset listing request: ?cursor=&limit=.
+type CursorPage struct {
+	After uint // Row ID the page starts after; 0 for the first page
+	Limit int
+}
+
+// GetCursorPage reads the cursor and limit query params, responding 400 and
+// returning false for an invalid cursor. A bad limit becomes defaultLimit.
+func GetCursorPage(c *gin.Context, defaultLimit int) (CursorPage, bool) {
+	page := CursorPage{Limit: defaultLimit}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit >= 1 && limit <= MaxPageSize {
+		page.Limit = limit
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		after, err := db.DecodeCursor(cursor)
+		if err != nil {
+			c.Data(lvn.Res(400, "", "Invalid cursor"))
+			return page, false
+		}
+		page.After = after
+	}
+	return page, true
+}
+
+// NextCursor takes the IDs of up to Limit+1 rows fetched for the page and
+// returns the cursor for the next one, or "" if this is the last.
+func (p CursorPage) NextCursor(ids []uint) string {
+	if len(ids) <= p.Limit {
+		return ""
+	}
+	return db.EncodeCursor(ids[p.Limit-1])
+}
@@ -0,0 +1,129 @@
+package svc_feedback
+
+import (
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/exporter"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type exportJobResponse struct {
+	models.ExportJob
+	DownloadURL string `json:"download_url,omitempty"` // Signed; see exporter.DownloadURL
+}
+
+func newExportJobResponse(job models.ExportJob) exportJobResponse {
+	return exportJobResponse{ExportJob: job, DownloadURL: exporter.DownloadURL(job, time.Now())}
+}
+
+// StartExport queues a background export with the same params as Export,
+// given in the query string or a form body. Poll GET /exports/:id for
+// progress; the requester is also notified when it is ready.
+func StartExport(c *gin.Context) {
+	if err := c.Request.ParseForm(); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+	params := c.Request.Form
+	tenantID := services.GetTenantID(c)
+	opts, err := jobOptions(tenantID, params)
+	if err != nil {
+		c.Data(lvn.Res(400, "", err.Error()))
+		return
+	}
+
+	job, err := exporter.StartJob(tenantID, services.GetUserID(c), params.Encode(), FilterQuery(tenantID, params),
+		opts, exportFilename(params, opts.Format))
+	if err != nil {
+		lvn.GinErr(c, 500, err, "Failed to start export")
+		return
+	}
+
+	c.Data(lvn.Res(202, newExportJobResponse(job), ""))
+}
+
+// jobOptions are the exportOptions of a background export, with timestamps
+// rounded as in the listing.
+func jobOptions(tenantID uint, params url.Values) (exporter.Options, error) {
+	opts, err := exportOptions(params)
+	if err != nil {
+		return opts, err
+	}
+	opts.Prepare = func(batch []models.Feedback) { RoundTimestamps(tenantID, batch) }
+	return opts, nil
+}
+
+// ResumeExports restarts the background exports interrupted by a restart,
+// rebuilding each one from its recorded params.
+func ResumeExports() {
+	exporter.ResumeAll(func(job models.ExportJob) (*gorm.DB, exporter.Options, error) {
+		params, err := url.ParseQuery(job.Params)
+		if err != nil {
+			return nil, exporter.Options{}, err
+		}
+		opts, err := jobOptions(job.TenantID, params)
+		if err != nil {
+			return nil, opts, err
+		}
+		return FilterQuery(job.TenantID, params), opts, nil
+	})
+}
+
+func GetExports(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	var jobs []models.ExportJob
+	models.DB.Scopes(db.TenantScope(tenantID)).Where("kind = ?", models.ExportKindFeedbacks).
+		Order("created_at DESC").Limit(50).Find(&jobs)
+
+	resp := make([]exportJobResponse, len(jobs))
+	for i, job := range jobs {
+		resp[i] = newExportJobResponse(job)
+	}
+	c.Data(lvn.Res(200, resp, ""))
+}
+
+func GetExport(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := services.GetTenantID(c)
+
+	var job models.ExportJob
+	if err := models.DB.Scopes(db.TenantScope(tenantID)).Where("kind = ?", models.ExportKindFeedbacks).First(&job, id).Error; err != nil {
+		c.Data(lvn.Res(404, "", "Export not found"))
+		return
+	}
+
+	c.Data(lvn.Res(200, newExportJobResponse(job), ""))
+}
+
+// DownloadExport serves a finished export to anyone holding a valid signed
+// link, so it is routed without auth.
+func DownloadExport(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	if !exporter.VerifyDownload(uint(id), c.Query("expires"), c.Query("signature"), time.Now()) {
+		c.Data(lvn.Res(403, "", "Download link is invalid or has expired"))
+		return
+	}
+
+	var job models.ExportJob
+	if err := models.DB.Where("kind = ?", models.ExportKindFeedbacks).First(&job, id).Error; err != nil ||
+		job.Status != models.ExportReady || job.FilePath == "" {
+		c.Data(lvn.Res(404, "", "Export not found"))
+		return
+	}
+	if _, err := os.Stat(job.FilePath); err != nil {
+		c.Data(lvn.Res(410, "", "Export file has expired"))
+		return
+	}
+
+	c.Header("Content-Type", exporter.ContentType(job.Format))
+	c.FileAttachment(job.FilePath, job.FileName)
+}
@@ -0,0 +1,102 @@
+package svc_feedback_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_feedback"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartExport_SignedDownload(t *testing.T) {
+	testutil.SetupTestDB(t)
+	config.Confs.Settings.DataDir = t.TempDir()
+	user, tenant, group := setupFeedbackTestData(t)
+
+	router := testutil.SetupRouter()
+	router.GET("/exports/:id/download", svc_feedback.DownloadExport)
+	router.POST("/exports", auth.Auth, services.TenantMiddleware, svc_feedback.StartExport)
+	router.GET("/exports/:id", auth.Auth, services.TenantMiddleware, svc_feedback.GetExport)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	w := testutil.DoRequest(router, "POST", "/exports?format=xml", nil, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testutil.DoRequest(router, "POST", fmt.Sprintf("/exports?group_id=%d&admin_only=false&columns=message", group.ID), nil, token)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	id := uint(resp["data"].(map[string]interface{})["id"].(float64))
+
+	require.Eventually(t, func() bool {
+		var count int64
+		models.DB.Model(&models.Notification{}).Where("user_id = ?", user.ID).Count(&count)
+		return count == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/exports/%d", id), nil, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, models.ExportReady, data["status"])
+	assert.Equal(t, float64(2), data["total"])
+	assert.Contains(t, data["file_name"], fmt.Sprintf("feedbacks_group-%d_public_", group.ID))
+	link := data["download_url"].(string)
+	require.NotEmpty(t, link)
+
+	// The link works without a token, and only as signed
+	w = testutil.DoRequest(router, "GET", link, nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Message\nPublic feedback 2\nPublic feedback 1\n", w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+
+	w = testutil.DoRequest(router, "GET", strings.Replace(link, "signature=", "signature=0", 1), nil, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Other tenants can't see the job
+	user2 := testutil.CreateTestUser(t, "exp2@example.com", "Exp User 2")
+	tenant2 := testutil.CreateTestTenant(t, user2.ID, "Exp Org 2", "exp-org-2")
+	token2 := testutil.GenerateTestToken(user2.ID, user2.Email, user2.Name, user2.Role, tenant2.ID)
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/exports/%d", id), nil, token2)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestResumeExports(t *testing.T) {
+	testutil.SetupTestDB(t)
+	config.Confs.Settings.DataDir = t.TempDir()
+	user, tenant, group := setupFeedbackTestData(t)
+
+	// Jobs a restart interrupted
+	running := models.ExportJob{TenantID: tenant.ID, UserID: user.ID, Kind: models.ExportKindFeedbacks, Status: models.ExportRunning,
+		Format: "csv", Params: fmt.Sprintf("group_id=%d&admin_only=false&columns=message", group.ID), Processed: 1}
+	require.NoError(t, models.DB.Create(&running).Error)
+	broken := models.ExportJob{TenantID: tenant.ID, Kind: models.ExportKindFeedbacks, Status: models.ExportPending, Params: "format=xml"}
+	require.NoError(t, models.DB.Create(&broken).Error)
+
+	svc_feedback.ResumeExports()
+
+	require.Eventually(t, func() bool {
+		models.DB.First(&running, running.ID)
+		return running.Status == models.ExportReady
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(2), running.Total)
+	assert.Equal(t, int64(2), running.Processed)
+	data, err := os.ReadFile(running.FilePath)
+	require.NoError(t, err)
+	assert.Equal(t, "Message\nPublic feedback 2\nPublic feedback 1\n", string(data))
+
+	models.DB.First(&broken, broken.ID)
+	assert.Equal(t, models.ExportFailed, broken.Status)
+	assert.NotEmpty(t, broken.Error)
+}
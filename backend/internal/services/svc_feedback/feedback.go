@@ -1,14 +1,21 @@
 package svc_feedback
 
 import (
-	"encoding/csv"
+	"errors"
 	"fmt"
+	"log"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/anonymity"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/exporter"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/search"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/tgbot"
 	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -17,96 +24,430 @@ import (
 type FeedbackResponse struct {
 	models.Feedback
 	GroupName string `json:"group_name"`
+	// Snippet is an HTML excerpt with the search matches in <mark>
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// searchQuery returns the parsed full-text search, if params have one.
+func searchQuery(params url.Values) (search.Query, bool) {
+	s := params.Get("search")
+	if s == "" || params.Get("search_mode") == "substring" {
+		return search.Query{}, false
+	}
+	return search.Parse(s), true
 }
 
 func applyFilters(c *gin.Context) *gorm.DB {
-	tenantID := services.GetTenantID(c)
+	return FilterQuery(services.GetTenantID(c), c.Request.URL.Query())
+}
+
+// FilterQuery selects a tenant's feedback by the listing filters in params.
+func FilterQuery(tenantID uint, params url.Values) *gorm.DB {
 	query := models.DB.Scopes(db.TenantScope(tenantID))
 
-	if groupID := c.Query("group_id"); groupID != "" {
+	if groupID := params.Get("group_id"); groupID != "" {
 		query = query.Where("group_id = ?", groupID)
 	}
 
-	if adminOnly := c.Query("admin_only"); adminOnly == "true" {
+	if adminOnly := params.Get("admin_only"); adminOnly == "true" {
 		query = query.Where("admin_only = ?", true)
 	} else if adminOnly == "false" {
 		query = query.Where("admin_only = ?", false)
 	}
 
-	if dateFrom := c.Query("date_from"); dateFrom != "" {
-		query = query.Where("created_at >= ?", dateFrom)
+	// Comments: ?parent_id= lists one thread, ?comments=false leaves them out
+	if parentID := params.Get("parent_id"); parentID != "" {
+		query = query.Where("parent_id = ?", parentID)
+	} else if params.Get("comments") == "false" {
+		query = query.Where("parent_id IS NULL")
+	}
+	switch params.Get("answered") {
+	case "true":
+		query = query.Where("answered_at IS NOT NULL")
+	case "false":
+		query = query.Where("answered_at IS NULL")
+	}
+
+	// ?type=idea,issue
+	if types := params.Get("type"); types != "" {
+		query = query.Where("type IN ?", strings.Split(types, ","))
 	}
-	if dateTo := c.Query("date_to"); dateTo != "" {
-		query = query.Where("created_at <= ?", dateTo)
+
+	if params.Get("date_from") != "" || params.Get("date_to") != "" {
+		query = dateFilter(query, tenantID, params)
 	}
 
-	if search := c.Query("search"); search != "" {
-		query = query.Where("message LIKE ?", "%"+search+"%")
+	// Sealed messages are ciphertext, so they can't match a search
+	if q, ok := searchQuery(params); ok {
+		query = query.Where("sealed = ?", false).Scopes(search.Filter(q))
+	} else if s := params.Get("search"); s != "" {
+		query = query.Where("sealed = ? AND LOWER(message) LIKE ?", false, "%"+strings.ToLower(s)+"%")
 	}
 
 	return query
 }
 
+// dateFilter applies ?date_from and ?date_to (RFC 3339 or YYYY-MM-DD in the
+// tenant timezone). With timestamp rounding the bounds are truncated to
+// whole rounding intervals, the coarsest of the selected groups, so the
+// filter can't narrow a post time down further than the listing shows it.
+// A bound that isn't a date matches nothing.
+func dateFilter(query *gorm.DB, tenantID uint, params url.Values) *gorm.DB {
+	var tenant models.Tenant
+	models.DB.Select("id", "timezone").First(&tenant, tenantID)
+	loc := anonymity.Location(tenant.Timezone)
+
+	groups := models.DB.Model(&models.Group{}).Select("id").Where("tenant_id = ?", tenantID)
+	if groupID := params.Get("group_id"); groupID != "" {
+		groups = groups.Where("id = ?", groupID)
+	}
+	var minutes int
+	models.DB.Model(&models.FeedbackConfig{}).Select("COALESCE(MAX(timestamp_rounding_minutes), 0)").
+		Where("group_id IN (?)", groups).Scan(&minutes)
+	step := time.Duration(minutes) * time.Minute
+
+	parse := func(value string) (time.Time, bool) {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t, true
+		}
+		t, err := time.ParseInLocation("2006-01-02", value, loc)
+		return t, err == nil
+	}
+	if value := params.Get("date_from"); value != "" {
+		from, ok := parse(value)
+		if !ok {
+			return query.Where("1 = 0")
+		}
+		query = query.Where("created_at >= ?", anonymity.Round(from, minutes, loc).UTC())
+	}
+	if value := params.Get("date_to"); value != "" {
+		to, ok := parse(value)
+		if !ok {
+			return query.Where("1 = 0")
+		}
+		if minutes > 0 {
+			// The interval holding date_to is included whole
+			query = query.Where("created_at < ?", anonymity.Round(to, minutes, loc).Add(step).UTC())
+		} else {
+			query = query.Where("created_at <= ?", to.UTC())
+		}
+	}
+	return query
+}
+
+// RoundTimestamps coarsens the timestamps of each feedback according to its
+// group's TimestampRoundingMinutes, so post times can't be matched to people.
+func RoundTimestamps(tenantID uint, feedbacks []models.Feedback) {
+	groupIDs := make([]uint, 0, len(feedbacks))
+	for _, fb := range feedbacks {
+		groupIDs = append(groupIDs, fb.GroupID)
+	}
+
+	var configs []models.FeedbackConfig
+	models.DB.Where("group_id IN ? AND timestamp_rounding_minutes > 0", groupIDs).Find(&configs)
+	if len(configs) == 0 {
+		return
+	}
+	rounding := make(map[uint]int, len(configs))
+	for _, cfg := range configs {
+		rounding[cfg.GroupID] = cfg.TimestampRoundingMinutes
+	}
+
+	var tenant models.Tenant
+	models.DB.Select("id", "timezone").First(&tenant, tenantID)
+	loc := anonymity.Location(tenant.Timezone)
+
+	round := func(t *time.Time, minutes int) {
+		if t != nil {
+			*t = anonymity.Round(*t, minutes, loc)
+		}
+	}
+	for i := range feedbacks {
+		minutes := rounding[feedbacks[i].GroupID]
+		if minutes == 0 {
+			continue
+		}
+		fb := &feedbacks[i]
+		round(&fb.CreatedAt, minutes)
+		round(&fb.UpdatedAt, minutes)
+		round(fb.EditedAt, minutes)
+		round(fb.ModeratedAt, minutes)
+		round(fb.ScheduledAt, minutes)
+		round(fb.AnsweredAt, minutes)
+	}
+}
+
+// Rounded returns fb with its timestamps rounded for a response.
+func Rounded(fb models.Feedback) models.Feedback {
+	feedbacks := []models.Feedback{fb}
+	RoundTimestamps(fb.TenantID, feedbacks)
+	return feedbacks[0]
+}
+
+// GetFeedbacks lists feedback newest first. Pages are either numbered
+// (?page=) or follow the opaque next_cursor of the previous page (?cursor=),
+// which stays fast on large tables. total is an exact count by default for
+// numbered pages; ?total=estimate gives the planner's estimate and
+// ?total=none skips it. Ranked searches only support numbered pages.
 func GetFeedbacks(c *gin.Context) {
+	cursorPage, ok := services.GetCursorPage(c, 20)
+	if !ok {
+		return
+	}
+	limit := cursorPage.Limit
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-
 	if page < 1 {
 		page = 1
 	}
-	if limit < 1 || limit > 100 {
-		limit = 20
-	}
-	offset := (page - 1) * limit
 
 	query := applyFilters(c)
+	q, ranked := searchQuery(c.Request.URL.Query())
+	if ranked && cursorPage.After != 0 {
+		c.Data(lvn.Res(400, "", "Search results are paged with page, not cursor"))
+		return
+	}
 
-	var total int64
-	query.Model(&models.Feedback{}).Count(&total)
+	res := gin.H{"limit": limit}
+	totalMode := c.Query("total")
+	if totalMode == "" {
+		totalMode = "exact"
+		if cursorPage.After != 0 {
+			totalMode = "none"
+		}
+	}
+	switch totalMode {
+	case "exact":
+		var total int64
+		query.Model(&models.Feedback{}).Count(&total)
+		res["total"] = total
+	case "estimate":
+		res["total"] = db.EstimateCount(query, &models.Feedback{})
+		res["total_estimated"] = true
+	}
 
-	var feedbacks []models.Feedback
-	query.Preload("Group", func(db *gorm.DB) *gorm.DB {
+	list := query.Preload("Group", func(db *gorm.DB) *gorm.DB {
 		return db.Select("id", "title")
-	}).Order("created_at DESC").Offset(offset).Limit(limit).Find(&feedbacks)
+	})
+	switch {
+	case ranked: // Best matches first
+		list = list.Scopes(search.OrderByRank(q), db.NewestFirst("feedbacks", 0)).Offset((page - 1) * limit)
+		res["page"] = page
+	case cursorPage.After != 0:
+		list = list.Scopes(db.NewestFirst("feedbacks", cursorPage.After))
+	default:
+		list = list.Scopes(db.NewestFirst("feedbacks", 0)).Offset((page - 1) * limit)
+		res["page"] = page
+	}
+
+	var feedbacks []models.Feedback
+	list.Limit(limit + 1).Find(&feedbacks)
+
+	ids := make([]uint, len(feedbacks))
+	for i, fb := range feedbacks {
+		ids[i] = fb.ID
+	}
+	if !ranked {
+		res["next_cursor"] = cursorPage.NextCursor(ids)
+	}
+	if len(feedbacks) > limit {
+		feedbacks, ids = feedbacks[:limit], ids[:limit]
+	}
+
+	RoundTimestamps(services.GetTenantID(c), feedbacks)
+
+	snippets := map[uint]string{}
+	if ranked {
+		snippets = search.Snippets(models.DB, ids, q)
+	}
 
 	resp := make([]FeedbackResponse, len(feedbacks))
 	for i, fb := range feedbacks {
-		resp[i] = FeedbackResponse{Feedback: fb, GroupName: fb.Group.Title}
+		resp[i] = FeedbackResponse{Feedback: fb, GroupName: fb.Group.Title, Snippet: snippets[fb.ID]}
 	}
+	res["data"] = resp
 
-	c.Data(lvn.Res(200, gin.H{
-		"data":  resp,
-		"total": total,
-		"page":  page,
-		"limit": limit,
-	}, ""))
+	c.Data(lvn.Res(200, res, ""))
 }
 
-func ExportCSV(c *gin.Context) {
-	query := applyFilters(c)
+// Export streams the filtered feedback as ?format=csv (the default), json,
+// ndjson or xlsx. ?columns= picks and orders the fields (exporter.Columns
+// keys, comma-separated) and ?bom=true prefixes CSV with a byte order mark
+// for Excel.
+func Export(c *gin.Context) {
+	params := c.Request.URL.Query()
+	opts, err := exportOptions(params)
+	if err != nil {
+		c.Data(lvn.Res(400, "", err.Error()))
+		return
+	}
+	tenantID := services.GetTenantID(c)
+	opts.Prepare = func(batch []models.Feedback) { RoundTimestamps(tenantID, batch) }
 
-	var feedbacks []models.Feedback
-	query.Preload("Group", func(db *gorm.DB) *gorm.DB {
-		return db.Select("id", "title")
-	}).Order("created_at DESC").Limit(10000).Find(&feedbacks)
+	c.Header("Content-Type", exporter.ContentType(opts.Format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", exportFilename(params, opts.Format)))
 
-	filename := fmt.Sprintf("feedbacks_%s.csv", time.Now().Format("2006-01-02"))
-	c.Header("Content-Type", "text/csv")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	// Headers are sent with the first rows, so a failure can only cut the
+	// download short
+	if err := exporter.Write(c.Writer, applyFilters(c), opts); err != nil {
+		log.Printf("[export] Tenant %d export failed: %v", tenantID, err)
+	}
+}
+
+// exportOptions reads the format, columns and bom params.
+func exportOptions(params url.Values) (exporter.Options, error) {
+	opts := exporter.Options{Format: params.Get("format")}
+	if opts.Format == "" {
+		opts.Format = exporter.FormatCSV
+	}
+	if columns := params.Get("columns"); columns != "" {
+		opts.Columns = strings.Split(columns, ",")
+	}
+	opts.BOM, _ = strconv.ParseBool(params.Get("bom"))
+	_, err := opts.Validate()
+	return opts, err
+}
 
-	w := csv.NewWriter(c.Writer)
-	w.Write([]string{"ID", "Group", "Message", "Admin Only", "Posted to Group", "Created At"})
+// exportFilename names an export after the filters applied, e.g.
+// feedbacks_group-3_admin-only_from-2026-01-01_2026-02-10.csv.
+func exportFilename(params url.Values, format string) string {
+	date := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' || r == '-' {
+				return r
+			}
+			return -1
+		}, s[:min(len(s), 10)])
+	}
 
-	for _, fb := range feedbacks {
-		w.Write([]string{
-			strconv.FormatUint(uint64(fb.ID), 10),
-			fb.Group.Title,
-			fb.Message,
-			strconv.FormatBool(fb.AdminOnly),
-			strconv.FormatBool(fb.Posted),
-			fb.CreatedAt.Format(time.RFC3339),
-		})
+	parts := []string{"feedbacks"}
+	if groupID, err := strconv.ParseUint(params.Get("group_id"), 10, 64); err == nil {
+		parts = append(parts, fmt.Sprintf("group-%d", groupID))
+	}
+	switch params.Get("admin_only") {
+	case "true":
+		parts = append(parts, "admin-only")
+	case "false":
+		parts = append(parts, "public")
+	}
+	if from := date(params.Get("date_from")); from != "" {
+		parts = append(parts, "from-"+from)
+	}
+	if to := date(params.Get("date_to")); to != "" {
+		parts = append(parts, "to-"+to)
+	}
+	if params.Get("search") != "" {
+		parts = append(parts, "search")
+	}
+	parts = append(parts, time.Now().Format("2006-01-02"))
+	return strings.Join(parts, "_") + "." + format
+}
+
+func findFeedback(c *gin.Context) (models.Feedback, bool) {
+	id := c.Param("id")
+	tenantID := services.GetTenantID(c)
+
+	var fb models.Feedback
+	if err := models.DB.Scopes(db.TenantScope(tenantID)).First(&fb, id).Error; err != nil {
+		c.Data(lvn.Res(404, "", "Feedback not found"))
+		return fb, false
+	}
+	return fb, true
+}
+
+// postError maps errors from the Telegram post helpers to responses.
+func postError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, tgbot.ErrNotPosted) {
+		c.Data(lvn.Res(409, "", "Feedback is not posted to the group"))
+		return
+	}
+	lvn.GinErr(c, 502, err, msg)
+}
+
+type updateFeedbackReq struct {
+	Message *string `json:"message"`
+}
+
+// UpdateFeedback lets a moderator redact the message. If the feedback is
+// posted, the group post is edited to match.
+func UpdateFeedback(c *gin.Context) {
+	fb, ok := findFeedback(c)
+	if !ok {
+		return
+	}
+
+	var req updateFeedbackReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+
+	if req.Message == nil {
+		c.Data(lvn.Res(200, Rounded(fb), ""))
+		return
+	}
+	if fb.Sealed {
+		c.Data(lvn.Res(409, "", "Sealed feedback can't be edited on the server"))
+		return
+	}
+	if strings.TrimSpace(*req.Message) == "" {
+		c.Data(lvn.Res(400, "", "message must not be empty"))
+		return
+	}
+
+	// Only the message is written, so concurrent moderation or posting
+	// isn't overwritten with the state loaded above
+	now := time.Now()
+	if err := models.DB.Model(&models.Feedback{}).Where("id = ?", fb.ID).
+		Updates(map[string]interface{}{"message": *req.Message, "edited_at": now}).Error; err != nil {
+		lvn.GinErr(c, 500, err, "Failed to update feedback")
+		return
+	}
+	fb.Message = *req.Message
+	fb.EditedAt = &now
+
+	if fb.Posted {
+		if err := tgbot.EditFeedbackPost(&fb); err != nil && !errors.Is(err, tgbot.ErrNotPosted) {
+			lvn.GinErr(c, 502, err, "Feedback updated, but editing the group post failed")
+			return
+		}
+	}
+
+	c.Data(lvn.Res(200, Rounded(fb), ""))
+}
+
+// UnpostFeedback deletes the feedback's message from the group.
+func UnpostFeedback(c *gin.Context) {
+	fb, ok := findFeedback(c)
+	if !ok {
+		return
+	}
+
+	if err := tgbot.UnpostFeedback(&fb); err != nil {
+		postError(c, err, "Failed to delete group post")
+		return
+	}
+
+	c.Data(lvn.Res(200, Rounded(fb), ""))
+}
+
+func PinFeedback(c *gin.Context) {
+	setPinned(c, true)
+}
+
+func UnpinFeedback(c *gin.Context) {
+	setPinned(c, false)
+}
+
+func setPinned(c *gin.Context, pin bool) {
+	fb, ok := findFeedback(c)
+	if !ok {
+		return
+	}
+
+	if err := tgbot.PinFeedbackPost(&fb, pin); err != nil {
+		postError(c, err, "Failed to update pinned state")
+		return
 	}
 
-	w.Flush()
+	c.Data(lvn.Res(200, Rounded(fb), ""))
 }
@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/search"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_feedback"
@@ -40,9 +44,9 @@ func setupFeedbackTestData(t *testing.T) (models.User, models.Tenant, models.Gro
 	models.DB.Create(&group)
 
 	groupUser := models.GroupUser{
-		TenantID:       tenant.ID,
-		GroupID:        group.ID,
-		TelegramUserID: 12345,
+		TenantID:   tenant.ID,
+		GroupID:    group.ID,
+		SenderHash: identity.Pseudonym(12345),
 	}
 	models.DB.Create(&groupUser)
 
@@ -50,7 +54,7 @@ func setupFeedbackTestData(t *testing.T) (models.User, models.Tenant, models.Gro
 	feedbacks := []models.Feedback{
 		{TenantID: tenant.ID, GroupID: group.ID, SenderID: groupUser.ID, Message: "Public feedback 1", AdminOnly: false},
 		{TenantID: tenant.ID, GroupID: group.ID, SenderID: groupUser.ID, Message: "Admin only feedback", AdminOnly: true},
-		{TenantID: tenant.ID, GroupID: group.ID, SenderID: groupUser.ID, Message: "Public feedback 2", AdminOnly: false},
+		{TenantID: tenant.ID, GroupID: group.ID, SenderID: groupUser.ID, Message: "Public feedback 2", AdminOnly: false, Type: models.FeedbackIdea},
 	}
 	for _, fb := range feedbacks {
 		models.DB.Create(&fb)
@@ -117,6 +121,60 @@ func TestGetFeedbacks_FilterPublicOnly(t *testing.T) {
 	assert.Len(t, feedbacks, 2)
 }
 
+func TestGetFeedbacks_FilterType(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, _ := setupFeedbackTestData(t)
+
+	router := testutil.SetupRouter()
+	router.GET("/feedbacks", auth.Auth, services.TenantMiddleware, svc_feedback.GetFeedbacks)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	count := func(query string) int {
+		w := testutil.DoRequest(router, "GET", "/feedbacks?"+query, nil, token)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return len(resp["data"].(map[string]interface{})["data"].([]interface{}))
+	}
+	assert.Equal(t, 1, count("type=idea"))
+	assert.Equal(t, 2, count("type=general"))
+	assert.Equal(t, 3, count("type=general,idea"))
+	assert.Equal(t, 0, count("type=praise"))
+}
+
+func TestGetFeedbacks_Comments(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, group := setupFeedbackTestData(t)
+	var parent models.Feedback
+	models.DB.Where("message = ?", "Public feedback 1").First(&parent)
+	models.DB.Create(&models.Feedback{TenantID: tenant.ID, GroupID: group.ID, Message: "A comment", ParentID: &parent.ID})
+
+	router := testutil.SetupRouter()
+	router.GET("/feedbacks", auth.Auth, services.TenantMiddleware, svc_feedback.GetFeedbacks)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	messages := func(query string) []string {
+		w := testutil.DoRequest(router, "GET", "/feedbacks?"+query, nil, token)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data struct {
+				Data []struct {
+					Message string `json:"message"`
+				} `json:"data"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		var out []string
+		for _, fb := range resp.Data.Data {
+			out = append(out, fb.Message)
+		}
+		return out
+	}
+	assert.Len(t, messages(""), 4)
+	assert.Equal(t, []string{"A comment"}, messages(fmt.Sprintf("parent_id=%d", parent.ID)))
+	assert.NotContains(t, messages("comments=false"), "A comment")
+}
+
 func TestGetFeedbacks_Pagination(t *testing.T) {
 	testutil.SetupTestDB(t)
 	user, tenant, group := setupFeedbackTestData(t)
@@ -137,6 +195,114 @@ func TestGetFeedbacks_Pagination(t *testing.T) {
 	assert.Equal(t, float64(3), data["total"])
 }
 
+func TestGetFeedbacks_Search(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, group := setupFeedbackTestData(t)
+	models.DB.Create(&models.Feedback{TenantID: tenant.ID, GroupID: group.ID, Message: "Standups <always> run late"})
+	models.DB.Create(&models.Feedback{TenantID: tenant.ID, GroupID: group.ID, Message: "Late, late, late again"})
+
+	router := testutil.SetupRouter()
+	router.GET("/feedbacks", auth.Auth, services.TenantMiddleware, svc_feedback.GetFeedbacks)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	get := func(query string) []interface{} {
+		w := testutil.DoRequest(router, "GET", "/feedbacks?"+query, nil, token)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp["data"].(map[string]interface{})["data"].([]interface{})
+	}
+
+	// Substring mode keeps the old behaviour, minus case sensitivity
+	data := get("search=STANDUP&search_mode=substring")
+	require.Len(t, data, 1)
+	assert.Nil(t, data[0].(map[string]interface{})["snippet"])
+
+	data = get("search=late+-again")
+	require.Len(t, data, 1)
+	assert.Equal(t, "Standups <always> run late", data[0].(map[string]interface{})["message"])
+
+	require.True(t, search.FullText(), "the test database must have FTS5")
+	data = get("search=late")
+	require.Len(t, data, 2)
+	best := data[0].(map[string]interface{})
+	assert.Equal(t, "Late, late, late again", best["message"])
+	assert.Contains(t, best["snippet"], "<mark>Late</mark>")
+	assert.Equal(t, "Standups &lt;always&gt; run <mark>late</mark>", data[1].(map[string]interface{})["snippet"])
+}
+
+func TestGetFeedbacks_CursorPages(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, group := setupFeedbackTestData(t)
+
+	router := testutil.SetupRouter()
+	router.GET("/feedbacks", auth.Auth, services.TenantMiddleware, svc_feedback.GetFeedbacks)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	get := func(query string) map[string]interface{} {
+		w := testutil.DoRequest(router, "GET", fmt.Sprintf("/feedbacks?group_id=%d&limit=2%s", group.ID, query), nil, token)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp["data"].(map[string]interface{})
+	}
+
+	first := get("")
+	assert.Equal(t, float64(3), first["total"])
+	assert.Len(t, first["data"], 2)
+	cursor := first["next_cursor"].(string)
+	require.NotEmpty(t, cursor)
+
+	second := get("&cursor=" + cursor)
+	assert.Len(t, second["data"], 1)
+	assert.Empty(t, second["next_cursor"])
+	assert.NotContains(t, second, "total")
+	assert.NotContains(t, second, "page")
+
+	var seen []interface{}
+	for _, page := range []map[string]interface{}{first, second} {
+		for _, fb := range page["data"].([]interface{}) {
+			seen = append(seen, fb.(map[string]interface{})["id"])
+		}
+	}
+	assert.Len(t, seen, 3)
+	assert.NotEqual(t, seen[0], seen[2])
+
+	estimated := get("&total=estimate")
+	assert.Equal(t, float64(3), estimated["total"])
+	assert.Equal(t, true, estimated["total_estimated"])
+
+	w := testutil.DoRequest(router, "GET", "/feedbacks?cursor=bogus", nil, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestExport_FormatsAndFilename(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, group := setupFeedbackTestData(t)
+
+	router := testutil.SetupRouter()
+	router.GET("/feedbacks/export", auth.Auth, services.TenantMiddleware, svc_feedback.Export)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	w := testutil.DoRequest(router, "GET", fmt.Sprintf("/feedbacks/export?group_id=%d&admin_only=true&date_from=2025-01-01T00:00:00Z", group.ID), nil, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"),
+		fmt.Sprintf("filename=feedbacks_group-%d_admin-only_from-2025-01-01_%s.csv", group.ID, time.Now().Format("2006-01-02")))
+	assert.Equal(t, 2, strings.Count(w.Body.String(), "\n"), "header and one admin-only row")
+
+	w = testutil.DoRequest(router, "GET", "/feedbacks/export?format=ndjson&columns=message,status", nil, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.NotContains(t, w.Body.String(), "sender")
+
+	w = testutil.DoRequest(router, "GET", "/feedbacks/export?format=pdf", nil, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = testutil.DoRequest(router, "GET", "/feedbacks/export?columns=sender_id", nil, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestGetFeedbacks_SenderIDNotExposed(t *testing.T) {
 	testutil.SetupTestDB(t)
 	user, tenant, group := setupFeedbackTestData(t)
@@ -162,7 +328,7 @@ func TestGetFeedbacks_TenantIsolation(t *testing.T) {
 	models.DB.Create(&bot1)
 	group1 := models.Group{TenantID: tenant1.ID, BotID: bot1.ID, ChatID: -100001, Title: "G1", Type: "supergroup", IsActive: true}
 	models.DB.Create(&group1)
-	gu1 := models.GroupUser{TenantID: tenant1.ID, GroupID: group1.ID, TelegramUserID: 111}
+	gu1 := models.GroupUser{TenantID: tenant1.ID, GroupID: group1.ID, SenderHash: identity.Pseudonym(111)}
 	models.DB.Create(&gu1)
 	models.DB.Create(&models.Feedback{TenantID: tenant1.ID, GroupID: group1.ID, SenderID: gu1.ID, Message: "T1 feedback"})
 
@@ -194,3 +360,136 @@ func TestGetFeedbacks_NoAuth(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestUpdateFeedback_RedactsMessage(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, group := setupFeedbackTestData(t)
+
+	var fb models.Feedback
+	models.DB.Where("group_id = ?", group.ID).First(&fb)
+
+	router := testutil.SetupRouter()
+	router.PATCH("/feedbacks/:id", auth.Auth, services.TenantMiddleware, svc_feedback.UpdateFeedback)
+
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	body := map[string]string{"message": "[redacted]"}
+	w := testutil.DoRequest(router, "PATCH", fmt.Sprintf("/feedbacks/%d", fb.ID), body, token)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&fb, fb.ID)
+	assert.Equal(t, "[redacted]", fb.Message)
+	assert.NotNil(t, fb.EditedAt)
+
+	w = testutil.DoRequest(router, "PATCH", fmt.Sprintf("/feedbacks/%d", fb.ID), map[string]string{"message": " "}, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSealedFeedback_NotSearchableOrEditable(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, group := setupFeedbackTestData(t)
+	sealedFb := models.Feedback{TenantID: tenant.ID, GroupID: group.ID, SenderID: 1, Message: "ZmVlZGJhY2s=",
+		AdminOnly: true, Sealed: true, SealedKey: "0123456789abcdef"}
+	models.DB.Create(&sealedFb)
+
+	router := testutil.SetupRouter()
+	router.GET("/feedbacks", auth.Auth, services.TenantMiddleware, svc_feedback.GetFeedbacks)
+	router.PATCH("/feedbacks/:id", auth.Auth, services.TenantMiddleware, svc_feedback.UpdateFeedback)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	// The sealed payload is returned as-is for the client to open
+	w := testutil.DoRequest(router, "GET", "/feedbacks?admin_only=true", nil, token)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	data := resp["data"].(map[string]interface{})["data"].([]interface{})
+	require.Len(t, data, 2)
+	first := data[0].(map[string]interface{})
+	assert.Equal(t, true, first["sealed"])
+	assert.Equal(t, "ZmVlZGJhY2s=", first["message"])
+
+	w = testutil.DoRequest(router, "GET", "/feedbacks?search=ZmVl", nil, token)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, float64(0), resp["data"].(map[string]interface{})["total"])
+
+	w = testutil.DoRequest(router, "PATCH", fmt.Sprintf("/feedbacks/%d", sealedFb.ID), map[string]string{"message": "x"}, token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestUnpostFeedback_NotPosted(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, group := setupFeedbackTestData(t)
+
+	var fb models.Feedback
+	models.DB.Where("group_id = ?", group.ID).First(&fb)
+
+	router := testutil.SetupRouter()
+	router.POST("/feedbacks/:id/unpost", auth.Auth, services.TenantMiddleware, svc_feedback.UnpostFeedback)
+	router.POST("/feedbacks/:id/pin", auth.Auth, services.TenantMiddleware, svc_feedback.PinFeedback)
+
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	w := testutil.DoRequest(router, "POST", fmt.Sprintf("/feedbacks/%d/unpost", fb.ID), nil, token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = testutil.DoRequest(router, "POST", fmt.Sprintf("/feedbacks/%d/pin", fb.ID), nil, token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = testutil.DoRequest(router, "POST", "/feedbacks/99999/unpost", nil, token)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetFeedbacks_RoundsTimestamps(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, group := setupFeedbackTestData(t)
+	models.DB.Model(&models.Tenant{}).Where("id = ?", tenant.ID).Update("timezone", "UTC")
+	models.DB.Create(&models.FeedbackConfig{GroupID: group.ID, TimestampRoundingMinutes: 60})
+
+	router := testutil.SetupRouter()
+	router.GET("/feedbacks", auth.Auth, services.TenantMiddleware, svc_feedback.GetFeedbacks)
+
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	w := testutil.DoRequest(router, "GET", fmt.Sprintf("/feedbacks?group_id=%d", group.ID), nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	data := resp["data"].(map[string]interface{})
+	for _, item := range data["data"].([]interface{}) {
+		createdAt, err := time.Parse(time.RFC3339Nano, item.(map[string]interface{})["createdAt"].(string))
+		require.NoError(t, err)
+		assert.Equal(t, 0, createdAt.Minute())
+		assert.Equal(t, 0, createdAt.Second())
+		assert.Equal(t, 0, createdAt.Nanosecond())
+	}
+}
+
+func TestGetFeedbacks_DateBoundsRounded(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, group := setupFeedbackTestData(t)
+	models.DB.Model(&models.Tenant{}).Where("id = ?", tenant.ID).Update("timezone", "UTC")
+	models.DB.Create(&models.FeedbackConfig{GroupID: group.ID, TimestampRoundingMinutes: 60})
+	for _, at := range []time.Time{
+		time.Date(2026, 10, 10, 10, 5, 0, 0, time.UTC),
+		time.Date(2026, 10, 10, 10, 40, 0, 0, time.UTC),
+	} {
+		fb := models.Feedback{TenantID: tenant.ID, GroupID: group.ID, Message: "timed"}
+		fb.CreatedAt = at
+		models.DB.Create(&fb)
+	}
+
+	router := testutil.SetupRouter()
+	router.GET("/feedbacks", auth.Auth, services.TenantMiddleware, svc_feedback.GetFeedbacks)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	count := func(query string) int {
+		w := testutil.DoRequest(router, "GET", "/feedbacks?"+query, nil, token)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return len(resp["data"].(map[string]interface{})["data"].([]interface{}))
+	}
+
+	// Bounds inside the hour can't tell the two posts apart
+	assert.Equal(t, 2, count("date_from=2026-10-10T10:30:00Z&date_to=2026-10-10T10:35:00Z"))
+	assert.Equal(t, 2, count("date_from=2026-10-10T10:06:00Z&date_to=2026-10-10T10:06:00Z"))
+	assert.Equal(t, 0, count("date_from=2026-10-10T11:00:00Z&date_to=2026-10-10T11:30:00Z"))
+	assert.Equal(t, 2, count("date_from=2026-10-10&date_to=2026-10-11"))
+	assert.Equal(t, 0, count("date_from=yesterday"))
+}
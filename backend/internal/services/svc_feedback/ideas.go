@@ -0,0 +1,56 @@
+package svc_feedback
+
+import (
+	"strconv"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// IdeaResponse is an idea with its vote score, upvotes minus downvotes.
+type IdeaResponse struct {
+	FeedbackResponse
+	Score int `json:"score"`
+}
+
+// GetIdeas ranks the ideas matching the listing filters by score, then by
+// upvotes, newest first on a tie. Pages are numbered (?page=, ?limit=).
+func GetIdeas(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > services.MaxPageSize {
+		limit = 20
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+
+	query := applyFilters(c).Where("type = ?", models.FeedbackIdea)
+	var total int64
+	query.Model(&models.Feedback{}).Count(&total)
+
+	var feedbacks []models.Feedback
+	query.Preload("Group", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "title")
+	}).Order("upvotes - downvotes DESC, upvotes DESC, created_at DESC, id DESC").
+		Offset((page - 1) * limit).Limit(limit).Find(&feedbacks)
+	RoundTimestamps(services.GetTenantID(c), feedbacks)
+
+	resp := make([]IdeaResponse, len(feedbacks))
+	for i, fb := range feedbacks {
+		resp[i] = IdeaResponse{
+			FeedbackResponse: FeedbackResponse{Feedback: fb, GroupName: fb.Group.Title},
+			Score:            fb.Upvotes - fb.Downvotes,
+		}
+	}
+
+	c.Data(lvn.Res(200, gin.H{
+		"data":  resp,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	}, ""))
+}
@@ -0,0 +1,62 @@
+package svc_feedback_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_feedback"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetIdeas_RankedByScore(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, group := setupFeedbackTestData(t)
+	for _, fb := range []models.Feedback{
+		{Message: "meh", Upvotes: 2, Downvotes: 2},
+		{Message: "best", Upvotes: 5, Downvotes: 1},
+		{Message: "popular", Upvotes: 9, Downvotes: 5},
+		{Message: "disliked", Downvotes: 3},
+	} {
+		fb.TenantID, fb.GroupID, fb.Type = tenant.ID, group.ID, models.FeedbackIdea
+		require.NoError(t, models.DB.Create(&fb).Error)
+	}
+
+	router := testutil.SetupRouter()
+	router.GET("/feedbacks/ideas", auth.Auth, services.TenantMiddleware, svc_feedback.GetIdeas)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	w := testutil.DoRequest(router, "GET", "/feedbacks/ideas", nil, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data struct {
+			Data []struct {
+				Message   string `json:"message"`
+				GroupName string `json:"group_name"`
+				Score     int    `json:"score"`
+			} `json:"data"`
+			Total int `json:"total"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	// Equal scores go to the idea with more upvotes. The seeded idea
+	// "Public feedback 2" has no votes.
+	assert.Equal(t, 5, resp.Data.Total)
+	var order []string
+	for _, idea := range resp.Data.Data {
+		order = append(order, idea.Message)
+	}
+	assert.Equal(t, []string{"popular", "best", "meh", "Public feedback 2", "disliked"}, order)
+	assert.Equal(t, 4, resp.Data.Data[0].Score)
+	assert.Equal(t, "FB Group", resp.Data.Data[0].GroupName)
+
+	w = testutil.DoRequest(router, "GET", "/feedbacks/ideas?limit=2&page=2", nil, token)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.Data, 2)
+	assert.Equal(t, "meh", resp.Data.Data[0].Message)
+}
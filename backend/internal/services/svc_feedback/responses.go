@@ -0,0 +1,165 @@
+package svc_feedback
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/tgbot"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxResponseLen leaves room in the Telegram message for the header and the
+// quoted feedback.
+const maxResponseLen = 3000
+
+type publishResponseReq struct {
+	Message string `json:"message"`
+}
+
+// PublishResponse publishes the official response to a feedback in its
+// group and marks the feedback as answered. Each feedback is answered once.
+// Admin-only feedback, and feedback held or rejected by moderation, stays
+// out of the group and can't be answered there. Feedback still waiting for
+// its own post or digest can't be answered yet: quoting it now would post
+// it without the anonymity delays.
+func PublishResponse(c *gin.Context) {
+	fb, ok := findFeedback(c)
+	if !ok {
+		return
+	}
+
+	var req publishResponseReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	switch {
+	case req.Message == "":
+		c.Data(lvn.Res(400, "", "message must not be empty"))
+		return
+	case utf8.RuneCountInString(req.Message) > maxResponseLen:
+		c.Data(lvn.Res(400, "", fmt.Sprintf("message must be at most %d characters", maxResponseLen)))
+		return
+	}
+
+	switch {
+	case fb.AnsweredAt != nil:
+		c.Data(lvn.Res(409, "", "Feedback already has a response"))
+		return
+	case fb.AdminOnly:
+		c.Data(lvn.Res(409, "", "Admin-only feedback can't be answered in the group"))
+		return
+	case fb.Moderation == models.ModerationPending || fb.Moderation == models.ModerationRejected:
+		c.Data(lvn.Res(409, "", "Feedback is not approved for the group"))
+		return
+	case fb.ScheduledAt != nil || fb.DigestQueued:
+		c.Data(lvn.Res(409, "", "Feedback is not posted to the group yet"))
+		return
+	case fb.Sealed && !(fb.Posted && !fb.InDigest):
+		c.Data(lvn.Res(409, "", "Sealed feedback can't be quoted in the group"))
+		return
+	}
+
+	// Claim the feedback first, so only one response is posted
+	now := time.Now()
+	res := models.DB.Model(&models.Feedback{}).Where("id = ? AND answered_at IS NULL", fb.ID).Update("answered_at", now)
+	if res.Error != nil {
+		lvn.GinErr(c, 500, res.Error, "Failed to publish the response")
+		return
+	}
+	if res.RowsAffected != 1 {
+		c.Data(lvn.Res(409, "", "Feedback already has a response"))
+		return
+	}
+	fb.AnsweredAt = &now
+
+	response := models.PublicResponse{
+		TenantID:   fb.TenantID,
+		FeedbackID: fb.ID,
+		GroupID:    fb.GroupID,
+		AuthorID:   services.GetUserID(c),
+		Message:    req.Message,
+	}
+	if err := tgbot.PublishResponse(&fb, &response); err != nil {
+		models.DB.Model(&models.Feedback{}).Where("id = ?", fb.ID).Update("answered_at", nil)
+		lvn.GinErr(c, 502, err, "Failed to post the response to the group")
+		return
+	}
+
+	if err := models.DB.Create(&response).Error; err != nil {
+		lvn.GinErr(c, 500, err, "Response posted, but saving it failed")
+		return
+	}
+
+	c.Data(lvn.Res(201, response, ""))
+}
+
+// ResponseItem is a changelog entry: the response with the feedback it
+// answers.
+type ResponseItem struct {
+	ID          uint      `json:"id"`
+	FeedbackID  uint      `json:"feedback_id"`
+	GroupID     uint      `json:"group_id"`
+	GroupName   string    `json:"group_name"`
+	Type        string    `json:"type"`
+	Feedback    string    `json:"feedback"`
+	Message     string    `json:"message"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// GetResponses lists the tenant's published responses, newest first, as a
+// changelog. ?group_id= narrows it to one group; pages follow next_cursor.
+func GetResponses(c *gin.Context) {
+	page, ok := services.GetCursorPage(c, 20)
+	if !ok {
+		return
+	}
+
+	query := models.DB.Scopes(db.TenantScope(services.GetTenantID(c)))
+	if groupID, err := strconv.ParseUint(c.Query("group_id"), 10, 64); err == nil {
+		query = query.Where("group_id = ?", groupID)
+	}
+
+	var responses []models.PublicResponse
+	query.Preload("Group", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "title")
+	}).Preload("Feedback", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "type", "message", "sealed")
+	}).Scopes(db.NewestFirst("public_responses", page.After)).Limit(page.Limit + 1).Find(&responses)
+
+	ids := make([]uint, len(responses))
+	for i, r := range responses {
+		ids[i] = r.ID
+	}
+	next := page.NextCursor(ids)
+	if len(responses) > page.Limit {
+		responses = responses[:page.Limit]
+	}
+
+	items := make([]ResponseItem, len(responses))
+	for i, r := range responses {
+		items[i] = ResponseItem{
+			ID:          r.ID,
+			FeedbackID:  r.FeedbackID,
+			GroupID:     r.GroupID,
+			GroupName:   r.Group.Title,
+			Type:        r.Feedback.Type,
+			Message:     r.Message,
+			PublishedAt: r.CreatedAt,
+		}
+		if !r.Feedback.Sealed {
+			items[i].Feedback = r.Feedback.Message
+		}
+	}
+
+	c.Data(lvn.Res(200, gin.H{"data": items, "next_cursor": next}, ""))
+}
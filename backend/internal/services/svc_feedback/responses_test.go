@@ -0,0 +1,142 @@
+package svc_feedback_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_feedback"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishResponse_Rejected(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, group := setupFeedbackTestData(t)
+	answered := time.Now()
+	for name, fb := range map[string]models.Feedback{
+		"admin only": {Message: "Private", AdminOnly: true},
+		"pending":    {Message: "Held", Moderation: models.ModerationPending},
+		"rejected":   {Message: "No", Moderation: models.ModerationRejected},
+		"answered":   {Message: "Done", AnsweredAt: &answered},
+		"sealed":     {Message: "ciphertext", Sealed: true},
+		"scheduled":  {Message: "Soon", ScheduledAt: &answered},
+		"in digest":  {Message: "Later", DigestQueued: true},
+	} {
+		fb.TenantID, fb.GroupID = tenant.ID, group.ID
+		require.NoError(t, models.DB.Create(&fb).Error)
+
+		router := testutil.SetupRouter()
+		router.POST("/feedbacks/:id/response", auth.Auth, services.TenantMiddleware, svc_feedback.PublishResponse)
+		token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+		path := fmt.Sprintf("/feedbacks/%d/response", fb.ID)
+
+		w := testutil.DoRequest(router, "POST", path, map[string]string{"message": "  "}, token)
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		w = testutil.DoRequest(router, "POST", path, map[string]string{"message": "Thanks"}, token)
+		assert.Equal(t, http.StatusConflict, w.Code, name)
+	}
+
+	var count int64
+	models.DB.Model(&models.PublicResponse{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestPublishResponse_UndoesClaimWhenNotSent(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, _ := setupFeedbackTestData(t)
+	// Its group is gone, so the response can't be sent
+	fb := models.Feedback{TenantID: tenant.ID, GroupID: 99999, Message: "Orphan"}
+	require.NoError(t, models.DB.Create(&fb).Error)
+
+	router := testutil.SetupRouter()
+	// lvn.GinErr panics after responding
+	router.Use(gin.RecoveryWithWriter(io.Discard))
+	router.POST("/feedbacks/:id/response", auth.Auth, services.TenantMiddleware, svc_feedback.PublishResponse)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	w := testutil.DoRequest(router, "POST", fmt.Sprintf("/feedbacks/%d/response", fb.ID), map[string]string{"message": "Thanks"}, token)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	require.NoError(t, models.DB.First(&fb, fb.ID).Error)
+	assert.Nil(t, fb.AnsweredAt, "the claim is released for another try")
+	var count int64
+	models.DB.Model(&models.PublicResponse{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestGetResponses_Changelog(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user, tenant, group := setupFeedbackTestData(t)
+	other := models.Group{TenantID: tenant.ID, ChatID: -777, Title: "Other"}
+	models.DB.Create(&other)
+
+	now := time.Now()
+	publish := func(g models.Group, feedback, response string, sealed bool) models.Feedback {
+		fb := models.Feedback{TenantID: tenant.ID, GroupID: g.ID, Message: feedback, Sealed: sealed, AnsweredAt: &now}
+		require.NoError(t, models.DB.Create(&fb).Error)
+		require.NoError(t, models.DB.Create(&models.PublicResponse{
+			TenantID: tenant.ID, FeedbackID: fb.ID, GroupID: g.ID, AuthorID: user.ID, Message: response,
+		}).Error)
+		return fb
+	}
+	first := publish(group, "Need standing desks", "Ordered for every floor", false)
+	publish(other, "Louder fire alarm", "Replaced", false)
+	publish(group, "ciphertext", "Handled", true)
+
+	router := testutil.SetupRouter()
+	router.GET("/responses", auth.Auth, services.TenantMiddleware, svc_feedback.GetResponses)
+	router.GET("/feedbacks", auth.Auth, services.TenantMiddleware, svc_feedback.GetFeedbacks)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	type changelog struct {
+		Data struct {
+			Data []struct {
+				FeedbackID uint   `json:"feedback_id"`
+				GroupName  string `json:"group_name"`
+				Feedback   string `json:"feedback"`
+				Message    string `json:"message"`
+			} `json:"data"`
+			NextCursor string `json:"next_cursor"`
+		} `json:"data"`
+	}
+	w := testutil.DoRequest(router, "GET", fmt.Sprintf("/responses?group_id=%d&limit=1", group.ID), nil, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp changelog
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.Data, 1)
+	// Newest first; sealed feedback isn't quoted
+	assert.Equal(t, "Handled", resp.Data.Data[0].Message)
+	assert.Empty(t, resp.Data.Data[0].Feedback)
+	require.NotEmpty(t, resp.Data.NextCursor)
+
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/responses?group_id=%d&limit=1&cursor=%s", group.ID, resp.Data.NextCursor), nil, token)
+	resp = changelog{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.Data, 1)
+	assert.Equal(t, first.ID, resp.Data.Data[0].FeedbackID)
+	assert.Equal(t, "FB Group", resp.Data.Data[0].GroupName)
+	assert.Equal(t, "Need standing desks", resp.Data.Data[0].Feedback)
+	assert.Empty(t, resp.Data.NextCursor)
+
+	w = testutil.DoRequest(router, "GET", "/responses", nil, token)
+	resp = changelog{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Data.Data, 3)
+
+	w = testutil.DoRequest(router, "GET", "/feedbacks?answered=true", nil, token)
+	var list struct {
+		Data struct {
+			Total int `json:"total"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 3, list.Data.Total)
+}
@@ -1,20 +1,52 @@
 package svc_group
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/anonymity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/cron"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/ratings"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/retention"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
 	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
 	"github.com/gin-gonic/gin"
 )
 
+// GetGroups lists all groups, or with ?limit= or ?cursor= one page of them
+// along with the next_cursor.
 func GetGroups(c *gin.Context) {
 	tenantID := services.GetTenantID(c)
+	query := models.DB.Scopes(db.TenantScope(tenantID))
+
+	if c.Query("limit") == "" && c.Query("cursor") == "" {
+		var groups []models.Group
+		query.Find(&groups)
+		c.Data(lvn.Res(200, groups, ""))
+		return
+	}
 
+	page, ok := services.GetCursorPage(c, 50)
+	if !ok {
+		return
+	}
 	var groups []models.Group
-	models.DB.Scopes(db.TenantScope(tenantID)).Find(&groups)
+	query.Scopes(db.ByID("groups", page.After)).Limit(page.Limit + 1).Find(&groups)
+
+	ids := make([]uint, len(groups))
+	for i, g := range groups {
+		ids[i] = g.ID
+	}
+	next := page.NextCursor(ids)
+	if len(groups) > page.Limit {
+		groups = groups[:page.Limit]
+	}
 
-	c.Data(lvn.Res(200, groups, ""))
+	c.Data(lvn.Res(200, gin.H{"data": groups, "next_cursor": next}, ""))
 }
 
 func GetGroup(c *gin.Context) {
@@ -63,8 +95,27 @@ func UpdateGroup(c *gin.Context) {
 }
 
 type updateConfigReq struct {
-	PostToGroup  *bool `json:"post_to_group"`
-	ForumTopicID *int  `json:"forum_topic_id"`
+	PostToGroup          *bool  `json:"post_to_group"`
+	ForumTopicID         *int   `json:"forum_topic_id"`
+	RetractWindowMinutes *int   `json:"retract_window_minutes"`
+	RequireApproval      *bool  `json:"require_approval"`
+	ModerationChatID     *int64 `json:"moderation_chat_id"`
+
+	PostDelayMinMinutes      *int    `json:"post_delay_min_minutes"`
+	PostDelayMaxMinutes      *int    `json:"post_delay_max_minutes"`
+	PostingWindows           *string `json:"posting_windows"`
+	MinDistinctSenders       *int    `json:"min_distinct_senders"`
+	TimestampRoundingMinutes *int    `json:"timestamp_rounding_minutes"`
+
+	RetentionMonths *int `json:"retention_months"` // -1 falls back to the tenant setting
+
+	DigestMode     *bool   `json:"digest_mode"`
+	DigestSchedule *string `json:"digest_schedule"`
+	DigestPin      *bool   `json:"digest_pin"`
+
+	RatingScale *string `json:"rating_scale"` // "" stops asking for ratings
+
+	AllowedTypes *string `json:"allowed_types"` // Comma-separated, "" allows all types
 }
 
 func UpdateGroupConfig(c *gin.Context) {
@@ -96,6 +147,61 @@ func UpdateGroupConfig(c *gin.Context) {
 	if req.ForumTopicID != nil {
 		config.ForumTopicID = req.ForumTopicID
 	}
+	if req.RetractWindowMinutes != nil {
+		if *req.RetractWindowMinutes < 0 {
+			c.Data(lvn.Res(400, "", "retract_window_minutes must not be negative"))
+			return
+		}
+		config.RetractWindowMinutes = *req.RetractWindowMinutes
+	}
+	if req.RequireApproval != nil {
+		config.RequireApproval = *req.RequireApproval
+	}
+	if req.ModerationChatID != nil {
+		if *req.ModerationChatID == 0 {
+			config.ModerationChatID = nil
+		} else {
+			config.ModerationChatID = req.ModerationChatID
+		}
+	}
+
+	if req.RatingScale != nil {
+		if _, ok := ratings.Lookup(*req.RatingScale); !ok && *req.RatingScale != "" {
+			c.Data(lvn.Res(400, "", "rating_scale must be enps, csat or empty"))
+			return
+		}
+		config.RatingScale = *req.RatingScale
+	}
+
+	if req.AllowedTypes != nil {
+		allowed, err := parseAllowedTypes(*req.AllowedTypes)
+		if err != nil {
+			c.Data(lvn.Res(400, "", err.Error()))
+			return
+		}
+		config.AllowedTypes = allowed
+	}
+
+	if req.RetentionMonths != nil {
+		switch months := *req.RetentionMonths; {
+		case months == -1:
+			config.RetentionMonths = nil
+		case months < 0 || months > retention.MaxRetentionMonths:
+			c.Data(lvn.Res(400, "", fmt.Sprintf("retention_months must be -1 or between 0 and %d", retention.MaxRetentionMonths)))
+			return
+		default:
+			config.RetentionMonths = &months
+		}
+	}
+
+	if msg := applyAnonymitySettings(&config, req); msg != "" {
+		c.Data(lvn.Res(400, "", msg))
+		return
+	}
+	if msg := applyDigestSettings(&config, req, group.TenantID); msg != "" {
+		c.Data(lvn.Res(400, "", msg))
+		return
+	}
 
 	if err := models.DB.Save(&config).Error; err != nil {
 		lvn.GinErr(c, 500, err, "Failed to update config")
@@ -104,3 +210,99 @@ func UpdateGroupConfig(c *gin.Context) {
 
 	c.Data(lvn.Res(200, config, ""))
 }
+
+const maxPostDelayMinutes = 7 * 24 * 60
+
+// applyAnonymitySettings copies the timing-protection fields from req into
+// config and validates the result. It returns an error message or "".
+func applyAnonymitySettings(config *models.FeedbackConfig, req updateConfigReq) string {
+	if req.PostDelayMinMinutes != nil {
+		config.PostDelayMinMinutes = *req.PostDelayMinMinutes
+	}
+	if req.PostDelayMaxMinutes != nil {
+		config.PostDelayMaxMinutes = *req.PostDelayMaxMinutes
+	}
+	if req.PostingWindows != nil {
+		config.PostingWindows = *req.PostingWindows
+	}
+	if req.MinDistinctSenders != nil {
+		config.MinDistinctSenders = *req.MinDistinctSenders
+	}
+	if req.TimestampRoundingMinutes != nil {
+		config.TimestampRoundingMinutes = *req.TimestampRoundingMinutes
+	}
+
+	if config.PostDelayMinMinutes < 0 || config.PostDelayMaxMinutes < 0 {
+		return "post delays must not be negative"
+	}
+	if config.PostDelayMaxMinutes > 0 && config.PostDelayMaxMinutes < config.PostDelayMinMinutes {
+		return "post_delay_max_minutes must not be less than post_delay_min_minutes"
+	}
+	if config.PostDelayMinMinutes > maxPostDelayMinutes || config.PostDelayMaxMinutes > maxPostDelayMinutes {
+		return "post delays must be at most one week"
+	}
+	if _, err := anonymity.ParseWindows(config.PostingWindows); err != nil {
+		return err.Error()
+	}
+	if config.MinDistinctSenders < 1 {
+		return "min_distinct_senders must be at least 1"
+	}
+	if config.TimestampRoundingMinutes < 0 || config.TimestampRoundingMinutes > 1440 {
+		return "timestamp_rounding_minutes must be between 0 and 1440"
+	}
+	return ""
+}
+
+// applyDigestSettings copies the digest fields from req into config,
+// validates them and works out when the next digest is due. It returns an
+// error message or "".
+func applyDigestSettings(config *models.FeedbackConfig, req updateConfigReq, tenantID uint) string {
+	if req.DigestMode != nil {
+		config.DigestMode = *req.DigestMode
+	}
+	if req.DigestSchedule != nil {
+		config.DigestSchedule = strings.TrimSpace(*req.DigestSchedule)
+	}
+	if req.DigestPin != nil {
+		config.DigestPin = *req.DigestPin
+	}
+
+	if !config.DigestMode {
+		config.DigestNextAt = nil
+		return ""
+	}
+	if config.DigestSchedule == "" {
+		return "digest_schedule is required in digest mode"
+	}
+	schedule, err := cron.Parse(config.DigestSchedule)
+	if err != nil {
+		return "digest_schedule: " + err.Error()
+	}
+	var tenant models.Tenant
+	models.DB.Select("id", "timezone").First(&tenant, tenantID)
+	next := schedule.Next(time.Now(), anonymity.Location(tenant.Timezone))
+	if next.IsZero() {
+		return "digest_schedule never runs"
+	}
+	next = next.UTC()
+	config.DigestNextAt = &next
+	return ""
+}
+
+// parseAllowedTypes normalizes a comma-separated list of feedback types.
+// "general" alone turns the type commands off; general feedback itself is
+// always allowed.
+func parseAllowedTypes(list string) (string, error) {
+	var types []string
+	for _, t := range strings.Split(list, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || slices.Contains(types, t) {
+			continue
+		}
+		if !models.ValidFeedbackType(t) {
+			return "", fmt.Errorf("allowed_types must list types among %s", strings.Join(models.FeedbackTypes, ", "))
+		}
+		types = append(types, t)
+	}
+	return strings.Join(types, ","), nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
@@ -72,6 +73,38 @@ func TestGetGroups_Success(t *testing.T) {
 	assert.Len(t, data, 1)
 }
 
+func TestGetGroups_CursorPages(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "grp@example.com", "Grp User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Grp Org", "grp-org")
+	bot := createTestBot(t, tenant.ID)
+	for i := 0; i < 3; i++ {
+		createTestGroup(t, tenant.ID, bot.ID, int64(-100500-i), fmt.Sprintf("Group %d", i))
+	}
+
+	router := testutil.SetupRouter()
+	router.GET("/groups", auth.Auth, services.TenantMiddleware, svc_group.GetGroups)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	var titles []string
+	path := "/groups?limit=2"
+	for path != "" {
+		w := testutil.DoRequest(router, "GET", path, nil, token)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		data := resp["data"].(map[string]interface{})
+		for _, g := range data["data"].([]interface{}) {
+			titles = append(titles, g.(map[string]interface{})["title"].(string))
+		}
+		path = ""
+		if next := data["next_cursor"].(string); next != "" {
+			path = "/groups?limit=2&cursor=" + next
+		}
+	}
+	assert.Equal(t, []string{"Group 0", "Group 1", "Group 2"}, titles)
+}
+
 func TestGetGroups_IsolatedByTenant(t *testing.T) {
 	testutil.SetupTestDB(t)
 	user1 := testutil.CreateTestUser(t, "u1@example.com", "User1")
@@ -171,3 +204,181 @@ func TestUpdateGroupConfig_Success(t *testing.T) {
 	data := resp["data"].(map[string]interface{})
 	assert.Equal(t, true, data["post_to_group"])
 }
+
+func TestUpdateGroupConfig_AnonymitySettings(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "anon@example.com", "Anon User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Anon Org", "anon-org")
+	bot := createTestBot(t, tenant.ID)
+	group := createTestGroup(t, tenant.ID, bot.ID, -100556, "Anon Group")
+
+	router := testutil.SetupRouter()
+	router.PATCH("/groups/:id/config", auth.Auth, services.TenantMiddleware, svc_group.UpdateGroupConfig)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	path := fmt.Sprintf("/groups/%d/config", group.ID)
+
+	body := map[string]interface{}{
+		"post_delay_min_minutes":     5,
+		"post_delay_max_minutes":     30,
+		"posting_windows":            "09:00,17:30",
+		"min_distinct_senders":       3,
+		"timestamp_rounding_minutes": 60,
+	}
+	w := testutil.DoRequest(router, "PATCH", path, body, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var config models.FeedbackConfig
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+	assert.Equal(t, "09:00,17:30", config.PostingWindows)
+	assert.Equal(t, 3, config.MinDistinctSenders)
+
+	invalid := []map[string]interface{}{
+		{"post_delay_min_minutes": 40},
+		{"posting_windows": "9am"},
+		{"min_distinct_senders": 0},
+		{"timestamp_rounding_minutes": 2000},
+	}
+	for _, body := range invalid {
+		w := testutil.DoRequest(router, "PATCH", path, body, token)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "body: %v", body)
+	}
+}
+
+func TestUpdateGroupConfig_DigestMode(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "digest@example.com", "Digest User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Digest Org", "digest-org")
+	bot := createTestBot(t, tenant.ID)
+	group := createTestGroup(t, tenant.ID, bot.ID, -100557, "Digest Group")
+
+	router := testutil.SetupRouter()
+	router.PATCH("/groups/:id/config", auth.Auth, services.TenantMiddleware, svc_group.UpdateGroupConfig)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	path := fmt.Sprintf("/groups/%d/config", group.ID)
+
+	invalid := []map[string]interface{}{
+		{"digest_mode": true},
+		{"digest_mode": true, "digest_schedule": "every friday"},
+		{"digest_mode": true, "digest_schedule": "0 0 30 2 *"},
+	}
+	for _, body := range invalid {
+		w := testutil.DoRequest(router, "PATCH", path, body, token)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "body: %v", body)
+	}
+
+	w := testutil.DoRequest(router, "PATCH", path, map[string]interface{}{
+		"digest_mode": true, "digest_schedule": "0 17 * * 5", "digest_pin": true,
+	}, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var config models.FeedbackConfig
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+	assert.True(t, config.DigestMode)
+	assert.True(t, config.DigestPin)
+	require.NotNil(t, config.DigestNextAt)
+	// Friday 17:00 in Asia/Tashkent is 12:00 UTC
+	assert.Equal(t, time.Friday, config.DigestNextAt.UTC().Weekday())
+	assert.Equal(t, 12, config.DigestNextAt.UTC().Hour())
+
+	w = testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"digest_mode": false}, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	config = models.FeedbackConfig{}
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+	assert.False(t, config.DigestMode)
+	assert.Nil(t, config.DigestNextAt)
+}
+
+func TestUpdateGroupConfig_RatingScale(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "rating@example.com", "Rating User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Rating Org", "rating-org")
+	bot := createTestBot(t, tenant.ID)
+	group := createTestGroup(t, tenant.ID, bot.ID, -100557, "Rating Group")
+
+	router := testutil.SetupRouter()
+	router.PATCH("/groups/:id/config", auth.Auth, services.TenantMiddleware, svc_group.UpdateGroupConfig)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	path := fmt.Sprintf("/groups/%d/config", group.ID)
+
+	w := testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"rating_scale": "stars"}, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"rating_scale": "enps"}, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var config models.FeedbackConfig
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+	assert.Equal(t, models.RatingENPS, config.RatingScale)
+
+	w = testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"rating_scale": ""}, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	config = models.FeedbackConfig{}
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+	assert.Empty(t, config.RatingScale)
+}
+
+func TestUpdateGroupConfig_AllowedTypes(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "types@example.com", "Types User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Types Org", "types-org")
+	bot := createTestBot(t, tenant.ID)
+	group := createTestGroup(t, tenant.ID, bot.ID, -100558, "Types Group")
+
+	router := testutil.SetupRouter()
+	router.PATCH("/groups/:id/config", auth.Auth, services.TenantMiddleware, svc_group.UpdateGroupConfig)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	path := fmt.Sprintf("/groups/%d/config", group.ID)
+	allowed := func() models.FeedbackConfig {
+		var config models.FeedbackConfig
+		models.DB.Where("group_id = ?", group.ID).First(&config)
+		return config
+	}
+
+	w := testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"allowed_types": "idea,rant"}, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"allowed_types": " Idea, praise,idea"}, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	config := allowed()
+	assert.Equal(t, "idea,praise", config.AllowedTypes)
+	assert.True(t, config.AllowsType(models.FeedbackGeneral))
+	assert.True(t, config.AllowsType(models.FeedbackPraise))
+	assert.False(t, config.AllowsType(models.FeedbackIssue))
+
+	// Only general turns the type commands off
+	w = testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"allowed_types": "general"}, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, allowed().AllowsType(models.FeedbackIdea))
+
+	w = testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"allowed_types": ""}, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, allowed().AllowsType(models.FeedbackIssue))
+}
+
+func TestUpdateGroupConfig_RetentionOverride(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "ret@example.com", "Ret User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Ret Org", "ret-org")
+	bot := createTestBot(t, tenant.ID)
+	group := createTestGroup(t, tenant.ID, bot.ID, -100557, "Ret Group")
+
+	router := testutil.SetupRouter()
+	router.PATCH("/groups/:id/config", auth.Auth, services.TenantMiddleware, svc_group.UpdateGroupConfig)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	path := fmt.Sprintf("/groups/%d/config", group.ID)
+
+	w := testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"retention_months": 12}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var config models.FeedbackConfig
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+	require.NotNil(t, config.RetentionMonths)
+	assert.Equal(t, 12, *config.RetentionMonths)
+
+	w = testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"retention_months": -1}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	config = models.FeedbackConfig{}
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+	assert.Nil(t, config.RetentionMonths)
+
+	w = testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"retention_months": 1000}, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
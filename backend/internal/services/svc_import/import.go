@@ -0,0 +1,122 @@
+package svc_import
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/importer"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+)
+
+const maxUploadSize = 50 << 20
+
+// CreateImport accepts a multipart upload: "file", "format", optional
+// "options" (JSON with columns, group_map and default_group_id) and
+// "dry_run". The import runs in the background; poll GET /imports/:id.
+func CreateImport(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.Data(lvn.Res(400, "", "file is required"))
+		return
+	}
+	if file.Size > maxUploadSize {
+		c.Data(lvn.Res(413, "", fmt.Sprintf("file must be at most %d MB", maxUploadSize>>20)))
+		return
+	}
+
+	var opts importer.Options
+	if raw := c.PostForm("options"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts); err != nil {
+			c.Data(lvn.Res(400, "", "Invalid options: "+err.Error()))
+			return
+		}
+	}
+	opts.Format = c.PostForm("format")
+	dryRun, _ := strconv.ParseBool(c.PostForm("dry_run"))
+
+	if err := opts.Validate(tenantID); err != nil {
+		c.Data(lvn.Res(400, "", err.Error()))
+		return
+	}
+
+	path := filepath.Join(config.DataDir(), "imports",
+		fmt.Sprintf("tenant-%d-%d%s", tenantID, time.Now().UnixNano(), filepath.Ext(file.Filename)))
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		lvn.GinErr(c, 500, err, "Failed to store upload")
+		return
+	}
+	if err := c.SaveUploadedFile(file, path); err != nil {
+		lvn.GinErr(c, 500, err, "Failed to store upload")
+		return
+	}
+
+	job, err := importer.Start(tenantID, opts, filepath.Base(file.Filename), path, dryRun)
+	if err != nil {
+		os.Remove(path)
+		lvn.GinErr(c, 500, err, "Failed to start import")
+		return
+	}
+
+	c.Data(lvn.Res(202, importer.NewReport(job), ""))
+}
+
+func GetImports(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	var jobs []models.ImportJob
+	models.DB.Scopes(db.TenantScope(tenantID)).Order("created_at DESC").Limit(50).Find(&jobs)
+
+	c.Data(lvn.Res(200, jobs, ""))
+}
+
+func findImport(c *gin.Context) (models.ImportJob, bool) {
+	id := c.Param("id")
+	tenantID := services.GetTenantID(c)
+
+	var job models.ImportJob
+	if err := models.DB.Scopes(db.TenantScope(tenantID)).First(&job, id).Error; err != nil {
+		c.Data(lvn.Res(404, "", "Import not found"))
+		return job, false
+	}
+	return job, true
+}
+
+// GetImport returns the job with its row errors; for a dry run this is the
+// validation report.
+func GetImport(c *gin.Context) {
+	job, ok := findImport(c)
+	if !ok {
+		return
+	}
+
+	c.Data(lvn.Res(200, importer.NewReport(job), ""))
+}
+
+// ResumeImport continues a failed job from the last committed row.
+func ResumeImport(c *gin.Context) {
+	job, ok := findImport(c)
+	if !ok {
+		return
+	}
+
+	if job.Status != models.ImportFailed || job.FilePath == "" {
+		c.Data(lvn.Res(409, "", "Only failed imports can be resumed"))
+		return
+	}
+
+	models.DB.Model(&job).Update("status", models.ImportPending)
+	go importer.Run(job.ID)
+
+	c.Data(lvn.Res(202, importer.NewReport(job), ""))
+}
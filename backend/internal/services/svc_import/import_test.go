@@ -0,0 +1,103 @@
+package svc_import_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_import"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func upload(router *gin.Engine, token string, fields map[string]string, file string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	if file != "" {
+		fw, _ := mw.CreateFormFile("file", "feedback.csv")
+		fw.Write([]byte(file))
+	}
+	mw.Close()
+
+	req, _ := http.NewRequest("POST", "/imports", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateImport_DryRunReport(t *testing.T) {
+	testutil.SetupTestDB(t)
+	config.Confs.Settings.DataDir = t.TempDir()
+	user := testutil.CreateTestUser(t, "imp@example.com", "Imp User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Imp Org", "imp-org")
+	group := models.Group{TenantID: tenant.ID, BotID: 1, ChatID: -100, Title: "All", IsActive: true}
+	models.DB.Create(&group)
+
+	router := testutil.SetupRouter()
+	router.POST("/imports", auth.Auth, services.TenantMiddleware, svc_import.CreateImport)
+	router.GET("/imports/:id", auth.Auth, services.TenantMiddleware, svc_import.GetImport)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	w := upload(router, token, map[string]string{"format": "csv"}, "text\nHi\n")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = upload(router, token, map[string]string{"format": "export_csv"}, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	options := fmt.Sprintf(`{"columns":{"message":"text"},"default_group_id":%d}`, group.ID)
+	w = upload(router, token, map[string]string{"format": "csv", "options": options, "dry_run": "true"}, "text\nHi\n\n\"\"\n")
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	id := uint(resp["data"].(map[string]interface{})["id"].(float64))
+
+	require.Eventually(t, func() bool {
+		var job models.ImportJob
+		models.DB.First(&job, id)
+		return job.Status == models.ImportCompleted
+	}, 5*time.Second, 10*time.Millisecond)
+
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/imports/%d", id), nil, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, true, data["dry_run"])
+	assert.Equal(t, float64(1), data["imported"])
+	assert.Len(t, data["row_errors"], 1)
+
+	var count int64
+	models.DB.Model(&models.Feedback{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestResumeImport_OnlyFailed(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "res@example.com", "Res User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Res Org", "res-org")
+	job := models.ImportJob{TenantID: tenant.ID, Format: models.ImportFormatCSV, Status: models.ImportCompleted}
+	models.DB.Create(&job)
+
+	router := testutil.SetupRouter()
+	router.POST("/imports/:id/resume", auth.Auth, services.TenantMiddleware, svc_import.ResumeImport)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	w := testutil.DoRequest(router, "POST", fmt.Sprintf("/imports/%d/resume", job.ID), nil, token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = testutil.DoRequest(router, "POST", "/imports/999/resume", nil, token)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
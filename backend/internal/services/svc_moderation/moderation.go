@@ -0,0 +1,191 @@
+package svc_moderation
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_feedback"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/tgbot"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type QueueItem struct {
+	models.Feedback
+	GroupName string `json:"group_name"`
+}
+
+// GetQueue lists feedback awaiting moderation (or, with ?status=, already
+// moderated items), oldest first.
+func GetQueue(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	status := c.DefaultQuery("status", models.ModerationPending)
+	if status != models.ModerationPending && status != models.ModerationApproved && status != models.ModerationRejected {
+		c.Data(lvn.Res(400, "", "Invalid status: "+status))
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	query := models.DB.Scopes(db.TenantScope(tenantID)).Where("moderation = ?", status)
+	if groupID := c.Query("group_id"); groupID != "" {
+		query = query.Where("group_id = ?", groupID)
+	}
+
+	var total int64
+	query.Model(&models.Feedback{}).Count(&total)
+
+	var feedbacks []models.Feedback
+	query.Preload("Group", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "title")
+	}).Order("created_at ASC").Limit(limit).Find(&feedbacks)
+	svc_feedback.RoundTimestamps(tenantID, feedbacks)
+
+	resp := make([]QueueItem, len(feedbacks))
+	for i, fb := range feedbacks {
+		resp[i] = QueueItem{Feedback: fb, GroupName: fb.Group.Title}
+	}
+
+	c.Data(lvn.Res(200, gin.H{
+		"data":  resp,
+		"total": total,
+	}, ""))
+}
+
+func findPending(c *gin.Context) (models.Feedback, bool) {
+	id := c.Param("id")
+	tenantID := services.GetTenantID(c)
+
+	var fb models.Feedback
+	if err := models.DB.Scopes(db.TenantScope(tenantID)).First(&fb, id).Error; err != nil {
+		c.Data(lvn.Res(404, "", "Feedback not found"))
+		return fb, false
+	}
+	if fb.Moderation != models.ModerationPending {
+		c.Data(lvn.Res(409, "", "Feedback is not awaiting moderation"))
+		return fb, false
+	}
+	return fb, true
+}
+
+type redactReq struct {
+	Message *string `json:"message"`
+}
+
+// redact applies an optional moderator edit to a pending feedback. Only the
+// message is written, and only while the feedback is still pending, so a
+// concurrent decision isn't undone.
+func redact(c *gin.Context, fb *models.Feedback, message *string) bool {
+	if message == nil {
+		return true
+	}
+	if strings.TrimSpace(*message) == "" {
+		c.Data(lvn.Res(400, "", "message must not be empty"))
+		return false
+	}
+	now := time.Now()
+	res := models.DB.Model(&models.Feedback{}).
+		Where("id = ? AND moderation = ?", fb.ID, models.ModerationPending).
+		Updates(map[string]interface{}{"message": *message, "edited_at": now})
+	if res.Error != nil {
+		lvn.GinErr(c, 500, res.Error, "Failed to update feedback")
+		return false
+	}
+	if res.RowsAffected == 0 {
+		c.Data(lvn.Res(409, "", "Feedback is not awaiting moderation"))
+		return false
+	}
+	fb.Message = *message
+	fb.EditedAt = &now
+	return true
+}
+
+// UpdatePending edits/redacts a feedback before it is approved.
+func UpdatePending(c *gin.Context) {
+	fb, ok := findPending(c)
+	if !ok {
+		return
+	}
+
+	var req redactReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+	if !redact(c, &fb, req.Message) {
+		return
+	}
+
+	c.Data(lvn.Res(200, svc_feedback.Rounded(fb), ""))
+}
+
+// Approve posts a pending feedback to its group, optionally redacting it first.
+func Approve(c *gin.Context) {
+	fb, ok := findPending(c)
+	if !ok {
+		return
+	}
+
+	var req redactReq
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+			return
+		}
+	}
+	if !redact(c, &fb, req.Message) {
+		return
+	}
+
+	if err := tgbot.ApproveFeedback(&fb); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.Data(lvn.Res(200, svc_feedback.Rounded(fb), ""))
+}
+
+type rejectReq struct {
+	Reason string `json:"reason"`
+}
+
+// Reject keeps a pending feedback out of the group.
+func Reject(c *gin.Context) {
+	fb, ok := findPending(c)
+	if !ok {
+		return
+	}
+
+	var req rejectReq
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+			return
+		}
+	}
+
+	if err := tgbot.RejectFeedback(&fb, strings.TrimSpace(req.Reason)); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.Data(lvn.Res(200, svc_feedback.Rounded(fb), ""))
+}
+
+func moderationError(c *gin.Context, err error) {
+	if errors.Is(err, tgbot.ErrNotPending) {
+		c.Data(lvn.Res(409, "", "Feedback is not awaiting moderation"))
+		return
+	}
+	lvn.GinErr(c, 500, err, "Failed to moderate feedback")
+}
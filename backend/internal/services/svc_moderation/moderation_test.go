@@ -0,0 +1,121 @@
+package svc_moderation_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_moderation"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupModerationData(t *testing.T) (string, models.Feedback) {
+	t.Helper()
+	user := testutil.CreateTestUser(t, "mod@example.com", "Mod User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Mod Org", "mod-org")
+	bot := models.Bot{TenantID: tenant.ID, Token: "mod-tok", BotUsername: "modbot", Verified: true}
+	models.DB.Create(&bot)
+	group := models.Group{TenantID: tenant.ID, BotID: bot.ID, ChatID: -400111, Title: "Mod Group", Type: "supergroup", IsActive: true}
+	models.DB.Create(&group)
+	models.DB.Create(&models.FeedbackConfig{GroupID: group.ID, PostToGroup: true, RequireApproval: true})
+	gu := models.GroupUser{TenantID: tenant.ID, GroupID: group.ID, SenderHash: identity.Pseudonym(555)}
+	models.DB.Create(&gu)
+
+	pending := models.Feedback{TenantID: tenant.ID, GroupID: group.ID, SenderID: gu.ID, Message: "needs review", Moderation: models.ModerationPending}
+	models.DB.Create(&pending)
+	models.DB.Create(&models.Feedback{TenantID: tenant.ID, GroupID: group.ID, SenderID: gu.ID, Message: "no review"})
+
+	return testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID), pending
+}
+
+func setupModerationRouter() *gin.Engine {
+	router := testutil.SetupRouter()
+	g := router.Group("/moderation", auth.Auth, services.TenantMiddleware)
+	g.GET("", svc_moderation.GetQueue)
+	g.PATCH("/:id", svc_moderation.UpdatePending)
+	g.POST("/:id/approve", svc_moderation.Approve)
+	g.POST("/:id/reject", svc_moderation.Reject)
+	return router
+}
+
+func TestGetQueue_ListsPending(t *testing.T) {
+	testutil.SetupTestDB(t)
+	token, pending := setupModerationData(t)
+
+	w := testutil.DoRequest(setupModerationRouter(), "GET", "/moderation", nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	data := resp["data"].(map[string]interface{})
+	items := data["data"].([]interface{})
+	require.Len(t, items, 1)
+	assert.Equal(t, float64(pending.ID), items[0].(map[string]interface{})["id"])
+	assert.Equal(t, "Mod Group", items[0].(map[string]interface{})["group_name"])
+}
+
+func TestUpdatePending_AndReject(t *testing.T) {
+	testutil.SetupTestDB(t)
+	token, pending := setupModerationData(t)
+	router := setupModerationRouter()
+
+	w := testutil.DoRequest(router, "PATCH", fmt.Sprintf("/moderation/%d", pending.ID), map[string]string{"message": "needs [redacted]"}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = testutil.DoRequest(router, "POST", fmt.Sprintf("/moderation/%d/reject", pending.ID), map[string]string{"reason": "off-topic"}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var fb models.Feedback
+	models.DB.First(&fb, pending.ID)
+	assert.Equal(t, "needs [redacted]", fb.Message)
+	assert.Equal(t, models.ModerationRejected, fb.Moderation)
+	assert.Equal(t, "off-topic", fb.RejectReason)
+	assert.False(t, fb.Posted)
+
+	// Already moderated
+	w = testutil.DoRequest(router, "POST", fmt.Sprintf("/moderation/%d/approve", pending.ID), nil, token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestGetQueue_RoundsTimestamps(t *testing.T) {
+	testutil.SetupTestDB(t)
+	token, pending := setupModerationData(t)
+	models.DB.Model(&models.Tenant{}).Where("id = ?", pending.TenantID).Update("timezone", "UTC")
+	models.DB.Model(&models.FeedbackConfig{}).Where("group_id = ?", pending.GroupID).Update("timestamp_rounding_minutes", 60)
+	models.DB.Model(&pending).UpdateColumn("created_at", time.Date(2026, 10, 10, 10, 40, 0, 0, time.UTC))
+
+	router := setupModerationRouter()
+	w := testutil.DoRequest(router, "GET", "/moderation", nil, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data struct {
+			Data []struct {
+				CreatedAt time.Time `json:"createdAt"`
+			} `json:"data"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.Data, 1)
+	assert.Equal(t, time.Date(2026, 10, 10, 10, 0, 0, 0, time.UTC), resp.Data.Data[0].CreatedAt.UTC())
+
+	w = testutil.DoRequest(router, "PATCH", fmt.Sprintf("/moderation/%d", pending.ID), map[string]string{"message": "redacted"}, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	var item struct {
+		Data struct {
+			CreatedAt time.Time `json:"createdAt"`
+			UpdatedAt time.Time `json:"updatedAt"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+	assert.Equal(t, time.Date(2026, 10, 10, 10, 0, 0, 0, time.UTC), item.Data.CreatedAt.UTC())
+	assert.Zero(t, item.Data.UpdatedAt.Minute())
+}
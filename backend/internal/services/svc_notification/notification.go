@@ -0,0 +1,60 @@
+package svc_notification
+
+import (
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotifications lists the current user's latest notifications; ?unread=true
+// leaves out read ones.
+func GetNotifications(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	query := models.DB.Scopes(db.TenantScope(tenantID)).Where("user_id = ?", services.GetUserID(c))
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	query.Order("created_at DESC").Limit(50).Find(&notifications)
+
+	c.Data(lvn.Res(200, notifications, ""))
+}
+
+func MarkRead(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := services.GetTenantID(c)
+
+	var n models.Notification
+	if err := models.DB.Scopes(db.TenantScope(tenantID)).Where("user_id = ?", services.GetUserID(c)).First(&n, id).Error; err != nil {
+		c.Data(lvn.Res(404, "", "Notification not found"))
+		return
+	}
+
+	if n.ReadAt == nil {
+		now := time.Now()
+		n.ReadAt = &now
+		models.DB.Model(&n).Update("read_at", now)
+	}
+
+	c.Data(lvn.Res(200, n, ""))
+}
+
+func MarkAllRead(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	res := models.DB.Model(&models.Notification{}).Scopes(db.TenantScope(tenantID)).
+		Where("user_id = ? AND read_at IS NULL", services.GetUserID(c)).
+		Update("read_at", time.Now())
+	if res.Error != nil {
+		lvn.GinErr(c, 500, res.Error, "Failed to update notifications")
+		return
+	}
+
+	c.Data(lvn.Res(200, gin.H{"updated": res.RowsAffected}, ""))
+}
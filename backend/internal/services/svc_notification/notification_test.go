@@ -0,0 +1,52 @@
+package svc_notification_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_notification"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications_ListAndMarkRead(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "n@example.com", "N User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "N Org", "n-org")
+	mine := models.Notification{TenantID: tenant.ID, UserID: user.ID, Kind: models.NotificationExportReady, Message: "ready"}
+	models.DB.Create(&mine)
+	models.DB.Create(&models.Notification{TenantID: tenant.ID, UserID: user.ID, Kind: models.NotificationExportFailed})
+	models.DB.Create(&models.Notification{TenantID: tenant.ID, UserID: user.ID + 1, Kind: models.NotificationExportReady})
+
+	router := testutil.SetupRouter()
+	router.GET("/notifications", auth.Auth, services.TenantMiddleware, svc_notification.GetNotifications)
+	router.POST("/notifications/read", auth.Auth, services.TenantMiddleware, svc_notification.MarkAllRead)
+	router.POST("/notifications/:id/read", auth.Auth, services.TenantMiddleware, svc_notification.MarkRead)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	unread := func() int {
+		w := testutil.DoRequest(router, "GET", "/notifications?unread=true", nil, token)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return len(resp["data"].([]interface{}))
+	}
+	assert.Equal(t, 2, unread())
+
+	w := testutil.DoRequest(router, "POST", fmt.Sprintf("/notifications/%d/read", mine.ID), nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, unread())
+
+	w = testutil.DoRequest(router, "POST", "/notifications/3/read", nil, token)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = testutil.DoRequest(router, "POST", "/notifications/read", nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 0, unread())
+}
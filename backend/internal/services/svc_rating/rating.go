@@ -0,0 +1,205 @@
+package svc_rating
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/anonymity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/ratings"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// allowedParams are the only query params the analytics take. Finer
+// filters, such as searches or exact times, could split off a single
+// response.
+var allowedParams = map[string]bool{
+	"scale":     true,
+	"interval":  true,
+	"group_id":  true,
+	"date_from": true,
+	"date_to":   true,
+}
+
+// ratingSet is the ratings matching a request, pooled in cells of one group
+// and one interval.
+type ratingSet struct {
+	Scale     string
+	Interval  string
+	Threshold int
+	Location  *time.Location
+	Cells     map[ratings.Cell][]int // Only the cells with at least Threshold responses
+	Raw       map[ratings.Cell]int   // Responses of every cell
+}
+
+// loadRatings reads the ?scale (default enps) ratings, pooled per ?interval
+// (week, the default, or month) in the tenant timezone. ?group_id narrows
+// them to one group. ?date_from and ?date_to are dates (YYYY-MM-DD) that
+// start an interval; date_to is exclusive. It responds 400 for anything
+// else.
+func loadRatings(c *gin.Context) (ratingSet, bool) {
+	set := ratingSet{
+		Scale:    c.DefaultQuery("scale", models.RatingENPS),
+		Interval: c.DefaultQuery("interval", ratings.Week),
+	}
+	for param := range c.Request.URL.Query() {
+		if !allowedParams[param] {
+			c.Data(lvn.Res(400, "", "Unsupported filter: "+param))
+			return set, false
+		}
+	}
+	if _, ok := ratings.Lookup(set.Scale); !ok {
+		c.Data(lvn.Res(400, "", "scale must be enps or csat"))
+		return set, false
+	}
+	if set.Interval != ratings.Week && set.Interval != ratings.Month {
+		c.Data(lvn.Res(400, "", "interval must be week or month"))
+		return set, false
+	}
+
+	tenantID := services.GetTenantID(c)
+	var tenant models.Tenant
+	models.DB.First(&tenant, tenantID)
+	set.Location = anonymity.Location(tenant.Timezone)
+	set.Threshold = max(tenant.MinRatingResponses, ratings.MinResponsesFloor)
+
+	query := models.DB.Scopes(db.TenantScope(tenantID)).Model(&models.Feedback{}).
+		Select("group_id, rating, created_at").
+		Where("rating IS NOT NULL AND rating_scale = ?", set.Scale)
+	if groupID := c.Query("group_id"); groupID != "" {
+		id, err := strconv.ParseUint(groupID, 10, 64)
+		if err != nil {
+			c.Data(lvn.Res(400, "", "Invalid group_id"))
+			return set, false
+		}
+		query = query.Where("group_id = ?", id)
+	}
+	for _, bound := range []struct{ param, cond string }{{"date_from", "created_at >= ?"}, {"date_to", "created_at < ?"}} {
+		value := c.Query(bound.param)
+		if value == "" {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", value, set.Location)
+		if err != nil || !ratings.PeriodStart(date, set.Interval, set.Location).Equal(date) {
+			c.Data(lvn.Res(400, "", bound.param+" must be a date starting a "+set.Interval))
+			return set, false
+		}
+		query = query.Where(bound.cond, date.UTC())
+	}
+
+	var rows []struct {
+		GroupID   uint
+		Rating    int
+		CreatedAt time.Time
+	}
+	query.Scan(&rows)
+
+	all := map[ratings.Cell][]int{}
+	set.Raw = map[ratings.Cell]int{}
+	for _, r := range rows {
+		cell := ratings.Cell{GroupID: r.GroupID, Start: ratings.PeriodStart(r.CreatedAt, set.Interval, set.Location)}
+		all[cell] = append(all[cell], r.Rating)
+		set.Raw[cell]++
+	}
+	set.Cells = ratings.Publishable(set.Scale, all, set.Threshold)
+	return set, true
+}
+
+// stats computes the figures of the cells selected by keep. When all of
+// their responses are in cells below the threshold, only the count is
+// given.
+func (set ratingSet) stats(keep func(ratings.Cell) bool) ratings.Stats {
+	var scores []int
+	for cell, s := range set.Cells {
+		if keep(cell) {
+			scores = append(scores, s...)
+		}
+	}
+	if len(scores) > 0 {
+		return ratings.Compute(set.Scale, scores)
+	}
+	raw := 0
+	for cell, n := range set.Raw {
+		if keep(cell) {
+			raw += n
+		}
+	}
+	if raw > 0 {
+		return ratings.Withheld(raw)
+	}
+	return ratings.Compute(set.Scale, nil)
+}
+
+type groupStats struct {
+	GroupID uint   `json:"group_id"`
+	Title   string `json:"title"`
+	ratings.Stats
+}
+
+// Summary reports the stats of all matching ratings and of each group.
+// Only cells with at least the tenant's minimum responses count, see
+// package ratings.
+func Summary(c *gin.Context) {
+	set, ok := loadRatings(c)
+	if !ok {
+		return
+	}
+
+	rated := map[uint]bool{}
+	for cell := range set.Raw {
+		rated[cell.GroupID] = true
+	}
+	var groups []models.Group
+	models.DB.Scopes(db.TenantScope(services.GetTenantID(c))).Order("title, id").Find(&groups)
+	stats := make([]groupStats, 0, len(rated))
+	for _, g := range groups {
+		if rated[g.ID] {
+			id := g.ID
+			stats = append(stats, groupStats{GroupID: id, Title: g.Title, Stats: set.stats(func(cell ratings.Cell) bool { return cell.GroupID == id })})
+		}
+	}
+
+	c.Data(lvn.Res(200, gin.H{
+		"scale":         set.Scale,
+		"interval":      set.Interval,
+		"min_responses": set.Threshold,
+		"overall":       set.stats(func(ratings.Cell) bool { return true }),
+		"groups":        stats,
+	}, ""))
+}
+
+type periodStats struct {
+	Start time.Time `json:"start"`
+	ratings.Stats
+}
+
+// Trend reports the stats of matching ratings per ?interval, in the
+// tenant's timezone. Only cells with at least the tenant's minimum
+// responses count, see package ratings.
+func Trend(c *gin.Context) {
+	set, ok := loadRatings(c)
+	if !ok {
+		return
+	}
+
+	starts := map[time.Time]bool{}
+	for cell := range set.Raw {
+		starts[cell.Start] = true
+	}
+	periods := make([]periodStats, 0, len(starts))
+	for start := range starts {
+		periods = append(periods, periodStats{Start: start, Stats: set.stats(func(cell ratings.Cell) bool { return cell.Start.Equal(start) })})
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+
+	c.Data(lvn.Res(200, gin.H{
+		"scale":         set.Scale,
+		"interval":      set.Interval,
+		"min_responses": set.Threshold,
+		"periods":       periods,
+	}, ""))
+}
@@ -0,0 +1,171 @@
+package svc_rating_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_rating"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stats struct {
+	GroupID      uint      `json:"group_id"`
+	Start        time.Time `json:"start"`
+	Responses    int       `json:"responses"`
+	Suppressed   bool      `json:"suppressed"`
+	Average      *float64  `json:"average"`
+	ENPS         *float64  `json:"enps"`
+	Distribution []int     `json:"distribution"`
+}
+
+func TestRatings_SummaryAndTrend(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "e@example.com", "E User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "E Org", "e-org")
+	models.DB.Model(&tenant).Update("timezone", "UTC")
+	big := models.Group{TenantID: tenant.ID, ChatID: -1, Title: "Big"}
+	mid := models.Group{TenantID: tenant.ID, ChatID: -2, Title: "Mid"}
+	small := models.Group{TenantID: tenant.ID, ChatID: -3, Title: "Small"}
+	models.DB.Create(&big)
+	models.DB.Create(&mid)
+	models.DB.Create(&small)
+
+	week1 := time.Date(2026, 10, 6, 12, 0, 0, 0, time.UTC)
+	week2 := week1.AddDate(0, 0, 7)
+	rate := func(group models.Group, score int, at time.Time) {
+		fb := models.Feedback{TenantID: tenant.ID, GroupID: group.ID, Message: "m", Rating: &score, RatingScale: models.RatingENPS}
+		fb.CreatedAt = at
+		require.NoError(t, models.DB.Create(&fb).Error)
+	}
+	for _, score := range []int{10, 10, 9, 8, 3, 10} {
+		rate(big, score, week1)
+	}
+	for _, score := range []int{7, 7, 7, 7, 7, 2, 9} {
+		rate(mid, score, week2)
+	}
+	rate(small, 0, week2)
+	// Other scales and unrated feedback don't count
+	csat := 4
+	models.DB.Create(&models.Feedback{TenantID: tenant.ID, GroupID: big.ID, Message: "c", Rating: &csat, RatingScale: models.RatingCSAT})
+	models.DB.Create(&models.Feedback{TenantID: tenant.ID, GroupID: big.ID, Message: "none"})
+
+	router := testutil.SetupRouter()
+	router.GET("/ratings/summary", auth.Auth, services.TenantMiddleware, svc_rating.Summary)
+	router.GET("/ratings/trend", auth.Auth, services.TenantMiddleware, svc_rating.Trend)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	w := testutil.DoRequest(router, "GET", "/ratings/summary?scale=nps", nil, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testutil.DoRequest(router, "GET", "/ratings/summary", nil, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var summary struct {
+		Data struct {
+			MinResponses int     `json:"min_responses"`
+			Overall      stats   `json:"overall"`
+			Groups       []stats `json:"groups"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, 5, summary.Data.MinResponses)
+	// Small's lone week is below the minimum, so it is left out everywhere
+	assert.Equal(t, 13, summary.Data.Overall.Responses)
+	require.NotNil(t, summary.Data.Overall.ENPS)
+	assert.InDelta(t, float64(5-2)*100/13, *summary.Data.Overall.ENPS, 0.001)
+
+	require.Len(t, summary.Data.Groups, 3)
+	byGroup := map[uint]stats{}
+	for _, g := range summary.Data.Groups {
+		byGroup[g.GroupID] = g
+	}
+	assert.True(t, byGroup[small.ID].Suppressed)
+	assert.Nil(t, byGroup[small.ID].ENPS)
+	assert.Equal(t, 1, byGroup[small.ID].Responses)
+	assert.False(t, byGroup[big.ID].Suppressed)
+	assert.False(t, byGroup[mid.ID].Suppressed)
+	assert.InDelta(t, 0, *byGroup[mid.ID].ENPS, 0.001)
+
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/ratings/trend?group_id=%d", big.ID), nil, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var trend struct {
+		Data struct {
+			Periods []stats `json:"periods"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &trend))
+	require.Len(t, trend.Data.Periods, 1)
+	assert.Equal(t, time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), trend.Data.Periods[0].Start.UTC())
+	assert.InDelta(t, float64(4-1)*100/6, *trend.Data.Periods[0].ENPS, 0.001)
+
+	w = testutil.DoRequest(router, "GET", "/ratings/trend?interval=day", nil, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = testutil.DoRequest(router, "GET", "/ratings/trend", nil, token)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &trend))
+	require.Len(t, trend.Data.Periods, 2)
+	assert.Equal(t, 6, trend.Data.Periods[0].Responses)
+	assert.Equal(t, 7, trend.Data.Periods[1].Responses)
+
+	// Only whole periods and groups can be selected
+	for _, query := range []string{"search=m", "parent_id=1", "date_from=2026-10-06", "date_to=2026-10-05T12:00:00Z", "interval=month&date_from=2026-10-05"} {
+		w = testutil.DoRequest(router, "GET", "/ratings/summary?"+query, nil, token)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	w = testutil.DoRequest(router, "GET", "/ratings/summary?interval=month&date_from=2026-10-01", nil, token)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+// Two queries that differ by one response must give the same figures, or
+// subtracting them would reveal that response's score.
+func TestRatings_OverlappingQueries(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "o@example.com", "O User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "O Org", "o-org")
+	models.DB.Model(&tenant).Update("timezone", "UTC")
+	group := models.Group{TenantID: tenant.ID, ChatID: -1, Title: "Team"}
+	models.DB.Create(&group)
+
+	week1 := time.Date(2026, 10, 6, 12, 0, 0, 0, time.UTC)
+	rate := func(score int, at time.Time) {
+		fb := models.Feedback{TenantID: tenant.ID, GroupID: group.ID, Message: "m", Rating: &score, RatingScale: models.RatingENPS}
+		fb.CreatedAt = at
+		require.NoError(t, models.DB.Create(&fb).Error)
+	}
+	for _, score := range []int{9, 8, 7, 6, 10} {
+		rate(score, week1)
+	}
+	// The one response to isolate, alone in the next week
+	rate(0, week1.AddDate(0, 0, 7))
+
+	router := testutil.SetupRouter()
+	router.GET("/ratings/summary", auth.Auth, services.TenantMiddleware, svc_rating.Summary)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	overall := func(query string) stats {
+		w := testutil.DoRequest(router, "GET", "/ratings/summary?"+query, nil, token)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var summary struct {
+			Data struct {
+				Overall stats `json:"overall"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+		return summary.Data.Overall
+	}
+
+	narrow := overall("date_from=2026-10-05&date_to=2026-10-12")
+	wide := overall("date_from=2026-10-05&date_to=2026-10-19")
+	all := overall(fmt.Sprintf("group_id=%d", group.ID))
+	assert.Equal(t, 5, narrow.Responses)
+	for _, other := range []stats{wide, all} {
+		assert.Equal(t, narrow.Responses, other.Responses)
+		assert.Equal(t, narrow.Distribution, other.Distribution)
+		assert.Equal(t, *narrow.ENPS, *other.ENPS)
+	}
+}
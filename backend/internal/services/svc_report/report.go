@@ -0,0 +1,219 @@
+package svc_report
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/anonymity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/reports"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// GetSchedules lists the current user's report schedules.
+func GetSchedules(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	var schedules []models.ReportSchedule
+	models.DB.Scopes(db.TenantScope(tenantID)).Where("user_id = ?", services.GetUserID(c)).Order("id").Find(&schedules)
+
+	c.Data(lvn.Res(200, schedules, ""))
+}
+
+type scheduleReq struct {
+	Frequency  *string `json:"frequency"`
+	Hour       *int    `json:"hour"`
+	Weekday    *int    `json:"weekday"`
+	DayOfMonth *int    `json:"day_of_month"`
+	GroupID    *uint   `json:"group_id"` // 0 for all groups
+	Enabled    *bool   `json:"enabled"`
+}
+
+// apply copies req into s and validates it. It returns an error message or "".
+func (req scheduleReq) apply(s *models.ReportSchedule) string {
+	if req.Frequency != nil {
+		s.Frequency = *req.Frequency
+	}
+	if req.Hour != nil {
+		s.Hour = *req.Hour
+	}
+	if req.Weekday != nil {
+		s.Weekday = *req.Weekday
+	}
+	if req.DayOfMonth != nil {
+		s.DayOfMonth = *req.DayOfMonth
+	}
+	if req.Enabled != nil {
+		s.Enabled = *req.Enabled
+	}
+	if req.GroupID != nil {
+		if *req.GroupID == 0 {
+			s.GroupID = nil
+		} else {
+			var group models.Group
+			if err := models.DB.Scopes(db.TenantScope(s.TenantID)).First(&group, *req.GroupID).Error; err != nil {
+				return "Group not found"
+			}
+			s.GroupID = req.GroupID
+		}
+	}
+	if err := reports.Validate(*s); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// schedule sets the next run of s from now in the tenant's timezone.
+func schedule(s *models.ReportSchedule, now time.Time) {
+	var tenant models.Tenant
+	models.DB.Select("id", "timezone").First(&tenant, s.TenantID)
+	s.NextRunAt = reports.NextRun(*s, now, anonymity.Location(tenant.Timezone)).UTC()
+}
+
+func CreateSchedule(c *gin.Context) {
+	var req scheduleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+
+	s := models.ReportSchedule{
+		TenantID:   services.GetTenantID(c),
+		UserID:     services.GetUserID(c),
+		Frequency:  models.ReportWeekly,
+		Hour:       9,
+		Weekday:    int(time.Monday),
+		DayOfMonth: 1,
+		Enabled:    true,
+	}
+	if msg := req.apply(&s); msg != "" {
+		c.Data(lvn.Res(400, "", msg))
+		return
+	}
+	schedule(&s, time.Now())
+
+	if err := models.DB.Create(&s).Error; err != nil {
+		lvn.GinErr(c, 500, err, "Failed to create report schedule")
+		return
+	}
+
+	c.Data(lvn.Res(201, s, ""))
+}
+
+// findSchedule loads one of the current user's schedules, or responds 404.
+func findSchedule(c *gin.Context) (models.ReportSchedule, bool) {
+	var s models.ReportSchedule
+	err := models.DB.Scopes(db.TenantScope(services.GetTenantID(c))).
+		Where("user_id = ?", services.GetUserID(c)).First(&s, c.Param("id")).Error
+	if err != nil {
+		c.Data(lvn.Res(404, "", "Report schedule not found"))
+		return s, false
+	}
+	return s, true
+}
+
+func UpdateSchedule(c *gin.Context) {
+	s, ok := findSchedule(c)
+	if !ok {
+		return
+	}
+
+	var req scheduleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+	if msg := req.apply(&s); msg != "" {
+		c.Data(lvn.Res(400, "", msg))
+		return
+	}
+	schedule(&s, time.Now())
+
+	if err := models.DB.Save(&s).Error; err != nil {
+		lvn.GinErr(c, 500, err, "Failed to update report schedule")
+		return
+	}
+
+	c.Data(lvn.Res(200, s, ""))
+}
+
+func DeleteSchedule(c *gin.Context) {
+	s, ok := findSchedule(c)
+	if !ok {
+		return
+	}
+
+	if err := models.DB.Delete(&s).Error; err != nil {
+		lvn.GinErr(c, 500, err, "Failed to delete report schedule")
+		return
+	}
+
+	c.Data(lvn.Res(200, "", "Report schedule deleted"))
+}
+
+// SendNow emails a schedule's report for the period up to today, without
+// changing when it next runs.
+func SendNow(c *gin.Context) {
+	s, ok := findSchedule(c)
+	if !ok {
+		return
+	}
+
+	var tenant models.Tenant
+	models.DB.First(&tenant, s.TenantID)
+	if err := reports.Send(s, tenant, time.Now()); err != nil {
+		lvn.GinErr(c, 500, err, "Failed to send report")
+		return
+	}
+
+	c.Data(lvn.Res(200, "", "Report sent"))
+}
+
+// Preview renders the report a schedule with ?frequency= and ?group_id=
+// would send today, as JSON or with ?format=html or pdf.
+func Preview(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	s := models.ReportSchedule{TenantID: tenantID, Frequency: c.DefaultQuery("frequency", models.ReportWeekly), DayOfMonth: 1}
+	var req scheduleReq
+	if id := c.Query("group_id"); id != "" {
+		groupID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			c.Data(lvn.Res(400, "", "Invalid group_id"))
+			return
+		}
+		g := uint(groupID)
+		req.GroupID = &g
+	}
+	if msg := req.apply(&s); msg != "" {
+		c.Data(lvn.Res(400, "", msg))
+		return
+	}
+
+	var tenant models.Tenant
+	models.DB.First(&tenant, tenantID)
+	report := reports.Build(s, tenant, time.Now())
+
+	switch c.Query("format") {
+	case "html":
+		html, err := report.HTML()
+		if err != nil {
+			lvn.GinErr(c, 500, err, "Failed to render report")
+			return
+		}
+		c.Data(200, "text/html; charset=utf-8", []byte(html))
+	case "pdf":
+		doc, err := report.PDF()
+		if err != nil {
+			lvn.GinErr(c, 500, err, "Failed to render report")
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="`+report.FileName()+`"`)
+		c.Data(200, "application/pdf", doc)
+	default:
+		c.Data(lvn.Res(200, report, ""))
+	}
+}
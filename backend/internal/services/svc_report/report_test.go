@@ -0,0 +1,141 @@
+package svc_report_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/mailer"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_report"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupRouter() *gin.Engine {
+	router := testutil.SetupRouter()
+	reports := router.Group("/reports", auth.Auth, services.TenantMiddleware)
+	reports.GET("/preview", svc_report.Preview)
+	reports.GET("/schedules", svc_report.GetSchedules)
+	reports.POST("/schedules", svc_report.CreateSchedule)
+	reports.PATCH("/schedules/:id", svc_report.UpdateSchedule)
+	reports.DELETE("/schedules/:id", svc_report.DeleteSchedule)
+	reports.POST("/schedules/:id/send", svc_report.SendNow)
+	return router
+}
+
+type recorder struct{ sent []mailer.Message }
+
+func (r *recorder) Send(msg mailer.Message) error {
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func TestSchedules_CRUD(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "r@example.com", "R User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "R Org", "r-org")
+	group := models.Group{TenantID: tenant.ID, ChatID: -1, Title: "Team"}
+	models.DB.Create(&group)
+	router := setupRouter()
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	w := testutil.DoRequest(router, "POST", "/reports/schedules", map[string]interface{}{"frequency": "hourly"}, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = testutil.DoRequest(router, "POST", "/reports/schedules", map[string]interface{}{"group_id": 999}, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testutil.DoRequest(router, "POST", "/reports/schedules", map[string]interface{}{"group_id": group.ID}, token)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created models.ReportSchedule
+	models.DB.Last(&created)
+	assert.Equal(t, models.ReportWeekly, created.Frequency)
+	assert.Equal(t, user.ID, created.UserID)
+	assert.True(t, created.Enabled)
+	assert.Equal(t, time.Monday, created.NextRunAt.In(time.FixedZone("", 5*60*60)).Weekday())
+	assert.True(t, created.NextRunAt.After(time.Now()))
+
+	w = testutil.DoRequest(router, "PATCH", fmt.Sprintf("/reports/schedules/%d", created.ID),
+		map[string]interface{}{"frequency": "monthly", "day_of_month": 15, "hour": 7, "group_id": 0}, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated models.ReportSchedule
+	models.DB.First(&updated, created.ID)
+	assert.Nil(t, updated.GroupID)
+	assert.Equal(t, 15, updated.NextRunAt.In(time.FixedZone("", 5*60*60)).Day())
+
+	// Another user's schedules are out of reach
+	other := testutil.CreateTestUser(t, "o@example.com", "O User")
+	models.DB.Create(&models.UserTenant{UserID: other.ID, TenantID: tenant.ID})
+	otherToken := testutil.GenerateTestToken(other.ID, other.Email, other.Name, other.Role, tenant.ID)
+	w = testutil.DoRequest(router, "DELETE", fmt.Sprintf("/reports/schedules/%d", created.ID), nil, otherToken)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = testutil.DoRequest(router, "GET", "/reports/schedules", nil, otherToken)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Empty(t, resp["data"])
+
+	w = testutil.DoRequest(router, "GET", "/reports/schedules", nil, token)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp["data"], 1)
+
+	w = testutil.DoRequest(router, "DELETE", fmt.Sprintf("/reports/schedules/%d", created.ID), nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Error(t, models.DB.First(&models.ReportSchedule{}, created.ID).Error)
+}
+
+func TestSendNowAndPreview(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "p@example.com", "P User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "P Org", "p-org")
+	group := models.Group{TenantID: tenant.ID, ChatID: -1, Title: "Team"}
+	models.DB.Create(&group)
+	twoDaysAgo := time.Now().AddDate(0, 0, -2)
+	models.DB.Create(&models.Feedback{TenantID: tenant.ID, GroupID: group.ID, Message: "needs review",
+		Moderation: models.ModerationPending, Model: gorm.Model{CreatedAt: twoDaysAgo}})
+	router := setupRouter()
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	rec := &recorder{}
+	prev := mailer.SetSender(rec)
+	defer mailer.SetSender(prev)
+
+	s := models.ReportSchedule{TenantID: tenant.ID, UserID: user.ID, Frequency: models.ReportWeekly, DayOfMonth: 1, Enabled: true}
+	models.DB.Create(&s)
+	w := testutil.DoRequest(router, "POST", fmt.Sprintf("/reports/schedules/%d/send", s.ID), nil, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, rec.sent, 1)
+	assert.Equal(t, []string{"p@example.com"}, rec.sent[0].To)
+	assert.Contains(t, rec.sent[0].HTML, "needs review")
+
+	w = testutil.DoRequest(router, "GET", "/reports/preview?frequency=weekly", nil, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data struct {
+			Received        struct{ Current int64 } `json:"received"`
+			UnresolvedCount int64                   `json:"unresolved_count"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(1), resp.Data.Received.Current)
+	assert.Equal(t, int64(1), resp.Data.UnresolvedCount)
+
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/reports/preview?format=pdf&group_id=%d", group.ID), nil, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "%PDF-"))
+
+	w = testutil.DoRequest(router, "GET", "/reports/preview?format=html&frequency=daily", nil, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Daily feedback report")
+
+	w = testutil.DoRequest(router, "GET", "/reports/preview?frequency=yearly", nil, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,35 @@
+package svc_retention
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/retention"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// Preview is a dry run of the purge job for the caller's tenant: what would
+// be deleted if it ran now.
+func Preview(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+	c.Data(lvn.Res(200, retention.Preview(tenantID, time.Now()), ""))
+}
+
+// GetPurgeLogs lists past purges, newest first.
+func GetPurgeLogs(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	var logs []models.PurgeLog
+	models.DB.Scopes(db.TenantScope(tenantID)).Order("created_at DESC").Limit(limit).Find(&logs)
+
+	c.Data(lvn.Res(200, logs, ""))
+}
@@ -0,0 +1,55 @@
+package svc_retention_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/retention"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_retention"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestPreviewAndLogs(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "ret@example.com", "Ret User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Ret Org", "ret-org")
+	models.DB.Model(&tenant).Update("retention_months", 3)
+
+	group := models.Group{TenantID: tenant.ID, BotID: 1, ChatID: -100777, Title: "Ret Group", IsActive: true}
+	models.DB.Create(&group)
+	models.DB.Create(&models.FeedbackConfig{GroupID: group.ID})
+	old := time.Now().AddDate(0, -4, 0)
+	models.DB.Create(&models.Feedback{TenantID: tenant.ID, GroupID: group.ID, SenderID: 1, Message: "old",
+		Model: gorm.Model{CreatedAt: old, UpdatedAt: old}})
+
+	router := testutil.SetupRouter()
+	router.GET("/retention/preview", auth.Auth, services.TenantMiddleware, svc_retention.Preview)
+	router.GET("/retention/logs", auth.Auth, services.TenantMiddleware, svc_retention.GetPurgeLogs)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	w := testutil.DoRequest(router, "GET", "/retention/preview", nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, true, data["dry_run"])
+	assert.Equal(t, float64(1), data["feedbacks"])
+
+	_, err := retention.PurgeTenant(tenant.ID, time.Now())
+	require.NoError(t, err)
+
+	w = testutil.DoRequest(router, "GET", "/retention/logs", nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	logs := resp["data"].([]interface{})
+	require.Len(t, logs, 1)
+	assert.Equal(t, float64(1), logs[0].(map[string]interface{})["feedbacks"])
+}
@@ -0,0 +1,49 @@
+package svc_retro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/anonymity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+)
+
+// Markdown renders a revealed session: a section per column with its notes
+// as a list, most dots first when the session had dot voting. Times are in
+// the tenant timezone.
+func Markdown(s models.RetroSession, groupTitle string, items []models.RetroItem, timezone string) string {
+	loc := anonymity.Location(timezone)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", s.Title)
+	fmt.Fprintf(&b, "%s · %s – %s\n", groupTitle,
+		s.StartsAt.In(loc).Format("2006-01-02 15:04"), s.EndsAt.In(loc).Format("2006-01-02 15:04"))
+
+	columns := make([][]models.RetroItem, len(s.Columns))
+	for _, item := range items {
+		if item.Column >= 0 && item.Column < len(columns) {
+			columns[item.Column] = append(columns[item.Column], item)
+		}
+	}
+	for c, name := range s.Columns {
+		fmt.Fprintf(&b, "\n## %s\n\n", name)
+		notes := columns[c]
+		if len(notes) == 0 {
+			b.WriteString("_No notes._\n")
+			continue
+		}
+		if s.DotVoting {
+			sort.SliceStable(notes, func(i, j int) bool { return notes[i].Dots > notes[j].Dots })
+		}
+		for _, item := range notes {
+			// Continuation lines are indented to stay in the list item
+			text := strings.ReplaceAll(strings.TrimSpace(item.Message), "\n", "\n  ")
+			if s.DotVoting {
+				fmt.Fprintf(&b, "- %s (●%d)\n", text, item.Dots)
+			} else {
+				fmt.Fprintf(&b, "- %s\n", text)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,338 @@
+package svc_retro
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/tgbot"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxRetroTitleLen = 200
+	maxColumns       = 6
+	maxColumnLen     = 50
+	maxRetroDuration = 14 * 24 * time.Hour
+	maxDotsPerMember = 10
+	defaultDots      = 3
+)
+
+// RetroResponse is a session with how many notes it has. Notes themselves
+// are only shown once the session is revealed.
+type RetroResponse struct {
+	models.RetroSession
+	Notes int64       `json:"notes"`
+	Items []RetroNote `json:"items,omitempty"`
+}
+
+// RetroNote is a revealed note, without the times it was written or
+// changed, which could tell who wrote it.
+type RetroNote struct {
+	ID       uint   `json:"id"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+	Position int    `json:"position"`
+	Dots     int    `json:"dots"`
+}
+
+func noteCounts(ids []uint) map[uint]int64 {
+	var rows []struct {
+		SessionID uint
+		Count     int64
+	}
+	models.DB.Model(&models.RetroItem{}).Select("session_id, COUNT(*) AS count").
+		Where("session_id IN ?", ids).Group("session_id").Scan(&rows)
+	counts := make(map[uint]int64, len(rows))
+	for _, r := range rows {
+		counts[r.SessionID] = r.Count
+	}
+	return counts
+}
+
+// GetRetros lists the tenant's retro sessions, latest first, optionally for
+// one ?group_id.
+func GetRetros(c *gin.Context) {
+	query := models.DB.Scopes(db.TenantScope(services.GetTenantID(c)))
+	if groupID := c.Query("group_id"); groupID != "" {
+		query = query.Where("group_id = ?", groupID)
+	}
+
+	var sessions []models.RetroSession
+	query.Order("starts_at DESC, id DESC").Find(&sessions)
+
+	ids := make([]uint, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.ID
+	}
+	counts := noteCounts(ids)
+	resp := make([]RetroResponse, len(sessions))
+	for i, s := range sessions {
+		resp[i] = RetroResponse{RetroSession: s, Notes: counts[s.ID]}
+	}
+
+	c.Data(lvn.Res(200, resp, ""))
+}
+
+// findRetro loads one of the tenant's retro sessions, or responds 404.
+func findRetro(c *gin.Context) (models.RetroSession, bool) {
+	var s models.RetroSession
+	if err := models.DB.Scopes(db.TenantScope(services.GetTenantID(c))).First(&s, c.Param("id")).Error; err != nil {
+		c.Data(lvn.Res(404, "", "Retro not found"))
+		return s, false
+	}
+	return s, true
+}
+
+// revealedItems returns the notes of a closed session by column, then by
+// their number in the group.
+func revealedItems(s models.RetroSession) []models.RetroItem {
+	var items []models.RetroItem
+	models.DB.Where("session_id = ?", s.ID).Order("column_index, position, id").Find(&items)
+	return items
+}
+
+// GetRetro returns a session, with its notes once it is revealed.
+func GetRetro(c *gin.Context) {
+	s, ok := findRetro(c)
+	if !ok {
+		return
+	}
+	resp := RetroResponse{RetroSession: s, Notes: noteCounts([]uint{s.ID})[s.ID]}
+	if s.ClosedAt != nil {
+		items := revealedItems(s)
+		resp.Items = make([]RetroNote, len(items))
+		for i, item := range items {
+			resp.Items[i] = RetroNote{ID: item.ID, Column: item.Column, Message: item.Message, Position: item.Position, Dots: item.Dots}
+		}
+	}
+	c.Data(lvn.Res(200, resp, ""))
+}
+
+type retroReq struct {
+	GroupID       *uint      `json:"group_id"`
+	Title         *string    `json:"title"`
+	Columns       []string   `json:"columns"` // Defaults to went well, to improve, action items
+	StartsAt      *time.Time `json:"starts_at"`
+	EndsAt        *time.Time `json:"ends_at"`
+	DotVoting     *bool      `json:"dot_voting"`
+	DotsPerMember *int       `json:"dots_per_member"`
+}
+
+// apply copies req into s and validates it. It returns an error message or
+// "".
+func (req retroReq) apply(s *models.RetroSession) string {
+	if req.GroupID != nil {
+		var group models.Group
+		if err := models.DB.Scopes(db.TenantScope(s.TenantID)).First(&group, *req.GroupID).Error; err != nil {
+			return "Group not found"
+		}
+		s.GroupID = group.ID
+	}
+	if req.Title != nil {
+		s.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Columns != nil {
+		s.Columns = make([]string, len(req.Columns))
+		for i, column := range req.Columns {
+			s.Columns[i] = strings.TrimSpace(column)
+		}
+	}
+	if req.StartsAt != nil {
+		s.StartsAt = req.StartsAt.UTC()
+	}
+	if req.EndsAt != nil {
+		s.EndsAt = req.EndsAt.UTC()
+	}
+	if req.DotVoting != nil {
+		s.DotVoting = *req.DotVoting
+	}
+	if req.DotsPerMember != nil {
+		s.DotsPerMember = *req.DotsPerMember
+	}
+
+	switch {
+	case s.GroupID == 0:
+		return "group_id is required"
+	case s.Title == "" || utf8.RuneCountInString(s.Title) > maxRetroTitleLen:
+		return fmt.Sprintf("title must be 1-%d characters", maxRetroTitleLen)
+	case len(s.Columns) == 0 || len(s.Columns) > maxColumns:
+		return fmt.Sprintf("a retro needs 1-%d columns", maxColumns)
+	case s.StartsAt.IsZero() || s.EndsAt.IsZero():
+		return "starts_at and ends_at are required"
+	case !s.EndsAt.After(s.StartsAt):
+		return "ends_at must be after starts_at"
+	case s.EndsAt.Sub(s.StartsAt) > maxRetroDuration:
+		return fmt.Sprintf("a retro can run for at most %d days", int(maxRetroDuration.Hours()/24))
+	case !s.EndsAt.After(time.Now()):
+		return "ends_at must be in the future"
+	case s.DotsPerMember < 1 || s.DotsPerMember > maxDotsPerMember:
+		return fmt.Sprintf("dots_per_member must be between 1 and %d", maxDotsPerMember)
+	}
+	for i, column := range s.Columns {
+		if column == "" || utf8.RuneCountInString(column) > maxColumnLen {
+			return fmt.Sprintf("column %d must be 1-%d characters", i+1, maxColumnLen)
+		}
+	}
+	return ""
+}
+
+// overlaps reports whether another session of s's group that hasn't ended
+// runs at the same time; DMs could not tell them apart.
+func overlaps(s models.RetroSession) bool {
+	var n int64
+	models.DB.Model(&models.RetroSession{}).
+		Where("group_id = ? AND id <> ? AND closed_at IS NULL AND starts_at < ? AND ends_at > ?", s.GroupID, s.ID, s.EndsAt, s.StartsAt).
+		Count(&n)
+	return n > 0
+}
+
+func CreateRetro(c *gin.Context) {
+	var req retroReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+
+	tenantID := services.GetTenantID(c)
+	s := models.RetroSession{
+		TenantID:      tenantID,
+		Columns:       tgbot.DefaultRetroColumns(tenantID),
+		DotsPerMember: defaultDots,
+	}
+	if msg := req.apply(&s); msg != "" {
+		c.Data(lvn.Res(400, "", msg))
+		return
+	}
+	if overlaps(s) {
+		c.Data(lvn.Res(409, "", "The group already has a retro at that time"))
+		return
+	}
+
+	if err := models.DB.Create(&s).Error; err != nil {
+		lvn.GinErr(c, 500, err, "Failed to create retro")
+		return
+	}
+
+	c.Data(lvn.Res(201, s, ""))
+}
+
+// UpdateRetro changes a session until it is revealed. Once it has started
+// taking notes, its group, columns and start stay as they are.
+func UpdateRetro(c *gin.Context) {
+	s, ok := findRetro(c)
+	if !ok {
+		return
+	}
+
+	var req retroReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+	if s.ClosedAt != nil {
+		c.Data(lvn.Res(409, "", "The retro has ended"))
+		return
+	}
+	if !time.Now().Before(s.StartsAt) && (req.GroupID != nil || req.Columns != nil || req.StartsAt != nil) {
+		c.Data(lvn.Res(409, "", "The group, columns and start can't change once the retro has started"))
+		return
+	}
+	if msg := req.apply(&s); msg != "" {
+		c.Data(lvn.Res(400, "", msg))
+		return
+	}
+	if overlaps(s) {
+		c.Data(lvn.Res(409, "", "The group already has a retro at that time"))
+		return
+	}
+
+	if err := models.DB.Save(&s).Error; err != nil {
+		lvn.GinErr(c, 500, err, "Failed to update retro")
+		return
+	}
+
+	c.Data(lvn.Res(200, s, ""))
+}
+
+// DeleteRetro deletes a session with its notes and dots. An open session
+// is deleted without revealing anything.
+func DeleteRetro(c *gin.Context) {
+	s, ok := findRetro(c)
+	if !ok {
+		return
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", s.ID).Delete(&models.RetroDot{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("session_id = ?", s.ID).Delete(&models.RetroItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&s).Error
+	})
+	if err != nil {
+		lvn.GinErr(c, 500, err, "Failed to delete retro")
+		return
+	}
+
+	c.Data(lvn.Res(200, "", "Retro deleted"))
+}
+
+// CloseRetro ends an open session now and reveals its notes in the group.
+// For a session that ended without being revealed, it retries the reveal.
+func CloseRetro(c *gin.Context) {
+	s, ok := findRetro(c)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	switch {
+	case s.RevealedAt != nil:
+		c.Data(lvn.Res(409, "", "The retro has already been revealed"))
+		return
+	case s.ClosedAt == nil && now.Before(s.StartsAt):
+		c.Data(lvn.Res(409, "", "The retro hasn't started; delete it instead"))
+		return
+	}
+
+	err := tgbot.CloseRetro(&s, now)
+	if err == tgbot.ErrGroupInactive {
+		c.Data(lvn.Res(409, "", "The bot is no longer in this retro's group; it will be revealed if the bot is added back"))
+		return
+	}
+	if err != nil {
+		lvn.GinErr(c, 502, err, "Retro closed, but revealing it in the group failed; it will be retried")
+		return
+	}
+
+	c.Data(lvn.Res(200, s, ""))
+}
+
+// ExportRetro downloads a revealed session as markdown.
+func ExportRetro(c *gin.Context) {
+	s, ok := findRetro(c)
+	if !ok {
+		return
+	}
+	if s.ClosedAt == nil {
+		c.Data(lvn.Res(409, "", "The retro hasn't ended yet"))
+		return
+	}
+
+	var group models.Group
+	models.DB.Select("id", "title").First(&group, s.GroupID)
+	var tenant models.Tenant
+	models.DB.Select("id", "timezone").First(&tenant, s.TenantID)
+
+	doc := Markdown(s, group.Title, revealedItems(s), tenant.Timezone)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=retro_%d.md", s.ID))
+	c.Data(200, "text/markdown; charset=utf-8", []byte(doc))
+}
@@ -0,0 +1,198 @@
+package svc_retro_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_retro"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRouter() *gin.Engine {
+	router := testutil.SetupRouter()
+	retros := router.Group("/retros", auth.Auth, services.TenantMiddleware)
+	retros.GET("", svc_retro.GetRetros)
+	retros.POST("", svc_retro.CreateRetro)
+	retros.GET("/:id", svc_retro.GetRetro)
+	retros.PATCH("/:id", svc_retro.UpdateRetro)
+	retros.DELETE("/:id", svc_retro.DeleteRetro)
+	retros.POST("/:id/close", svc_retro.CloseRetro)
+	retros.GET("/:id/export", svc_retro.ExportRetro)
+	return router
+}
+
+func setup(t *testing.T) (*gin.Engine, string, models.Group) {
+	t.Helper()
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "r@example.com", "R User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "R Org", "r-org")
+	models.DB.Model(&tenant).Update("timezone", "UTC")
+	group := models.Group{TenantID: tenant.ID, ChatID: -1, Title: "Team", IsActive: true}
+	models.DB.Create(&group)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	return setupRouter(), token, group
+}
+
+func retro(groupID uint, starts, ends time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"group_id":  groupID,
+		"title":     "Sprint 12",
+		"starts_at": starts,
+		"ends_at":   ends,
+	}
+}
+
+func TestRetros_CreateAndValidate(t *testing.T) {
+	router, token, group := setup(t)
+	now := time.Now()
+
+	invalid := []map[string]interface{}{
+		{"group_id": group.ID, "title": "No times"},
+		retro(999, now, now.Add(time.Hour)),
+		retro(group.ID, now, now.Add(-time.Hour)),
+		retro(group.ID, now, now.Add(15*24*time.Hour)),
+		retro(group.ID, now.Add(-2*time.Hour), now.Add(-time.Hour)),
+	}
+	tooMany := retro(group.ID, now, now.Add(time.Hour))
+	tooMany["columns"] = []string{"a", "b", "c", "d", "e", "f", "g"}
+	blank := retro(group.ID, now, now.Add(time.Hour))
+	blank["columns"] = []string{"Good", " "}
+	dots := retro(group.ID, now, now.Add(time.Hour))
+	dots["dots_per_member"] = 0
+	invalid = append(invalid, tooMany, blank, dots)
+	for _, body := range invalid {
+		w := testutil.DoRequest(router, "POST", "/retros", body, token)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	w := testutil.DoRequest(router, "POST", "/retros", retro(group.ID, now.Add(time.Hour), now.Add(2*time.Hour)), token)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var s models.RetroSession
+	models.DB.Last(&s)
+	assert.Equal(t, []string{"Went well", "To improve", "Action items"}, s.Columns)
+	assert.Equal(t, 3, s.DotsPerMember)
+	assert.False(t, s.DotVoting)
+
+	// Sessions of one group can't overlap
+	w = testutil.DoRequest(router, "POST", "/retros", retro(group.ID, now.Add(90*time.Minute), now.Add(3*time.Hour)), token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = testutil.DoRequest(router, "POST", "/retros", retro(group.ID, now.Add(2*time.Hour), now.Add(3*time.Hour)), token)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Before it starts, anything can change
+	w = testutil.DoRequest(router, "PATCH", fmt.Sprintf("/retros/%d", s.ID), map[string]interface{}{"columns": []string{"Keep", "Drop"}}, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/retros?group_id=%d", group.ID), nil, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp["data"], 2)
+
+	// Another tenant can't see it
+	other := testutil.CreateTestUser(t, "o@example.com", "O User")
+	otherTenant := testutil.CreateTestTenant(t, other.ID, "O Org", "o-org")
+	otherToken := testutil.GenerateTestToken(other.ID, other.Email, other.Name, other.Role, otherTenant.ID)
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/retros/%d", s.ID), nil, otherToken)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRetros_NotesHiddenUntilRevealed(t *testing.T) {
+	router, token, group := setup(t)
+	now := time.Now()
+	s := models.RetroSession{
+		TenantID: group.TenantID, GroupID: group.ID, Title: "Sprint 12", Columns: []string{"Went well", "To improve"},
+		StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), DotsPerMember: 3,
+	}
+	models.DB.Create(&s)
+	models.DB.Create(&models.RetroItem{TenantID: s.TenantID, SessionID: s.ID, Column: 0, Message: "Pairing"})
+
+	get := func() map[string]interface{} {
+		w := testutil.DoRequest(router, "GET", fmt.Sprintf("/retros/%d", s.ID), nil, token)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Data
+	}
+	data := get()
+	assert.EqualValues(t, 1, data["notes"])
+	assert.Nil(t, data["items"])
+
+	w := testutil.DoRequest(router, "GET", fmt.Sprintf("/retros/%d/export", s.ID), nil, token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Once started, columns are fixed but the end can move
+	w = testutil.DoRequest(router, "PATCH", fmt.Sprintf("/retros/%d", s.ID), map[string]interface{}{"columns": []string{"Other"}}, token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = testutil.DoRequest(router, "PATCH", fmt.Sprintf("/retros/%d", s.ID), map[string]interface{}{"ends_at": now.Add(2 * time.Hour)}, token)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// The bot left the group: the session still stops taking notes
+	models.DB.Model(&group).Update("is_active", false)
+	w = testutil.DoRequest(router, "POST", fmt.Sprintf("/retros/%d/close", s.ID), nil, token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	data = get()
+	assert.NotNil(t, data["closed_at"])
+	assert.Nil(t, data["revealed_at"])
+	require.Len(t, data["items"], 1)
+	note := data["items"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "Pairing", note["message"])
+	for _, key := range []string{"CreatedAt", "createdAt", "created_at", "UpdatedAt", "updatedAt", "updated_at"} {
+		assert.NotContains(t, note, key)
+	}
+
+	w = testutil.DoRequest(router, "POST", fmt.Sprintf("/retros/%d/close", s.ID), nil, token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = testutil.DoRequest(router, "PATCH", fmt.Sprintf("/retros/%d", s.ID), map[string]interface{}{"title": "x"}, token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = testutil.DoRequest(router, "DELETE", fmt.Sprintf("/retros/%d", s.ID), nil, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	var items int64
+	models.DB.Model(&models.RetroItem{}).Count(&items)
+	assert.Zero(t, items)
+}
+
+func TestRetros_ExportMarkdown(t *testing.T) {
+	router, token, group := setup(t)
+	starts := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	closed := starts.Add(time.Hour)
+	s := models.RetroSession{
+		TenantID: group.TenantID, GroupID: group.ID, Title: "Sprint 12", Columns: []string{"Went well", "To improve", "Action items"},
+		StartsAt: starts, EndsAt: closed, ClosedAt: &closed, DotVoting: true, DotsPerMember: 3,
+	}
+	models.DB.Create(&s)
+	for _, item := range []models.RetroItem{
+		{Column: 0, Position: 1, Message: "Pairing", Dots: 1},
+		{Column: 0, Position: 2, Message: "Demo went\nsmoothly", Dots: 4},
+		{Column: 1, Position: 1, Message: "Flaky CI", Dots: 2},
+	} {
+		item.TenantID, item.SessionID = s.TenantID, s.ID
+		models.DB.Create(&item)
+	}
+
+	w := testutil.DoRequest(router, "GET", fmt.Sprintf("/retros/%d/export", s.ID), nil, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/markdown")
+	assert.Contains(t, w.Header().Get("Content-Disposition"), fmt.Sprintf("retro_%d.md", s.ID))
+	assert.Equal(t, "# Sprint 12\n\n"+
+		"Team · 2026-10-16 14:00 – 2026-10-16 15:00\n\n"+
+		"## Went well\n\n"+
+		"- Demo went\n  smoothly (●4)\n"+
+		"- Pairing (●1)\n\n"+
+		"## To improve\n\n"+
+		"- Flaky CI (●2)\n\n"+
+		"## Action items\n\n"+
+		"_No notes._\n", w.Body.String())
+}
@@ -0,0 +1,126 @@
+package svc_survey
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// maxResultRounds is how many recent rounds GetResults returns by default.
+const maxResultRounds = 12
+
+type OptionResult struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+type QuestionResult struct {
+	QuestionID uint           `json:"question_id"`
+	Text       string         `json:"text"`
+	Kind       string         `json:"kind"`
+	Responses  int            `json:"responses"`
+	Options    []OptionResult `json:"options"`
+	Average    *float64       `json:"average,omitempty"` // Scale questions with responses
+}
+
+type RoundResult struct {
+	RoundID   uint             `json:"round_id"`
+	StartedAt time.Time        `json:"started_at"`
+	ClosesAt  time.Time        `json:"closes_at"`
+	Closed    bool             `json:"closed"`
+	Questions []QuestionResult `json:"questions"`
+}
+
+type Results struct {
+	SurveyID uint          `json:"survey_id"`
+	Title    string        `json:"title"`
+	Delivery string        `json:"delivery"`
+	Rounds   []RoundResult `json:"rounds"` // Newest first
+}
+
+// GetResults aggregates the answers of a survey's recent rounds, or of one
+// ?round_id.
+func GetResults(c *gin.Context) {
+	s, ok := findSurvey(c)
+	if !ok {
+		return
+	}
+
+	query := models.DB.Scopes(db.TenantScope(s.TenantID)).Where("survey_id = ?", s.ID)
+	if id := c.Query("round_id"); id != "" {
+		roundID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			c.Data(lvn.Res(400, "", "Invalid round_id"))
+			return
+		}
+		query = query.Where("id = ?", roundID)
+	}
+	var rounds []models.SurveyRound
+	query.Order("created_at DESC, id DESC").Limit(maxResultRounds).Find(&rounds)
+
+	results := Results{SurveyID: s.ID, Title: s.Title, Delivery: s.Delivery, Rounds: []RoundResult{}}
+	for _, round := range rounds {
+		results.Rounds = append(results.Rounds, roundResult(round, s.Questions))
+	}
+
+	c.Data(lvn.Res(200, results, ""))
+}
+
+// roundResult counts a round's answers per option, from the final or latest
+// poll counts or from the DM answers.
+func roundResult(round models.SurveyRound, questions []models.SurveyQuestion) RoundResult {
+	result := RoundResult{
+		RoundID:   round.ID,
+		StartedAt: round.CreatedAt,
+		ClosesAt:  round.ClosesAt,
+		Closed:    round.ClosedAt != nil,
+		Questions: make([]QuestionResult, 0, len(questions)),
+	}
+
+	var polls []models.SurveyPoll
+	models.DB.Where("round_id = ?", round.ID).Find(&polls)
+	pollCounts := map[uint][]int{}
+	for _, sp := range polls {
+		pollCounts[sp.QuestionID] = sp.Counts
+	}
+
+	var answers []struct {
+		QuestionID uint
+		Choice     int
+		Count      int
+	}
+	models.DB.Model(&models.SurveyAnswer{}).
+		Select("question_id, choice, COUNT(*) AS count").
+		Where("round_id = ?", round.ID).
+		Group("question_id, choice").
+		Scan(&answers)
+
+	for _, q := range questions {
+		labels := q.Choices()
+		counts := make([]int, len(labels))
+		copy(counts, pollCounts[q.ID])
+		for _, a := range answers {
+			if a.QuestionID == q.ID && a.Choice < len(counts) {
+				counts[a.Choice] += a.Count
+			}
+		}
+
+		qr := QuestionResult{QuestionID: q.ID, Text: q.Text, Kind: q.Kind, Options: make([]OptionResult, len(labels))}
+		sum := 0
+		for i, label := range labels {
+			qr.Options[i] = OptionResult{Label: label, Count: counts[i]}
+			qr.Responses += counts[i]
+			sum += (i + 1) * counts[i]
+		}
+		if q.Kind == models.QuestionScale && qr.Responses > 0 {
+			average := float64(sum) / float64(qr.Responses)
+			qr.Average = &average
+		}
+		result.Questions = append(result.Questions, qr)
+	}
+	return result
+}
@@ -0,0 +1,320 @@
+package svc_survey
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/tgbot"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Limits follow what Telegram accepts for a poll.
+const (
+	maxQuestions      = 10
+	maxQuestionLen    = 300
+	maxOptions        = 10
+	maxOptionLen      = 100
+	defaultScaleMax   = 5
+	maxScale          = 10
+	maxOpenHours      = 30 * 24
+	defaultOpenHours  = 24
+	maxSurveyTitleLen = 200
+)
+
+func withQuestions(db *gorm.DB) *gorm.DB {
+	return db.Preload("Questions", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") })
+}
+
+// GetSurveys lists the tenant's surveys, optionally for one ?group_id.
+func GetSurveys(c *gin.Context) {
+	query := models.DB.Scopes(db.TenantScope(services.GetTenantID(c)), withQuestions)
+	if groupID := c.Query("group_id"); groupID != "" {
+		query = query.Where("group_id = ?", groupID)
+	}
+
+	var surveys []models.Survey
+	query.Order("id").Find(&surveys)
+
+	c.Data(lvn.Res(200, surveys, ""))
+}
+
+// findSurvey loads one of the tenant's surveys, or responds 404.
+func findSurvey(c *gin.Context) (models.Survey, bool) {
+	var s models.Survey
+	err := models.DB.Scopes(db.TenantScope(services.GetTenantID(c)), withQuestions).First(&s, c.Param("id")).Error
+	if err != nil {
+		c.Data(lvn.Res(404, "", "Survey not found"))
+		return s, false
+	}
+	return s, true
+}
+
+func GetSurvey(c *gin.Context) {
+	s, ok := findSurvey(c)
+	if !ok {
+		return
+	}
+	c.Data(lvn.Res(200, s, ""))
+}
+
+type questionReq struct {
+	Text     string   `json:"text"`
+	Kind     string   `json:"kind"`
+	ScaleMax int      `json:"scale_max"` // Scale questions, defaults to 5
+	Options  []string `json:"options"`   // Choice questions
+}
+
+type surveyReq struct {
+	GroupID   *uint         `json:"group_id"`
+	Title     *string       `json:"title"`
+	Delivery  *string       `json:"delivery"`
+	Schedule  *string       `json:"schedule"`  // Cron expression, "" to send once at starts_at
+	StartsAt  *time.Time    `json:"starts_at"` // One-off surveys
+	OpenHours *int          `json:"open_hours"`
+	Active    *bool         `json:"active"`
+	Questions []questionReq `json:"questions"` // Replaces all questions when set
+}
+
+// apply copies req into s and validates it. Questions are returned
+// separately, as they are saved once s has an ID. It returns an error
+// message or "".
+func (req surveyReq) apply(s *models.Survey) ([]models.SurveyQuestion, string) {
+	if req.GroupID != nil {
+		var group models.Group
+		if err := models.DB.Scopes(db.TenantScope(s.TenantID)).First(&group, *req.GroupID).Error; err != nil {
+			return nil, "Group not found"
+		}
+		s.GroupID = group.ID
+	}
+	if req.Title != nil {
+		s.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Delivery != nil {
+		s.Delivery = *req.Delivery
+	}
+	if req.Schedule != nil {
+		s.Schedule = strings.TrimSpace(*req.Schedule)
+	}
+	if req.StartsAt != nil {
+		startsAt := req.StartsAt.UTC()
+		s.StartsAt = &startsAt
+	}
+	if req.OpenHours != nil {
+		s.OpenHours = *req.OpenHours
+	}
+	if req.Active != nil {
+		s.Active = *req.Active
+	}
+
+	switch {
+	case s.GroupID == 0:
+		return nil, "group_id is required"
+	case s.Title == "" || utf8.RuneCountInString(s.Title) > maxSurveyTitleLen:
+		return nil, fmt.Sprintf("title must be 1-%d characters", maxSurveyTitleLen)
+	case s.Delivery != models.SurveyDeliveryPoll && s.Delivery != models.SurveyDeliveryDM:
+		return nil, "delivery must be poll or dm"
+	case s.OpenHours < 1 || s.OpenHours > maxOpenHours:
+		return nil, fmt.Sprintf("open_hours must be between 1 and %d", maxOpenHours)
+	}
+
+	next, err := tgbot.NextSurveyRun(*s, time.Now())
+	if err != nil {
+		return nil, "schedule: " + err.Error()
+	}
+	if s.Schedule != "" && next == nil {
+		return nil, "schedule never runs"
+	}
+	s.NextRunAt = next
+
+	if req.Questions == nil {
+		return nil, ""
+	}
+	return buildQuestions(req.Questions)
+}
+
+func buildQuestions(reqs []questionReq) ([]models.SurveyQuestion, string) {
+	if len(reqs) == 0 || len(reqs) > maxQuestions {
+		return nil, fmt.Sprintf("a survey needs 1-%d questions", maxQuestions)
+	}
+	questions := make([]models.SurveyQuestion, len(reqs))
+	for i, q := range reqs {
+		n := i + 1
+		text := strings.TrimSpace(q.Text)
+		if text == "" || utf8.RuneCountInString(text) > maxQuestionLen {
+			return nil, fmt.Sprintf("question %d: text must be 1-%d characters", n, maxQuestionLen)
+		}
+		question := models.SurveyQuestion{Position: i, Text: text, Kind: q.Kind}
+
+		switch q.Kind {
+		case models.QuestionScale:
+			question.ScaleMax = q.ScaleMax
+			if question.ScaleMax == 0 {
+				question.ScaleMax = defaultScaleMax
+			}
+			if question.ScaleMax < 2 || question.ScaleMax > maxScale {
+				return nil, fmt.Sprintf("question %d: scale_max must be between 2 and %d", n, maxScale)
+			}
+		case models.QuestionChoice:
+			if len(q.Options) < 2 || len(q.Options) > maxOptions {
+				return nil, fmt.Sprintf("question %d: needs 2-%d options", n, maxOptions)
+			}
+			for _, option := range q.Options {
+				option = strings.TrimSpace(option)
+				if option == "" || utf8.RuneCountInString(option) > maxOptionLen {
+					return nil, fmt.Sprintf("question %d: options must be 1-%d characters", n, maxOptionLen)
+				}
+				question.Options = append(question.Options, option)
+			}
+		default:
+			return nil, fmt.Sprintf("question %d: kind must be scale or choice", n)
+		}
+		questions[i] = question
+	}
+	return questions, ""
+}
+
+// saveQuestions replaces the questions of s.
+func saveQuestions(tx *gorm.DB, s *models.Survey, questions []models.SurveyQuestion) error {
+	if err := tx.Where("survey_id = ?", s.ID).Delete(&models.SurveyQuestion{}).Error; err != nil {
+		return err
+	}
+	for i := range questions {
+		questions[i].SurveyID = s.ID
+	}
+	if err := tx.Create(&questions).Error; err != nil {
+		return err
+	}
+	s.Questions = questions
+	return nil
+}
+
+func CreateSurvey(c *gin.Context) {
+	var req surveyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+	if req.Questions == nil {
+		c.Data(lvn.Res(400, "", "questions are required"))
+		return
+	}
+
+	s := models.Survey{
+		TenantID:  services.GetTenantID(c),
+		Delivery:  models.SurveyDeliveryPoll,
+		OpenHours: defaultOpenHours,
+		Active:    true,
+	}
+	questions, msg := req.apply(&s)
+	if msg != "" {
+		c.Data(lvn.Res(400, "", msg))
+		return
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Questions").Create(&s).Error; err != nil {
+			return err
+		}
+		return saveQuestions(tx, &s, questions)
+	})
+	if err != nil {
+		lvn.GinErr(c, 500, err, "Failed to create survey")
+		return
+	}
+
+	c.Data(lvn.Res(201, s, ""))
+}
+
+// UpdateSurvey changes a survey. Its questions can only be replaced until
+// it was first sent, so results stay comparable across rounds.
+func UpdateSurvey(c *gin.Context) {
+	s, ok := findSurvey(c)
+	if !ok {
+		return
+	}
+
+	var req surveyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+	questions, msg := req.apply(&s)
+	if msg != "" {
+		c.Data(lvn.Res(400, "", msg))
+		return
+	}
+	if questions != nil {
+		var rounds int64
+		models.DB.Model(&models.SurveyRound{}).Where("survey_id = ?", s.ID).Count(&rounds)
+		if rounds > 0 {
+			c.Data(lvn.Res(409, "", "Questions can't be changed once the survey was sent; create a new survey instead"))
+			return
+		}
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Questions").Save(&s).Error; err != nil {
+			return err
+		}
+		if questions == nil {
+			return nil
+		}
+		return saveQuestions(tx, &s, questions)
+	})
+	if err != nil {
+		lvn.GinErr(c, 500, err, "Failed to update survey")
+		return
+	}
+
+	c.Data(lvn.Res(200, s, ""))
+}
+
+// DeleteSurvey closes the survey's open round and deletes it. Past rounds
+// are kept with their results.
+func DeleteSurvey(c *gin.Context) {
+	s, ok := findSurvey(c)
+	if !ok {
+		return
+	}
+
+	var open []models.SurveyRound
+	models.DB.Where("survey_id = ? AND closed_at IS NULL", s.ID).Find(&open)
+	for _, round := range open {
+		tgbot.CloseRound(round, time.Now())
+	}
+
+	if err := models.DB.Delete(&s).Error; err != nil {
+		lvn.GinErr(c, 500, err, "Failed to delete survey")
+		return
+	}
+
+	c.Data(lvn.Res(200, "", "Survey deleted"))
+}
+
+// SendSurvey sends a new round right away, without changing when the
+// survey next runs on its own.
+func SendSurvey(c *gin.Context) {
+	s, ok := findSurvey(c)
+	if !ok {
+		return
+	}
+
+	round, err := tgbot.StartRound(s, time.Now())
+	if err == tgbot.ErrGroupInactive {
+		c.Data(lvn.Res(409, "", "The bot is no longer in this survey's group"))
+		return
+	}
+	if err != nil {
+		lvn.GinErr(c, 500, err, "Failed to send survey")
+		return
+	}
+
+	c.Data(lvn.Res(201, round, ""))
+}
@@ -0,0 +1,173 @@
+package svc_survey_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_survey"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRouter() *gin.Engine {
+	router := testutil.SetupRouter()
+	surveys := router.Group("/surveys", auth.Auth, services.TenantMiddleware)
+	surveys.GET("", svc_survey.GetSurveys)
+	surveys.POST("", svc_survey.CreateSurvey)
+	surveys.GET("/:id", svc_survey.GetSurvey)
+	surveys.PATCH("/:id", svc_survey.UpdateSurvey)
+	surveys.DELETE("/:id", svc_survey.DeleteSurvey)
+	surveys.GET("/:id/results", svc_survey.GetResults)
+	return router
+}
+
+func setup(t *testing.T) (*gin.Engine, string, models.Group) {
+	t.Helper()
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "s@example.com", "S User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "S Org", "s-org")
+	group := models.Group{TenantID: tenant.ID, ChatID: -1, Title: "Team", IsActive: true}
+	models.DB.Create(&group)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	return setupRouter(), token, group
+}
+
+func pulse(groupID uint) map[string]interface{} {
+	return map[string]interface{}{
+		"group_id": groupID,
+		"title":    "Sprint pulse",
+		"schedule": "0 16 * * 5",
+		"questions": []map[string]interface{}{
+			{"text": "How was this sprint?", "kind": "scale"},
+			{"text": "Ship faster?", "kind": "choice", "options": []string{"Yes", "No"}},
+		},
+	}
+}
+
+func TestSurveys_CreateAndValidate(t *testing.T) {
+	router, token, group := setup(t)
+
+	invalid := []map[string]interface{}{
+		{"group_id": group.ID, "title": "No questions"},
+		{"group_id": 999, "title": "x", "questions": []map[string]interface{}{{"text": "q", "kind": "scale"}}},
+		{"group_id": group.ID, "title": "x", "delivery": "email", "questions": []map[string]interface{}{{"text": "q", "kind": "scale"}}},
+		{"group_id": group.ID, "title": "x", "schedule": "every friday", "questions": []map[string]interface{}{{"text": "q", "kind": "scale"}}},
+		{"group_id": group.ID, "title": "x", "questions": []map[string]interface{}{{"text": "q", "kind": "scale", "scale_max": 11}}},
+		{"group_id": group.ID, "title": "x", "questions": []map[string]interface{}{{"text": "q", "kind": "choice", "options": []string{"only"}}}},
+		{"group_id": group.ID, "title": "x", "questions": []map[string]interface{}{{"text": "q", "kind": "text"}}},
+	}
+	for _, body := range invalid {
+		w := testutil.DoRequest(router, "POST", "/surveys", body, token)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	w := testutil.DoRequest(router, "POST", "/surveys", pulse(group.ID), token)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var s models.Survey
+	models.DB.Preload("Questions").Last(&s)
+	assert.Equal(t, models.SurveyDeliveryPoll, s.Delivery)
+	assert.Equal(t, 24, s.OpenHours)
+	require.NotNil(t, s.NextRunAt)
+	assert.True(t, s.NextRunAt.After(time.Now()))
+	require.Len(t, s.Questions, 2)
+	assert.Equal(t, 5, s.Questions[0].ScaleMax)
+	assert.Equal(t, []string{"Yes", "No"}, s.Questions[1].Options)
+
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/surveys?group_id=%d", group.ID), nil, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp["data"], 1)
+	assert.Len(t, resp["data"].([]interface{})[0].(map[string]interface{})["questions"], 2)
+
+	// Another tenant can't see it
+	other := testutil.CreateTestUser(t, "o@example.com", "O User")
+	otherTenant := testutil.CreateTestTenant(t, other.ID, "O Org", "o-org")
+	otherToken := testutil.GenerateTestToken(other.ID, other.Email, other.Name, other.Role, otherTenant.ID)
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/surveys/%d", s.ID), nil, otherToken)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSurveys_QuestionsLockedOnceSent(t *testing.T) {
+	router, token, group := setup(t)
+	w := testutil.DoRequest(router, "POST", "/surveys", pulse(group.ID), token)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var s models.Survey
+	models.DB.Last(&s)
+
+	questions := map[string]interface{}{"questions": []map[string]interface{}{{"text": "Mood?", "kind": "scale", "scale_max": 3}}}
+	w = testutil.DoRequest(router, "PATCH", fmt.Sprintf("/surveys/%d", s.ID), questions, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var count int64
+	models.DB.Model(&models.SurveyQuestion{}).Where("survey_id = ?", s.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	models.DB.Create(&models.SurveyRound{TenantID: s.TenantID, SurveyID: s.ID, GroupID: group.ID})
+	w = testutil.DoRequest(router, "PATCH", fmt.Sprintf("/surveys/%d", s.ID), questions, token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Other settings can still change
+	w = testutil.DoRequest(router, "PATCH", fmt.Sprintf("/surveys/%d", s.ID), map[string]interface{}{"active": false, "schedule": ""}, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated models.Survey
+	models.DB.First(&updated, s.ID)
+	assert.False(t, updated.Active)
+	assert.Nil(t, updated.NextRunAt)
+}
+
+func TestSurveys_Results(t *testing.T) {
+	router, token, group := setup(t)
+	w := testutil.DoRequest(router, "POST", "/surveys", pulse(group.ID), token)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var s models.Survey
+	models.DB.Preload("Questions").Last(&s)
+	scale, choice := s.Questions[0], s.Questions[1]
+
+	closed := time.Now()
+	first := models.SurveyRound{TenantID: s.TenantID, SurveyID: s.ID, GroupID: group.ID, ClosedAt: &closed}
+	models.DB.Create(&first)
+	models.DB.Create(&models.SurveyPoll{RoundID: first.ID, QuestionID: scale.ID, PollID: "a", Counts: []int{0, 1, 1, 0, 2}, Voters: 4})
+	models.DB.Create(&models.SurveyPoll{RoundID: first.ID, QuestionID: choice.ID, PollID: "b", Counts: []int{3, 1}, Voters: 4})
+
+	// A later round answered by DM
+	second := models.SurveyRound{TenantID: s.TenantID, SurveyID: s.ID, GroupID: group.ID}
+	models.DB.Create(&second)
+	for i, choice := range []int{4, 4, 2} {
+		models.DB.Create(&models.SurveyAnswer{RoundID: second.ID, QuestionID: scale.ID, RespondentHash: fmt.Sprint(i), Choice: choice})
+	}
+
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/surveys/%d/results", s.ID), nil, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data svc_survey.Results `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	rounds := resp.Data.Rounds
+	require.Len(t, rounds, 2)
+
+	assert.Equal(t, second.ID, rounds[0].RoundID)
+	assert.False(t, rounds[0].Closed)
+	assert.Equal(t, 3, rounds[0].Questions[0].Responses)
+	assert.InDelta(t, 13.0/3, *rounds[0].Questions[0].Average, 0.001)
+	assert.Equal(t, 0, rounds[0].Questions[1].Responses)
+
+	assert.True(t, rounds[1].Closed)
+	assert.Equal(t, 4, rounds[1].Questions[0].Responses)
+	assert.InDelta(t, 3.75, *rounds[1].Questions[0].Average, 0.001)
+	assert.Equal(t, []svc_survey.OptionResult{{Label: "Yes", Count: 3}, {Label: "No", Count: 1}}, rounds[1].Questions[1].Options)
+	assert.Nil(t, rounds[1].Questions[1].Average)
+
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/surveys/%d/results?round_id=%d", s.ID, first.ID), nil, token)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.Rounds, 1)
+	assert.Equal(t, first.ID, resp.Data.Rounds[0].RoundID)
+}
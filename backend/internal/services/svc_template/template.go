@@ -0,0 +1,225 @@
+package svc_template
+
+import (
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/msgtemplate"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+)
+
+type templateReq struct {
+	Key      string `json:"key"`
+	GroupID  *uint  `json:"group_id"`
+	Language string `json:"language"`
+	Body     string `json:"body"`
+}
+
+type defaultTemplate struct {
+	Key      string `json:"key"`
+	Language string `json:"language"`
+	Body     string `json:"body"`
+}
+
+func GetTemplates(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	query := models.DB.Scopes(db.TenantScope(tenantID))
+	if groupID := c.Query("group_id"); groupID != "" {
+		query = query.Where("group_id = ?", groupID)
+	}
+
+	var templates []models.MessageTemplate
+	query.Order("key, group_id, language").Find(&templates)
+
+	c.Data(lvn.Res(200, templates, ""))
+}
+
+// GetDefaults lists the built-in messages that templates can override.
+func GetDefaults(c *gin.Context) {
+	var defaults []defaultTemplate
+	for _, lang := range i18n.Supported {
+		for key := range msgtemplate.Keys {
+			defaults = append(defaults, defaultTemplate{Key: key, Language: lang, Body: msgtemplate.Default(key, lang)})
+		}
+	}
+
+	c.Data(lvn.Res(200, gin.H{
+		"defaults":  defaults,
+		"variables": msgtemplate.SampleVars,
+	}, ""))
+}
+
+func CreateTemplate(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	var req templateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+
+	if msg := validateReq(tenantID, req); msg != "" {
+		c.Data(lvn.Res(400, "", msg))
+		return
+	}
+
+	if duplicateExists(tenantID, 0, req) {
+		c.Data(lvn.Res(409, "", "Template for this key, group and language already exists"))
+		return
+	}
+
+	tmpl := models.MessageTemplate{
+		TenantID: tenantID,
+		GroupID:  req.GroupID,
+		Key:      req.Key,
+		Language: req.Language,
+		Body:     req.Body,
+	}
+
+	if err := models.DB.Create(&tmpl).Error; err != nil {
+		lvn.GinErr(c, 500, err, "Failed to create template")
+		return
+	}
+
+	c.Data(lvn.Res(201, tmpl, ""))
+}
+
+func GetTemplate(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := services.GetTenantID(c)
+
+	var tmpl models.MessageTemplate
+	if err := models.DB.Scopes(db.TenantScope(tenantID)).First(&tmpl, id).Error; err != nil {
+		c.Data(lvn.Res(404, "", "Template not found"))
+		return
+	}
+
+	c.Data(lvn.Res(200, tmpl, ""))
+}
+
+func UpdateTemplate(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := services.GetTenantID(c)
+
+	var tmpl models.MessageTemplate
+	if err := models.DB.Scopes(db.TenantScope(tenantID)).First(&tmpl, id).Error; err != nil {
+		c.Data(lvn.Res(404, "", "Template not found"))
+		return
+	}
+
+	req := templateReq{Key: tmpl.Key, GroupID: tmpl.GroupID, Language: tmpl.Language, Body: tmpl.Body}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+
+	if msg := validateReq(tenantID, req); msg != "" {
+		c.Data(lvn.Res(400, "", msg))
+		return
+	}
+
+	if duplicateExists(tenantID, tmpl.ID, req) {
+		c.Data(lvn.Res(409, "", "Template for this key, group and language already exists"))
+		return
+	}
+
+	tmpl.Key = req.Key
+	tmpl.GroupID = req.GroupID
+	tmpl.Language = req.Language
+	tmpl.Body = req.Body
+
+	if err := models.DB.Save(&tmpl).Error; err != nil {
+		lvn.GinErr(c, 500, err, "Failed to update template")
+		return
+	}
+
+	c.Data(lvn.Res(200, tmpl, ""))
+}
+
+func DeleteTemplate(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := services.GetTenantID(c)
+
+	var tmpl models.MessageTemplate
+	if err := models.DB.Scopes(db.TenantScope(tenantID)).First(&tmpl, id).Error; err != nil {
+		c.Data(lvn.Res(404, "", "Template not found"))
+		return
+	}
+
+	if err := models.DB.Delete(&tmpl).Error; err != nil {
+		lvn.GinErr(c, 500, err, "Failed to delete template")
+		return
+	}
+
+	c.Data(lvn.Res(200, "", "Template deleted"))
+}
+
+// PreviewTemplate renders a template body without saving it. Variables come
+// from the given group when set, otherwise from sample values.
+func PreviewTemplate(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	var req templateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+
+	if msg := validateReq(tenantID, req); msg != "" {
+		c.Data(lvn.Res(400, "", msg))
+		return
+	}
+
+	vars := msgtemplate.SampleVars
+	if req.GroupID != nil {
+		var group models.Group
+		models.DB.Preload("Bot").Preload("Tenant").First(&group, *req.GroupID)
+		vars.TenantName = group.Tenant.Name
+		vars.GroupTitle = group.Title
+		vars.BotName = group.Bot.BotName
+		vars.BotUsername = group.Bot.BotUsername
+	}
+
+	out, err := msgtemplate.Execute(req.Body, vars)
+	if err != nil {
+		c.Data(lvn.Res(400, "", "Template error: "+err.Error()))
+		return
+	}
+
+	c.Data(lvn.Res(200, gin.H{"rendered": out, "variables": vars}, ""))
+}
+
+func validateReq(tenantID uint, req templateReq) string {
+	if _, ok := msgtemplate.Keys[req.Key]; !ok {
+		return "Unknown template key: " + req.Key
+	}
+	if req.Language != "" && !i18n.IsSupported(req.Language) {
+		return "Unsupported language: " + req.Language
+	}
+	if req.GroupID != nil {
+		var group models.Group
+		if err := models.DB.Scopes(db.TenantScope(tenantID)).First(&group, *req.GroupID).Error; err != nil {
+			return "Group not found"
+		}
+	}
+	if err := msgtemplate.Validate(req.Body); err != nil {
+		return "Template error: " + err.Error()
+	}
+	return ""
+}
+
+func duplicateExists(tenantID uint, excludeID uint, req templateReq) bool {
+	query := models.DB.Model(&models.MessageTemplate{}).Scopes(db.TenantScope(tenantID)).
+		Where("key = ? AND language = ? AND id <> ?", req.Key, req.Language, excludeID)
+	if req.GroupID != nil {
+		query = query.Where("group_id = ?", *req.GroupID)
+	} else {
+		query = query.Where("group_id IS NULL")
+	}
+	var count int64
+	query.Count(&count)
+	return count > 0
+}
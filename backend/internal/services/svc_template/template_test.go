@@ -0,0 +1,122 @@
+package svc_template_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/msgtemplate"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_template"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTemplateRouter() *gin.Engine {
+	router := testutil.SetupRouter()
+	g := router.Group("/templates", auth.Auth, services.TenantMiddleware)
+	g.GET("", svc_template.GetTemplates)
+	g.POST("", svc_template.CreateTemplate)
+	g.POST("/preview", svc_template.PreviewTemplate)
+	return router
+}
+
+func TestCreateTemplate_Success(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "tpl@example.com", "Tpl User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Tpl Org", "tpl-org")
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	body := map[string]interface{}{"key": "post_header", "body": "📣 {{.GroupTitle}} says:"}
+	w := testutil.DoRequest(setupTemplateRouter(), "POST", "/templates", body, token)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Same key/group/language again is a conflict
+	w = testutil.DoRequest(setupTemplateRouter(), "POST", "/templates", body, token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestCreateTemplate_Validation(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "tplv@example.com", "Tplv User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Tplv Org", "tplv-org")
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	router := setupTemplateRouter()
+
+	cases := []map[string]interface{}{
+		{"key": "unknown", "body": "hi"},
+		{"key": "welcome", "body": "{{.Unclosed"},
+		{"key": "welcome", "body": "{{.Secret}}"},
+		{"key": "welcome", "body": "hi", "language": "de"},
+		{"key": "welcome", "body": "hi", "group_id": 9999},
+		{"key": "welcome", "body": "{{range 1000000000}}x{{end}}"},
+		{"key": "welcome", "body": `{{printf "%0999999999d" 1}}`},
+		{"key": "welcome", "body": `{{define "a"}}{{template "a"}}{{end}}{{template "a"}}`},
+	}
+	for _, body := range cases {
+		w := testutil.DoRequest(router, "POST", "/templates", body, token)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "body: %v", body)
+	}
+}
+
+func TestPreviewTemplate(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "tplp@example.com", "Tplp User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Tplp Org", "tplp-org")
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	body := map[string]interface{}{"key": "welcome", "body": "Hi from {{.BotName}}"}
+	w := testutil.DoRequest(setupTemplateRouter(), "POST", "/templates/preview", body, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, "Hi from Feedback Bot", data["rendered"])
+}
+
+func TestRender_PrefersGroupOverride(t *testing.T) {
+	testutil.SetupTestDB(t)
+	groupID := uint(7)
+	models.DB.Create(&models.MessageTemplate{TenantID: 1, Key: "post_header", Body: "tenant"})
+	models.DB.Create(&models.MessageTemplate{TenantID: 1, GroupID: &groupID, Key: "post_header", Body: "group {{.GroupTitle}}"})
+	models.DB.Create(&models.MessageTemplate{TenantID: 1, Key: "welcome", Body: "{{.Broken"})
+
+	vars := msgtemplate.Vars{GroupTitle: "G"}
+	assert.Equal(t, "group G", msgtemplate.Render(1, 7, "post_header", "en", vars))
+	assert.Equal(t, "tenant", msgtemplate.Render(1, 8, "post_header", "en", vars))
+	assert.Equal(t, "📬 Anonymous Feedback:", msgtemplate.Render(2, 7, "post_header", "en", vars))
+	// A broken template falls back to the built-in default
+	assert.Equal(t, msgtemplate.Default("welcome", "ru"), msgtemplate.Render(1, 0, "welcome", "ru", vars))
+}
+
+func TestExecute_Limits(t *testing.T) {
+	vars := msgtemplate.SampleVars
+	out, err := msgtemplate.Execute(`{{printf "%s in %q, 100%%" .BotName .GroupTitle}}`, vars)
+	require.NoError(t, err)
+	assert.Equal(t, `Feedback Bot in "Team Chat", 100%`, out)
+
+	for _, body := range []string{
+		`{{range $i, $c := .GroupTitle}}{{$c}}{{end}}`,
+		`{{printf "%.999999999f" 1.0}}`,
+		`{{printf "%*d" 999999999 1}}`,
+		`{{printf "%[1]*d" 999999999}}`,
+		`{{"%999999999d" | printf}}`,
+		`{{$f := "%999999999d"}}{{printf $f 1}}`,
+		`{{if true}}{{print (printf "%9d" 1)}}{{end}}`,
+		`{{block "b" .}}{{end}}`,
+	} {
+		_, err := msgtemplate.Execute(body, vars)
+		assert.Error(t, err, body)
+	}
+
+	// Output stops at the limit instead of being built in full
+	long := strings.Repeat("{{.GroupTitle}}{{.GroupTitle}}{{.GroupTitle}}{{.GroupTitle}}", 100)
+	_, err = msgtemplate.Execute(long, msgtemplate.Vars{GroupTitle: strings.Repeat("x", 100)})
+	assert.ErrorContains(t, err, "exceeds")
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/envelope"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/tgbot"
 	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
@@ -61,7 +62,7 @@ func CreateBot(c *gin.Context) {
 
 	// Check for duplicate token
 	var existingBot models.Bot
-	if err := models.DB.Where("token = ?", req.Token).First(&existingBot).Error; err == nil {
+	if err := models.DB.Where("token_hash IN ?", envelope.LookupHashes(req.Token)).First(&existingBot).Error; err == nil {
 		c.Data(lvn.Res(409, "", "Bot with this token already exists"))
 		return
 	}
@@ -72,6 +73,10 @@ func CreateBot(c *gin.Context) {
 		c.Data(lvn.Res(404, "", "Tenant not found"))
 		return
 	}
+	if tenant.DeletionScheduledAt != nil {
+		c.Data(lvn.Res(409, "", "Tenant is scheduled for deletion"))
+		return
+	}
 
 	// Verify bot token with Telegram
 	tgResp, err := verifyBotToken(req.Token)
@@ -103,13 +108,36 @@ func CreateBot(c *gin.Context) {
 	c.Data(lvn.Res(201, bot, ""))
 }
 
+// GetBots lists all bots, or with ?limit= or ?cursor= one page of them
+// along with the next_cursor.
 func GetBots(c *gin.Context) {
 	tenantID := services.GetTenantID(c)
+	query := models.DB.Scopes(db.TenantScope(tenantID))
+
+	if c.Query("limit") == "" && c.Query("cursor") == "" {
+		var bots []models.Bot
+		query.Find(&bots)
+		c.Data(lvn.Res(200, bots, ""))
+		return
+	}
 
+	page, ok := services.GetCursorPage(c, 50)
+	if !ok {
+		return
+	}
 	var bots []models.Bot
-	models.DB.Scopes(db.TenantScope(tenantID)).Find(&bots)
+	query.Scopes(db.ByID("bots", page.After)).Limit(page.Limit + 1).Find(&bots)
+
+	ids := make([]uint, len(bots))
+	for i, b := range bots {
+		ids[i] = b.ID
+	}
+	next := page.NextCursor(ids)
+	if len(bots) > page.Limit {
+		bots = bots[:page.Limit]
+	}
 
-	c.Data(lvn.Res(200, bots, ""))
+	c.Data(lvn.Res(200, gin.H{"data": bots, "next_cursor": next}, ""))
 }
 
 func GetBot(c *gin.Context) {
@@ -139,6 +167,7 @@ func DeleteBot(c *gin.Context) {
 		lvn.GinErr(c, 500, err, "Failed to delete bot")
 		return
 	}
+	tgbot.StopBot(bot.ID)
 
 	c.Data(lvn.Res(200, "", "Bot deleted"))
 }
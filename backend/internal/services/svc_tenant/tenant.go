@@ -1,7 +1,15 @@
 package svc_tenant
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/ratings"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/retention"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/sealed"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
 	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
 	"github.com/gin-gonic/gin"
 )
@@ -63,3 +71,89 @@ func GetTenant(c *gin.Context) {
 
 	c.Data(lvn.Res(200, tenant, ""))
 }
+
+type updateTenantReq struct {
+	Name            *string `json:"name"`
+	DefaultLanguage *string `json:"default_language"`
+	Timezone        *string `json:"timezone"`
+	AdminPublicKey  *string `json:"admin_public_key"` // "" stops sealing new admin-only feedback
+	RetentionMonths *int    `json:"retention_months"`
+
+	MinRatingResponses *int `json:"min_rating_responses"`
+}
+
+// UpdateTenant updates the caller's own tenant settings.
+func UpdateTenant(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := services.GetTenantID(c)
+
+	if id != fmt.Sprintf("%d", tenantID) {
+		c.Data(lvn.Res(404, "", "Tenant not found"))
+		return
+	}
+
+	var tenant models.Tenant
+	if err := models.DB.First(&tenant, tenantID).Error; err != nil {
+		c.Data(lvn.Res(404, "", "Tenant not found"))
+		return
+	}
+
+	var req updateTenantReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			c.Data(lvn.Res(400, "", "name must not be empty"))
+			return
+		}
+		tenant.Name = *req.Name
+	}
+	if req.DefaultLanguage != nil {
+		if !i18n.IsSupported(*req.DefaultLanguage) {
+			c.Data(lvn.Res(400, "", "Unsupported language: "+*req.DefaultLanguage))
+			return
+		}
+		tenant.DefaultLanguage = *req.DefaultLanguage
+	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			c.Data(lvn.Res(400, "", "Unknown timezone: "+*req.Timezone))
+			return
+		}
+		tenant.Timezone = *req.Timezone
+	}
+	if req.AdminPublicKey != nil {
+		if *req.AdminPublicKey != "" {
+			if _, err := sealed.ParsePublicKey(*req.AdminPublicKey); err != nil {
+				c.Data(lvn.Res(400, "", "admin_public_key must be a base64-encoded 32-byte X25519 key"))
+				return
+			}
+		}
+		tenant.AdminPublicKey = *req.AdminPublicKey
+	}
+	if req.RetentionMonths != nil {
+		if *req.RetentionMonths < 0 || *req.RetentionMonths > retention.MaxRetentionMonths {
+			c.Data(lvn.Res(400, "", fmt.Sprintf("retention_months must be between 0 and %d", retention.MaxRetentionMonths)))
+			return
+		}
+		tenant.RetentionMonths = *req.RetentionMonths
+	}
+
+	if req.MinRatingResponses != nil {
+		if *req.MinRatingResponses < ratings.MinResponsesFloor {
+			c.Data(lvn.Res(400, "", fmt.Sprintf("min_rating_responses must be at least %d", ratings.MinResponsesFloor)))
+			return
+		}
+		tenant.MinRatingResponses = *req.MinRatingResponses
+	}
+
+	if err := models.DB.Save(&tenant).Error; err != nil {
+		lvn.GinErr(c, 500, err, "Failed to update tenant")
+		return
+	}
+
+	c.Data(lvn.Res(200, tenant, ""))
+}
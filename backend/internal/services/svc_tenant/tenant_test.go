@@ -1,11 +1,13 @@
 package svc_tenant_test
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
 
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_tenant"
@@ -86,6 +88,51 @@ func TestGetTenant_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestUpdateTenant_AdminPublicKey(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "key@example.com", "Key User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Key Org", "key-org")
+
+	router := testutil.SetupRouter()
+	router.PATCH("/tenants/:id", auth.Auth, services.TenantMiddleware, svc_tenant.UpdateTenant)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	path := "/tenants/" + uintToStr(tenant.ID)
+
+	key := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	w := testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"admin_public_key": key}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&tenant, tenant.ID)
+	assert.Equal(t, key, tenant.AdminPublicKey)
+
+	w = testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"admin_public_key": "c2hvcnQ="}, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"admin_public_key": ""}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&tenant, tenant.ID)
+	assert.Empty(t, tenant.AdminPublicKey)
+}
+
+func TestUpdateTenant_MinRatingResponses(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "min@example.com", "Min User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Min Org", "min-org")
+	assert.Equal(t, 5, tenant.MinRatingResponses)
+
+	router := testutil.SetupRouter()
+	router.PATCH("/tenants/:id", auth.Auth, services.TenantMiddleware, svc_tenant.UpdateTenant)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	path := "/tenants/" + uintToStr(tenant.ID)
+
+	w := testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"min_rating_responses": 2}, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testutil.DoRequest(router, "PATCH", path, map[string]interface{}{"min_rating_responses": 10}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&tenant, tenant.ID)
+	assert.Equal(t, 10, tenant.MinRatingResponses)
+}
+
 func TestGetBots_Success(t *testing.T) {
 	testutil.SetupTestDB(t)
 	user := testutil.CreateTestUser(t, "bots@example.com", "Bots User")
@@ -100,6 +147,33 @@ func TestGetBots_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestGetBots_CursorPage(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "bots@example.com", "Bots User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Bot Org", "bot-org")
+	for _, name := range []string{"one", "two"} {
+		require.NoError(t, models.DB.Create(&models.Bot{TenantID: tenant.ID, Token: name + "-token", BotUsername: name}).Error)
+	}
+
+	router := testutil.SetupRouter()
+	router.GET("/bots", auth.Auth, services.TenantMiddleware, svc_tenant.GetBots)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	w := testutil.DoRequest(router, "GET", "/bots?limit=1", nil, token)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	data := resp["data"].(map[string]interface{})
+	require.Len(t, data["data"], 1)
+	assert.Equal(t, "one", data["data"].([]interface{})[0].(map[string]interface{})["bot_username"])
+
+	w = testutil.DoRequest(router, "GET", "/bots?limit=1&cursor="+data["next_cursor"].(string), nil, token)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	data = resp["data"].(map[string]interface{})
+	assert.Equal(t, "two", data["data"].([]interface{})[0].(map[string]interface{})["bot_username"])
+	assert.Empty(t, data["next_cursor"])
+}
+
 func TestDeleteBot_NotFound(t *testing.T) {
 	testutil.SetupTestDB(t)
 	user := testutil.CreateTestUser(t, "delbot@example.com", "Del User")
@@ -114,6 +188,30 @@ func TestDeleteBot_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestCreateBot_DuplicateTokenByHash(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "dupbot@example.com", "Dup User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Dup Org", "dup-org")
+	existing := models.Bot{TenantID: tenant.ID, Token: "123:secret-token", BotUsername: "dupbot"}
+	require.NoError(t, models.DB.Create(&existing).Error)
+
+	// The token is only stored encrypted, but still reads back transparently
+	var raw map[string]interface{}
+	models.DB.Table("bots").Where("id = ?", existing.ID).Take(&raw)
+	assert.NotContains(t, fmt.Sprint(raw), "secret-token")
+	var loaded models.Bot
+	models.DB.First(&loaded, existing.ID)
+	assert.Equal(t, "123:secret-token", loaded.Token)
+
+	router := testutil.SetupRouter()
+	router.POST("/bots", auth.Auth, services.TenantMiddleware, svc_tenant.CreateBot)
+
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+	w := testutil.DoRequest(router, "POST", "/bots", map[string]interface{}{"token": "123:secret-token"}, token)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
 func uintToStr(n uint) string {
 	return fmt.Sprintf("%d", n)
 }
@@ -0,0 +1,149 @@
+package svc_tenantdata
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/tenantdata"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/tgbot"
+	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// StartExport queues a full archive of the caller's tenant.
+func StartExport(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	job, err := tenantdata.StartExport(tenantID)
+	if err != nil {
+		lvn.GinErr(c, 500, err, "Failed to start export")
+		return
+	}
+
+	c.Data(lvn.Res(202, job, ""))
+}
+
+func GetExports(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	var jobs []models.ExportJob
+	models.DB.Scopes(db.TenantScope(tenantID)).Order("created_at DESC").Limit(50).Find(&jobs)
+
+	c.Data(lvn.Res(200, jobs, ""))
+}
+
+func findExport(c *gin.Context) (models.ExportJob, bool) {
+	id := c.Param("id")
+	tenantID := services.GetTenantID(c)
+
+	var job models.ExportJob
+	if err := models.DB.Scopes(db.TenantScope(tenantID)).First(&job, id).Error; err != nil {
+		c.Data(lvn.Res(404, "", "Export not found"))
+		return job, false
+	}
+	return job, true
+}
+
+func GetExport(c *gin.Context) {
+	job, ok := findExport(c)
+	if !ok {
+		return
+	}
+
+	c.Data(lvn.Res(200, job, ""))
+}
+
+// DownloadExport streams a finished export file.
+func DownloadExport(c *gin.Context) {
+	job, ok := findExport(c)
+	if !ok {
+		return
+	}
+
+	if job.Status != models.ExportReady {
+		c.Data(lvn.Res(409, "", "Export is not ready: "+job.Status))
+		return
+	}
+	if _, err := os.Stat(job.FilePath); job.FilePath == "" || err != nil {
+		c.Data(lvn.Res(410, "", "Export has expired"))
+		return
+	}
+
+	filename := fmt.Sprintf("tenant_%d_%s.zip", job.TenantID, job.CreatedAt.Format("2006-01-02"))
+	c.FileAttachment(job.FilePath, filename)
+}
+
+type requestDeletionReq struct {
+	Confirm string `json:"confirm" binding:"required"` // Must repeat the tenant slug
+}
+
+// RequestDeletion schedules the caller's tenant, and everything in it, for
+// deletion after tenantdata.DeletionGracePeriod. Its bots stop right away.
+func RequestDeletion(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	var tenant models.Tenant
+	if err := models.DB.First(&tenant, tenantID).Error; err != nil {
+		c.Data(lvn.Res(404, "", "Tenant not found"))
+		return
+	}
+
+	var req requestDeletionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Data(lvn.Res(400, "", "Invalid request: "+err.Error()))
+		return
+	}
+	if req.Confirm != tenant.Slug {
+		c.Data(lvn.Res(400, "", "confirm must match the tenant slug"))
+		return
+	}
+	if tenant.DeletionScheduledAt != nil {
+		c.Data(lvn.Res(409, "", "Deletion is already scheduled"))
+		return
+	}
+
+	if err := tenantdata.ScheduleDeletion(&tenant, time.Now()); err != nil {
+		lvn.GinErr(c, 500, err, "Failed to schedule deletion")
+		return
+	}
+
+	var botIDs []uint
+	models.DB.Model(&models.Bot{}).Scopes(db.TenantScope(tenantID)).Pluck("id", &botIDs)
+	for _, botID := range botIDs {
+		tgbot.StopBot(botID)
+	}
+
+	c.Data(lvn.Res(200, tenant, ""))
+}
+
+// CancelDeletion keeps the tenant and restarts its bots.
+func CancelDeletion(c *gin.Context) {
+	tenantID := services.GetTenantID(c)
+
+	var tenant models.Tenant
+	if err := models.DB.First(&tenant, tenantID).Error; err != nil {
+		c.Data(lvn.Res(404, "", "Tenant not found"))
+		return
+	}
+	if tenant.DeletionScheduledAt == nil {
+		c.Data(lvn.Res(409, "", "No deletion is scheduled"))
+		return
+	}
+
+	if err := tenantdata.CancelDeletion(&tenant); err != nil {
+		lvn.GinErr(c, 500, err, "Failed to cancel deletion")
+		return
+	}
+
+	var bots []models.Bot
+	models.DB.Scopes(db.TenantScope(tenantID)).Where("verified = ?", true).Find(&bots)
+	for _, bot := range bots {
+		go tgbot.StartPolling(bot)
+	}
+
+	c.Data(lvn.Res(200, tenant, ""))
+}
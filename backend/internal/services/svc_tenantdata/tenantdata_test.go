@@ -0,0 +1,83 @@
+package svc_tenantdata_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_tenantdata"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport_BuildsDownloadableArchive(t *testing.T) {
+	testutil.SetupTestDB(t)
+	config.Confs.Settings.DataDir = t.TempDir()
+	user := testutil.CreateTestUser(t, "exp@example.com", "Exp User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Exp Org", "exp-org")
+
+	router := testutil.SetupRouter()
+	router.POST("/tenant/export", auth.Auth, services.TenantMiddleware, svc_tenantdata.StartExport)
+	router.GET("/tenant/exports/:id", auth.Auth, services.TenantMiddleware, svc_tenantdata.GetExport)
+	router.GET("/tenant/exports/:id/download", auth.Auth, services.TenantMiddleware, svc_tenantdata.DownloadExport)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	w := testutil.DoRequest(router, "POST", "/tenant/export", nil, token)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	id := uint(resp["data"].(map[string]interface{})["id"].(float64))
+
+	require.Eventually(t, func() bool {
+		var job models.ExportJob
+		models.DB.First(&job, id)
+		return job.Status == models.ExportReady
+	}, 5*time.Second, 10*time.Millisecond)
+
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/tenant/exports/%d/download", id), nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "PK", w.Body.String()[:2])
+
+	// Other tenants can't see it
+	user2 := testutil.CreateTestUser(t, "exp2@example.com", "Exp User 2")
+	tenant2 := testutil.CreateTestTenant(t, user2.ID, "Exp Org 2", "exp-org-2")
+	token2 := testutil.GenerateTestToken(user2.ID, user2.Email, user2.Name, user2.Role, tenant2.ID)
+	w = testutil.DoRequest(router, "GET", fmt.Sprintf("/tenant/exports/%d", id), nil, token2)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeletion_ScheduleAndCancel(t *testing.T) {
+	testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, "del@example.com", "Del User")
+	tenant := testutil.CreateTestTenant(t, user.ID, "Del Org", "del-org")
+
+	router := testutil.SetupRouter()
+	router.POST("/tenant/delete", auth.Auth, services.TenantMiddleware, svc_tenantdata.RequestDeletion)
+	router.POST("/tenant/delete/cancel", auth.Auth, services.TenantMiddleware, svc_tenantdata.CancelDeletion)
+	token := testutil.GenerateTestToken(user.ID, user.Email, user.Name, user.Role, tenant.ID)
+
+	w := testutil.DoRequest(router, "POST", "/tenant/delete", map[string]string{"confirm": "wrong"}, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testutil.DoRequest(router, "POST", "/tenant/delete", map[string]string{"confirm": "del-org"}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&tenant, tenant.ID)
+	require.NotNil(t, tenant.DeletionScheduledAt)
+	assert.True(t, tenant.DeletionScheduledAt.After(time.Now().Add(6*24*time.Hour)))
+
+	w = testutil.DoRequest(router, "POST", "/tenant/delete", map[string]string{"confirm": "del-org"}, token)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = testutil.DoRequest(router, "POST", "/tenant/delete/cancel", nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var reloaded models.Tenant
+	models.DB.First(&reloaded, tenant.ID)
+	assert.Nil(t, reloaded.DeletionScheduledAt)
+}
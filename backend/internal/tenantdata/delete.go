@@ -0,0 +1,93 @@
+package tenantdata
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"gorm.io/gorm"
+)
+
+// DeletionGracePeriod is how long a tenant can cancel a requested deletion.
+const DeletionGracePeriod = 7 * 24 * time.Hour
+
+// staleExportAge marks exports that stopped without finishing as failed.
+// Those interrupted by a restart are resumed at startup instead.
+const staleExportAge = time.Hour
+
+// ScheduleDeletion marks the tenant for deletion after the grace period.
+// Callers must stop the tenant's bots; main doesn't restart them meanwhile.
+func ScheduleDeletion(tenant *models.Tenant, now time.Time) error {
+	at := now.Add(DeletionGracePeriod)
+	tenant.DeletionScheduledAt = &at
+	return models.DB.Model(tenant).Update("deletion_scheduled_at", at).Error
+}
+
+// CancelDeletion clears a pending deletion.
+func CancelDeletion(tenant *models.Tenant) error {
+	tenant.DeletionScheduledAt = nil
+	return models.DB.Model(tenant).Update("deletion_scheduled_at", nil).Error
+}
+
+// DeleteTenant hard-deletes every row that belongs to the tenant, and its
+// export and import files.
+func DeleteTenant(tenantID uint) error {
+	var files, imports []string
+	models.DB.Unscoped().Model(&models.ExportJob{}).Where("tenant_id = ? AND file_path <> ''", tenantID).Pluck("file_path", &files)
+	models.DB.Unscoped().Model(&models.ImportJob{}).Where("tenant_id = ? AND file_path <> ''", tenantID).Pluck("file_path", &imports)
+	files = append(files, imports...)
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		// Users are found through user_tenants, which references them and
+		// so is deleted first
+		var userIDs []uint
+		if err := tx.Model(&models.UserTenant{}).Where("tenant_id = ?", tenantID).Pluck("user_id", &userIDs).Error; err != nil {
+			return err
+		}
+		for i := len(entities) - 1; i >= 0; i-- {
+			e := entities[i]
+			query := tx.Unscoped().Scopes(e.scope(tenantID))
+			if _, ok := e.model.(*models.User); ok {
+				query = tx.Unscoped().Where("id IN ?", userIDs)
+			}
+			if err := query.Delete(e.model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		os.Remove(file)
+	}
+	return nil
+}
+
+// Run deletes tenants whose grace period is over and removes expired export
+// files. It is called by the hourly retention job.
+func Run(now time.Time) {
+	var tenantIDs []uint
+	models.DB.Model(&models.Tenant{}).Where("deletion_scheduled_at <= ?", now).Pluck("id", &tenantIDs)
+	for _, tenantID := range tenantIDs {
+		if err := DeleteTenant(tenantID); err != nil {
+			log.Printf("[tenantdata] Failed to delete tenant %d: %v", tenantID, err)
+			continue
+		}
+		log.Printf("[tenantdata] Deleted tenant %d", tenantID)
+	}
+
+	var expired []models.ExportJob
+	models.DB.Where("expires_at <= ? AND file_path <> ''", now).Find(&expired)
+	for _, job := range expired {
+		os.Remove(job.FilePath)
+		models.DB.Model(&job).Update("file_path", "")
+	}
+
+	models.DB.Model(&models.ExportJob{}).
+		Where("status IN ? AND updated_at < ?", []string{models.ExportPending, models.ExportRunning}, now.Add(-staleExportAge)).
+		Updates(map[string]interface{}{"status": models.ExportFailed, "error": "interrupted"})
+}
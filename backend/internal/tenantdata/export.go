@@ -0,0 +1,142 @@
+package tenantdata
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// ArchiveVersion is bumped when the archive layout changes.
+	ArchiveVersion = 1
+	// ExportTTL is how long a finished export stays downloadable.
+	ExportTTL = 7 * 24 * time.Hour
+
+	exportBatchSize = 1000
+)
+
+// Manifest is written to manifest.json in every archive.
+type Manifest struct {
+	Version    int              `json:"version"`
+	TenantID   uint             `json:"tenant_id"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Counts     map[string]int64 `json:"counts"` // Rows per <entity>.ndjson file
+}
+
+// WriteArchive writes a zip with one NDJSON file per entity, soft-deleted
+// rows included, plus manifest.json. Rows use their API JSON form, so
+// secrets such as bot tokens and sender pseudonyms are left out.
+func WriteArchive(w io.Writer, tenantID uint) error {
+	zw := zip.NewWriter(w)
+	manifest := Manifest{Version: ArchiveVersion, TenantID: tenantID, ExportedAt: time.Now(), Counts: map[string]int64{}}
+
+	for _, e := range entities {
+		f, err := zw.Create(e.name + ".ndjson")
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		rows := e.rows()
+		err = models.DB.Unscoped().Scopes(e.scope(tenantID)).
+			FindInBatches(rows, exportBatchSize, func(tx *gorm.DB, batch int) error {
+				v := reflect.ValueOf(rows).Elem()
+				for i := 0; i < v.Len(); i++ {
+					if err := enc.Encode(v.Index(i).Interface()); err != nil {
+						return err
+					}
+				}
+				manifest.Counts[e.name] += int64(v.Len())
+				return nil
+			}).Error
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.name, err)
+		}
+	}
+
+	f, err := zw.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(manifest); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// StartExport queues an archive of the tenant's data and builds it in the
+// background. Poll the returned job until it is ready.
+func StartExport(tenantID uint) (models.ExportJob, error) {
+	job := models.ExportJob{TenantID: tenantID, Kind: models.ExportKindTenantArchive, Status: models.ExportPending}
+	if err := models.DB.Create(&job).Error; err != nil {
+		return job, err
+	}
+	go runExport(job)
+	return job, nil
+}
+
+// ResumeExports restarts the archives a restart left pending or running, from
+// the beginning.
+func ResumeExports() {
+	var jobs []models.ExportJob
+	models.DB.Where("kind = ? AND status IN ?", models.ExportKindTenantArchive, []string{models.ExportPending, models.ExportRunning}).Find(&jobs)
+	for _, job := range jobs {
+		log.Printf("[tenantdata] Resuming export %d", job.ID)
+		go runExport(job)
+	}
+}
+
+func exportPath(job models.ExportJob) string {
+	return filepath.Join(config.DataDir(), "exports", fmt.Sprintf("tenant-%d-%d.zip", job.TenantID, job.ID))
+}
+
+func runExport(job models.ExportJob) {
+	models.DB.Model(&job).Update("status", models.ExportRunning)
+
+	path := exportPath(job)
+	size, err := writeArchiveFile(path, job.TenantID)
+	if err != nil {
+		log.Printf("[tenantdata] Export %d for tenant %d failed: %v", job.ID, job.TenantID, err)
+		os.Remove(path)
+		models.DB.Model(&job).Updates(map[string]interface{}{"status": models.ExportFailed, "error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	models.DB.Model(&job).Updates(map[string]interface{}{
+		"status":       models.ExportReady,
+		"file_path":    path,
+		"file_size":    size,
+		"completed_at": now,
+		"expires_at":   now.Add(ExportTTL),
+	})
+}
+
+func writeArchiveFile(path string, tenantID uint) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	if err := WriteArchive(f, tenantID); err != nil {
+		f.Close()
+		return 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+	return info.Size(), f.Close()
+}
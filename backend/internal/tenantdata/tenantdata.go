@@ -0,0 +1,88 @@
+// Package tenantdata exports a tenant's complete data as an archive and
+// deletes it for good.
+//
+// Both work off one table of entities, so anything that can be exported is
+// also deleted. Every model in db.Models must appear here; the tests check it.
+package tenantdata
+
+import (
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"gorm.io/gorm"
+)
+
+type scope func(tenantID uint) func(*gorm.DB) *gorm.DB
+
+type entity struct {
+	name  string
+	model interface{}        // For deletes
+	rows  func() interface{} // New slice pointer for exports
+	scope scope
+}
+
+func byTenant(tenantID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB { return db.Where("tenant_id = ?", tenantID) }
+}
+
+func byMembership(tenantID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id IN (SELECT user_id FROM user_tenants WHERE tenant_id = ?)", tenantID)
+	}
+}
+
+func byGroup(tenantID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("group_id IN (SELECT id FROM groups WHERE tenant_id = ?)", tenantID)
+	}
+}
+
+func byBot(tenantID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("bot_id IN (SELECT id FROM bots WHERE tenant_id = ?)", tenantID)
+	}
+}
+
+func bySurvey(tenantID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("survey_id IN (SELECT id FROM surveys WHERE tenant_id = ?)", tenantID)
+	}
+}
+
+func byRound(tenantID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("round_id IN (SELECT id FROM survey_rounds WHERE tenant_id = ?)", tenantID)
+	}
+}
+
+// entities are ordered parents first; deletes run in reverse.
+var entities = []entity{
+	{"tenant", &models.Tenant{}, func() interface{} { return &[]models.Tenant{} }, func(tenantID uint) func(*gorm.DB) *gorm.DB {
+		return func(db *gorm.DB) *gorm.DB { return db.Where("id = ?", tenantID) }
+	}},
+	// A user belongs to at most one tenant (UserTenant.UserID is unique)
+	{"users", &models.User{}, func() interface{} { return &[]models.User{} }, byMembership},
+	{"user_tenants", &models.UserTenant{}, func() interface{} { return &[]models.UserTenant{} }, byTenant},
+	{"bots", &models.Bot{}, func() interface{} { return &[]models.Bot{} }, byTenant},
+	{"groups", &models.Group{}, func() interface{} { return &[]models.Group{} }, byTenant},
+	{"feedback_configs", &models.FeedbackConfig{}, func() interface{} { return &[]models.FeedbackConfig{} }, byGroup},
+	{"group_users", &models.GroupUser{}, func() interface{} { return &[]models.GroupUser{} }, byTenant},
+	{"feedbacks", &models.Feedback{}, func() interface{} { return &[]models.Feedback{} }, byTenant},
+	{"idea_votes", &models.IdeaVote{}, func() interface{} { return &[]models.IdeaVote{} }, byTenant},
+	{"public_responses", &models.PublicResponse{}, func() interface{} { return &[]models.PublicResponse{} }, byTenant},
+	{"pending_feedbacks", &models.PendingFeedback{}, func() interface{} { return &[]models.PendingFeedback{} }, byBot},
+	{"pending_comments", &models.PendingComment{}, func() interface{} { return &[]models.PendingComment{} }, byBot},
+	{"sender_preferences", &models.SenderPreference{}, func() interface{} { return &[]models.SenderPreference{} }, byBot},
+	{"message_templates", &models.MessageTemplate{}, func() interface{} { return &[]models.MessageTemplate{} }, byTenant},
+	{"purge_logs", &models.PurgeLog{}, func() interface{} { return &[]models.PurgeLog{} }, byTenant},
+	{"export_jobs", &models.ExportJob{}, func() interface{} { return &[]models.ExportJob{} }, byTenant},
+	{"import_jobs", &models.ImportJob{}, func() interface{} { return &[]models.ImportJob{} }, byTenant},
+	{"notifications", &models.Notification{}, func() interface{} { return &[]models.Notification{} }, byTenant},
+	{"report_schedules", &models.ReportSchedule{}, func() interface{} { return &[]models.ReportSchedule{} }, byTenant},
+	{"surveys", &models.Survey{}, func() interface{} { return &[]models.Survey{} }, byTenant},
+	{"survey_questions", &models.SurveyQuestion{}, func() interface{} { return &[]models.SurveyQuestion{} }, bySurvey},
+	{"survey_rounds", &models.SurveyRound{}, func() interface{} { return &[]models.SurveyRound{} }, byTenant},
+	{"survey_polls", &models.SurveyPoll{}, func() interface{} { return &[]models.SurveyPoll{} }, byRound},
+	{"survey_answers", &models.SurveyAnswer{}, func() interface{} { return &[]models.SurveyAnswer{} }, byRound},
+	{"retro_sessions", &models.RetroSession{}, func() interface{} { return &[]models.RetroSession{} }, byTenant},
+	{"retro_items", &models.RetroItem{}, func() interface{} { return &[]models.RetroItem{} }, byTenant},
+	{"retro_dots", &models.RetroDot{}, func() interface{} { return &[]models.RetroDot{} }, byTenant},
+}
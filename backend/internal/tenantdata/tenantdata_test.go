@@ -0,0 +1,175 @@
+package tenantdata
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntities_CoverEveryModel(t *testing.T) {
+	covered := map[reflect.Type]bool{}
+	for _, e := range entities {
+		covered[reflect.TypeOf(e.model)] = true
+	}
+	for _, m := range db.Models {
+		assert.True(t, covered[reflect.TypeOf(m)], "%T is missing from tenantdata entities", m)
+	}
+}
+
+// seed creates a tenant with one row in most tables and returns its ID.
+func seed(t *testing.T, slug string, chatID int64) uint {
+	t.Helper()
+	user := testutil.CreateTestUser(t, slug+"@example.com", slug)
+	tenant := testutil.CreateTestTenant(t, user.ID, slug, slug)
+	bot := models.Bot{TenantID: tenant.ID, Token: slug + "-secret-token", BotUsername: slug + "bot"}
+	require.NoError(t, models.DB.Create(&bot).Error)
+	group := models.Group{TenantID: tenant.ID, BotID: bot.ID, ChatID: chatID, Title: slug + " group", IsActive: true}
+	models.DB.Create(&group)
+	models.DB.Create(&models.FeedbackConfig{GroupID: group.ID})
+	gu := models.GroupUser{TenantID: tenant.ID, GroupID: group.ID, SenderHash: identity.Pseudonym(chatID)}
+	models.DB.Create(&gu)
+	idea := models.Feedback{TenantID: tenant.ID, GroupID: group.ID, SenderID: gu.ID, Message: slug + " feedback", Type: models.FeedbackIdea}
+	models.DB.Create(&idea)
+	models.DB.Create(&models.IdeaVote{TenantID: tenant.ID, FeedbackID: idea.ID, VoterHash: slug, Value: 1})
+	retracted := models.Feedback{TenantID: tenant.ID, GroupID: group.ID, SenderID: gu.ID, Message: slug + " retracted"}
+	models.DB.Create(&retracted)
+	models.DB.Delete(&retracted)
+	models.DB.Create(&models.PendingFeedback{SenderHash: identity.Pseudonym(chatID), BotID: bot.ID, Text: "pending"})
+	models.DB.Create(&models.PublicResponse{TenantID: tenant.ID, FeedbackID: idea.ID, GroupID: group.ID, AuthorID: user.ID, Message: "Done"})
+	models.DB.Create(&models.PendingComment{SenderHash: identity.Pseudonym(chatID), BotID: bot.ID, FeedbackID: idea.ID})
+	models.DB.Create(&models.SenderPreference{SenderHash: identity.Pseudonym(chatID), BotID: bot.ID, Language: "ru"})
+	models.DB.Create(&models.MessageTemplate{TenantID: tenant.ID, Key: "welcome", Language: "en", Body: "hi"})
+	models.DB.Create(&models.ImportJob{TenantID: tenant.ID, Format: models.ImportFormatCSV, Status: models.ImportCompleted})
+	models.DB.Create(&models.Notification{TenantID: tenant.ID, UserID: user.ID, Kind: models.NotificationExportReady})
+	models.DB.Create(&models.ReportSchedule{TenantID: tenant.ID, UserID: user.ID, Frequency: models.ReportWeekly})
+	survey := models.Survey{TenantID: tenant.ID, GroupID: group.ID, Title: "Pulse"}
+	models.DB.Create(&survey)
+	question := models.SurveyQuestion{SurveyID: survey.ID, Text: "How was the sprint?", Kind: models.QuestionScale, ScaleMax: 5}
+	models.DB.Create(&question)
+	round := models.SurveyRound{TenantID: tenant.ID, SurveyID: survey.ID, GroupID: group.ID}
+	models.DB.Create(&round)
+	models.DB.Create(&models.SurveyPoll{RoundID: round.ID, QuestionID: question.ID, PollID: slug, Counts: []int{1, 0, 0, 0, 0}})
+	models.DB.Create(&models.SurveyAnswer{RoundID: round.ID, QuestionID: question.ID, RespondentHash: slug, Choice: 3})
+	retro := models.RetroSession{TenantID: tenant.ID, GroupID: group.ID, Title: "Sprint retro", Columns: []string{"Went well"}}
+	models.DB.Create(&retro)
+	item := models.RetroItem{TenantID: tenant.ID, SessionID: retro.ID, Message: "Pairing"}
+	models.DB.Create(&item)
+	models.DB.Create(&models.RetroDot{TenantID: tenant.ID, SessionID: retro.ID, ItemID: item.ID, VoterHash: slug})
+	return tenant.ID
+}
+
+func TestWriteArchive(t *testing.T) {
+	testutil.SetupTestDBWithForeignKeys(t)
+	tenantID := seed(t, "arch", -100)
+	seed(t, "other", -200)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteArchive(&buf, tenantID))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	var manifest Manifest
+	require.NoError(t, json.Unmarshal([]byte(files["manifest.json"]), &manifest))
+	assert.Equal(t, tenantID, manifest.TenantID)
+	assert.Equal(t, int64(2), manifest.Counts["feedbacks"])
+	assert.Equal(t, int64(1), manifest.Counts["users"])
+	for _, e := range entities {
+		assert.Contains(t, files, e.name+".ndjson")
+	}
+
+	assert.Contains(t, files["feedbacks.ndjson"], "arch retracted")
+	assert.Equal(t, 2, strings.Count(files["feedbacks.ndjson"], "\n"))
+	all := strings.Join(mapValues(files), "")
+	assert.NotContains(t, all, "other")
+	assert.NotContains(t, all, "secret-token")
+	assert.NotContains(t, all, identity.Pseudonym(-100))
+}
+
+func mapValues(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for _, v := range m {
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestResumeExports(t *testing.T) {
+	testutil.SetupTestDB(t)
+	config.Confs.Settings.DataDir = t.TempDir()
+	tenantID := seed(t, "resume", -100)
+	// An archive a restart interrupted, and a feedbacks export it leaves alone
+	job := models.ExportJob{TenantID: tenantID, Kind: models.ExportKindTenantArchive, Status: models.ExportRunning}
+	require.NoError(t, models.DB.Create(&job).Error)
+	other := models.ExportJob{TenantID: tenantID, Kind: models.ExportKindFeedbacks, Status: models.ExportRunning}
+	require.NoError(t, models.DB.Create(&other).Error)
+
+	ResumeExports()
+
+	require.Eventually(t, func() bool {
+		models.DB.First(&job, job.ID)
+		return job.Status == models.ExportReady
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.FileExists(t, job.FilePath)
+	models.DB.First(&other, other.ID)
+	assert.Equal(t, models.ExportRunning, other.Status)
+}
+
+func TestDeleteTenant_RemovesEverything(t *testing.T) {
+	testutil.SetupTestDBWithForeignKeys(t)
+	tenantID := seed(t, "gone", -100)
+	otherID := seed(t, "kept", -200)
+
+	require.NoError(t, DeleteTenant(tenantID))
+	// Users are scoped through user_tenants, so check them directly
+	var users []string
+	models.DB.Unscoped().Model(&models.User{}).Pluck("email", &users)
+	assert.Equal(t, []string{"kept@example.com"}, users)
+
+	for _, e := range entities {
+		var gone, kept int64
+		models.DB.Unscoped().Model(e.model).Scopes(e.scope(tenantID)).Count(&gone)
+		models.DB.Unscoped().Model(e.model).Scopes(e.scope(otherID)).Count(&kept)
+		assert.Zero(t, gone, e.name)
+		if e.name != "purge_logs" && e.name != "export_jobs" {
+			assert.NotZero(t, kept, e.name)
+		}
+	}
+}
+
+func TestRun_DeletesAfterGracePeriod(t *testing.T) {
+	testutil.SetupTestDBWithForeignKeys(t)
+	tenantID := seed(t, "grace", -100)
+	var tenant models.Tenant
+	models.DB.First(&tenant, tenantID)
+
+	now := time.Now()
+	require.NoError(t, ScheduleDeletion(&tenant, now))
+
+	Run(now.Add(DeletionGracePeriod - time.Minute))
+	assert.NoError(t, models.DB.First(&models.Tenant{}, tenantID).Error)
+
+	Run(now.Add(DeletionGracePeriod + time.Minute))
+	assert.Error(t, models.DB.Unscoped().First(&models.Tenant{}, tenantID).Error)
+}
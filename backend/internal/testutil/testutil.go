@@ -9,42 +9,61 @@ import (
 	"time"
 
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/envelope"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/search"
 	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-const TestJWTSecret = "test-secret-key"
+const (
+	TestJWTSecret   = "test-secret-key"
+	TestIdentityKey = "1:test-identity-key-0123456789"
+	TestMasterKey   = "1:test-master-key-0123456789"
+)
 
 // SetupTestDB initializes an in-memory SQLite database with all models migrated.
 func SetupTestDB(t *testing.T) *gorm.DB {
 	t.Helper()
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	return setupTestDB(t, ":memory:")
+}
+
+// SetupTestDBWithForeignKeys is SetupTestDB with foreign keys enforced, as
+// they are on PostgreSQL.
+func SetupTestDBWithForeignKeys(t *testing.T) *gorm.DB {
+	t.Helper()
+	return setupTestDB(t, "file::memory:?_pragma=foreign_keys(1)")
+}
+
+func setupTestDB(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	if err != nil {
 		t.Fatalf("failed to open test db: %v", err)
 	}
 
-	err = db.AutoMigrate(
-		&models.Tenant{},
-		&models.Bot{},
-		&models.Group{},
-		&models.FeedbackConfig{},
-		&models.GroupUser{},
-		&models.Feedback{},
-		&models.User{},
-		&models.UserTenant{},
-		&models.PendingFeedback{},
-	)
+	err = conn.AutoMigrate(db.Models...)
 	if err != nil {
 		t.Fatalf("failed to migrate test db: %v", err)
 	}
+	if err := search.Migrate(conn); err != nil {
+		t.Fatalf("failed to create search index: %v", err)
+	}
 
-	models.DB = db
+	models.DB = conn
 	config.Confs.Settings.JWTSecret = TestJWTSecret
-	return db
+	if err := identity.Configure([]string{TestIdentityKey}); err != nil {
+		t.Fatalf("failed to configure identity keys: %v", err)
+	}
+	if err := envelope.Configure([]string{TestMasterKey}); err != nil {
+		t.Fatalf("failed to configure bot token keys: %v", err)
+	}
+	return conn
 }
 
 // CreateTestUser creates a user and returns it.
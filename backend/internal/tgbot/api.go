@@ -0,0 +1,88 @@
+package tgbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+// apiBaseURL is the Telegram Bot API endpoint; tests point it at a local server.
+var apiBaseURL = "https://api.telegram.org"
+
+type apiResponse struct {
+	Ok          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+}
+
+type inlineButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
+// callAPI posts params to a Bot API method and returns the raw result.
+func callAPI(token string, method string, params url.Values) (json.RawMessage, error) {
+	apiURL := fmt.Sprintf("%s/bot%s/%s", apiBaseURL, token, method)
+	resp, err := http.PostForm(apiURL, params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result apiResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	if !result.Ok {
+		return nil, fmt.Errorf("telegram %s: %s", method, result.Description)
+	}
+	return result.Result, nil
+}
+
+// sendRaw calls sendMessage and returns the ID of the sent message, or 0.
+func sendRaw(token string, params url.Values) int64 {
+	result, err := callAPI(token, "sendMessage", params)
+	if err != nil {
+		log.Printf("[tgbot] Error sending message: %v", err)
+		return 0
+	}
+	var msg Message
+	json.Unmarshal(result, &msg)
+	return msg.MessageID
+}
+
+func sendMessage(token string, chatID int64, text string) int64 {
+	return sendRaw(token, url.Values{
+		"chat_id": {fmt.Sprintf("%d", chatID)},
+		"text":    {text},
+	})
+}
+
+func sendMessageWithKeyboard(token string, chatID int64, text string, keyboard [][]inlineButton) int64 {
+	kbJSON, _ := json.Marshal(map[string]interface{}{
+		"inline_keyboard": keyboard,
+	})
+
+	return sendRaw(token, url.Values{
+		"chat_id":      {fmt.Sprintf("%d", chatID)},
+		"text":         {text},
+		"reply_markup": {string(kbJSON)},
+	})
+}
+
+func deleteMessage(token string, chatID int64, messageID int64) error {
+	_, err := callAPI(token, "deleteMessage", url.Values{
+		"chat_id":    {fmt.Sprintf("%d", chatID)},
+		"message_id": {fmt.Sprintf("%d", messageID)},
+	})
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
@@ -16,13 +17,14 @@ type Update struct {
 	MyChatMember  *ChatMemberUp  `json:"my_chat_member"`
 	Message       *Message       `json:"message"`
 	CallbackQuery *CallbackQuery `json:"callback_query"`
+	Poll          *Poll          `json:"poll"`
 }
 
 type CallbackQuery struct {
-	ID      string  `json:"id"`
-	From    User    `json:"from"`
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
 	Message *Message `json:"message"`
-	Data    string  `json:"data"`
+	Data    string   `json:"data"`
 }
 
 type ChatMemberUp struct {
@@ -39,15 +41,17 @@ type Chat struct {
 }
 
 type User struct {
-	ID        int64  `json:"id"`
-	IsBot     bool   `json:"is_bot"`
-	FirstName string `json:"first_name"`
-	Username  string `json:"username"`
+	ID           int64  `json:"id"`
+	IsBot        bool   `json:"is_bot"`
+	FirstName    string `json:"first_name"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code"`
 }
 
 type ChatMember struct {
-	Status string `json:"status"`
-	User   User   `json:"user"`
+	Status   string `json:"status"`
+	User     User   `json:"user"`
+	IsMember bool   `json:"is_member"` // For "restricted" members
 }
 
 type Message struct {
@@ -55,6 +59,19 @@ type Message struct {
 	Chat      Chat   `json:"chat"`
 	From      User   `json:"from"`
 	Text      string `json:"text"`
+	Poll      *Poll  `json:"poll"`
+}
+
+type Poll struct {
+	ID              string       `json:"id"`
+	Options         []PollOption `json:"options"`
+	TotalVoterCount int          `json:"total_voter_count"`
+	IsClosed        bool         `json:"is_closed"`
+}
+
+type PollOption struct {
+	Text       string `json:"text"`
+	VoterCount int    `json:"voter_count"`
 }
 
 type getUpdatesResponse struct {
@@ -69,15 +86,42 @@ func StopAll() {
 	close(stopCh)
 }
 
+// botStops lets StopBot end the polling of a single bot.
+var (
+	botStopsMu sync.Mutex
+	botStops   = map[uint]chan struct{}{}
+)
+
+// StopBot stops polling for one bot, e.g. when it or its tenant is deleted.
+func StopBot(botID uint) {
+	botStopsMu.Lock()
+	defer botStopsMu.Unlock()
+	if ch, ok := botStops[botID]; ok {
+		close(ch)
+		delete(botStops, botID)
+	}
+}
+
 func StartPolling(bot models.Bot) {
 	log.Printf("[tgbot] Starting polling for bot @%s (ID: %d)", bot.BotUsername, bot.ID)
 	offset := int64(0)
 
+	botStopsMu.Lock()
+	if old, ok := botStops[bot.ID]; ok {
+		close(old) // Never poll one bot twice
+	}
+	botStop := make(chan struct{})
+	botStops[bot.ID] = botStop
+	botStopsMu.Unlock()
+
 	for {
 		select {
 		case <-stopCh:
 			log.Printf("[tgbot] Stopping polling for bot @%s", bot.BotUsername)
 			return
+		case <-botStop:
+			log.Printf("[tgbot] Stopping polling for bot @%s", bot.BotUsername)
+			return
 		default:
 		}
 
@@ -98,7 +142,7 @@ func StartPolling(bot models.Bot) {
 }
 
 func getUpdates(token string, offset int64) ([]Update, error) {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/getUpdates?offset=%d&timeout=30&allowed_updates=[\"my_chat_member\",\"message\",\"callback_query\"]", token, offset)
+	url := fmt.Sprintf("%s/bot%s/getUpdates?offset=%d&timeout=30&allowed_updates=[\"my_chat_member\",\"message\",\"callback_query\",\"poll\"]", apiBaseURL, token, offset)
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, err
@@ -132,6 +176,9 @@ func handleUpdate(bot models.Bot, update Update) {
 	if update.CallbackQuery != nil {
 		handleCallbackQuery(bot, update.CallbackQuery)
 	}
+	if update.Poll != nil {
+		handlePollUpdate(bot, update.Poll)
+	}
 }
 
 func handleMyChatMember(bot models.Bot, member *ChatMemberUp) {
@@ -9,12 +9,48 @@ import (
 	"strings"
 
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
 )
 
 func handleCallbackQuery(bot models.Bot, cq *CallbackQuery) {
 	// Answer callback to remove loading state
 	answerCallback(bot.Token, cq.ID)
 
+	if strings.HasPrefix(cq.Data, "lang:") {
+		handleLanguageCallback(bot, cq)
+		return
+	}
+
+	if strings.HasPrefix(cq.Data, "mod:") {
+		handleModerationCallback(bot, cq)
+		return
+	}
+
+	if strings.HasPrefix(cq.Data, "rt:") {
+		handleRatingCallback(bot, cq)
+		return
+	}
+
+	if strings.HasPrefix(cq.Data, "vt:") {
+		handleVoteCallback(bot, cq)
+		return
+	}
+
+	if strings.HasPrefix(cq.Data, "sv:") {
+		handleSurveyCallback(bot, cq)
+		return
+	}
+
+	if strings.HasPrefix(cq.Data, "ro:") {
+		handleRetroColumnCallback(bot, cq)
+		return
+	}
+
+	if strings.HasPrefix(cq.Data, "rd:") {
+		handleRetroDotCallback(bot, cq)
+		return
+	}
+
 	if !strings.HasPrefix(cq.Data, "fb:") {
 		return
 	}
@@ -26,25 +62,27 @@ func handleCallbackQuery(bot models.Bot, cq *CallbackQuery) {
 		return
 	}
 
+	lang := senderLanguage(bot, cq.From)
+
 	// Get pending feedback
 	pending, ok := getPendingFeedback(cq.From.ID)
 	if !ok {
-		sendMessage(bot.Token, cq.Message.Chat.ID, "⏳ Session expired. Please send your feedback again.")
+		sendMessage(bot.Token, cq.Message.Chat.ID, i18n.T(lang, "feedback.session_expired"))
 		return
 	}
 
 	// Find group
 	var group models.Group
 	if err := models.DB.First(&group, groupID).Error; err != nil {
-		sendMessage(bot.Token, cq.Message.Chat.ID, "❌ Group not found.")
+		sendMessage(bot.Token, cq.Message.Chat.ID, i18n.T(lang, "feedback.group_not_found"))
 		return
 	}
 
-	submitFeedback(bot, cq.Message.Chat.ID, cq.From.ID, group, pending.Text, pending.AdminOnly)
+	submitPendingFeedback(bot, cq.Message.Chat.ID, cq.From.ID, group, pending, lang)
 }
 
 func answerCallback(token string, callbackID string) {
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/answerCallbackQuery", token)
+	apiURL := fmt.Sprintf("%s/bot%s/answerCallbackQuery", apiBaseURL, token)
 	resp, err := http.PostForm(apiURL, url.Values{
 		"callback_query_id": {callbackID},
 	})
@@ -0,0 +1,128 @@
+package tgbot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/retention"
+	"gorm.io/gorm"
+)
+
+// commentStartPrefix is the /start parameter of the comment button's link.
+const commentStartPrefix = "comment_"
+
+// postKeyboard returns the buttons under the group post of fb: votes for
+// ideas and a link for commenting anonymously. Comments get none.
+func postKeyboard(bot models.Bot, fb models.Feedback) [][]inlineButton {
+	if fb.ParentID != nil {
+		return nil
+	}
+	keyboard := voteKeyboard(fb)
+	return append(keyboard, []inlineButton{{
+		Text: i18n.T(tenantLanguage(fb.TenantID), "comment.button"),
+		URL:  fmt.Sprintf("https://t.me/%s?start=%s%d", bot.BotUsername, commentStartPrefix, fb.ID),
+	}})
+}
+
+// commentable selects the posted, top-level feedback of bot's active groups.
+func commentable(bot models.Bot) *gorm.DB {
+	return models.DB.Where("posted = ? AND in_digest = ? AND parent_id IS NULL", true, false).
+		Where("group_id IN (SELECT id FROM groups WHERE bot_id = ? AND is_active = ?)", bot.ID, true)
+}
+
+// handleCommentStart opens a comment on a posted feedback from its group
+// link. Only members of the group may comment.
+func handleCommentStart(bot models.Bot, msg *Message, lang string, arg string) {
+	feedbackID, err := strconv.ParseUint(strings.TrimPrefix(arg, commentStartPrefix), 10, 64)
+	var parent models.Feedback
+	if err != nil || commentable(bot).First(&parent, feedbackID).Error != nil {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "comment.not_found"))
+		return
+	}
+	var group models.Group
+	models.DB.First(&group, parent.GroupID)
+	if !isChatMember(bot.Token, group.ChatID, msg.From.ID) {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "comment.not_member", i18n.Args{"group": group.Title}))
+		return
+	}
+
+	pc := models.PendingComment{SenderHash: identity.Pseudonym(msg.From.ID), BotID: bot.ID, FeedbackID: parent.ID}
+	var existing models.PendingComment
+	if err := models.DB.Where("sender_hash IN ?", identity.Pseudonyms(msg.From.ID)).First(&existing).Error; err == nil {
+		models.DB.Model(&existing).Updates(map[string]interface{}{
+			"sender_hash": pc.SenderHash,
+			"bot_id":      bot.ID,
+			"feedback_id": parent.ID,
+		})
+	} else {
+		models.DB.Create(&pc)
+	}
+	sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "comment.prompt", i18n.Args{"preview": preview(parent.Message, historyPreviewLen)}))
+}
+
+// pendingComment returns the unexpired comment prompt of the sender, if any.
+func pendingComment(bot models.Bot, userID int64) (models.PendingComment, bool) {
+	var pc models.PendingComment
+	err := models.DB.Where("bot_id = ? AND sender_hash IN ? AND updated_at >= ?", bot.ID, identity.Pseudonyms(userID), time.Now().Add(-retention.PendingFeedbackTTL)).
+		First(&pc).Error
+	return pc, err == nil
+}
+
+// handleCancelCommand drops the sender's comment prompt.
+func handleCancelCommand(bot models.Bot, msg *Message, lang string) {
+	pc, ok := pendingComment(bot, msg.From.ID)
+	if !ok {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "comment.nothing_to_cancel"))
+		return
+	}
+	models.DB.Delete(&pc)
+	sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "comment.cancelled"))
+}
+
+// submitComment stores text as an anonymous comment on the feedback of pc.
+// Comments go through the group's moderation and posting delays like any
+// feedback, and are posted as replies to the parent's post.
+func submitComment(bot models.Bot, chatID int64, telegramUserID int64, pc models.PendingComment, text string, lang string) {
+	models.DB.Delete(&pc)
+	var parent models.Feedback
+	if err := commentable(bot).First(&parent, pc.FeedbackID).Error; err != nil {
+		sendMessage(bot.Token, chatID, i18n.T(lang, "comment.not_found"))
+		return
+	}
+	var group models.Group
+	models.DB.First(&group, parent.GroupID)
+	groupUser := findOrCreateGroupUser(group, telegramUserID)
+
+	comment := models.Feedback{
+		TenantID:     group.TenantID,
+		GroupID:      group.ID,
+		SenderID:     groupUser.ID,
+		Message:      text,
+		ParentID:     &parent.ID,
+		TrackingCode: generateTrackingCode(),
+	}
+	models.DB.Create(&comment)
+
+	var config models.FeedbackConfig
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+	if config.PostToGroup && config.RequireApproval {
+		requestModeration(bot, group, config, &comment, telegramUserID)
+	} else if config.PostToGroup {
+		schedulePost(bot, group, config, &comment)
+	}
+
+	confirmation := i18n.T(lang, "comment.sent")
+	if comment.Moderation == models.ModerationPending {
+		confirmation += "\n\n" + i18n.T(lang, "feedback.awaiting_approval")
+	}
+	if comment.ScheduledAt != nil {
+		confirmation += "\n\n" + i18n.T(lang, "feedback.scheduled")
+	}
+	tracking := i18n.T(lang, "feedback.tracking_code", i18n.Args{"code": comment.TrackingCode})
+	sendMessage(bot.Token, chatID, confirmation+"\n\n"+tracking)
+}
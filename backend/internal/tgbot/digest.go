@@ -0,0 +1,249 @@
+package tgbot
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/cron"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/msgtemplate"
+	"gorm.io/gorm"
+)
+
+// maxMessageLen is Telegram's limit for message text, in UTF-16 code units.
+const maxMessageLen = 4096
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// queueForDigest holds fb for the group's next digest.
+func queueForDigest(fb *models.Feedback) {
+	fb.DigestQueued = true
+	models.DB.Model(fb).Update("digest_queued", true)
+}
+
+func digestHeader(bot models.Bot, group models.Group) string {
+	vars := templateVars(bot, group, "feedback")
+	return msgtemplate.Render(group.TenantID, group.ID, msgtemplate.KeyDigestHeader, tenantLanguage(group.TenantID), vars)
+}
+
+// digestOrder sorts items so the order doesn't follow submission time, but
+// comes out the same again when a digest message is re-rendered.
+func digestOrder(items []models.Feedback) {
+	key := func(fb models.Feedback) string {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("digest:%d:%d", fb.GroupID, fb.ID)))
+		return string(sum[:])
+	}
+	sort.Slice(items, func(i, j int) bool { return key(items[i]) < key(items[j]) })
+}
+
+// digestText renders one digest message. A single item too long to share a
+// message with the header is sent without it, and cut if still too long.
+func digestText(header string, items []models.Feedback) string {
+	lines := make([]string, len(items))
+	for i, fb := range items {
+		lines[i] = "▪️ " + fb.Message
+	}
+	text := header + "\n\n" + strings.Join(lines, "\n\n")
+	if utf16Len(text) <= maxMessageLen || len(items) != 1 {
+		return text
+	}
+	return cutUTF16(lines[0], maxMessageLen)
+}
+
+// cutUTF16 shortens s with an ellipsis to at most max UTF-16 code units.
+func cutUTF16(s string, max int) string {
+	if utf16Len(s) <= max {
+		return s
+	}
+	n := 0
+	for i, r := range s {
+		n += utf16.RuneLen(r)
+		if n > max-1 {
+			return s[:i] + "…"
+		}
+	}
+	return s
+}
+
+// splitDigest groups ordered items into messages within maxMessageLen.
+func splitDigest(header string, items []models.Feedback) [][]models.Feedback {
+	var parts [][]models.Feedback
+	var part []models.Feedback
+	for _, fb := range items {
+		if len(part) > 0 && utf16Len(digestText(header, append(part, fb))) > maxMessageLen {
+			parts = append(parts, part)
+			part = nil
+		}
+		part = append(part, fb)
+	}
+	if len(part) > 0 {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// runDigests posts the digests that are due and works out when each group's
+// next one is. Feedback still queued for a group that left digest mode is
+// handed to the regular posting path.
+func runDigests(now time.Time) {
+	var configs []models.FeedbackConfig
+	models.DB.Where("digest_mode = ? AND (digest_next_at IS NULL OR digest_next_at <= ?)", true, now).
+		Where("group_id IN (?)", models.DB.Model(&models.Group{}).Select("id").Scopes(activeTenants)).
+		Find(&configs)
+	for _, config := range configs {
+		var group models.Group
+		if err := models.DB.Preload("Bot").First(&group, config.GroupID).Error; err != nil {
+			continue
+		}
+		// A missing next time was just (re)configured, so nothing is due yet
+		if config.DigestNextAt != nil && group.IsActive {
+			postDigest(group, config)
+		}
+
+		schedule, err := cron.Parse(config.DigestSchedule)
+		if err != nil {
+			log.Printf("[tgbot] Group %d has an invalid digest schedule: %v", group.ID, err)
+			continue
+		}
+		if next := schedule.Next(now, tenantLocation(group.TenantID)); !next.IsZero() {
+			models.DB.Model(&config).Update("digest_next_at", next.UTC())
+		}
+	}
+
+	var stranded []models.Feedback
+	models.DB.Where("digest_queued = ? AND posted = ?", true, false).
+		Where("group_id IN (SELECT group_id FROM feedback_configs WHERE digest_mode = ? AND deleted_at IS NULL)", false).
+		Find(&stranded)
+	for i := range stranded {
+		fb := &stranded[i]
+		var group models.Group
+		if err := models.DB.Preload("Bot").First(&group, fb.GroupID).Error; err != nil {
+			continue
+		}
+		var config models.FeedbackConfig
+		models.DB.Where("group_id = ?", group.ID).First(&config)
+
+		fb.DigestQueued = false
+		models.DB.Model(fb).Update("digest_queued", false)
+		if config.PostToGroup {
+			schedulePost(group.Bot, group, config, fb)
+		}
+	}
+}
+
+// postDigest posts a group's queued feedback as one digest, once it comes
+// from at least MinDistinctSenders people. Items are marked posted only after
+// every part of the digest was sent.
+func postDigest(group models.Group, config models.FeedbackConfig) {
+	queued := func() *gorm.DB {
+		return models.DB.Model(&models.Feedback{}).Where("group_id = ? AND digest_queued = ? AND posted = ?", group.ID, true, false)
+	}
+
+	if !config.PostToGroup {
+		// Posting was turned off while items were queued
+		queued().Update("digest_queued", false)
+		return
+	}
+
+	if config.MinDistinctSenders > 1 {
+		var senders int64
+		queued().Distinct("sender_id").Count(&senders)
+		if senders < int64(config.MinDistinctSenders) {
+			return
+		}
+	}
+
+	var items []models.Feedback
+	queued().Find(&items)
+	if len(items) == 0 {
+		return
+	}
+	digestOrder(items)
+
+	header := digestHeader(group.Bot, group)
+	threadID := postThreadID(config)
+	parts := splitDigest(header, items)
+	messageIDs := make([]int64, len(parts))
+	for i, part := range parts {
+		messageIDs[i] = sendPost(group.Bot.Token, group.ChatID, threadID, digestText(header, part), nil, 0)
+		if messageIDs[i] == 0 {
+			// Take back what was sent, so the whole digest is retried next time
+			for _, id := range messageIDs[:i] {
+				deleteMessage(group.Bot.Token, group.ChatID, id)
+			}
+			log.Printf("[tgbot] Failed to post digest to group %d; keeping %d feedback(s) queued", group.ID, len(items))
+			return
+		}
+	}
+
+	for i, part := range parts {
+		ids := make([]uint, len(part))
+		for j, fb := range part {
+			ids[j] = fb.ID
+		}
+		models.DB.Model(&models.Feedback{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"posted":          true,
+			"digest_queued":   false,
+			"in_digest":       true,
+			"post_chat_id":    group.ChatID,
+			"post_thread_id":  threadID,
+			"post_message_id": messageIDs[i],
+			"scheduled_at":    nil,
+		})
+	}
+	if config.DigestPin {
+		pinDigest(group, messageIDs[0])
+	}
+	log.Printf("[tgbot] Posted a digest of %d feedback(s) in %d message(s) to group %d", len(items), len(parts), group.ID)
+}
+
+// pinDigest pins a new digest message and unpins the group's previous one.
+func pinDigest(group models.Group, messageID int64) {
+	pinned := func() *gorm.DB {
+		return models.DB.Model(&models.Feedback{}).Where("group_id = ? AND in_digest = ? AND pinned = ?", group.ID, true, true)
+	}
+	var previous []int64
+	pinned().Distinct().Pluck("post_message_id", &previous)
+	for _, id := range previous {
+		if _, err := callAPI(group.Bot.Token, "unpinChatMessage", url.Values{
+			"chat_id":    {fmt.Sprintf("%d", group.ChatID)},
+			"message_id": {fmt.Sprintf("%d", id)},
+		}); err != nil {
+			log.Printf("[tgbot] Failed to unpin digest %d in group %d: %v", id, group.ID, err)
+		}
+	}
+	pinned().Update("pinned", false)
+
+	if _, err := callAPI(group.Bot.Token, "pinChatMessage", url.Values{
+		"chat_id":              {fmt.Sprintf("%d", group.ChatID)},
+		"message_id":           {fmt.Sprintf("%d", messageID)},
+		"disable_notification": {"true"},
+	}); err != nil {
+		log.Printf("[tgbot] Failed to pin digest %d in group %d: %v", messageID, group.ID, err)
+		return
+	}
+	models.DB.Model(&models.Feedback{}).Where("group_id = ? AND in_digest = ? AND post_message_id = ?", group.ID, true, messageID).
+		Update("pinned", true)
+}
+
+// digestSiblings loads the posted feedback sharing fb's digest message,
+// in digest order.
+func digestSiblings(fb *models.Feedback) []models.Feedback {
+	var items []models.Feedback
+	models.DB.Where("in_digest = ? AND posted = ? AND post_chat_id = ? AND post_message_id = ?", true, true, fb.PostChatID, fb.PostMessageID).
+		Find(&items)
+	digestOrder(items)
+	return items
+}
@@ -1,45 +1,84 @@
 package tgbot
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/msgtemplate"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/retention"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/sealed"
 )
 
+const maxFeedbackLen = 4000
+
 func handlePrivateMessage(bot models.Bot, msg *Message) {
 	userID := msg.From.ID
 	text := strings.TrimSpace(msg.Text)
+	lang := senderLanguage(bot, msg.From)
 
-	if text == "/start" {
-		sendMessage(bot.Token, msg.Chat.ID, "👋 Welcome to FeedbackBot!\n\nSend me a message and I'll deliver it anonymously to your team admin.\n\nUse /adminOnly before your message to keep it visible only to the admin.")
+	switch cmd, arg := parseCommand(text); cmd {
+	case "/start":
+		if strings.HasPrefix(arg, surveyStartPrefix) {
+			handleSurveyStart(bot, msg, lang, arg)
+			return
+		}
+		if strings.HasPrefix(arg, commentStartPrefix) {
+			handleCommentStart(bot, msg, lang, arg)
+			return
+		}
+		vars := templateVars(bot, models.Group{}, "")
+		sendMessage(bot.Token, msg.Chat.ID, msgtemplate.Render(bot.TenantID, 0, msgtemplate.KeyWelcome, lang, vars))
+		return
+	case "/language":
+		handleLanguageCommand(bot, msg, lang, arg)
+		return
+	case "/history":
+		handleHistoryCommand(bot, msg, lang)
+		return
+	case "/status":
+		handleStatusCommand(bot, msg, lang, arg)
+		return
+	case "/retract":
+		handleRetractCommand(bot, msg, lang, arg)
+		return
+	case "/edit":
+		handleEditCommand(bot, msg, lang, arg)
+		return
+	case "/cancel":
+		handleCancelCommand(bot, msg, lang)
 		return
 	}
 
-	// Check if admin_only (case-insensitive prefix)
-	adminOnly := false
-	if len(text) >= len("/adminOnly") && strings.EqualFold(text[:len("/adminOnly")], "/adminOnly") {
-		adminOnly = true
-		text = strings.TrimSpace(text[len("/adminOnly"):])
-		if text == "" {
-			sendMessage(bot.Token, msg.Chat.ID, "Please write your feedback after /adminOnly.\n\nExample: /adminOnly I think we should improve our standup meetings.")
-			return
+	// After a comment link, the next message is the comment
+	if pc, ok := pendingComment(bot, userID); ok {
+		if checkFeedbackText(bot, msg.Chat.ID, text, lang) {
+			submitComment(bot, msg.Chat.ID, userID, pc, text, lang)
 		}
+		return
 	}
 
-	if text == "" {
-		sendMessage(bot.Token, msg.Chat.ID, "Please send a text message with your feedback.")
+	text, feedbackType, adminOnly := cutFeedbackPrefixes(text)
+	if text == "" && feedbackType != models.FeedbackGeneral {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "feedback.type_empty", i18n.Args{"type": feedbackType}))
+		return
+	}
+	if text == "" && adminOnly {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "feedback.admin_only_empty"))
 		return
 	}
 
-	const maxFeedbackLen = 4000
-	if len(text) > maxFeedbackLen {
-		sendMessage(bot.Token, msg.Chat.ID, "Your message is too long. Please keep it under 4000 characters.")
+	if !checkFeedbackText(bot, msg.Chat.ID, text, lang) {
+		return
+	}
+
+	// While a retro of the sender's group is open, plain messages are notes
+	if feedbackType == models.FeedbackGeneral && !adminOnly && handleRetroMessage(bot, msg, text, lang) {
 		return
 	}
 
@@ -48,20 +87,30 @@ func handlePrivateMessage(bot models.Bot, msg *Message) {
 	models.DB.Where("bot_id = ? AND is_active = ?", bot.ID, true).Find(&groups)
 
 	if len(groups) == 0 {
-		sendMessage(bot.Token, msg.Chat.ID, "❌ No active groups found. The bot needs to be added to a group first.")
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "feedback.no_groups"))
+		return
+	}
+
+	groups = groupsAllowingType(groups, feedbackType)
+	if len(groups) == 0 {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "feedback.type_not_allowed", i18n.Args{"type": feedbackType}))
 		return
 	}
 
 	if len(groups) == 1 {
 		// Auto-assign to the only group
-		submitFeedback(bot, msg.Chat.ID, userID, groups[0], text, adminOnly)
+		submitFeedback(bot, msg.Chat.ID, userID, groups[0], text, adminOnly, feedbackType, lang)
 		return
 	}
 
 	// Multiple groups — store pending feedback and show keyboard
 	// For now, use the first group (TODO: implement inline keyboard picker in future iteration)
 	// Store in a simple way: use callback data pattern
-	storePendingFeedback(bot.ID, userID, text, adminOnly)
+	if err := storePendingFeedback(bot, userID, text, adminOnly, feedbackType); err != nil {
+		log.Printf("[tgbot] Failed to seal admin-only feedback for tenant %d: %v", bot.TenantID, err)
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "feedback.seal_failed"))
+		return
+	}
 
 	var keyboard [][]inlineButton
 	for _, g := range groups {
@@ -70,135 +119,246 @@ func handlePrivateMessage(bot models.Bot, msg *Message) {
 		})
 	}
 
-	sendMessageWithKeyboard(bot.Token, msg.Chat.ID, "📋 Which group is this feedback for?", keyboard)
+	sendMessageWithKeyboard(bot.Token, msg.Chat.ID, i18n.T(lang, "feedback.pick_group"), keyboard)
+}
+
+// checkFeedbackText tells the sender when text is empty or too long.
+func checkFeedbackText(bot models.Bot, chatID int64, text string, lang string) bool {
+	if text == "" {
+		sendMessage(bot.Token, chatID, i18n.T(lang, "feedback.empty"))
+		return false
+	}
+	if len(text) > maxFeedbackLen {
+		sendMessage(bot.Token, chatID, i18n.N(lang, "feedback.too_long", maxFeedbackLen))
+		return false
+	}
+	return true
 }
 
-func submitFeedback(bot models.Bot, chatID int64, telegramUserID int64, group models.Group, message string, adminOnly bool) {
-	// Find or create GroupUser
-	var groupUser models.GroupUser
-	result := models.DB.Where("group_id = ? AND telegram_user_id = ?", group.ID, telegramUserID).First(&groupUser)
-	if result.Error != nil {
-		groupUser = models.GroupUser{
-			TenantID:       group.TenantID,
-			GroupID:        group.ID,
-			TelegramUserID: telegramUserID,
+// cutFeedbackPrefixes strips the /adminOnly and type command (/idea, /issue,
+// /praise or /question) prefixes from text, in either order. Both are
+// matched case-insensitively; feedback without a type command is general.
+func cutFeedbackPrefixes(text string) (rest, feedbackType string, adminOnly bool) {
+	feedbackType = models.FeedbackGeneral
+	for {
+		if !adminOnly && len(text) >= len("/adminOnly") && strings.EqualFold(text[:len("/adminOnly")], "/adminOnly") {
+			adminOnly = true
+			text = strings.TrimSpace(text[len("/adminOnly"):])
+			continue
 		}
-		models.DB.Create(&groupUser)
+		if t, n := typeCommand(text); n > 0 && feedbackType == models.FeedbackGeneral {
+			feedbackType = t
+			text = strings.TrimSpace(text[n:])
+			continue
+		}
+		return text, feedbackType, adminOnly
 	}
+}
 
-	// Create feedback
+// typeCommand returns the feedback type named by the command text starts
+// with and the command's length, or 0 when there is none.
+func typeCommand(text string) (string, int) {
+	for _, t := range models.FeedbackTypes {
+		cmd := "/" + t
+		if t == models.FeedbackGeneral || len(text) < len(cmd) || !strings.EqualFold(text[:len(cmd)], cmd) {
+			continue
+		}
+		if len(text) == len(cmd) || unicode.IsSpace(rune(text[len(cmd)])) {
+			return t, len(cmd)
+		}
+	}
+	return "", 0
+}
+
+// groupsAllowingType keeps the groups whose config accepts feedbackType.
+func groupsAllowingType(groups []models.Group, feedbackType string) []models.Group {
+	if feedbackType == models.FeedbackGeneral {
+		return groups
+	}
+	ids := make([]uint, len(groups))
+	for i, g := range groups {
+		ids[i] = g.ID
+	}
+	var configs []models.FeedbackConfig
+	models.DB.Where("group_id IN ?", ids).Find(&configs)
+	denied := map[uint]bool{}
+	for _, c := range configs {
+		denied[c.GroupID] = !c.AllowsType(feedbackType)
+	}
+
+	var allowed []models.Group
+	for _, g := range groups {
+		if !denied[g.ID] {
+			allowed = append(allowed, g)
+		}
+	}
+	return allowed
+}
+
+func submitFeedback(bot models.Bot, chatID int64, telegramUserID int64, group models.Group, message string, adminOnly bool, feedbackType string, lang string) {
 	feedback := models.Feedback{
 		TenantID:  group.TenantID,
-		GroupID:   group.ID,
-		SenderID:  groupUser.ID,
 		Message:   message,
+		Type:      feedbackType,
 		AdminOnly: adminOnly,
-		Posted:    false,
 	}
+	if adminOnly {
+		if err := sealForAdmin(&feedback, message); err != nil {
+			log.Printf("[tgbot] Failed to seal admin-only feedback for tenant %d: %v", group.TenantID, err)
+			sendMessage(bot.Token, chatID, i18n.T(lang, "feedback.seal_failed"))
+			return
+		}
+	}
+	createFeedback(bot, chatID, telegramUserID, group, feedback, lang)
+}
+
+// submitPendingFeedback submits the feedback a sender wrote before picking
+// its group. Admin-only text was sealed when it was stored, if the tenant
+// had an admin key then.
+func submitPendingFeedback(bot models.Bot, chatID int64, telegramUserID int64, group models.Group, pending models.PendingFeedback, lang string) {
+	if pending.SealedKey == "" {
+		submitFeedback(bot, chatID, telegramUserID, group, pending.Text, pending.AdminOnly, pending.Type, lang)
+		return
+	}
+	createFeedback(bot, chatID, telegramUserID, group, models.Feedback{
+		Message:   pending.Text,
+		Type:      pending.Type,
+		AdminOnly: true,
+		Sealed:    true,
+		SealedKey: pending.SealedKey,
+	}, lang)
+}
+
+// createFeedback stores feedback, with its message ready to store, from the
+// sender in group, queues its post and confirms it to the sender.
+func createFeedback(bot models.Bot, chatID int64, telegramUserID int64, group models.Group, feedback models.Feedback, lang string) {
+	groupUser := findOrCreateGroupUser(group, telegramUserID)
+	feedback.TenantID = group.TenantID
+	feedback.GroupID = group.ID
+	feedback.SenderID = groupUser.ID
+	feedback.TrackingCode = generateTrackingCode()
+	adminOnly := feedback.AdminOnly
 	models.DB.Create(&feedback)
 
 	// Post to group if config allows and not admin_only
-	if !adminOnly {
-		var config models.FeedbackConfig
-		if err := models.DB.Where("group_id = ?", group.ID).First(&config).Error; err == nil {
-			if config.PostToGroup {
-				postText := fmt.Sprintf("📬 Anonymous Feedback:\n\n%s", message)
-				if config.ForumTopicID != nil && *config.ForumTopicID > 0 {
-					sendMessageToTopic(bot.Token, group.ChatID, *config.ForumTopicID, postText)
-				} else {
-					sendMessage(bot.Token, group.ChatID, postText)
-				}
-				models.DB.Model(&feedback).Update("posted", true)
-			}
+	var config models.FeedbackConfig
+	hasConfig := models.DB.Where("group_id = ?", group.ID).First(&config).Error == nil
+	if !adminOnly && hasConfig {
+		if config.PostToGroup && config.RequireApproval {
+			requestModeration(bot, group, config, &feedback, telegramUserID)
+		} else if config.PostToGroup {
+			schedulePost(bot, group, config, &feedback)
 		}
 	}
 
-	// Confirm to user
+	templateType := "feedback"
 	if adminOnly {
-		sendMessage(bot.Token, chatID, "✅ Your feedback has been sent privately to the admin. It will NOT be posted in the group.")
-	} else {
-		sendMessage(bot.Token, chatID, "✅ Your feedback has been submitted anonymously. Thank you!")
+		templateType = "admin_only"
+	}
+	vars := templateVars(bot, group, templateType)
+
+	// Confirm to user, with the tracking code for /status and /retract
+	confirmKey := msgtemplate.KeyConfirmation
+	if adminOnly {
+		confirmKey = msgtemplate.KeyConfirmationAdminOnly
+	}
+	confirmation := msgtemplate.Render(group.TenantID, group.ID, confirmKey, lang, vars)
+	if feedback.Moderation == models.ModerationPending {
+		confirmation += "\n\n" + i18n.T(lang, "feedback.awaiting_approval")
+	}
+	if feedback.ScheduledAt != nil {
+		confirmation += "\n\n" + i18n.T(lang, "feedback.scheduled")
+	}
+	if feedback.DigestQueued {
+		confirmation += "\n\n" + i18n.T(lang, "feedback.digest_queued")
+	}
+	tracking := i18n.T(lang, "feedback.tracking_code", i18n.Args{"code": feedback.TrackingCode})
+	sendMessage(bot.Token, chatID, confirmation+"\n\n"+tracking)
+
+	if hasConfig {
+		askRating(bot, chatID, config, feedback, lang)
+	}
+}
+
+// sealForAdmin stores text in fb as a sealed box for the tenant's admin
+// public key, or in plaintext when the tenant hasn't registered one.
+func sealForAdmin(fb *models.Feedback, text string) error {
+	var tenant models.Tenant
+	models.DB.Select("id", "admin_public_key").First(&tenant, fb.TenantID)
+	if tenant.AdminPublicKey == "" {
+		fb.Message, fb.Sealed, fb.SealedKey = text, false, ""
+		return nil
+	}
+	ciphertext, err := sealed.Seal(tenant.AdminPublicKey, text)
+	if err != nil {
+		return err
+	}
+	fb.Message, fb.Sealed, fb.SealedKey = ciphertext, true, sealed.Fingerprint(tenant.AdminPublicKey)
+	return nil
+}
+
+// messagePreview is what the sender sees of their own feedback; sealed
+// messages can't be read back by the server.
+func messagePreview(lang string, fb models.Feedback) string {
+	if fb.Sealed {
+		return i18n.T(lang, "feedback.sealed_preview")
 	}
+	return preview(fb.Message, historyPreviewLen)
 }
 
-func storePendingFeedback(botID uint, userID int64, text string, adminOnly bool) {
+// templateVars collects the values available to tenant message templates.
+func templateVars(bot models.Bot, group models.Group, feedbackType string) msgtemplate.Vars {
+	var tenant models.Tenant
+	models.DB.Select("id", "name").First(&tenant, bot.TenantID)
+	return msgtemplate.Vars{
+		TenantName:   tenant.Name,
+		GroupTitle:   group.Title,
+		BotName:      bot.BotName,
+		BotUsername:  bot.BotUsername,
+		FeedbackType: feedbackType,
+	}
+}
+
+// storePendingFeedback keeps the sender's message while they pick a group.
+// Admin-only text is sealed right away, so it is never stored in plaintext
+// when the tenant has an admin key.
+func storePendingFeedback(bot models.Bot, userID int64, text string, adminOnly bool, feedbackType string) error {
 	pf := models.PendingFeedback{
-		TelegramUserID: userID,
-		BotID:          botID,
-		Text:           text,
-		AdminOnly:      adminOnly,
+		SenderHash: identity.Pseudonym(userID),
+		BotID:      bot.ID,
+		Text:       text,
+		AdminOnly:  adminOnly,
+		Type:       feedbackType,
+	}
+	if adminOnly {
+		fb := models.Feedback{TenantID: bot.TenantID}
+		if err := sealForAdmin(&fb, text); err != nil {
+			return err
+		}
+		pf.Text, pf.SealedKey = fb.Message, fb.SealedKey
 	}
 	var existing models.PendingFeedback
-	if err := models.DB.Where("telegram_user_id = ?", userID).First(&existing).Error; err == nil {
+	if err := models.DB.Where("sender_hash IN ?", identity.Pseudonyms(userID)).First(&existing).Error; err == nil {
 		models.DB.Model(&existing).Updates(map[string]interface{}{
-			"bot_id":     botID,
-			"text":       text,
-			"admin_only": adminOnly,
+			"sender_hash": pf.SenderHash,
+			"bot_id":      pf.BotID,
+			"text":        pf.Text,
+			"admin_only":  pf.AdminOnly,
+			"sealed_key":  pf.SealedKey,
+			"type":        pf.Type,
 		})
 	} else {
 		models.DB.Create(&pf)
 	}
+	return nil
 }
 
 func getPendingFeedback(userID int64) (models.PendingFeedback, bool) {
 	var pf models.PendingFeedback
-	if err := models.DB.Where("telegram_user_id = ?", userID).First(&pf).Error; err != nil {
+	if err := models.DB.Where("sender_hash IN ? AND updated_at >= ?", identity.Pseudonyms(userID), time.Now().Add(-retention.PendingFeedbackTTL)).
+		First(&pf).Error; err != nil {
 		return pf, false
 	}
 	models.DB.Delete(&pf)
 	return pf, true
 }
-
-type inlineButton struct {
-	Text         string `json:"text"`
-	CallbackData string `json:"callback_data"`
-}
-
-func sendMessage(token string, chatID int64, text string) {
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", token)
-	resp, err := http.PostForm(apiURL, url.Values{
-		"chat_id": {fmt.Sprintf("%d", chatID)},
-		"text":    {text},
-	})
-	if err != nil {
-		log.Printf("[tgbot] Error sending message: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-	io.ReadAll(resp.Body)
-}
-
-func sendMessageToTopic(token string, chatID int64, topicID int, text string) {
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", token)
-	resp, err := http.PostForm(apiURL, url.Values{
-		"chat_id":           {fmt.Sprintf("%d", chatID)},
-		"text":              {text},
-		"message_thread_id": {fmt.Sprintf("%d", topicID)},
-	})
-	if err != nil {
-		log.Printf("[tgbot] Error sending message to topic: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-	io.ReadAll(resp.Body)
-}
-
-func sendMessageWithKeyboard(token string, chatID int64, text string, keyboard [][]inlineButton) {
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", token)
-
-	kbJSON, _ := json.Marshal(map[string]interface{}{
-		"inline_keyboard": keyboard,
-	})
-
-	resp, err := http.PostForm(apiURL, url.Values{
-		"chat_id":      {fmt.Sprintf("%d", chatID)},
-		"text":         {text},
-		"reply_markup": {string(kbJSON)},
-	})
-	if err != nil {
-		log.Printf("[tgbot] Error sending keyboard message: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-	io.ReadAll(resp.Body)
-}
@@ -0,0 +1,107 @@
+package tgbot
+
+import (
+	"strings"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+)
+
+// senderLanguage picks the language for messages to a sender: their /language
+// choice, then the Telegram client language, then the tenant default.
+func senderLanguage(bot models.Bot, from User) string {
+	return userLanguage(bot, from.ID, from.LanguageCode)
+}
+
+// userLanguage is senderLanguage for when only the user ID is known, e.g.
+// when notifying a sender outside of an update; languageCode may be empty.
+func userLanguage(bot models.Bot, telegramUserID int64, languageCode string) string {
+	var pref models.SenderPreference
+	if err := models.DB.Where("bot_id = ? AND sender_hash IN ?", bot.ID, identity.Pseudonyms(telegramUserID)).First(&pref).Error; err == nil {
+		if i18n.IsSupported(pref.Language) {
+			return pref.Language
+		}
+	}
+
+	if lang := i18n.Normalize(languageCode); lang != "" {
+		return lang
+	}
+
+	return tenantLanguage(bot.TenantID)
+}
+
+// tenantLanguage returns the tenant default language, used for group posts.
+func tenantLanguage(tenantID uint) string {
+	var tenant models.Tenant
+	if err := models.DB.Select("id", "default_language").First(&tenant, tenantID).Error; err == nil {
+		if i18n.IsSupported(tenant.DefaultLanguage) {
+			return tenant.DefaultLanguage
+		}
+	}
+	return i18n.DefaultLanguage
+}
+
+func setSenderLanguage(botID uint, telegramUserID int64, lang string) {
+	var pref models.SenderPreference
+	if err := models.DB.Where("bot_id = ? AND sender_hash IN ?", botID, identity.Pseudonyms(telegramUserID)).First(&pref).Error; err == nil {
+		models.DB.Model(&pref).Updates(map[string]interface{}{
+			"language":    lang,
+			"sender_hash": identity.Pseudonym(telegramUserID),
+		})
+		return
+	}
+	models.DB.Create(&models.SenderPreference{
+		BotID:      botID,
+		SenderHash: identity.Pseudonym(telegramUserID),
+		Language:   lang,
+	})
+}
+
+// handleLanguageCommand handles "/language" (shows a picker) and
+// "/language <code>" (sets the language directly).
+func handleLanguageCommand(bot models.Bot, msg *Message, lang string, arg string) {
+	if arg == "" {
+		var keyboard [][]inlineButton
+		for _, code := range i18n.Supported {
+			keyboard = append(keyboard, []inlineButton{
+				{Text: i18n.Names[code], CallbackData: "lang:" + code},
+			})
+		}
+		sendMessageWithKeyboard(bot.Token, msg.Chat.ID, i18n.T(lang, "language.choose"), keyboard)
+		return
+	}
+
+	code := i18n.Normalize(arg)
+	if code == "" {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "language.unsupported", i18n.Args{
+			"languages": strings.Join(i18n.Supported, ", "),
+		}))
+		return
+	}
+
+	setSenderLanguage(bot.ID, msg.From.ID, code)
+	sendMessage(bot.Token, msg.Chat.ID, i18n.T(code, "language.set", i18n.Args{"language": i18n.Names[code]}))
+}
+
+func handleLanguageCallback(bot models.Bot, cq *CallbackQuery) {
+	code := i18n.Normalize(strings.TrimPrefix(cq.Data, "lang:"))
+	if code == "" {
+		return
+	}
+	setSenderLanguage(bot.ID, cq.From.ID, code)
+	sendMessage(bot.Token, cq.Message.Chat.ID, i18n.T(code, "language.set", i18n.Args{"language": i18n.Names[code]}))
+}
+
+// parseCommand splits "/cmd args" into a lower-cased command (without any
+// "@botname" suffix) and the trimmed argument string.
+func parseCommand(text string) (string, string) {
+	if !strings.HasPrefix(text, "/") {
+		return "", text
+	}
+	cmd, arg, _ := strings.Cut(text, " ")
+	if i := strings.Index(cmd, "@"); i >= 0 {
+		cmd = cmd[:i]
+	}
+	return strings.ToLower(cmd), strings.TrimSpace(arg)
+}
@@ -0,0 +1,215 @@
+package tgbot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+)
+
+var ErrNotPending = errors.New("feedback is not awaiting moderation")
+
+// requestModeration queues fb for approval and sends approve/reject buttons
+// to the group's moderation chat, if one is configured.
+func requestModeration(bot models.Bot, group models.Group, config models.FeedbackConfig, fb *models.Feedback, telegramUserID int64) {
+	// Keep an encrypted reply address until the sender has been told the outcome
+	replyTo, err := identity.Encrypt(telegramUserID)
+	if err != nil {
+		log.Printf("[tgbot] Failed to encrypt reply address: %v", err)
+	}
+	fb.Moderation = models.ModerationPending
+	fb.ReplyTo = replyTo
+	models.DB.Model(fb).Updates(map[string]interface{}{
+		"moderation": models.ModerationPending,
+		"reply_to":   replyTo,
+	})
+
+	if config.ModerationChatID == nil || *config.ModerationChatID == 0 {
+		return
+	}
+
+	lang := tenantLanguage(group.TenantID)
+	text := i18n.T(lang, "moderation.request", i18n.Args{"group": group.Title, "message": fb.Message})
+	keyboard := [][]inlineButton{{
+		{Text: i18n.T(lang, "moderation.approve"), CallbackData: fmt.Sprintf("mod:approve:%d", fb.ID)},
+		{Text: i18n.T(lang, "moderation.reject"), CallbackData: fmt.Sprintf("mod:reject:%d", fb.ID)},
+	}}
+	sendMessageWithKeyboard(bot.Token, *config.ModerationChatID, text, keyboard)
+}
+
+// moderate moves fb out of pending with a single conditional update, so of
+// two moderators acting at once only one gets through; the other gets
+// ErrNotPending.
+func moderate(fb *models.Feedback, moderation, reason string) error {
+	now := time.Now()
+	updates := map[string]interface{}{"moderation": moderation, "moderated_at": now}
+	if moderation == models.ModerationRejected {
+		updates["reject_reason"] = reason
+	}
+	res := models.DB.Model(&models.Feedback{}).
+		Where("id = ? AND moderation = ?", fb.ID, models.ModerationPending).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected != 1 {
+		return ErrNotPending
+	}
+	fb.Moderation = moderation
+	fb.ModeratedAt = &now
+	if moderation == models.ModerationRejected {
+		fb.RejectReason = reason
+	}
+	return nil
+}
+
+// ApproveFeedback posts a pending feedback to its group and tells the sender.
+func ApproveFeedback(fb *models.Feedback) error {
+	if fb.Moderation != models.ModerationPending {
+		return ErrNotPending
+	}
+
+	var group models.Group
+	if err := models.DB.Preload("Bot").First(&group, fb.GroupID).Error; err != nil {
+		return err
+	}
+	var config models.FeedbackConfig
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+
+	if err := moderate(fb, models.ModerationApproved, ""); err != nil {
+		return err
+	}
+
+	schedulePost(group.Bot, group, config, fb)
+	notifySender(group.Bot, fb, "moderation.sender_approved", nil)
+	return nil
+}
+
+// RejectFeedback marks a pending feedback as rejected and tells the sender.
+func RejectFeedback(fb *models.Feedback, reason string) error {
+	if fb.Moderation != models.ModerationPending {
+		return ErrNotPending
+	}
+
+	var group models.Group
+	if err := models.DB.Preload("Bot").First(&group, fb.GroupID).Error; err != nil {
+		return err
+	}
+
+	if err := moderate(fb, models.ModerationRejected, reason); err != nil {
+		return err
+	}
+
+	args := i18n.Args{"reason": ""}
+	if reason != "" {
+		args["reason"] = "\n\n" + reason
+	}
+	notifySender(group.Bot, fb, "moderation.sender_rejected", args)
+	return nil
+}
+
+// notifySender DMs the sender of fb using its encrypted reply address, then
+// forgets the address. The private chat ID of a user equals their user ID.
+func notifySender(bot models.Bot, fb *models.Feedback, key string, args i18n.Args) {
+	if fb.ReplyTo == "" {
+		return
+	}
+	telegramUserID, err := identity.Decrypt(fb.ReplyTo)
+	if err != nil {
+		log.Printf("[tgbot] Cannot open reply address of feedback %d: %v", fb.ID, err)
+		return
+	}
+
+	lang := userLanguage(bot, telegramUserID, "")
+	if args == nil {
+		args = i18n.Args{}
+	}
+	args["code"] = fb.TrackingCode
+	sendMessage(bot.Token, telegramUserID, i18n.T(lang, key, args))
+
+	fb.ReplyTo = ""
+	models.DB.Model(fb).Update("reply_to", "")
+}
+
+// isChatAdmin reports whether userID administers chatID.
+func isChatAdmin(token string, chatID int64, userID int64) bool {
+	result, err := callAPI(token, "getChatMember", url.Values{
+		"chat_id": {fmt.Sprintf("%d", chatID)},
+		"user_id": {fmt.Sprintf("%d", userID)},
+	})
+	if err != nil {
+		log.Printf("[tgbot] getChatMember failed: %v", err)
+		return false
+	}
+	var member ChatMember
+	json.Unmarshal(result, &member)
+	return member.Status == "creator" || member.Status == "administrator"
+}
+
+// handleModerationCallback handles "mod:approve:<id>" and "mod:reject:<id>"
+// pressed in a moderation chat. Only admins of the feedback's group may decide.
+func handleModerationCallback(bot models.Bot, cq *CallbackQuery) {
+	parts := strings.Split(cq.Data, ":")
+	if len(parts) != 3 || cq.Message == nil {
+		return
+	}
+	feedbackID, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		log.Printf("[tgbot] Invalid moderation callback: %s", cq.Data)
+		return
+	}
+
+	var fb models.Feedback
+	if err := models.DB.First(&fb, feedbackID).Error; err != nil {
+		return
+	}
+	var group models.Group
+	if err := models.DB.First(&group, fb.GroupID).Error; err != nil || group.BotID != bot.ID {
+		return
+	}
+
+	lang := tenantLanguage(group.TenantID)
+	if !isChatAdmin(bot.Token, group.ChatID, cq.From.ID) {
+		sendMessage(bot.Token, cq.Message.Chat.ID, i18n.T(lang, "moderation.not_allowed"))
+		return
+	}
+
+	var decided string
+	switch parts[1] {
+	case "approve":
+		err = ApproveFeedback(&fb)
+		decided = "moderation.approved_by"
+	case "reject":
+		err = RejectFeedback(&fb, "")
+		decided = "moderation.rejected_by"
+	default:
+		return
+	}
+	if errors.Is(err, ErrNotPending) {
+		sendMessage(bot.Token, cq.Message.Chat.ID, i18n.T(lang, "moderation.already"))
+		return
+	}
+	if err != nil {
+		log.Printf("[tgbot] Moderation of feedback %d failed: %v", fb.ID, err)
+		return
+	}
+
+	// Replace the buttons with the decision so it can't be pressed twice
+	name := cq.From.FirstName
+	if cq.From.Username != "" {
+		name = "@" + cq.From.Username
+	}
+	callAPI(bot.Token, "editMessageText", url.Values{
+		"chat_id":    {fmt.Sprintf("%d", cq.Message.Chat.ID)},
+		"message_id": {fmt.Sprintf("%d", cq.Message.MessageID)},
+		"text":       {cq.Message.Text + "\n\n" + i18n.T(lang, decided, i18n.Args{"name": name})},
+	})
+}
@@ -0,0 +1,200 @@
+package tgbot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/msgtemplate"
+)
+
+var ErrNotPosted = errors.New("feedback is not posted to the group")
+
+// postText builds the group post for fb: the (customizable) header for its
+// type, or for comments, followed by the feedback message.
+func postText(bot models.Bot, group models.Group, fb models.Feedback) string {
+	vars := templateVars(bot, group, "feedback")
+	key := msgtemplate.PostHeaderKey(fb.Type)
+	if fb.ParentID != nil {
+		key = msgtemplate.KeyCommentHeader
+	}
+	header := msgtemplate.Render(group.TenantID, group.ID, key, tenantLanguage(group.TenantID), vars)
+	return fmt.Sprintf("%s\n\n%s", header, fb.Message)
+}
+
+// postFeedback sends fb to its group (or configured forum topic) and records
+// where it was posted so it can later be edited, pinned or deleted. If the
+// send fails, fb stays queued in ScheduledAt for the scheduler to retry. It
+// reports whether fb was posted.
+func postFeedback(bot models.Bot, group models.Group, config models.FeedbackConfig, fb *models.Feedback) bool {
+	chatID, threadID := group.ChatID, postThreadID(config)
+	var replyTo int64
+	if fb.ParentID != nil {
+		// Comments reply to their parent's post, and are dropped with it
+		var parent models.Feedback
+		if err := models.DB.First(&parent, *fb.ParentID).Error; err != nil || !parent.Posted || parent.PostMessageID == 0 {
+			models.DB.Model(fb).Update("scheduled_at", nil)
+			return false
+		}
+		chatID, threadID, replyTo = postChatID(&parent, group), parent.PostThreadID, parent.PostMessageID
+	}
+	messageID := sendPost(bot.Token, chatID, threadID, postText(bot, group, *fb), postKeyboard(bot, *fb), replyTo)
+	if messageID == 0 {
+		log.Printf("[tgbot] Failed to post feedback %d to group %d, will retry", fb.ID, group.ID)
+		if fb.ScheduledAt == nil {
+			now := time.Now()
+			fb.ScheduledAt = &now
+			models.DB.Model(fb).Update("scheduled_at", now)
+		}
+		return false
+	}
+
+	models.DB.Model(fb).Updates(map[string]interface{}{
+		"posted":          true,
+		"post_chat_id":    chatID,
+		"post_thread_id":  threadID,
+		"post_message_id": messageID,
+		"scheduled_at":    nil,
+	})
+	return true
+}
+
+// postThreadID returns the forum topic posts go to, or 0 for the main chat.
+func postThreadID(config models.FeedbackConfig) int {
+	if config.ForumTopicID != nil && *config.ForumTopicID > 0 {
+		return *config.ForumTopicID
+	}
+	return 0
+}
+
+// sendPost sends text to a group, or to its forum topic when threadID is set,
+// with an optional inline keyboard and as a reply to replyTo when set. It
+// returns the message ID or 0.
+func sendPost(token string, chatID int64, threadID int, text string, keyboard [][]inlineButton, replyTo int64) int64 {
+	params := url.Values{
+		"chat_id": {fmt.Sprintf("%d", chatID)},
+		"text":    {text},
+	}
+	if threadID > 0 {
+		params.Set("message_thread_id", fmt.Sprintf("%d", threadID))
+	}
+	if keyboard != nil {
+		markup, _ := json.Marshal(map[string]interface{}{"inline_keyboard": keyboard})
+		params.Set("reply_markup", string(markup))
+	}
+	if replyTo > 0 {
+		reply, _ := json.Marshal(map[string]interface{}{"message_id": replyTo, "allow_sending_without_reply": true})
+		params.Set("reply_parameters", string(reply))
+	}
+	return sendRaw(token, params)
+}
+
+// postContext loads the bot and group a posted feedback belongs to.
+func postContext(fb *models.Feedback) (models.Bot, models.Group, error) {
+	var group models.Group
+	var bot models.Bot
+	if !fb.Posted || fb.PostMessageID == 0 {
+		return bot, group, ErrNotPosted
+	}
+	if err := models.DB.First(&group, fb.GroupID).Error; err != nil {
+		return bot, group, err
+	}
+	if err := models.DB.First(&bot, group.BotID).Error; err != nil {
+		return bot, group, err
+	}
+	return bot, group, nil
+}
+
+func postChatID(fb *models.Feedback, group models.Group) int64 {
+	if fb.PostChatID != 0 {
+		return fb.PostChatID
+	}
+	return group.ChatID
+}
+
+// UnpostFeedback deletes the group post of fb and marks it as not posted.
+// A feedback in a digest is taken out of the digest message instead, which
+// is deleted once nothing is left in it.
+func UnpostFeedback(fb *models.Feedback) error {
+	bot, group, err := postContext(fb)
+	if err != nil {
+		return err
+	}
+
+	var rest []models.Feedback
+	if fb.InDigest {
+		for _, item := range digestSiblings(fb) {
+			if item.ID != fb.ID {
+				rest = append(rest, item)
+			}
+		}
+	}
+	if len(rest) > 0 {
+		err = editPost(bot, group, fb, digestText(digestHeader(bot, group), rest), nil)
+	} else {
+		err = deleteMessage(bot.Token, postChatID(fb, group), fb.PostMessageID)
+	}
+	if err != nil {
+		return err
+	}
+	return models.DB.Model(fb).Updates(map[string]interface{}{
+		"posted":          false,
+		"pinned":          false,
+		"in_digest":       false,
+		"post_message_id": 0,
+	}).Error
+}
+
+// EditFeedbackPost rewrites the group post of fb with its current message,
+// e.g. after a moderator redacted it.
+func EditFeedbackPost(fb *models.Feedback) error {
+	bot, group, err := postContext(fb)
+	if err != nil {
+		return err
+	}
+	if fb.InDigest {
+		return editPost(bot, group, fb, digestText(digestHeader(bot, group), digestSiblings(fb)), nil)
+	}
+	return editPost(bot, group, fb, postText(bot, group, *fb), postKeyboard(bot, *fb))
+}
+
+// editPost replaces the text of the group post of fb. Telegram drops the
+// inline keyboard of an edited message unless it is sent again.
+func editPost(bot models.Bot, group models.Group, fb *models.Feedback, text string, keyboard [][]inlineButton) error {
+	params := url.Values{
+		"chat_id":    {fmt.Sprintf("%d", postChatID(fb, group))},
+		"message_id": {fmt.Sprintf("%d", fb.PostMessageID)},
+		"text":       {text},
+	}
+	if keyboard != nil {
+		markup, _ := json.Marshal(map[string]interface{}{"inline_keyboard": keyboard})
+		params.Set("reply_markup", string(markup))
+	}
+	_, err := callAPI(bot.Token, "editMessageText", params)
+	return err
+}
+
+// PinFeedbackPost pins or unpins the group post of fb.
+func PinFeedbackPost(fb *models.Feedback, pin bool) error {
+	bot, group, err := postContext(fb)
+	if err != nil {
+		return err
+	}
+	method := "unpinChatMessage"
+	params := url.Values{
+		"chat_id":    {fmt.Sprintf("%d", postChatID(fb, group))},
+		"message_id": {fmt.Sprintf("%d", fb.PostMessageID)},
+	}
+	if pin {
+		method = "pinChatMessage"
+		params.Set("disable_notification", "true")
+	}
+	if _, err := callAPI(bot.Token, method, params); err != nil {
+		return err
+	}
+	return models.DB.Model(fb).Update("pinned", pin).Error
+}
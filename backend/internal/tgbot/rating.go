@@ -0,0 +1,92 @@
+package tgbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/ratings"
+)
+
+// ratingSkip is the score of the button that declines to rate.
+const ratingSkip = "skip"
+
+// askRating offers the sender a score for fb when the group collects ratings.
+func askRating(bot models.Bot, chatID int64, config models.FeedbackConfig, fb models.Feedback, lang string) {
+	scale, ok := ratings.Lookup(config.RatingScale)
+	if !ok {
+		return
+	}
+	sendMessageWithKeyboard(bot.Token, chatID, i18n.T(lang, "rating.ask_"+config.RatingScale), ratingKeyboard(fb.ID, config.RatingScale, scale, lang))
+}
+
+// ratingKeyboard lays out scores of up to six per row, then a skip button.
+func ratingKeyboard(feedbackID uint, name string, scale ratings.Scale, lang string) [][]inlineButton {
+	const perRow = 6
+	var keyboard [][]inlineButton
+	var row []inlineButton
+	for score := scale.Min; score <= scale.Max; score++ {
+		row = append(row, inlineButton{
+			Text:         strconv.Itoa(score),
+			CallbackData: fmt.Sprintf("rt:%d:%s:%d", feedbackID, name, score),
+		})
+		if len(row) == perRow {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+	return append(keyboard, []inlineButton{{
+		Text:         i18n.T(lang, "rating.skip"),
+		CallbackData: fmt.Sprintf("rt:%d:%s:%s", feedbackID, name, ratingSkip),
+	}})
+}
+
+// handleRatingCallback stores the score pressed under a rating prompt,
+// "rt:<feedback>:<scale>:<score>" or "skip". Only the feedback's sender can
+// rate it.
+func handleRatingCallback(bot models.Bot, cq *CallbackQuery) {
+	parts := strings.Split(strings.TrimPrefix(cq.Data, "rt:"), ":")
+	if len(parts) != 3 || cq.Message == nil {
+		return
+	}
+	feedbackID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		log.Printf("[tgbot] Invalid rating callback data: %s", cq.Data)
+		return
+	}
+	name, choice := parts[1], parts[2]
+	lang := senderLanguage(bot, cq.From)
+
+	// Drop the keyboard so the prompt can't be answered twice by accident
+	empty, _ := json.Marshal(map[string]interface{}{"inline_keyboard": [][]inlineButton{}})
+	callAPI(bot.Token, "editMessageReplyMarkup", url.Values{
+		"chat_id":      {fmt.Sprintf("%d", cq.Message.Chat.ID)},
+		"message_id":   {fmt.Sprintf("%d", cq.Message.MessageID)},
+		"reply_markup": {string(empty)},
+	})
+	if choice == ratingSkip {
+		return
+	}
+
+	score, err := strconv.Atoi(choice)
+	if err != nil || !ratings.Valid(name, score) {
+		return
+	}
+	var fb models.Feedback
+	if err := senderFeedback(bot, cq.From.ID).Where("feedbacks.id = ? AND feedbacks.retracted_at IS NULL", feedbackID).
+		First(&fb).Error; err != nil {
+		sendMessage(bot.Token, cq.Message.Chat.ID, i18n.T(lang, "rating.not_found"))
+		return
+	}
+
+	models.DB.Model(&fb).Updates(map[string]interface{}{"rating": score, "rating_scale": name})
+	sendMessage(bot.Token, cq.Message.Chat.ID, i18n.T(lang, "rating.thanks", i18n.Args{"score": strconv.Itoa(score)}))
+}
@@ -0,0 +1,50 @@
+package tgbot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/msgtemplate"
+)
+
+var ErrResponseNotSent = errors.New("response could not be sent to the group")
+
+// responseQuoteLen is how much of the feedback a standalone response quotes.
+const responseQuoteLen = 300
+
+// PublishResponse posts r, the official response to fb, in fb's group. It
+// replies to the feedback's own post when there is one; otherwise (posted
+// in a digest, or never to be posted) it is a standalone message quoting
+// the feedback. Feedback still queued for its post or digest must not be
+// answered yet. Where it was posted is recorded on r.
+func PublishResponse(fb *models.Feedback, r *models.PublicResponse) error {
+	var group models.Group
+	if err := models.DB.Preload("Bot").First(&group, fb.GroupID).Error; err != nil {
+		return err
+	}
+	var config models.FeedbackConfig
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+
+	lang := tenantLanguage(group.TenantID)
+	header := msgtemplate.Render(group.TenantID, group.ID, msgtemplate.KeyResponseHeader, lang,
+		templateVars(group.Bot, group, "feedback"))
+	text := fmt.Sprintf("%s\n\n%s", header, r.Message)
+
+	chatID, threadID := group.ChatID, postThreadID(config)
+	var replyTo int64
+	if fb.Posted && !fb.InDigest && fb.PostMessageID != 0 {
+		chatID, threadID, replyTo = postChatID(fb, group), fb.PostThreadID, fb.PostMessageID
+	} else {
+		quote := i18n.T(lang, "group.response_quote", i18n.Args{"message": preview(fb.Message, responseQuoteLen)})
+		text = fmt.Sprintf("%s\n\n%s\n\n%s", header, quote, r.Message)
+	}
+
+	messageID := sendPost(group.Bot.Token, chatID, threadID, text, nil, replyTo)
+	if messageID == 0 {
+		return ErrResponseNotSent
+	}
+	r.PostChatID, r.PostMessageID = chatID, messageID
+	return nil
+}
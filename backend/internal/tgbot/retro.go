@@ -0,0 +1,428 @@
+package tgbot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+	"gorm.io/gorm"
+)
+
+// retroColumnKeys name the default columns of a retro session.
+var retroColumnKeys = []string{"retro.column_went_well", "retro.column_to_improve", "retro.column_action_items"}
+
+// Revealed columns are split so each message's keyboard stays well under
+// Telegram's 100 buttons.
+const (
+	maxRetroItemsPerMessage = 40
+	retroButtonsPerRow      = 5
+)
+
+// DefaultRetroColumns returns the default retro columns in the tenant's
+// language.
+func DefaultRetroColumns(tenantID uint) []string {
+	lang := tenantLanguage(tenantID)
+	columns := make([]string, len(retroColumnKeys))
+	for i, key := range retroColumnKeys {
+		columns[i] = i18n.T(lang, key)
+	}
+	return columns
+}
+
+func retroTime(s models.RetroSession, t time.Time) string {
+	return t.In(tenantLocation(s.TenantID)).Format("2006-01-02 15:04")
+}
+
+// runRetros announces the retro sessions that opened and reveals the ones
+// that ended. A reveal that failed is tried again, and one in a group the
+// bot left waits until it is back.
+func runRetros(now time.Time) {
+	var opened []models.RetroSession
+	models.DB.Scopes(activeTenants).Where("announced_at IS NULL AND closed_at IS NULL AND starts_at <= ? AND ends_at > ?", now.UTC(), now.UTC()).
+		Find(&opened)
+	for _, s := range opened {
+		if err := announceRetro(s, now); err != nil {
+			log.Printf("[tgbot] Failed to announce retro %d: %v", s.ID, err)
+		}
+	}
+
+	var ended []models.RetroSession
+	models.DB.Scopes(activeTenants).Where("revealed_at IS NULL AND (closed_at IS NOT NULL OR ends_at <= ?)", now.UTC()).Find(&ended)
+	for i := range ended {
+		if err := CloseRetro(&ended[i], now); err != nil && !errors.Is(err, ErrGroupInactive) {
+			log.Printf("[tgbot] Failed to reveal retro %d: %v", ended[i].ID, err)
+		}
+	}
+}
+
+// announceRetro tells the group that s takes notes, with a link to the
+// bot's private chat. It is only tried once.
+func announceRetro(s models.RetroSession, now time.Time) error {
+	models.DB.Model(&s).Update("announced_at", now.UTC())
+
+	var group models.Group
+	if err := models.DB.Preload("Bot").First(&group, s.GroupID).Error; err != nil {
+		return err
+	}
+	if !group.IsActive {
+		return ErrGroupInactive
+	}
+	var config models.FeedbackConfig
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+
+	lang := tenantLanguage(group.TenantID)
+	text := i18n.T(lang, "retro.announce", i18n.Args{
+		"title":   s.Title,
+		"ends":    retroTime(s, s.EndsAt),
+		"columns": strings.Join(s.Columns, ", "),
+	})
+	keyboard := [][]inlineButton{{{
+		Text: i18n.T(lang, "retro.open_button"),
+		URL:  fmt.Sprintf("https://t.me/%s", group.Bot.BotUsername),
+	}}}
+	id := sendPost(group.Bot.Token, group.ChatID, postThreadID(config), text, keyboard, 0)
+	if id == 0 {
+		return errors.New("failed to post retro announcement")
+	}
+	return models.DB.Model(&s).Update("announce_message_id", id).Error
+}
+
+// CloseRetro stops s taking notes and reveals them in the group. A session
+// closed before its end time ends now. Until the reveal succeeds,
+// RevealedAt stays nil and CloseRetro can be called again to retry it.
+func CloseRetro(s *models.RetroSession, now time.Time) error {
+	if s.ClosedAt == nil {
+		if err := closeRetro(s, now); err != nil {
+			return err
+		}
+	}
+	if s.RevealedAt != nil {
+		return nil
+	}
+	return revealRetro(s, now)
+}
+
+// closeRetro marks s closed and numbers the notes of each column in random
+// order.
+func closeRetro(s *models.RetroSession, now time.Time) error {
+	closedAt := now.UTC()
+	endsAt := s.EndsAt
+	if closedAt.Before(endsAt) {
+		endsAt = closedAt
+	}
+	return models.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.RetroSession{}).Where("id = ? AND closed_at IS NULL", s.ID).
+			Updates(map[string]interface{}{"closed_at": closedAt, "ends_at": endsAt})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			// Closed meanwhile
+			return tx.First(s, s.ID).Error
+		}
+		s.ClosedAt = &closedAt
+		s.EndsAt = endsAt
+
+		var items []models.RetroItem
+		tx.Where("session_id = ?", s.ID).Find(&items)
+		columns := map[int][]models.RetroItem{}
+		for _, item := range items {
+			columns[item.Column] = append(columns[item.Column], item)
+		}
+		for _, column := range columns {
+			rand.Shuffle(len(column), func(i, j int) { column[i], column[j] = column[j], column[i] })
+			for i := range column {
+				if err := tx.Model(&column[i]).Update("position", i+1).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// revealRetro posts the notes of a closed session: a header, then each
+// column with its notes by position, with dot buttons when the session has
+// dot voting. RevealedAt is set once the header is sent.
+func revealRetro(s *models.RetroSession, now time.Time) error {
+	var group models.Group
+	if err := models.DB.Preload("Bot").First(&group, s.GroupID).Error; err != nil {
+		return err
+	}
+	if !group.IsActive {
+		return ErrGroupInactive
+	}
+	var config models.FeedbackConfig
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+	threadID := postThreadID(config)
+	lang := tenantLanguage(group.TenantID)
+
+	columns := make([][]models.RetroItem, len(s.Columns))
+	var items []models.RetroItem
+	models.DB.Where("session_id = ?", s.ID).Order("position, id").Find(&items)
+	for _, item := range items {
+		if item.Column >= 0 && item.Column < len(columns) {
+			columns[item.Column] = append(columns[item.Column], item)
+		}
+	}
+
+	header := i18n.T(lang, "retro.reveal", i18n.Args{"title": s.Title})
+	if s.DotVoting {
+		header += "\n\n" + i18n.T(lang, "retro.vote_hint", i18n.Args{"dots": s.DotsPerMember})
+	}
+	if sendPost(group.Bot.Token, group.ChatID, threadID, header, nil, 0) == 0 {
+		return errors.New("failed to post retro results")
+	}
+	revealedAt := now.UTC()
+	s.RevealedAt = &revealedAt
+	if err := models.DB.Model(s).Update("revealed_at", s.RevealedAt).Error; err != nil {
+		return err
+	}
+
+	for c, column := range columns {
+		for _, part := range splitRetroColumn(s.Columns[c], column) {
+			var keyboard [][]inlineButton
+			if s.DotVoting {
+				keyboard = retroKeyboard(part)
+			}
+			text := cutUTF16(retroColumnText(lang, s.Columns[c], part), maxMessageLen)
+			id := sendPost(group.Bot.Token, group.ChatID, threadID, text, keyboard, 0)
+			if id == 0 {
+				log.Printf("[tgbot] Failed to post column %d of retro %d", c, s.ID)
+				continue
+			}
+			ids := make([]uint, len(part))
+			for i, item := range part {
+				ids[i] = item.ID
+			}
+			if len(ids) > 0 {
+				models.DB.Model(&models.RetroItem{}).Where("id IN ?", ids).Update("post_message_id", id)
+			}
+		}
+	}
+	log.Printf("[tgbot] Revealed retro %d in group %d (%d notes)", s.ID, group.ID, len(items))
+	return nil
+}
+
+// retroColumnText renders one message of a revealed column.
+func retroColumnText(lang string, column string, items []models.RetroItem) string {
+	if len(items) == 0 {
+		return fmt.Sprintf("📌 %s\n\n%s", column, i18n.T(lang, "retro.column_empty"))
+	}
+	lines := make([]string, len(items))
+	for i, item := range items {
+		lines[i] = fmt.Sprintf("%d. %s", item.Position, item.Message)
+	}
+	return fmt.Sprintf("📌 %s\n\n%s", column, strings.Join(lines, "\n\n"))
+}
+
+// splitRetroColumn groups the ordered notes of a column into messages
+// within maxMessageLen and maxRetroItemsPerMessage. An empty column is one
+// message, and a single note too long for one is cut when sent.
+func splitRetroColumn(column string, items []models.RetroItem) [][]models.RetroItem {
+	parts := [][]models.RetroItem{nil}
+	for _, item := range items {
+		part := parts[len(parts)-1]
+		if len(part) > 0 && (len(part) == maxRetroItemsPerMessage ||
+			utf16Len(retroColumnText("", column, append(part, item))) > maxMessageLen) {
+			parts = append(parts, nil)
+		}
+		parts[len(parts)-1] = append(parts[len(parts)-1], item)
+	}
+	return parts
+}
+
+// retroKeyboard has a dot button per note, labelled with its number and
+// dots.
+func retroKeyboard(items []models.RetroItem) [][]inlineButton {
+	var keyboard [][]inlineButton
+	for i, item := range items {
+		if i%retroButtonsPerRow == 0 {
+			keyboard = append(keyboard, nil)
+		}
+		keyboard[len(keyboard)-1] = append(keyboard[len(keyboard)-1], inlineButton{
+			Text:         fmt.Sprintf("%d · ●%d", item.Position, item.Dots),
+			CallbackData: fmt.Sprintf("rd:%d", item.ID),
+		})
+	}
+	return keyboard
+}
+
+// openRetros returns the sessions of bot's active groups taking notes now.
+func openRetros(bot models.Bot, now time.Time) []models.RetroSession {
+	var sessions []models.RetroSession
+	models.DB.Where("closed_at IS NULL AND starts_at <= ? AND ends_at > ?", now.UTC(), now.UTC()).
+		Where("group_id IN (SELECT id FROM groups WHERE bot_id = ? AND is_active = ?)", bot.ID, true).
+		Order("id").Find(&sessions)
+	return sessions
+}
+
+// handleRetroMessage takes text as a note when a retro of one of the
+// sender's groups is open, asking which column it goes in. It returns false
+// when there is no such retro and text is feedback as usual.
+func handleRetroMessage(bot models.Bot, msg *Message, text string, lang string) bool {
+	sessions := openRetros(bot, time.Now())
+	if len(sessions) == 0 {
+		return false
+	}
+
+	member := map[uint]bool{}
+	var joined []models.RetroSession
+	for _, s := range sessions {
+		isMember, checked := member[s.GroupID]
+		if !checked {
+			var group models.Group
+			models.DB.First(&group, s.GroupID)
+			isMember = isChatMember(bot.Token, group.ChatID, msg.From.ID)
+			member[s.GroupID] = isMember
+		}
+		if isMember {
+			joined = append(joined, s)
+		}
+	}
+	if len(joined) == 0 {
+		return false
+	}
+
+	var keyboard [][]inlineButton
+	for _, s := range joined {
+		for c, column := range s.Columns {
+			label := column
+			if len(joined) > 1 {
+				label = s.Title + ": " + column
+			}
+			keyboard = append(keyboard, []inlineButton{{Text: label, CallbackData: fmt.Sprintf("ro:%d:%d", s.ID, c)}})
+		}
+	}
+	storePendingFeedback(bot, msg.From.ID, text, false, models.FeedbackGeneral)
+	sendMessageWithKeyboard(bot.Token, msg.Chat.ID, i18n.T(lang, "retro.pick_column"), keyboard)
+	return true
+}
+
+// handleRetroColumnCallback adds the sender's pending message to a retro
+// column, "ro:<session>:<column>".
+func handleRetroColumnCallback(bot models.Bot, cq *CallbackQuery) {
+	parts := strings.Split(strings.TrimPrefix(cq.Data, "ro:"), ":")
+	if len(parts) != 2 || cq.Message == nil {
+		return
+	}
+	sessionID, err1 := strconv.ParseUint(parts[0], 10, 64)
+	column, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		log.Printf("[tgbot] Invalid retro callback data: %s", cq.Data)
+		return
+	}
+
+	lang := senderLanguage(bot, cq.From)
+	chatID := cq.Message.Chat.ID
+	now := time.Now()
+	var s models.RetroSession
+	err := models.DB.Where("group_id IN (SELECT id FROM groups WHERE bot_id = ?)", bot.ID).First(&s, sessionID).Error
+	if err != nil || s.ClosedAt != nil || now.Before(s.StartsAt) || !now.Before(s.EndsAt) {
+		sendMessage(bot.Token, chatID, i18n.T(lang, "retro.closed"))
+		return
+	}
+	if column < 0 || column >= len(s.Columns) {
+		return
+	}
+	var group models.Group
+	models.DB.First(&group, s.GroupID)
+	if !isChatMember(bot.Token, group.ChatID, cq.From.ID) {
+		sendMessage(bot.Token, chatID, i18n.T(lang, "retro.not_member", i18n.Args{"group": group.Title}))
+		return
+	}
+
+	pending, ok := getPendingFeedback(cq.From.ID)
+	if !ok {
+		sendMessage(bot.Token, chatID, i18n.T(lang, "feedback.session_expired"))
+		return
+	}
+	models.DB.Create(&models.RetroItem{TenantID: s.TenantID, SessionID: s.ID, Column: column, Message: pending.Text})
+	sendMessage(bot.Token, chatID, i18n.T(lang, "retro.added", i18n.Args{"column": s.Columns[column], "ends": retroTime(s, s.EndsAt)}))
+}
+
+func retroVoterHash(sessionID uint, pseudonym string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("retro:%d:%s", sessionID, pseudonym)))
+	return hex.EncodeToString(sum[:])
+}
+
+// handleRetroDotCallback puts a dot on a revealed note, "rd:<item>", or
+// takes it back when pressed again. Dots beyond the session's
+// DotsPerMember are ignored.
+func handleRetroDotCallback(bot models.Bot, cq *CallbackQuery) {
+	itemID, err := strconv.ParseUint(strings.TrimPrefix(cq.Data, "rd:"), 10, 64)
+	if err != nil || cq.Message == nil {
+		return
+	}
+
+	// Only the note's own column message takes dots
+	var item models.RetroItem
+	if err := models.DB.Where("post_message_id = ?", cq.Message.MessageID).First(&item, itemID).Error; err != nil {
+		return
+	}
+	var s models.RetroSession
+	err = models.DB.Where("dot_voting = ? AND revealed_at IS NOT NULL", true).
+		Where("group_id IN (SELECT id FROM groups WHERE bot_id = ? AND chat_id = ?)", bot.ID, cq.Message.Chat.ID).
+		First(&s, item.SessionID).Error
+	if err != nil {
+		return
+	}
+
+	var hashes []string
+	for _, p := range identity.Pseudonyms(cq.From.ID) {
+		hashes = append(hashes, retroVoterHash(s.ID, p))
+	}
+	changed := false
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		var dot models.RetroDot
+		switch err := tx.Where("item_id = ? AND voter_hash IN ?", item.ID, hashes).First(&dot).Error; {
+		case err == nil:
+			if err := tx.Unscoped().Delete(&dot).Error; err != nil {
+				return err
+			}
+		case err != gorm.ErrRecordNotFound:
+			return err
+		default:
+			var used int64
+			tx.Model(&models.RetroDot{}).Where("session_id = ? AND voter_hash IN ?", s.ID, hashes).Count(&used)
+			if used >= int64(s.DotsPerMember) {
+				return nil
+			}
+			dot = models.RetroDot{TenantID: s.TenantID, SessionID: s.ID, ItemID: item.ID, VoterHash: hashes[0]}
+			if err := tx.Create(&dot).Error; err != nil {
+				return err
+			}
+		}
+		changed = true
+		var dots int64
+		tx.Model(&models.RetroDot{}).Where("item_id = ?", item.ID).Count(&dots)
+		return tx.Model(&item).Update("dots", dots).Error
+	})
+	if err != nil {
+		log.Printf("[tgbot] Failed to record dot on retro item %d: %v", item.ID, err)
+		return
+	}
+	if !changed {
+		return
+	}
+
+	var message []models.RetroItem
+	models.DB.Where("session_id = ? AND post_message_id = ?", s.ID, item.PostMessageID).Order("position").Find(&message)
+	keyboard, _ := json.Marshal(map[string]interface{}{"inline_keyboard": retroKeyboard(message)})
+	callAPI(bot.Token, "editMessageReplyMarkup", url.Values{
+		"chat_id":      {fmt.Sprintf("%d", cq.Message.Chat.ID)},
+		"message_id":   {fmt.Sprintf("%d", item.PostMessageID)},
+		"reply_markup": {string(keyboard)},
+	})
+}
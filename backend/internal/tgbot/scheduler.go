@@ -0,0 +1,128 @@
+package tgbot
+
+import (
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/anonymity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"gorm.io/gorm"
+)
+
+const schedulerInterval = 30 * time.Second
+
+// tenantLocation returns the tenant timezone used for posting windows.
+func tenantLocation(tenantID uint) *time.Location {
+	var tenant models.Tenant
+	models.DB.Select("id", "timezone").First(&tenant, tenantID)
+	return anonymity.Location(tenant.Timezone)
+}
+
+// schedulePost queues fb for posting according to the group's anonymity
+// settings or for its digest, or posts it right away when neither is
+// configured. The queue lives in Feedback.ScheduledAt, so it survives
+// restarts.
+func schedulePost(bot models.Bot, group models.Group, config models.FeedbackConfig, fb *models.Feedback) {
+	// Comments are replies to one post, so they never go into a digest
+	if config.DigestMode && fb.ParentID == nil {
+		queueForDigest(fb)
+		return
+	}
+
+	windows, err := anonymity.ParseWindows(config.PostingWindows)
+	if err != nil {
+		log.Printf("[tgbot] Group %d has invalid posting windows: %v", group.ID, err)
+	}
+
+	if config.PostDelayMaxMinutes <= 0 && config.PostDelayMinMinutes <= 0 && len(windows) == 0 && config.MinDistinctSenders <= 1 {
+		postFeedback(bot, group, config, fb)
+		return
+	}
+
+	delay := anonymity.RandomDelay(config.PostDelayMinMinutes, config.PostDelayMaxMinutes)
+	due := anonymity.NextWindow(time.Now().Add(delay), windows, tenantLocation(group.TenantID))
+	fb.ScheduledAt = &due
+	models.DB.Model(fb).Update("scheduled_at", due)
+}
+
+// activeTenants narrows a query on a table with tenant_id to tenants that
+// aren't waiting for deletion, so their bots stay quiet during the grace
+// period.
+func activeTenants(db *gorm.DB) *gorm.DB {
+	return db.Where("tenant_id NOT IN (SELECT id FROM tenants WHERE deletion_scheduled_at IS NOT NULL)")
+}
+
+// StartScheduler posts queued feedback and digests, sends surveys and runs
+// retro sessions when they fall due, until StopAll.
+func StartScheduler() {
+	log.Printf("[tgbot] Starting post scheduler")
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			log.Printf("[tgbot] Stopping post scheduler")
+			return
+		case now := <-ticker.C:
+			runScheduledPosts(now)
+			runDigests(now)
+			runSurveys(now)
+			runRetros(now)
+		}
+	}
+}
+
+func runScheduledPosts(now time.Time) {
+	var groupIDs []uint
+	models.DB.Model(&models.Feedback{}).Scopes(activeTenants).
+		Where("scheduled_at IS NOT NULL AND scheduled_at <= ? AND posted = ?", now, false).
+		Distinct().Pluck("group_id", &groupIDs)
+
+	for _, groupID := range groupIDs {
+		postDueFeedback(groupID, now)
+	}
+}
+
+// postDueFeedback posts a group's due feedback as one batch, in random order,
+// once it comes from at least MinDistinctSenders people.
+func postDueFeedback(groupID uint, now time.Time) {
+	var group models.Group
+	if err := models.DB.Preload("Bot").First(&group, groupID).Error; err != nil || !group.IsActive {
+		return
+	}
+	var config models.FeedbackConfig
+	models.DB.Where("group_id = ?", groupID).First(&config)
+
+	due := func() *gorm.DB {
+		return models.DB.Model(&models.Feedback{}).
+			Where("group_id = ? AND scheduled_at IS NOT NULL AND scheduled_at <= ? AND posted = ?", groupID, now, false)
+	}
+
+	if !config.PostToGroup {
+		// Posting was turned off while items were queued
+		due().Update("scheduled_at", nil)
+		return
+	}
+
+	if config.MinDistinctSenders > 1 {
+		var senders int64
+		due().Distinct("sender_id").Count(&senders)
+		if senders < int64(config.MinDistinctSenders) {
+			return
+		}
+	}
+
+	var feedbacks []models.Feedback
+	due().Find(&feedbacks)
+	rand.Shuffle(len(feedbacks), func(i, j int) { feedbacks[i], feedbacks[j] = feedbacks[j], feedbacks[i] })
+
+	posted := 0
+	for i := range feedbacks {
+		if postFeedback(group.Bot, group, config, &feedbacks[i]) {
+			posted++
+		}
+	}
+	log.Printf("[tgbot] Posted %d of %d scheduled feedback(s) to group %d", posted, len(feedbacks), groupID)
+}
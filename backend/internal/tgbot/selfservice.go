@@ -0,0 +1,256 @@
+package tgbot
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+	"gorm.io/gorm"
+)
+
+const (
+	historyLimit      = 10
+	historyPreviewLen = 80
+	trackingCodeLen   = 8
+	// Unambiguous characters only, so codes are easy to retype
+	trackingAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// generateTrackingCode returns a random short code not used by any feedback.
+func generateTrackingCode() string {
+	buf := make([]byte, trackingCodeLen)
+	for {
+		rand.Read(buf)
+		for i := range buf {
+			buf[i] = trackingAlphabet[int(buf[i])%len(trackingAlphabet)]
+		}
+		code := string(buf)
+
+		var count int64
+		models.DB.Unscoped().Model(&models.Feedback{}).Where("tracking_code = ?", code).Count(&count)
+		if count == 0 {
+			return code
+		}
+	}
+}
+
+// senderFeedback scopes feedback to what telegramUserID sent through this bot,
+// including retracted items.
+func senderFeedback(bot models.Bot, telegramUserID int64) *gorm.DB {
+	return models.DB.Unscoped().Model(&models.Feedback{}).
+		Joins("JOIN group_users ON group_users.id = feedbacks.sender_id").
+		Joins("JOIN groups ON groups.id = feedbacks.group_id").
+		Where("group_users.sender_hash IN ? AND groups.bot_id = ?", identity.Pseudonyms(telegramUserID), bot.ID).
+		Where("feedbacks.deleted_at IS NULL OR feedbacks.retracted_at IS NOT NULL")
+}
+
+func findSenderFeedback(bot models.Bot, telegramUserID int64, code string) (models.Feedback, bool) {
+	var fb models.Feedback
+	err := senderFeedback(bot, telegramUserID).
+		Where("feedbacks.tracking_code = ?", strings.ToUpper(code)).
+		First(&fb).Error
+	return fb, err == nil
+}
+
+func feedbackStatus(lang string, fb models.Feedback) string {
+	switch {
+	case fb.RetractedAt != nil:
+		return i18n.T(lang, "status.retracted")
+	case fb.Posted:
+		return i18n.T(lang, "status.posted")
+	case fb.Moderation == models.ModerationPending:
+		return i18n.T(lang, "status.pending")
+	case fb.Moderation == models.ModerationRejected:
+		return i18n.T(lang, "status.rejected")
+	case fb.ScheduledAt != nil:
+		return i18n.T(lang, "status.scheduled")
+	case fb.DigestQueued:
+		return i18n.T(lang, "status.digest_queued")
+	case fb.AdminOnly:
+		return i18n.T(lang, "status.admin_only")
+	default:
+		return i18n.T(lang, "status.received")
+	}
+}
+
+func preview(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	return string([]rune(text)[:max]) + "…"
+}
+
+func handleHistoryCommand(bot models.Bot, msg *Message, lang string) {
+	var feedbacks []models.Feedback
+	senderFeedback(bot, msg.From.ID).
+		Order("feedbacks.created_at DESC").
+		Limit(historyLimit).
+		Find(&feedbacks)
+
+	if len(feedbacks) == 0 {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "history.empty"))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.N(lang, "history.header", len(feedbacks)))
+	for _, fb := range feedbacks {
+		fmt.Fprintf(&sb, "\n\n🔖 %s · %s · %s\n%s",
+			fb.TrackingCode, fb.CreatedAt.Format("2006-01-02"), feedbackStatus(lang, fb), messagePreview(lang, fb))
+	}
+	sendMessage(bot.Token, msg.Chat.ID, sb.String())
+}
+
+func handleStatusCommand(bot models.Bot, msg *Message, lang string, code string) {
+	if code == "" {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "status.usage"))
+		return
+	}
+
+	fb, ok := findSenderFeedback(bot, msg.From.ID, code)
+	if !ok {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "status.not_found", i18n.Args{"code": strings.ToUpper(code)}))
+		return
+	}
+
+	sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "status.details", i18n.Args{
+		"code":    fb.TrackingCode,
+		"date":    fb.CreatedAt.Format("2006-01-02 15:04"),
+		"status":  feedbackStatus(lang, fb),
+		"message": messagePreview(lang, fb),
+	}))
+}
+
+// handleRetractCommand withdraws the feedback with the given code, or the
+// sender's latest feedback when no code is given.
+func handleRetractCommand(bot models.Bot, msg *Message, lang string, code string) {
+	var fb models.Feedback
+	if code != "" {
+		found, ok := findSenderFeedback(bot, msg.From.ID, code)
+		if !ok {
+			sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "status.not_found", i18n.Args{"code": strings.ToUpper(code)}))
+			return
+		}
+		fb = found
+	} else if err := senderFeedback(bot, msg.From.ID).Where("feedbacks.retracted_at IS NULL").
+		Order("feedbacks.created_at DESC").First(&fb).Error; err != nil {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "history.empty"))
+		return
+	}
+
+	sendMessage(bot.Token, msg.Chat.ID, retractFeedback(fb, lang))
+}
+
+// checkSenderWindow returns a reply explaining why the sender may no longer
+// change fb, or "" if they still can.
+func checkSenderWindow(fb models.Feedback, lang string) string {
+	var config models.FeedbackConfig
+	models.DB.Where("group_id = ?", fb.GroupID).First(&config)
+	if config.RetractWindowMinutes <= 0 {
+		return i18n.T(lang, "retract.disabled")
+	}
+	if time.Since(fb.CreatedAt) > time.Duration(config.RetractWindowMinutes)*time.Minute {
+		return i18n.N(lang, "retract.expired", config.RetractWindowMinutes)
+	}
+	return ""
+}
+
+// handleEditCommand handles "/edit <code> <new text>".
+func handleEditCommand(bot models.Bot, msg *Message, lang string, arg string) {
+	code, text, _ := strings.Cut(arg, " ")
+	text = strings.TrimSpace(text)
+	if code == "" || text == "" {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "edit.usage"))
+		return
+	}
+	if len(text) > maxFeedbackLen {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.N(lang, "feedback.too_long", maxFeedbackLen))
+		return
+	}
+
+	fb, ok := findSenderFeedback(bot, msg.From.ID, code)
+	if !ok || fb.RetractedAt != nil {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "status.not_found", i18n.Args{"code": strings.ToUpper(code)}))
+		return
+	}
+
+	sendMessage(bot.Token, msg.Chat.ID, editFeedback(fb, text, lang))
+}
+
+// editFeedback replaces the message of fb and updates its group post.
+// Feedback a moderator has approved or rejected can't be edited, or the new
+// text would reach the group without review.
+func editFeedback(fb models.Feedback, text string, lang string) string {
+	if reply := checkSenderWindow(fb, lang); reply != "" {
+		return reply
+	}
+	moderated := i18n.T(lang, "edit.moderated", i18n.Args{"code": fb.TrackingCode})
+	if fb.Moderation == models.ModerationApproved || fb.Moderation == models.ModerationRejected {
+		return moderated
+	}
+
+	if fb.AdminOnly {
+		if err := sealForAdmin(&fb, text); err != nil {
+			log.Printf("[tgbot] Failed to seal edited feedback %d: %v", fb.ID, err)
+			return i18n.T(lang, "feedback.seal_failed")
+		}
+	} else {
+		fb.Message = text
+	}
+
+	// A decision made since fb was loaded wins
+	now := time.Now()
+	res := models.DB.Model(&models.Feedback{}).
+		Where("id = ? AND moderation NOT IN ?", fb.ID, []string{models.ModerationApproved, models.ModerationRejected}).
+		Updates(map[string]interface{}{
+			"message":    fb.Message,
+			"sealed":     fb.Sealed,
+			"sealed_key": fb.SealedKey,
+			"edited_at":  now,
+		})
+	if res.Error != nil {
+		log.Printf("[tgbot] Failed to edit feedback %d: %v", fb.ID, res.Error)
+		return i18n.T(lang, "edit.failed", i18n.Args{"code": fb.TrackingCode})
+	}
+	if res.RowsAffected == 0 {
+		return moderated
+	}
+
+	if fb.Posted {
+		if err := EditFeedbackPost(&fb); err != nil && err != ErrNotPosted {
+			log.Printf("[tgbot] Failed to edit post %d: %v", fb.PostMessageID, err)
+		}
+	}
+
+	return i18n.T(lang, "edit.done", i18n.Args{"code": fb.TrackingCode})
+}
+
+// retractFeedback removes the group post (if any) and soft-deletes fb when it
+// is within the group's retract window. It returns the reply for the sender.
+func retractFeedback(fb models.Feedback, lang string) string {
+	if fb.RetractedAt != nil {
+		return i18n.T(lang, "retract.already", i18n.Args{"code": fb.TrackingCode})
+	}
+	if reply := checkSenderWindow(fb, lang); reply != "" {
+		return reply
+	}
+
+	if fb.Posted {
+		if err := UnpostFeedback(&fb); err != nil && err != ErrNotPosted {
+			log.Printf("[tgbot] Failed to delete retracted post %d: %v", fb.PostMessageID, err)
+		}
+	}
+
+	now := time.Now()
+	models.DB.Model(&fb).Updates(map[string]interface{}{"retracted_at": now, "posted": false})
+	models.DB.Delete(&fb)
+
+	return i18n.T(lang, "retract.done", i18n.Args{"code": fb.TrackingCode})
+}
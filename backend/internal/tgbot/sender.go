@@ -0,0 +1,29 @@
+package tgbot
+
+import (
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+)
+
+// findOrCreateGroupUser returns the sender record of a Telegram user in a
+// group. Records still on an older pseudonym key are upgraded in place, which
+// is how key rotation reaches senders whose raw ID isn't stored anywhere.
+func findOrCreateGroupUser(group models.Group, telegramUserID int64) models.GroupUser {
+	var groupUser models.GroupUser
+	err := models.DB.Where("group_id = ? AND sender_hash IN ?", group.ID, identity.Pseudonyms(telegramUserID)).
+		First(&groupUser).Error
+	if err != nil {
+		groupUser = models.GroupUser{
+			TenantID:   group.TenantID,
+			GroupID:    group.ID,
+			SenderHash: identity.Pseudonym(telegramUserID),
+		}
+		models.DB.Create(&groupUser)
+		return groupUser
+	}
+
+	if !identity.IsCurrent(groupUser.SenderHash) {
+		models.DB.Model(&groupUser).Update("sender_hash", identity.Pseudonym(telegramUserID))
+	}
+	return groupUser
+}
@@ -0,0 +1,438 @@
+package tgbot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/cron"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+)
+
+// Telegram's limits for polls, in UTF-16 code units.
+const (
+	maxPollQuestionLen = 300
+	maxPollOptionLen   = 100
+)
+
+// surveyStartPrefix is the /start argument of the link to a DM survey round.
+const surveyStartPrefix = "survey_"
+
+// ErrGroupInactive is returned when a survey's group no longer has the bot.
+var ErrGroupInactive = errors.New("group is not active")
+
+// NextSurveyRun returns when s next sends a round after the given time, in
+// UTC, or nil if it won't again.
+func NextSurveyRun(s models.Survey, after time.Time) (*time.Time, error) {
+	if s.Schedule == "" {
+		if s.StartsAt != nil && s.StartsAt.After(after) {
+			next := s.StartsAt.UTC()
+			return &next, nil
+		}
+		return nil, nil
+	}
+	schedule, err := cron.Parse(s.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	next := schedule.Next(after, tenantLocation(s.TenantID))
+	if next.IsZero() {
+		return nil, nil
+	}
+	next = next.UTC()
+	return &next, nil
+}
+
+// runSurveys closes the rounds whose time is up and sends the surveys that
+// are due.
+func runSurveys(now time.Time) {
+	var rounds []models.SurveyRound
+	models.DB.Scopes(activeTenants).Where("closed_at IS NULL AND closes_at <= ?", now.UTC()).Find(&rounds)
+	for _, round := range rounds {
+		CloseRound(round, now)
+	}
+
+	var surveys []models.Survey
+	models.DB.Scopes(activeTenants).Where("active = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now.UTC()).Find(&surveys)
+	for _, s := range surveys {
+		if _, err := StartRound(s, now); err != nil {
+			log.Printf("[tgbot] Failed to send survey %d: %v", s.ID, err)
+		}
+		next, err := NextSurveyRun(s, now)
+		if err != nil {
+			log.Printf("[tgbot] Survey %d has an invalid schedule: %v", s.ID, err)
+		}
+		models.DB.Model(&s).Update("next_run_at", next)
+	}
+}
+
+func surveyQuestions(surveyID uint) []models.SurveyQuestion {
+	var questions []models.SurveyQuestion
+	models.DB.Where("survey_id = ?", surveyID).Order("position, id").Find(&questions)
+	return questions
+}
+
+// StartRound sends survey s to its group and opens a round for answers,
+// closing the survey's previous round if it is still open. Nothing is kept
+// if sending fails part way.
+func StartRound(s models.Survey, now time.Time) (models.SurveyRound, error) {
+	var round models.SurveyRound
+	var group models.Group
+	if err := models.DB.Preload("Bot").First(&group, s.GroupID).Error; err != nil {
+		return round, err
+	}
+	if !group.IsActive {
+		return round, ErrGroupInactive
+	}
+	questions := surveyQuestions(s.ID)
+	if len(questions) == 0 {
+		return round, errors.New("survey has no questions")
+	}
+
+	var open []models.SurveyRound
+	models.DB.Where("survey_id = ? AND closed_at IS NULL", s.ID).Find(&open)
+	for _, r := range open {
+		CloseRound(r, now)
+	}
+
+	round = models.SurveyRound{
+		TenantID: s.TenantID,
+		SurveyID: s.ID,
+		GroupID:  group.ID,
+		ClosesAt: now.Add(time.Duration(s.OpenHours) * time.Hour).UTC(),
+	}
+	if err := models.DB.Create(&round).Error; err != nil {
+		return round, err
+	}
+
+	var config models.FeedbackConfig
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+	threadID := postThreadID(config)
+
+	var err error
+	if s.Delivery == models.SurveyDeliveryDM {
+		err = announceSurvey(group, threadID, s, &round)
+	} else {
+		err = sendSurveyPolls(group, threadID, &round, questions)
+	}
+	if err != nil {
+		models.DB.Unscoped().Where("round_id = ?", round.ID).Delete(&models.SurveyPoll{})
+		models.DB.Unscoped().Delete(&round)
+		return round, err
+	}
+	log.Printf("[tgbot] Sent survey %d to group %d (round %d)", s.ID, group.ID, round.ID)
+	return round, nil
+}
+
+// sendSurveyPolls posts one anonymous poll per question. If one fails, the
+// polls already posted are deleted.
+func sendSurveyPolls(group models.Group, threadID int, round *models.SurveyRound, questions []models.SurveyQuestion) error {
+	var sent []int64
+	for _, q := range questions {
+		options := make([]map[string]string, 0, len(q.Choices()))
+		for _, label := range q.Choices() {
+			options = append(options, map[string]string{"text": cutUTF16(label, maxPollOptionLen)})
+		}
+		optionsJSON, _ := json.Marshal(options)
+		params := url.Values{
+			"chat_id":      {fmt.Sprintf("%d", group.ChatID)},
+			"question":     {cutUTF16(q.Text, maxPollQuestionLen)},
+			"options":      {string(optionsJSON)},
+			"is_anonymous": {"true"},
+		}
+		if threadID > 0 {
+			params.Set("message_thread_id", fmt.Sprintf("%d", threadID))
+		}
+
+		result, err := callAPI(group.Bot.Token, "sendPoll", params)
+		var msg Message
+		if err == nil {
+			err = json.Unmarshal(result, &msg)
+		}
+		if err == nil && msg.Poll == nil {
+			err = errors.New("telegram sendPoll: no poll in result")
+		}
+		if err != nil {
+			for _, id := range sent {
+				deleteMessage(group.Bot.Token, group.ChatID, id)
+			}
+			return err
+		}
+		sent = append(sent, msg.MessageID)
+
+		models.DB.Create(&models.SurveyPoll{
+			RoundID:    round.ID,
+			QuestionID: q.ID,
+			ChatID:     group.ChatID,
+			MessageID:  msg.MessageID,
+			PollID:     msg.Poll.ID,
+			Counts:     make([]int, len(options)),
+		})
+	}
+	return nil
+}
+
+// announceSurvey posts a message linking to the bot's private chat, where
+// the questionnaire is answered.
+func announceSurvey(group models.Group, threadID int, s models.Survey, round *models.SurveyRound) error {
+	lang := tenantLanguage(group.TenantID)
+	closes := round.ClosesAt.In(tenantLocation(group.TenantID)).Format("2006-01-02 15:04")
+	keyboard, _ := json.Marshal(map[string]interface{}{
+		"inline_keyboard": [][]inlineButton{{{
+			Text: i18n.T(lang, "survey.open_button"),
+			URL:  fmt.Sprintf("https://t.me/%s?start=%s%d", group.Bot.BotUsername, surveyStartPrefix, round.ID),
+		}}},
+	})
+	params := url.Values{
+		"chat_id":      {fmt.Sprintf("%d", group.ChatID)},
+		"text":         {i18n.T(lang, "survey.announce", i18n.Args{"title": s.Title, "closes": closes})},
+		"reply_markup": {string(keyboard)},
+	}
+	if threadID > 0 {
+		params.Set("message_thread_id", fmt.Sprintf("%d", threadID))
+	}
+
+	id := sendRaw(group.Bot.Token, params)
+	if id == 0 {
+		return errors.New("failed to post survey announcement")
+	}
+	round.AnnounceMessageID = id
+	return models.DB.Model(round).Update("announce_message_id", id).Error
+}
+
+// CloseRound stops taking answers for round. Its polls are stopped, which
+// also reports their final counts.
+func CloseRound(round models.SurveyRound, now time.Time) {
+	var group models.Group
+	models.DB.Preload("Bot").First(&group, round.GroupID)
+
+	var polls []models.SurveyPoll
+	models.DB.Where("round_id = ?", round.ID).Find(&polls)
+	for _, sp := range polls {
+		result, err := callAPI(group.Bot.Token, "stopPoll", url.Values{
+			"chat_id":    {fmt.Sprintf("%d", sp.ChatID)},
+			"message_id": {fmt.Sprintf("%d", sp.MessageID)},
+		})
+		if err != nil {
+			log.Printf("[tgbot] Failed to stop survey poll %d: %v", sp.MessageID, err)
+			continue
+		}
+		var poll Poll
+		if json.Unmarshal(result, &poll) == nil {
+			recordPollCounts(sp, &poll)
+		}
+	}
+
+	closedAt := now.UTC()
+	models.DB.Model(&round).Update("closed_at", closedAt)
+}
+
+// handlePollUpdate records the counts of a survey poll the bot sent.
+func handlePollUpdate(bot models.Bot, poll *Poll) {
+	var sp models.SurveyPoll
+	err := models.DB.Where("poll_id = ? AND chat_id IN (SELECT chat_id FROM groups WHERE bot_id = ?)", poll.ID, bot.ID).
+		First(&sp).Error
+	if err != nil {
+		return
+	}
+	recordPollCounts(sp, poll)
+}
+
+func recordPollCounts(sp models.SurveyPoll, poll *Poll) {
+	sp.Counts = make([]int, len(poll.Options))
+	for i, option := range poll.Options {
+		sp.Counts[i] = option.VoterCount
+	}
+	sp.Voters = poll.TotalVoterCount
+	models.DB.Model(&sp).Select("counts", "voters").Updates(&sp)
+}
+
+// respondentHash identifies one person's answer to one question of a round.
+func respondentHash(roundID, questionID uint, pseudonym string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("survey:%d:%d:%s", roundID, questionID, pseudonym)))
+	return hex.EncodeToString(sum[:])
+}
+
+// openRound loads a round of a DM survey sent through bot. It returns the
+// reply for the sender when the round can't be answered.
+func openRound(bot models.Bot, roundID uint, lang string) (models.SurveyRound, models.Survey, models.Group, string) {
+	var round models.SurveyRound
+	var s models.Survey
+	var group models.Group
+	err := models.DB.Where("group_id IN (SELECT id FROM groups WHERE bot_id = ?)", bot.ID).First(&round, roundID).Error
+	if err == nil {
+		err = models.DB.Unscoped().First(&s, round.SurveyID).Error
+	}
+	if err == nil {
+		err = models.DB.First(&group, round.GroupID).Error
+	}
+	if err != nil || s.Delivery != models.SurveyDeliveryDM {
+		return round, s, group, i18n.T(lang, "survey.not_found")
+	}
+	if round.ClosedAt != nil || !time.Now().Before(round.ClosesAt) {
+		return round, s, group, i18n.T(lang, "survey.closed")
+	}
+	return round, s, group, ""
+}
+
+// isChatMember reports whether userID is currently in chatID.
+func isChatMember(token string, chatID int64, userID int64) bool {
+	result, err := callAPI(token, "getChatMember", url.Values{
+		"chat_id": {fmt.Sprintf("%d", chatID)},
+		"user_id": {fmt.Sprintf("%d", userID)},
+	})
+	if err != nil {
+		log.Printf("[tgbot] getChatMember failed: %v", err)
+		return false
+	}
+	var member ChatMember
+	json.Unmarshal(result, &member)
+	switch member.Status {
+	case "creator", "administrator", "member":
+		return true
+	case "restricted":
+		return member.IsMember
+	}
+	return false
+}
+
+// handleSurveyStart opens a DM survey from its group link,
+// "/start survey_<round>", and asks the first question.
+func handleSurveyStart(bot models.Bot, msg *Message, lang string, arg string) {
+	roundID, err := strconv.ParseUint(strings.TrimPrefix(arg, surveyStartPrefix), 10, 64)
+	if err != nil {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "survey.not_found"))
+		return
+	}
+	round, s, group, reply := openRound(bot, uint(roundID), lang)
+	if reply != "" {
+		sendMessage(bot.Token, msg.Chat.ID, reply)
+		return
+	}
+	if !isChatMember(bot.Token, group.ChatID, msg.From.ID) {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "survey.not_member", i18n.Args{"group": group.Title}))
+		return
+	}
+
+	questions := surveyQuestions(s.ID)
+	if len(questions) == 0 {
+		sendMessage(bot.Token, msg.Chat.ID, i18n.T(lang, "survey.not_found"))
+		return
+	}
+	sendSurveyQuestion(bot, msg.Chat.ID, lang, round, s, questions, 0)
+}
+
+// sendSurveyQuestion asks questions[i] with one button per answer.
+func sendSurveyQuestion(bot models.Bot, chatID int64, lang string, round models.SurveyRound, s models.Survey, questions []models.SurveyQuestion, i int) {
+	q := questions[i]
+	text := i18n.T(lang, "survey.question", i18n.Args{
+		"title":  s.Title,
+		"number": strconv.Itoa(i + 1),
+		"total":  strconv.Itoa(len(questions)),
+		"text":   q.Text,
+	})
+	sendMessageWithKeyboard(bot.Token, chatID, text, surveyKeyboard(round, q, -1))
+}
+
+// surveyKeyboard lays out the answers to q, scales in one row and choices
+// one per row, marking the chosen option if any.
+func surveyKeyboard(round models.SurveyRound, q models.SurveyQuestion, chosen int) [][]inlineButton {
+	var keyboard [][]inlineButton
+	var row []inlineButton
+	for i, label := range q.Choices() {
+		if i == chosen {
+			label = "✅ " + label
+		}
+		button := inlineButton{Text: label, CallbackData: fmt.Sprintf("sv:%d:%d:%d", round.ID, q.ID, i)}
+		if q.Kind == models.QuestionScale {
+			row = append(row, button)
+		} else {
+			keyboard = append(keyboard, []inlineButton{button})
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+	return keyboard
+}
+
+// handleSurveyCallback records an answer, "sv:<round>:<question>:<option>",
+// and asks the next question. Pressing another button on an answered
+// question changes the answer.
+func handleSurveyCallback(bot models.Bot, cq *CallbackQuery) {
+	parts := strings.Split(strings.TrimPrefix(cq.Data, "sv:"), ":")
+	if len(parts) != 3 || cq.Message == nil {
+		return
+	}
+	roundID, err1 := strconv.ParseUint(parts[0], 10, 64)
+	questionID, err2 := strconv.ParseUint(parts[1], 10, 64)
+	option, err3 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil || err3 != nil {
+		log.Printf("[tgbot] Invalid survey callback data: %s", cq.Data)
+		return
+	}
+
+	lang := senderLanguage(bot, cq.From)
+	chatID := cq.Message.Chat.ID
+	round, s, _, reply := openRound(bot, uint(roundID), lang)
+	if reply != "" {
+		sendMessage(bot.Token, chatID, reply)
+		return
+	}
+
+	questions := surveyQuestions(s.ID)
+	index := -1
+	for i, q := range questions {
+		if q.ID == uint(questionID) {
+			index = i
+		}
+	}
+	if index < 0 || option < 0 || option >= len(questions[index].Choices()) {
+		return
+	}
+	q := questions[index]
+
+	// Look for an earlier answer under any identity key still in use
+	var answer models.SurveyAnswer
+	var hashes []string
+	for _, p := range identity.Pseudonyms(cq.From.ID) {
+		hashes = append(hashes, respondentHash(round.ID, q.ID, p))
+	}
+	changed := models.DB.Where("round_id = ? AND question_id = ? AND respondent_hash IN ?", round.ID, q.ID, hashes).
+		First(&answer).Error == nil
+	if changed {
+		models.DB.Model(&answer).Update("choice", option)
+	} else {
+		models.DB.Create(&models.SurveyAnswer{
+			RoundID:        round.ID,
+			QuestionID:     q.ID,
+			RespondentHash: respondentHash(round.ID, q.ID, identity.Pseudonym(cq.From.ID)),
+			Choice:         option,
+		})
+	}
+
+	keyboard, _ := json.Marshal(map[string]interface{}{"inline_keyboard": surveyKeyboard(round, q, option)})
+	callAPI(bot.Token, "editMessageReplyMarkup", url.Values{
+		"chat_id":      {fmt.Sprintf("%d", chatID)},
+		"message_id":   {fmt.Sprintf("%d", cq.Message.MessageID)},
+		"reply_markup": {string(keyboard)},
+	})
+
+	if changed {
+		return
+	}
+	if index+1 < len(questions) {
+		sendSurveyQuestion(bot, chatID, lang, round, s, questions, index+1)
+		return
+	}
+	sendMessage(bot.Token, chatID, i18n.T(lang, "survey.thanks"))
+}
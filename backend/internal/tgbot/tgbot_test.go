@@ -1,43 +1,55 @@
 package tgbot
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/envelope"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/i18n"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/ratings"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/sealed"
+	"github.com/glebarez/sqlite"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/box"
 	"gorm.io/gorm"
 )
 
 func setupTestDB(t *testing.T) {
 	t.Helper()
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	if err != nil {
 		t.Fatalf("failed to open test db: %v", err)
 	}
-	db.AutoMigrate(
-		&models.Tenant{},
-		&models.Bot{},
-		&models.Group{},
-		&models.FeedbackConfig{},
-		&models.GroupUser{},
-		&models.Feedback{},
-		&models.User{},
-		&models.UserTenant{},
-		&models.PendingFeedback{},
-	)
-	models.DB = db
+	conn.AutoMigrate(db.Models...)
+	models.DB = conn
 	config.Confs.Settings.JWTSecret = "test-secret"
+	identity.Configure([]string{"1:test-identity-key-0123456789"})
+	envelope.Configure([]string{"1:test-master-key-0123456789"})
 }
 
+// pendingBot belongs to a tenant without an admin key.
+var pendingBot = models.Bot{Model: gorm.Model{ID: 1}, TenantID: 1}
+
 func TestStorePendingFeedback(t *testing.T) {
 	setupTestDB(t)
 
-	storePendingFeedback(1, 12345, "hello", false)
+	storePendingFeedback(pendingBot, 12345, "hello", false, models.FeedbackGeneral)
 
 	var pf models.PendingFeedback
-	err := models.DB.Where("telegram_user_id = ?", 12345).First(&pf).Error
+	err := models.DB.Where("sender_hash = ?", identity.Pseudonym(12345)).First(&pf).Error
 	assert.NoError(t, err)
 	assert.Equal(t, "hello", pf.Text)
 	assert.Equal(t, false, pf.AdminOnly)
@@ -47,24 +59,24 @@ func TestStorePendingFeedback(t *testing.T) {
 func TestStorePendingFeedback_Overwrites(t *testing.T) {
 	setupTestDB(t)
 
-	storePendingFeedback(1, 12345, "first message", false)
-	storePendingFeedback(1, 12345, "second message", true)
+	storePendingFeedback(pendingBot, 12345, "first message", false, models.FeedbackGeneral)
+	storePendingFeedback(pendingBot, 12345, "second message", true, models.FeedbackGeneral)
 
 	var pf models.PendingFeedback
-	err := models.DB.Where("telegram_user_id = ?", 12345).First(&pf).Error
+	err := models.DB.Where("sender_hash = ?", identity.Pseudonym(12345)).First(&pf).Error
 	assert.NoError(t, err)
 	assert.Equal(t, "second message", pf.Text)
 	assert.Equal(t, true, pf.AdminOnly)
 
 	var count int64
-	models.DB.Model(&models.PendingFeedback{}).Where("telegram_user_id = ?", 12345).Count(&count)
+	models.DB.Model(&models.PendingFeedback{}).Where("sender_hash = ?", identity.Pseudonym(12345)).Count(&count)
 	assert.Equal(t, int64(1), count)
 }
 
 func TestGetPendingFeedback_Found(t *testing.T) {
 	setupTestDB(t)
 
-	storePendingFeedback(1, 12345, "pending msg", true)
+	storePendingFeedback(pendingBot, 12345, "pending msg", true, models.FeedbackGeneral)
 
 	pf, ok := getPendingFeedback(12345)
 	assert.True(t, ok)
@@ -203,10 +215,10 @@ func TestSubmitFeedback_CreatesGroupUserAndFeedback(t *testing.T) {
 
 	// submitFeedback will try to send a Telegram message which will fail silently
 	// We only verify DB state
-	submitFeedback(bot, 12345, 67890, group, "test feedback", false)
+	submitFeedback(bot, 12345, 67890, group, "test feedback", false, models.FeedbackGeneral, "en")
 
 	var gu models.GroupUser
-	err := models.DB.Where("group_id = ? AND telegram_user_id = ?", group.ID, 67890).First(&gu).Error
+	err := models.DB.Where("group_id = ? AND sender_hash = ?", group.ID, identity.Pseudonym(67890)).First(&gu).Error
 	assert.NoError(t, err)
 
 	var fb models.Feedback
@@ -227,7 +239,7 @@ func TestSubmitFeedback_AdminOnly(t *testing.T) {
 	models.DB.Create(&group)
 	models.DB.Create(&models.FeedbackConfig{GroupID: group.ID, PostToGroup: true})
 
-	submitFeedback(bot, 12345, 67890, group, "admin secret", true)
+	submitFeedback(bot, 12345, 67890, group, "admin secret", true, models.FeedbackGeneral, "en")
 
 	var fb models.Feedback
 	err := models.DB.Where("group_id = ? AND message = ?", group.ID, "admin secret").First(&fb).Error
@@ -236,3 +248,1238 @@ func TestSubmitFeedback_AdminOnly(t *testing.T) {
 	// Admin-only feedback should NOT be posted even if config says post_to_group
 	assert.Equal(t, false, fb.Posted)
 }
+
+func TestSenderLanguage_Resolution(t *testing.T) {
+	setupTestDB(t)
+
+	tenant := models.Tenant{Name: "Lang", Slug: "lang", DefaultLanguage: "uz"}
+	models.DB.Create(&tenant)
+	bot := models.Bot{TenantID: tenant.ID, Token: "lang-tok", BotUsername: "langbot", Verified: true}
+	models.DB.Create(&bot)
+
+	// Unsupported client language falls back to the tenant default
+	assert.Equal(t, "uz", senderLanguage(bot, User{ID: 1, LanguageCode: "de"}))
+
+	// Supported client language wins over the tenant default
+	assert.Equal(t, "ru", senderLanguage(bot, User{ID: 1, LanguageCode: "ru-RU"}))
+
+	// Explicit /language choice wins over everything
+	setSenderLanguage(bot.ID, 1, "en")
+	assert.Equal(t, "en", senderLanguage(bot, User{ID: 1, LanguageCode: "ru"}))
+
+	setSenderLanguage(bot.ID, 1, "uz")
+	var count int64
+	models.DB.Model(&models.SenderPreference{}).Where("sender_hash = ?", identity.Pseudonym(1)).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestParseCommand(t *testing.T) {
+	cmd, arg := parseCommand("/language ru")
+	assert.Equal(t, "/language", cmd)
+	assert.Equal(t, "ru", arg)
+
+	cmd, arg = parseCommand("/Start@feedback_bot")
+	assert.Equal(t, "/start", cmd)
+	assert.Equal(t, "", arg)
+
+	cmd, _ = parseCommand("hello")
+	assert.Equal(t, "", cmd)
+}
+
+func TestSubmitFeedback_AdminOnlySealed(t *testing.T) {
+	setupTestDB(t)
+	fakeTelegram(t)
+
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	publicKey := base64.StdEncoding.EncodeToString(pub[:])
+	tenant := models.Tenant{Name: "E2E", Slug: "e2e", AdminPublicKey: publicKey}
+	models.DB.Create(&tenant)
+	bot := models.Bot{TenantID: tenant.ID, Token: "e2e-tok", BotUsername: "e2ebot", Verified: true}
+	models.DB.Create(&bot)
+	group := models.Group{TenantID: tenant.ID, BotID: bot.ID, ChatID: -200333, Title: "E2E Group", Type: "supergroup", IsActive: true}
+	models.DB.Create(&group)
+	models.DB.Create(&models.FeedbackConfig{GroupID: group.ID, RetractWindowMinutes: 60})
+
+	submitFeedback(bot, 12345, 67890, group, "for the admin only", true, models.FeedbackGeneral, "en")
+
+	var fb models.Feedback
+	require.NoError(t, models.DB.Where("group_id = ?", group.ID).First(&fb).Error)
+	assert.True(t, fb.Sealed)
+	assert.Equal(t, sealed.Fingerprint(publicKey), fb.SealedKey)
+	assert.NotContains(t, fb.Message, "admin")
+
+	open := func(fb models.Feedback) string {
+		raw, err := base64.StdEncoding.DecodeString(fb.Message)
+		require.NoError(t, err)
+		plain, ok := box.OpenAnonymous(nil, raw, pub, priv)
+		require.True(t, ok)
+		return string(plain)
+	}
+	assert.Equal(t, "for the admin only", open(fb))
+	assert.Equal(t, i18n.T("en", "feedback.sealed_preview"), messagePreview("en", fb))
+
+	// Edits are sealed again
+	editFeedback(fb, "changed my mind", "en")
+	models.DB.First(&fb, fb.ID)
+	assert.True(t, fb.Sealed)
+	assert.Equal(t, "changed my mind", open(fb))
+
+	// Text waiting for the sender to pick a group is sealed as well
+	require.NoError(t, storePendingFeedback(bot, 777, "picked later", true, models.FeedbackGeneral))
+	var pf models.PendingFeedback
+	require.NoError(t, models.DB.Where("sender_hash = ?", identity.Pseudonym(777)).First(&pf).Error)
+	assert.NotContains(t, pf.Text, "picked")
+	assert.Equal(t, sealed.Fingerprint(publicKey), pf.SealedKey)
+
+	handleCallbackQuery(bot, &CallbackQuery{ID: "cb", From: User{ID: 777}, Message: &Message{MessageID: 1, Chat: Chat{ID: 777}}, Data: fmt.Sprintf("fb:%d", group.ID)})
+	var picked models.Feedback
+	require.NoError(t, models.DB.Where("group_id = ? AND id <> ?", group.ID, fb.ID).First(&picked).Error)
+	assert.True(t, picked.AdminOnly)
+	assert.True(t, picked.Sealed)
+	assert.Equal(t, pf.SealedKey, picked.SealedKey)
+	assert.Equal(t, "picked later", open(picked))
+}
+
+// fakeTelegram points the bot API at a local server that answers every call
+// with ok and a fresh message_id, and records the called methods.
+func fakeTelegram(t *testing.T) *[]string {
+	t.Helper()
+	var calls []string
+	var nextID int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, path.Base(r.URL.Path))
+		nextID++
+		fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d}}`, nextID)
+	}))
+	old := apiBaseURL
+	apiBaseURL = srv.URL
+	t.Cleanup(func() {
+		apiBaseURL = old
+		srv.Close()
+	})
+	return &calls
+}
+
+func createSelfServiceFixture(t *testing.T, postToGroup bool) (models.Bot, models.Group) {
+	t.Helper()
+	tenant := models.Tenant{Name: "SS", Slug: "ss"}
+	models.DB.Create(&tenant)
+	bot := models.Bot{TenantID: tenant.ID, Token: "ss-tok", BotUsername: "ssbot", Verified: true}
+	models.DB.Create(&bot)
+	group := models.Group{TenantID: tenant.ID, BotID: bot.ID, ChatID: -300111, Title: "SS Group", Type: "supergroup", IsActive: true}
+	models.DB.Create(&group)
+	models.DB.Create(&models.FeedbackConfig{GroupID: group.ID, PostToGroup: postToGroup})
+	return bot, group
+}
+
+func TestSubmitFeedback_TrackingCodeAndPostMessageID(t *testing.T) {
+	setupTestDB(t)
+	fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, true)
+
+	submitFeedback(bot, 12345, 67890, group, "tracked", false, models.FeedbackGeneral, "en")
+
+	var fb models.Feedback
+	models.DB.Where("message = ?", "tracked").First(&fb)
+	assert.Len(t, fb.TrackingCode, trackingCodeLen)
+	assert.True(t, fb.Posted)
+	assert.NotZero(t, fb.PostMessageID)
+
+	found, ok := findSenderFeedback(bot, 67890, strings.ToLower(fb.TrackingCode))
+	assert.True(t, ok)
+	assert.Equal(t, fb.ID, found.ID)
+
+	// Another sender cannot look up the code
+	_, ok = findSenderFeedback(bot, 11111, fb.TrackingCode)
+	assert.False(t, ok)
+}
+
+func TestRetractFeedback_DeletesPost(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, true)
+
+	submitFeedback(bot, 12345, 67890, group, "oops", false, models.FeedbackGeneral, "en")
+	var fb models.Feedback
+	models.DB.Where("message = ?", "oops").First(&fb)
+
+	reply := retractFeedback(fb, "en")
+	assert.Contains(t, reply, "withdrawn")
+	assert.Contains(t, *calls, "deleteMessage")
+
+	// Hidden from the dashboard but still visible to the sender as retracted
+	var count int64
+	models.DB.Model(&models.Feedback{}).Where("id = ?", fb.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	found, ok := findSenderFeedback(bot, 67890, fb.TrackingCode)
+	assert.True(t, ok)
+	assert.NotNil(t, found.RetractedAt)
+	assert.Contains(t, retractFeedback(found, "en"), "already")
+}
+
+func TestRetractFeedback_WindowExpired(t *testing.T) {
+	setupTestDB(t)
+	fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, false)
+
+	submitFeedback(bot, 12345, 67890, group, "old", false, models.FeedbackGeneral, "en")
+	var fb models.Feedback
+	models.DB.Where("message = ?", "old").First(&fb)
+	models.DB.Model(&fb).Update("created_at", time.Now().Add(-2*time.Hour))
+	models.DB.First(&fb, fb.ID)
+
+	assert.Contains(t, retractFeedback(fb, "en"), "within 60 minutes")
+
+	models.DB.Model(&models.FeedbackConfig{}).Where("group_id = ?", group.ID).Update("retract_window_minutes", 0)
+	assert.Contains(t, retractFeedback(fb, "en"), "turned off")
+}
+
+func TestEditFeedback_RefusesModerated(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, true)
+
+	submitFeedback(bot, 12345, 67890, group, "approved", false, models.FeedbackGeneral, "en")
+	var fb models.Feedback
+	models.DB.Where("message = ?", "approved").First(&fb)
+	models.DB.Model(&fb).Update("moderation", models.ModerationApproved)
+	*calls = nil
+
+	assert.Contains(t, editFeedback(fb, "unreviewed", "en"), "reviewed by a moderator")
+
+	// Approved between loading and editing
+	stale := fb
+	stale.Moderation = models.ModerationPending
+	assert.Contains(t, editFeedback(stale, "unreviewed", "en"), "reviewed by a moderator")
+	models.DB.First(&fb, fb.ID)
+	assert.Equal(t, "approved", fb.Message)
+	assert.Nil(t, fb.EditedAt)
+	assert.NotContains(t, *calls, "editMessageText")
+}
+
+func TestPostLifecycle_EditPinUnpost(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, true)
+
+	submitFeedback(bot, 12345, 67890, group, "original", false, models.FeedbackGeneral, "en")
+	var fb models.Feedback
+	models.DB.Where("message = ?", "original").First(&fb)
+	assert.Equal(t, group.ChatID, fb.PostChatID)
+
+	assert.Contains(t, editFeedback(fb, "changed", "en"), "updated")
+	assert.Contains(t, *calls, "editMessageText")
+
+	assert.NoError(t, PinFeedbackPost(&fb, true))
+	assert.Contains(t, *calls, "pinChatMessage")
+	models.DB.First(&fb, fb.ID)
+	assert.True(t, fb.Pinned)
+	assert.Equal(t, "changed", fb.Message)
+
+	assert.NoError(t, UnpostFeedback(&fb))
+	models.DB.First(&fb, fb.ID)
+	assert.False(t, fb.Posted)
+	assert.False(t, fb.Pinned)
+	assert.Equal(t, ErrNotPosted, UnpostFeedback(&fb))
+}
+
+func TestSubmitFeedback_RequireApproval(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, true)
+	modChat := int64(-999)
+	models.DB.Model(&models.FeedbackConfig{}).Where("group_id = ?", group.ID).
+		Updates(map[string]interface{}{"require_approval": true, "moderation_chat_id": modChat})
+
+	submitFeedback(bot, 12345, 67890, group, "moderate me", false, models.FeedbackGeneral, "en")
+
+	var fb models.Feedback
+	models.DB.Where("message = ?", "moderate me").First(&fb)
+	assert.Equal(t, models.ModerationPending, fb.Moderation)
+	assert.False(t, fb.Posted)
+
+	assert.NoError(t, ApproveFeedback(&fb))
+	models.DB.First(&fb, fb.ID)
+	assert.Equal(t, models.ModerationApproved, fb.Moderation)
+	assert.True(t, fb.Posted)
+	assert.Equal(t, ErrNotPending, ApproveFeedback(&fb))
+	assert.GreaterOrEqual(t, len(*calls), 4) // confirmation, moderation request, post, sender notice
+}
+
+func TestModeration_ConcurrentDecisions(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, true)
+	models.DB.Model(&models.FeedbackConfig{}).Where("group_id = ?", group.ID).Update("require_approval", true)
+	submitFeedback(bot, 12345, 67890, group, "race me", false, models.FeedbackGeneral, "en")
+
+	// Two moderators load the same pending feedback
+	var first, second models.Feedback
+	models.DB.Where("message = ?", "race me").First(&first)
+	models.DB.First(&second, first.ID)
+	require.Equal(t, models.ModerationPending, second.Moderation)
+
+	require.NoError(t, RejectFeedback(&first, "off topic"))
+	sent := len(*calls)
+	assert.Equal(t, ErrNotPending, ApproveFeedback(&second))
+	assert.Equal(t, ErrNotPending, RejectFeedback(&second, ""))
+	assert.Len(t, *calls, sent, "nothing posted or sent for the losing decision")
+
+	var fb models.Feedback
+	models.DB.First(&fb, first.ID)
+	assert.Equal(t, models.ModerationRejected, fb.Moderation)
+	assert.Equal(t, "off topic", fb.RejectReason)
+	assert.False(t, fb.Posted)
+}
+
+func TestScheduledPosts_MinDistinctSenders(t *testing.T) {
+	setupTestDB(t)
+	fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, true)
+	models.DB.Model(&models.FeedbackConfig{}).Where("group_id = ?", group.ID).Update("min_distinct_senders", 2)
+
+	submitFeedback(bot, 1, 1001, group, "first", false, models.FeedbackGeneral, "en")
+	submitFeedback(bot, 1, 1001, group, "second", false, models.FeedbackGeneral, "en")
+
+	var fb models.Feedback
+	models.DB.Where("message = ?", "first").First(&fb)
+	assert.NotNil(t, fb.ScheduledAt)
+	assert.False(t, fb.Posted)
+
+	// Only one distinct sender so far: nothing is posted
+	runScheduledPosts(time.Now().Add(time.Minute))
+	var posted int64
+	models.DB.Model(&models.Feedback{}).Where("posted = ?", true).Count(&posted)
+	assert.Equal(t, int64(0), posted)
+
+	submitFeedback(bot, 2, 1002, group, "third", false, models.FeedbackGeneral, "en")
+	runScheduledPosts(time.Now().Add(time.Minute))
+	models.DB.Model(&models.Feedback{}).Where("posted = ? AND scheduled_at IS NULL", true).Count(&posted)
+	assert.Equal(t, int64(3), posted)
+}
+
+func TestScheduler_SkipsTenantsPendingDeletion(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegram(t)
+	_, group := createSelfServiceFixture(t, true)
+	now := time.Now()
+	due := now.Add(-time.Minute)
+	models.DB.Create(&models.Feedback{TenantID: group.TenantID, GroupID: group.ID, Message: "queued", ScheduledAt: &due})
+	models.DB.Create(&models.RetroSession{
+		TenantID: group.TenantID, GroupID: group.ID, Title: "Retro", Columns: []string{"Went well"},
+		StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), DotsPerMember: 3,
+	})
+	deleteAt := now.Add(7 * 24 * time.Hour)
+	models.DB.Model(&models.Tenant{}).Where("id = ?", group.TenantID).Update("deletion_scheduled_at", deleteAt)
+
+	runScheduledPosts(now)
+	runRetros(now)
+	assert.Empty(t, *calls)
+	var posted int64
+	models.DB.Model(&models.Feedback{}).Where("posted = ?", true).Count(&posted)
+	assert.Zero(t, posted)
+
+	// Once the deletion is cancelled, everything goes out again
+	models.DB.Model(&models.Tenant{}).Where("id = ?", group.TenantID).Update("deletion_scheduled_at", nil)
+	runScheduledPosts(now)
+	runRetros(now)
+	assert.Len(t, *calls, 2, "the queued post and the retro announcement")
+}
+
+func TestPostFeedback_FailedSendStaysQueued(t *testing.T) {
+	setupTestDB(t)
+	bot, group := createSelfServiceFixture(t, true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok":false,"description":"Too Many Requests: retry after 5"}`)
+	}))
+	defer srv.Close()
+	old := apiBaseURL
+	apiBaseURL = srv.URL
+	defer func() { apiBaseURL = old }()
+
+	submitFeedback(bot, 1, 1001, group, "immediate", false, models.FeedbackGeneral, "en")
+	var fb models.Feedback
+	models.DB.Where("message = ?", "immediate").First(&fb)
+	assert.False(t, fb.Posted)
+	assert.Zero(t, fb.PostMessageID)
+	require.NotNil(t, fb.ScheduledAt, "queued for the scheduler to retry")
+
+	// Still failing: the item keeps its place in the queue
+	due := *fb.ScheduledAt
+	runScheduledPosts(time.Now().Add(time.Minute))
+	models.DB.First(&fb, fb.ID)
+	assert.False(t, fb.Posted)
+	require.NotNil(t, fb.ScheduledAt)
+	assert.True(t, due.Equal(*fb.ScheduledAt))
+
+	apiBaseURL = old
+	fakeTelegram(t)
+	runScheduledPosts(time.Now().Add(time.Minute))
+	var posted models.Feedback
+	models.DB.First(&posted, fb.ID)
+	assert.True(t, posted.Posted)
+	assert.NotZero(t, posted.PostMessageID)
+	assert.Nil(t, posted.ScheduledAt)
+}
+
+func TestScheduledPosts_DelayNotDue(t *testing.T) {
+	setupTestDB(t)
+	fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, true)
+	models.DB.Model(&models.FeedbackConfig{}).Where("group_id = ?", group.ID).
+		Updates(map[string]interface{}{"post_delay_min_minutes": 10, "post_delay_max_minutes": 20})
+
+	submitFeedback(bot, 1, 1001, group, "delayed", false, models.FeedbackGeneral, "en")
+
+	var fb models.Feedback
+	models.DB.Where("message = ?", "delayed").First(&fb)
+	require.NotNil(t, fb.ScheduledAt)
+	assert.True(t, fb.ScheduledAt.After(time.Now().Add(9*time.Minute)))
+
+	runScheduledPosts(time.Now())
+	models.DB.First(&fb, fb.ID)
+	assert.False(t, fb.Posted)
+
+	runScheduledPosts(time.Now().Add(21 * time.Minute))
+	models.DB.First(&fb, fb.ID)
+	assert.True(t, fb.Posted)
+}
+
+// enableDigest puts group in digest mode with a digest due now.
+func enableDigest(t *testing.T, group models.Group, pin bool) {
+	t.Helper()
+	due := time.Now().Add(-time.Minute)
+	models.DB.Model(&models.FeedbackConfig{}).Where("group_id = ?", group.ID).Updates(map[string]interface{}{
+		"digest_mode": true, "digest_schedule": "0 17 * * 5", "digest_pin": pin, "digest_next_at": due,
+	})
+}
+
+func TestDigest_QueuesAndPostsTogether(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, true)
+	enableDigest(t, group, false)
+
+	submitFeedback(bot, 1, 1001, group, "first", false, models.FeedbackGeneral, "en")
+	submitFeedback(bot, 2, 1002, group, "second", false, models.FeedbackGeneral, "en")
+	submitFeedback(bot, 3, 1003, group, "private", true, models.FeedbackGeneral, "en")
+
+	var fb models.Feedback
+	models.DB.Where("message = ?", "first").First(&fb)
+	assert.True(t, fb.DigestQueued)
+	assert.False(t, fb.Posted)
+	assert.Contains(t, feedbackStatus("en", fb), "digest")
+	sent := len(*calls)
+
+	runDigests(time.Now())
+	assert.Equal(t, []string{"sendMessage"}, (*calls)[sent:])
+
+	var posted []models.Feedback
+	models.DB.Where("posted = ?", true).Find(&posted)
+	require.Len(t, posted, 2)
+	assert.Equal(t, posted[0].PostMessageID, posted[1].PostMessageID)
+	for _, fb := range posted {
+		assert.True(t, fb.InDigest)
+		assert.False(t, fb.DigestQueued)
+	}
+
+	var config models.FeedbackConfig
+	models.DB.Where("group_id = ?", group.ID).First(&config)
+	require.NotNil(t, config.DigestNextAt)
+	assert.True(t, config.DigestNextAt.After(time.Now()))
+	assert.Equal(t, time.Friday, config.DigestNextAt.In(tenantLocation(group.TenantID)).Weekday())
+
+	// Nothing queued: no empty digest
+	models.DB.Model(&config).Update("digest_next_at", time.Now().Add(-time.Minute))
+	sent = len(*calls)
+	runDigests(time.Now())
+	assert.Len(t, *calls, sent)
+}
+
+func TestDigest_EditAndUnpost(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, true)
+	enableDigest(t, group, false)
+
+	submitFeedback(bot, 1, 1001, group, "keep", false, models.FeedbackGeneral, "en")
+	submitFeedback(bot, 2, 1002, group, "drop", false, models.FeedbackGeneral, "en")
+	runDigests(time.Now())
+
+	var keep, drop models.Feedback
+	models.DB.Where("message = ?", "keep").First(&keep)
+	models.DB.Where("message = ?", "drop").First(&drop)
+	require.True(t, drop.Posted)
+
+	sent := len(*calls)
+	assert.Contains(t, editFeedback(keep, "kept", "en"), "updated")
+	assert.Equal(t, []string{"editMessageText"}, (*calls)[sent:])
+
+	// Taking one item out rewrites the shared message instead of deleting it
+	sent = len(*calls)
+	require.NoError(t, UnpostFeedback(&drop))
+	assert.Equal(t, []string{"editMessageText"}, (*calls)[sent:])
+	models.DB.First(&drop, drop.ID)
+	assert.False(t, drop.Posted)
+	assert.False(t, drop.InDigest)
+	models.DB.First(&keep, keep.ID)
+	assert.True(t, keep.Posted)
+
+	sent = len(*calls)
+	require.NoError(t, UnpostFeedback(&keep))
+	assert.Equal(t, []string{"deleteMessage"}, (*calls)[sent:])
+}
+
+func TestDigest_PinsLatest(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, true)
+	enableDigest(t, group, true)
+
+	submitFeedback(bot, 1, 1001, group, "week one", false, models.FeedbackGeneral, "en")
+	runDigests(time.Now())
+	assert.Contains(t, *calls, "pinChatMessage")
+
+	models.DB.Model(&models.FeedbackConfig{}).Where("group_id = ?", group.ID).Update("digest_next_at", time.Now().Add(-time.Minute))
+	submitFeedback(bot, 2, 1002, group, "week two", false, models.FeedbackGeneral, "en")
+	sent := len(*calls)
+	runDigests(time.Now())
+	assert.Equal(t, []string{"sendMessage", "unpinChatMessage", "pinChatMessage"}, (*calls)[sent:])
+
+	var one, two models.Feedback
+	models.DB.Where("message = ?", "week one").First(&one)
+	models.DB.Where("message = ?", "week two").First(&two)
+	assert.False(t, one.Pinned)
+	assert.True(t, two.Pinned)
+}
+
+func TestDigest_FailedSendStaysQueued(t *testing.T) {
+	setupTestDB(t)
+	_, group := createSelfServiceFixture(t, true)
+	enableDigest(t, group, false)
+	models.DB.Create(&models.Feedback{TenantID: group.TenantID, GroupID: group.ID, SenderID: 1, Message: "queued", DigestQueued: true})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok":false,"description":"Forbidden: bot was kicked"}`)
+	}))
+	defer srv.Close()
+	old := apiBaseURL
+	apiBaseURL = srv.URL
+	defer func() { apiBaseURL = old }()
+
+	runDigests(time.Now())
+
+	var fb models.Feedback
+	models.DB.Where("message = ?", "queued").First(&fb)
+	assert.False(t, fb.Posted)
+	assert.True(t, fb.DigestQueued)
+}
+
+func TestDigest_LeavingDigestModePostsQueued(t *testing.T) {
+	setupTestDB(t)
+	fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, true)
+	enableDigest(t, group, false)
+	submitFeedback(bot, 1, 1001, group, "waiting", false, models.FeedbackGeneral, "en")
+
+	models.DB.Model(&models.FeedbackConfig{}).Where("group_id = ?", group.ID).Update("digest_mode", false)
+	runDigests(time.Now())
+
+	var fb models.Feedback
+	models.DB.Where("message = ?", "waiting").First(&fb)
+	assert.True(t, fb.Posted)
+	assert.False(t, fb.DigestQueued)
+	assert.False(t, fb.InDigest)
+}
+
+func TestSplitDigest(t *testing.T) {
+	header := "📋 Feedback digest:"
+	var items []models.Feedback
+	for i := 1; i <= 6; i++ {
+		items = append(items, models.Feedback{Model: gorm.Model{ID: uint(i)}, Message: strings.Repeat("ж", 1500)})
+	}
+	parts := splitDigest(header, items)
+	assert.Len(t, parts, 3)
+	total := 0
+	for _, part := range parts {
+		text := digestText(header, part)
+		assert.LessOrEqual(t, utf16Len(text), maxMessageLen)
+		assert.True(t, strings.HasPrefix(text, header))
+		total += len(part)
+	}
+	assert.Equal(t, 6, total)
+
+	// Emoji count twice towards the limit
+	assert.Equal(t, 2, utf16Len("😀"))
+
+	huge := []models.Feedback{{Message: strings.Repeat("x", 5000)}}
+	parts = splitDigest(header, huge)
+	require.Len(t, parts, 1)
+	text := digestText(header, parts[0])
+	assert.Equal(t, maxMessageLen, utf16Len(text))
+	assert.True(t, strings.HasSuffix(text, "…"))
+}
+
+// fakeSurveyTelegram answers sendPoll and stopPoll with polls and
+// getChatMember with member, recording each method with its form values.
+func fakeSurveyTelegram(t *testing.T, member bool) *[]string {
+	t.Helper()
+	var calls []string
+	var nextID int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		method := path.Base(r.URL.Path)
+		calls = append(calls, method+" "+r.Form.Get("text")+r.Form.Get("question"))
+		nextID++
+		switch method {
+		case "sendPoll":
+			fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d,"poll":{"id":"poll-%d"}}}`, nextID, nextID)
+		case "stopPoll":
+			fmt.Fprint(w, `{"ok":true,"result":{"id":"x","total_voter_count":4,"is_closed":true,"options":[{"text":"1","voter_count":1},{"text":"2","voter_count":3}]}}`)
+		case "getChatMember":
+			status := "left"
+			if member {
+				status = "member"
+			}
+			fmt.Fprintf(w, `{"ok":true,"result":{"status":%q}}`, status)
+		default:
+			fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d}}`, nextID)
+		}
+	}))
+	old := apiBaseURL
+	apiBaseURL = srv.URL
+	t.Cleanup(func() {
+		apiBaseURL = old
+		srv.Close()
+	})
+	return &calls
+}
+
+func createSurvey(t *testing.T, group models.Group, delivery string) models.Survey {
+	t.Helper()
+	s := models.Survey{TenantID: group.TenantID, GroupID: group.ID, Title: "Sprint pulse", Delivery: delivery, OpenHours: 24, Active: true}
+	require.NoError(t, models.DB.Create(&s).Error)
+	models.DB.Create(&models.SurveyQuestion{SurveyID: s.ID, Position: 0, Text: "How was this sprint?", Kind: models.QuestionScale, ScaleMax: 2})
+	models.DB.Create(&models.SurveyQuestion{SurveyID: s.ID, Position: 1, Text: "Ship faster?", Kind: models.QuestionChoice, Options: []string{"Yes", "No", "Unsure"}})
+	return s
+}
+
+func TestSurvey_PollRound(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeSurveyTelegram(t, true)
+	bot, group := createSelfServiceFixture(t, false)
+	s := createSurvey(t, group, models.SurveyDeliveryPoll)
+
+	now := time.Now()
+	round, err := StartRound(s, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sendPoll How was this sprint?", "sendPoll Ship faster?"}, *calls)
+
+	var polls []models.SurveyPoll
+	models.DB.Where("round_id = ?", round.ID).Order("id").Find(&polls)
+	require.Len(t, polls, 2)
+	assert.Equal(t, []int{0, 0, 0}, polls[1].Counts)
+
+	handlePollUpdate(bot, &Poll{ID: polls[1].PollID, TotalVoterCount: 3, Options: []PollOption{{VoterCount: 2}, {VoterCount: 0}, {VoterCount: 1}}})
+	// Another bot's poll with the same ID is ignored
+	other := bot
+	other.ID++
+	handlePollUpdate(other, &Poll{ID: polls[0].PollID, TotalVoterCount: 9, Options: []PollOption{{VoterCount: 9}}})
+
+	models.DB.Where("round_id = ?", round.ID).Order("id").Find(&polls)
+	assert.Equal(t, []int{0, 0}, polls[0].Counts)
+	assert.Equal(t, []int{2, 0, 1}, polls[1].Counts)
+	assert.Equal(t, 3, polls[1].Voters)
+
+	// Closing stops the polls and keeps their final counts
+	runSurveys(now.Add(25 * time.Hour))
+	models.DB.First(&round, round.ID)
+	assert.NotNil(t, round.ClosedAt)
+	models.DB.Where("round_id = ?", round.ID).Order("id").Find(&polls)
+	assert.Equal(t, []int{1, 3}, polls[0].Counts)
+	assert.Contains(t, *calls, "stopPoll ")
+}
+
+func TestSurvey_ScheduledRounds(t *testing.T) {
+	setupTestDB(t)
+	fakeSurveyTelegram(t, true)
+	_, group := createSelfServiceFixture(t, false)
+	s := createSurvey(t, group, models.SurveyDeliveryPoll)
+	due := time.Now().Add(-time.Minute).UTC()
+	models.DB.Model(&s).Updates(map[string]interface{}{"schedule": "0 9 * * 1", "next_run_at": due})
+
+	runSurveys(time.Now())
+
+	var rounds int64
+	models.DB.Model(&models.SurveyRound{}).Where("survey_id = ?", s.ID).Count(&rounds)
+	assert.Equal(t, int64(1), rounds)
+	models.DB.First(&s, s.ID)
+	require.NotNil(t, s.NextRunAt)
+	assert.True(t, s.NextRunAt.After(time.Now()))
+
+	// A one-off survey isn't sent again
+	models.DB.Model(&s).Updates(map[string]interface{}{"schedule": "", "next_run_at": due})
+	runSurveys(time.Now())
+	var sent models.Survey
+	models.DB.First(&sent, s.ID)
+	assert.Nil(t, sent.NextRunAt)
+	models.DB.Model(&models.SurveyRound{}).Where("survey_id = ?", s.ID).Count(&rounds)
+	assert.Equal(t, int64(2), rounds)
+}
+
+func TestSurvey_DMQuestionnaire(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeSurveyTelegram(t, true)
+	bot, group := createSelfServiceFixture(t, false)
+	s := createSurvey(t, group, models.SurveyDeliveryDM)
+	questions := surveyQuestions(s.ID)
+
+	round, err := StartRound(s, time.Now())
+	require.NoError(t, err)
+	assert.NotZero(t, round.AnnounceMessageID)
+	assert.Contains(t, (*calls)[0], "Sprint pulse")
+
+	*calls = nil
+	start := &Message{Chat: Chat{ID: 555, Type: "private"}, From: User{ID: 555}, Text: fmt.Sprintf("/start survey_%d", round.ID)}
+	handlePrivateMessage(bot, start)
+	require.Len(t, *calls, 2)
+	assert.Equal(t, "getChatMember ", (*calls)[0])
+	assert.Contains(t, (*calls)[1], "Question 1 of 2")
+
+	answer := func(q models.SurveyQuestion, choice int) {
+		handleSurveyCallback(bot, &CallbackQuery{From: User{ID: 555}, Message: &Message{MessageID: 1, Chat: Chat{ID: 555}},
+			Data: fmt.Sprintf("sv:%d:%d:%d", round.ID, q.ID, choice)})
+	}
+
+	*calls = nil
+	answer(questions[0], 1)
+	assert.Equal(t, "editMessageReplyMarkup ", (*calls)[0])
+	assert.Contains(t, (*calls)[1], "Question 2 of 2")
+
+	// Changing an answer asks nothing new
+	*calls = nil
+	answer(questions[0], 0)
+	assert.Equal(t, []string{"editMessageReplyMarkup "}, *calls)
+
+	answer(questions[1], 2)
+	assert.Contains(t, (*calls)[len(*calls)-1], i18n.T("en", "survey.thanks"))
+
+	// Out of range choices are ignored
+	answer(questions[1], 3)
+
+	var answers []models.SurveyAnswer
+	models.DB.Where("round_id = ?", round.ID).Order("question_id").Find(&answers)
+	require.Len(t, answers, 2)
+	assert.Equal(t, 0, answers[0].Choice)
+	assert.Equal(t, 2, answers[1].Choice)
+	assert.NotEqual(t, answers[0].RespondentHash, answers[1].RespondentHash)
+
+	// Closed rounds take no answers
+	CloseRound(round, time.Now())
+	*calls = nil
+	answer(questions[1], 0)
+	assert.Equal(t, []string{"sendMessage " + i18n.T("en", "survey.closed")}, *calls)
+}
+
+func TestSurvey_DMRequiresMembership(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeSurveyTelegram(t, false)
+	bot, group := createSelfServiceFixture(t, false)
+	s := createSurvey(t, group, models.SurveyDeliveryDM)
+	round, err := StartRound(s, time.Now())
+	require.NoError(t, err)
+
+	*calls = nil
+	handlePrivateMessage(bot, &Message{Chat: Chat{ID: 556, Type: "private"}, From: User{ID: 556}, Text: fmt.Sprintf("/start survey_%d", round.ID)})
+	assert.Contains(t, (*calls)[len(*calls)-1], i18n.T("en", "survey.not_member", i18n.Args{"group": group.Title}))
+
+	// Poll surveys can't be answered by DM
+	p := createSurvey(t, group, models.SurveyDeliveryPoll)
+	pollRound, err := StartRound(p, time.Now())
+	require.NoError(t, err)
+	*calls = nil
+	handlePrivateMessage(bot, &Message{Chat: Chat{ID: 556, Type: "private"}, From: User{ID: 556}, Text: fmt.Sprintf("/start survey_%d", pollRound.ID)})
+	assert.Equal(t, []string{"sendMessage " + i18n.T("en", "survey.not_found")}, *calls)
+}
+
+func TestRating_AskedAfterFeedback(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, false)
+	models.DB.Model(&models.FeedbackConfig{}).Where("group_id = ?", group.ID).Update("rating_scale", models.RatingENPS)
+
+	submitFeedback(bot, 777, 777, group, "rate me", false, models.FeedbackGeneral, "en")
+	assert.Equal(t, []string{"sendMessage", "sendMessage"}, *calls)
+
+	var fb models.Feedback
+	models.DB.Where("message = ?", "rate me").First(&fb)
+	rate := func(userID int64, data string) {
+		handleRatingCallback(bot, &CallbackQuery{From: User{ID: userID}, Message: &Message{MessageID: 2, Chat: Chat{ID: userID}}, Data: data})
+	}
+
+	// Only the sender can rate, and only on the scale
+	rate(778, fmt.Sprintf("rt:%d:enps:9", fb.ID))
+	rate(777, fmt.Sprintf("rt:%d:enps:11", fb.ID))
+	models.DB.First(&fb, fb.ID)
+	assert.Nil(t, fb.Rating)
+
+	rate(777, fmt.Sprintf("rt:%d:enps:9", fb.ID))
+	models.DB.First(&fb, fb.ID)
+	require.NotNil(t, fb.Rating)
+	assert.Equal(t, 9, *fb.Rating)
+	assert.Equal(t, models.RatingENPS, fb.RatingScale)
+}
+
+func TestRating_NotAskedWhenOff(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegram(t)
+	bot, group := createSelfServiceFixture(t, false)
+
+	submitFeedback(bot, 777, 777, group, "no rating", false, models.FeedbackGeneral, "en")
+	assert.Equal(t, []string{"sendMessage"}, *calls)
+}
+
+func TestRatingKeyboard(t *testing.T) {
+	scale, _ := ratings.Lookup(models.RatingENPS)
+	keyboard := ratingKeyboard(4, models.RatingENPS, scale, "en")
+	require.Len(t, keyboard, 3)
+	assert.Len(t, keyboard[0], 6)
+	assert.Len(t, keyboard[1], 5)
+	assert.Equal(t, "rt:4:enps:10", keyboard[1][4].CallbackData)
+	assert.Equal(t, "rt:4:enps:skip", keyboard[2][0].CallbackData)
+}
+
+func TestCutFeedbackPrefixes(t *testing.T) {
+	cases := []struct {
+		text, rest, feedbackType string
+		adminOnly                bool
+	}{
+		{"plain text", "plain text", models.FeedbackGeneral, false},
+		{"/idea Try pairing", "Try pairing", models.FeedbackIdea, false},
+		{"/Praise\nGreat demo", "Great demo", models.FeedbackPraise, false},
+		{"/adminOnly /issue Broken VPN", "Broken VPN", models.FeedbackIssue, true},
+		{"/question /adminonly Why?", "Why?", models.FeedbackQuestion, true},
+		{"/ideas are welcome", "/ideas are welcome", models.FeedbackGeneral, false},
+		{"/idea /praise twice", "/praise twice", models.FeedbackIdea, false},
+		{"/issue", "", models.FeedbackIssue, false},
+	}
+	for _, tc := range cases {
+		rest, feedbackType, adminOnly := cutFeedbackPrefixes(tc.text)
+		assert.Equal(t, tc.rest, rest, tc.text)
+		assert.Equal(t, tc.feedbackType, feedbackType, tc.text)
+		assert.Equal(t, tc.adminOnly, adminOnly, tc.text)
+	}
+}
+
+func TestFeedbackTypes_AllowedPerGroup(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeSurveyTelegram(t, true)
+	bot, group := createSelfServiceFixture(t, true)
+	models.DB.Model(&models.FeedbackConfig{}).Where("group_id = ?", group.ID).Update("allowed_types", "idea")
+	dm := func(text string) {
+		handlePrivateMessage(bot, &Message{Chat: Chat{ID: 901, Type: "private"}, From: User{ID: 901}, Text: text})
+	}
+	last := func() string { return (*calls)[len(*calls)-1] }
+
+	dm("/praise Great demo")
+	assert.Equal(t, "sendMessage "+i18n.T("en", "feedback.type_not_allowed", i18n.Args{"type": "praise"}), last())
+	dm("/IDEA")
+	assert.Equal(t, "sendMessage "+i18n.T("en", "feedback.type_empty", i18n.Args{"type": "idea"}), last())
+	var count int64
+	models.DB.Model(&models.Feedback{}).Count(&count)
+	assert.Zero(t, count)
+
+	*calls = nil
+	dm("/idea Try pairing")
+	var fb models.Feedback
+	require.NoError(t, models.DB.Where("message = ?", "Try pairing").First(&fb).Error)
+	assert.Equal(t, models.FeedbackIdea, fb.Type)
+	assert.True(t, fb.Posted)
+	assert.Equal(t, "sendMessage "+i18n.T("en", "group.post_header_idea")+"\n\nTry pairing", (*calls)[0])
+
+	// A group without restrictions takes the types the first one refuses
+	other := models.Group{TenantID: group.TenantID, BotID: bot.ID, ChatID: -300222, Title: "Open Group", Type: "supergroup", IsActive: true}
+	models.DB.Create(&other)
+	models.DB.Create(&models.FeedbackConfig{GroupID: other.ID})
+	dm("/praise Great demo")
+	fb = models.Feedback{}
+	require.NoError(t, models.DB.Where("message = ?", "Great demo").First(&fb).Error)
+	assert.Equal(t, other.ID, fb.GroupID)
+	assert.Equal(t, models.FeedbackPraise, fb.Type)
+
+	// With a choice of groups the type is kept while the sender picks one
+	dm("/idea Another one")
+	pending, ok := getPendingFeedback(901)
+	require.True(t, ok)
+	assert.Equal(t, models.FeedbackIdea, pending.Type)
+}
+
+func TestIdeaVotes(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeSurveyTelegram(t, true)
+	bot, group := createSelfServiceFixture(t, true)
+
+	submitFeedback(bot, 12345, 67890, group, "Try pairing", false, models.FeedbackIdea, "en")
+	var fb models.Feedback
+	require.NoError(t, models.DB.Where("message = ?", "Try pairing").First(&fb).Error)
+	require.True(t, fb.Posted)
+	post := &Message{MessageID: fb.PostMessageID, Chat: Chat{ID: group.ChatID}}
+	vote := func(userID int64, data string, msg *Message) {
+		handleCallbackQuery(bot, &CallbackQuery{ID: "cb", From: User{ID: userID}, Message: msg, Data: data})
+	}
+	counts := func() (int, int) {
+		var got models.Feedback
+		models.DB.First(&got, fb.ID)
+		return got.Upvotes, got.Downvotes
+	}
+	up, down := fmt.Sprintf("vt:%d:up", fb.ID), fmt.Sprintf("vt:%d:down", fb.ID)
+
+	*calls = nil
+	vote(1, up, post)
+	vote(2, up, post)
+	vote(3, down, post)
+	u, d := counts()
+	assert.Equal(t, 2, u)
+	assert.Equal(t, 1, d)
+	assert.Equal(t, "editMessageReplyMarkup ", (*calls)[len(*calls)-1])
+
+	// One vote each: pressing again takes it back, the other button changes it
+	vote(1, up, post)
+	vote(2, down, post)
+	u, d = counts()
+	assert.Equal(t, 0, u)
+	assert.Equal(t, 2, d)
+	var votes int64
+	models.DB.Unscoped().Model(&models.IdeaVote{}).Count(&votes)
+	assert.Equal(t, int64(2), votes)
+
+	// Votes only count on the idea's own post
+	vote(4, up, &Message{MessageID: 999, Chat: Chat{ID: group.ChatID}})
+	u, _ = counts()
+	assert.Zero(t, u)
+
+	// General feedback isn't voted on
+	assert.Nil(t, voteKeyboard(models.Feedback{Type: models.FeedbackGeneral}))
+	keyboard := voteKeyboard(models.Feedback{Type: models.FeedbackIdea, Upvotes: 3, Downvotes: 1})
+	require.Len(t, keyboard, 1)
+	assert.Equal(t, "👍 3", keyboard[0][0].Text)
+	assert.Equal(t, "👎 1", keyboard[0][1].Text)
+}
+
+// fakeTelegramForms records the form of every call, with the method under
+// "method", and answers getChatMember with a member or a stranger.
+func fakeTelegramForms(t *testing.T, member bool) *[]url.Values {
+	t.Helper()
+	var calls []url.Values
+	var nextID int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form := r.PostForm
+		form.Set("method", path.Base(r.URL.Path))
+		calls = append(calls, form)
+		nextID++
+		if path.Base(r.URL.Path) == "getChatMember" {
+			status := "left"
+			if member {
+				status = "member"
+			}
+			fmt.Fprintf(w, `{"ok":true,"result":{"status":%q}}`, status)
+			return
+		}
+		fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d}}`, nextID)
+	}))
+	old := apiBaseURL
+	apiBaseURL = srv.URL
+	t.Cleanup(func() {
+		apiBaseURL = old
+		srv.Close()
+	})
+	return &calls
+}
+
+func TestComments_RelayedAsReplies(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegramForms(t, true)
+	bot, group := createSelfServiceFixture(t, true)
+	dm := func(userID int64, text string) {
+		handlePrivateMessage(bot, &Message{Chat: Chat{ID: userID, Type: "private"}, From: User{ID: userID}, Text: text})
+	}
+	last := func() url.Values { return (*calls)[len(*calls)-1] }
+
+	submitFeedback(bot, 12345, 67890, group, "Original", false, models.FeedbackGeneral, "en")
+	var parent models.Feedback
+	require.NoError(t, models.DB.Where("message = ?", "Original").First(&parent).Error)
+	require.True(t, parent.Posted)
+	post := (*calls)[0]
+	assert.Contains(t, post.Get("reply_markup"), fmt.Sprintf("https://t.me/ssbot?start=comment_%d", parent.ID))
+
+	dm(555, fmt.Sprintf("/start comment_%d", parent.ID))
+	assert.Contains(t, last().Get("text"), "Original")
+	dm(555, "I agree")
+
+	var comment models.Feedback
+	require.NoError(t, models.DB.Where("message = ?", "I agree").First(&comment).Error)
+	require.NotNil(t, comment.ParentID)
+	assert.Equal(t, parent.ID, *comment.ParentID)
+	assert.True(t, comment.Posted)
+	assert.NotEqual(t, parent.SenderID, comment.SenderID)
+
+	var reply url.Values
+	for _, c := range *calls {
+		if strings.HasSuffix(c.Get("text"), "I agree") {
+			reply = c
+		}
+	}
+	require.NotNil(t, reply)
+	assert.Equal(t, i18n.T("en", "group.comment_header")+"\n\nI agree", reply.Get("text"))
+	assert.Contains(t, reply.Get("reply_parameters"), fmt.Sprintf(`"message_id":%d`, parent.PostMessageID))
+	assert.Empty(t, reply.Get("reply_markup"))
+	assert.True(t, strings.HasPrefix(last().Get("text"), i18n.T("en", "comment.sent")))
+
+	// The prompt is used up: the next message is feedback again
+	dm(555, "Something else")
+	var next models.Feedback
+	require.NoError(t, models.DB.Where("message = ?", "Something else").First(&next).Error)
+	assert.Nil(t, next.ParentID)
+
+	// Comments can't be commented on, and /cancel drops a prompt
+	dm(556, fmt.Sprintf("/start comment_%d", comment.ID))
+	assert.Equal(t, i18n.T("en", "comment.not_found"), last().Get("text"))
+	dm(556, fmt.Sprintf("/start comment_%d", parent.ID))
+	dm(556, "/cancel")
+	assert.Equal(t, i18n.T("en", "comment.cancelled"), last().Get("text"))
+	_, ok := pendingComment(bot, 556)
+	assert.False(t, ok)
+}
+
+func TestComments_ModeratedAndMembersOnly(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegramForms(t, false)
+	bot, group := createSelfServiceFixture(t, true)
+	submitFeedback(bot, 12345, 67890, group, "Original", false, models.FeedbackGeneral, "en")
+	var parent models.Feedback
+	models.DB.Where("message = ?", "Original").First(&parent)
+	dm := func(text string) {
+		handlePrivateMessage(bot, &Message{Chat: Chat{ID: 555, Type: "private"}, From: User{ID: 555}, Text: text})
+	}
+
+	dm(fmt.Sprintf("/start comment_%d", parent.ID))
+	assert.Equal(t, i18n.T("en", "comment.not_member", i18n.Args{"group": group.Title}), (*calls)[len(*calls)-1].Get("text"))
+
+	// Members' comments wait for approval when the group requires it, and
+	// are never held for a digest
+	fakeTelegramForms(t, true)
+	models.DB.Model(&models.FeedbackConfig{}).Where("group_id = ?", group.ID).
+		Updates(map[string]interface{}{"require_approval": true, "digest_mode": true})
+	dm(fmt.Sprintf("/start comment_%d", parent.ID))
+	dm("Needs a look")
+	var comment models.Feedback
+	require.NoError(t, models.DB.Where("message = ?", "Needs a look").First(&comment).Error)
+	assert.Equal(t, models.ModerationPending, comment.Moderation)
+
+	require.NoError(t, ApproveFeedback(&comment))
+	models.DB.First(&comment, comment.ID)
+	assert.True(t, comment.Posted)
+	assert.False(t, comment.DigestQueued)
+}
+
+func TestPublishResponse_ReplyOrQuote(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegramForms(t, true)
+	bot, group := createSelfServiceFixture(t, true)
+	last := func() url.Values { return (*calls)[len(*calls)-1] }
+	header := i18n.T("en", "group.response_header")
+
+	submitFeedback(bot, 12345, 67890, group, "Fix the coffee machine", false, models.FeedbackIssue, "en")
+	var posted models.Feedback
+	require.NoError(t, models.DB.Where("message = ?", "Fix the coffee machine").First(&posted).Error)
+	require.True(t, posted.Posted)
+
+	r := models.PublicResponse{Message: "Replaced it on Monday"}
+	require.NoError(t, PublishResponse(&posted, &r))
+	assert.Equal(t, "sendMessage", last().Get("method"))
+	assert.Equal(t, header+"\n\nReplaced it on Monday", last().Get("text"))
+	assert.Contains(t, last().Get("reply_parameters"), fmt.Sprintf(`"message_id":%d`, posted.PostMessageID))
+	assert.Equal(t, posted.PostChatID, r.PostChatID)
+	assert.NotZero(t, r.PostMessageID)
+
+	// Never posted: a standalone message quoting the feedback
+	unposted := models.Feedback{TenantID: group.TenantID, GroupID: group.ID, Message: "More plants please"}
+	models.DB.Create(&unposted)
+	r = models.PublicResponse{Message: "Ordered ten"}
+	require.NoError(t, PublishResponse(&unposted, &r))
+	quote := i18n.T("en", "group.response_quote", i18n.Args{"message": "More plants please"})
+	assert.Equal(t, header+"\n\n"+quote+"\n\nOrdered ten", last().Get("text"))
+	assert.Empty(t, last().Get("reply_parameters"))
+	assert.Equal(t, fmt.Sprintf("%d", group.ChatID), last().Get("chat_id"))
+	assert.Equal(t, group.ChatID, r.PostChatID)
+}
+
+func TestRetro_CollectRevealAndDots(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegramForms(t, true)
+	bot, group := createSelfServiceFixture(t, true)
+	dm := func(userID int64, text string) {
+		handlePrivateMessage(bot, &Message{Chat: Chat{ID: userID, Type: "private"}, From: User{ID: userID}, Text: text})
+	}
+	press := func(userID int64, data string, msg *Message) {
+		handleCallbackQuery(bot, &CallbackQuery{ID: "cb", From: User{ID: userID}, Message: msg, Data: data})
+	}
+	last := func() url.Values { return (*calls)[len(*calls)-1] }
+	groupPosts := func() []url.Values {
+		var posts []url.Values
+		for _, c := range *calls {
+			if c.Get("method") == "sendMessage" && c.Get("chat_id") == fmt.Sprintf("%d", group.ChatID) {
+				posts = append(posts, c)
+			}
+		}
+		return posts
+	}
+
+	now := time.Now()
+	s := models.RetroSession{
+		TenantID: group.TenantID, GroupID: group.ID, Title: "Sprint 12",
+		Columns:  []string{"Went well", "To improve"},
+		StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour),
+		DotVoting: true, DotsPerMember: 1,
+	}
+	require.NoError(t, models.DB.Create(&s).Error)
+
+	runRetros(now)
+	require.Len(t, groupPosts(), 1)
+	assert.Contains(t, groupPosts()[0].Get("text"), "Sprint 12")
+	assert.Contains(t, groupPosts()[0].Get("reply_markup"), "https://t.me/ssbot")
+
+	// DMs are notes for the retro, once a column is picked
+	dm(555, "Pairing helped")
+	assert.Contains(t, last().Get("reply_markup"), fmt.Sprintf("ro:%d:0", s.ID))
+	press(555, fmt.Sprintf("ro:%d:0", s.ID), &Message{Chat: Chat{ID: 555}})
+	assert.True(t, strings.HasPrefix(last().Get("text"), "✅"))
+	dm(556, "Flaky CI")
+	press(556, fmt.Sprintf("ro:%d:1", s.ID), &Message{Chat: Chat{ID: 556}})
+	dm(557, "Long standups")
+	press(557, fmt.Sprintf("ro:%d:1", s.ID), &Message{Chat: Chat{ID: 557}})
+
+	var feedbacks, items int64
+	models.DB.Model(&models.Feedback{}).Count(&feedbacks)
+	models.DB.Model(&models.RetroItem{}).Count(&items)
+	assert.Zero(t, feedbacks)
+	assert.Equal(t, int64(3), items)
+	assert.Len(t, groupPosts(), 1, "nothing is posted while the retro is open")
+
+	// Typed feedback still goes through as usual
+	dm(555, "/idea Quarterly hackathon")
+	models.DB.Model(&models.Feedback{}).Count(&feedbacks)
+	assert.Equal(t, int64(1), feedbacks)
+
+	runRetros(now.Add(2 * time.Hour))
+	require.NoError(t, models.DB.First(&s, s.ID).Error)
+	require.NotNil(t, s.ClosedAt)
+	posts := groupPosts()
+	require.Len(t, posts, 2+3, "feedback post, then the header and one message per column")
+	assert.Contains(t, posts[2].Get("text"), "Sprint 12")
+	assert.Contains(t, posts[3].Get("text"), "1. Pairing helped")
+	assert.Contains(t, posts[4].Get("text"), "Flaky CI")
+	assert.Contains(t, posts[4].Get("text"), "Long standups")
+
+	var pairing, flaky models.RetroItem
+	models.DB.Where("message = ?", "Pairing helped").First(&pairing)
+	models.DB.Where("message = ?", "Flaky CI").First(&flaky)
+	assert.Equal(t, 1, pairing.Position)
+	assert.Contains(t, posts[3].Get("reply_markup"), fmt.Sprintf("rd:%d", pairing.ID))
+	pairingMsg := &Message{MessageID: pairing.PostMessageID, Chat: Chat{ID: group.ChatID}}
+	flakyMsg := &Message{MessageID: flaky.PostMessageID, Chat: Chat{ID: group.ChatID}}
+	dots := func(item models.RetroItem) int {
+		models.DB.First(&item, item.ID)
+		return item.Dots
+	}
+
+	press(1, fmt.Sprintf("rd:%d", pairing.ID), pairingMsg)
+	press(2, fmt.Sprintf("rd:%d", pairing.ID), pairingMsg)
+	assert.Equal(t, 2, dots(pairing))
+	assert.Equal(t, "editMessageReplyMarkup", last().Get("method"))
+	assert.Contains(t, last().Get("reply_markup"), "●2")
+
+	// One dot each here: a second one is ignored, pressing again takes it back
+	press(1, fmt.Sprintf("rd:%d", flaky.ID), flakyMsg)
+	assert.Equal(t, 0, dots(flaky))
+	press(1, fmt.Sprintf("rd:%d", pairing.ID), pairingMsg)
+	press(1, fmt.Sprintf("rd:%d", flaky.ID), flakyMsg)
+	assert.Equal(t, 1, dots(pairing))
+	assert.Equal(t, 1, dots(flaky))
+
+	// Dots only count on the note's own message
+	press(3, fmt.Sprintf("rd:%d", pairing.ID), flakyMsg)
+	assert.Equal(t, 1, dots(pairing))
+
+	// Once closed, DMs are feedback again
+	dm(558, "After the retro")
+	models.DB.Model(&models.Feedback{}).Count(&feedbacks)
+	assert.Equal(t, int64(2), feedbacks)
+}
+
+func TestSplitRetroColumn(t *testing.T) {
+	long := strings.Repeat("x", 1500)
+	var items []models.RetroItem
+	for i := 1; i <= 5; i++ {
+		items = append(items, models.RetroItem{Position: i, Message: long})
+	}
+	parts := splitRetroColumn("Went well", items)
+	require.Len(t, parts, 3)
+	assert.Len(t, parts[0], 2)
+	assert.Len(t, parts[2], 1)
+
+	var many []models.RetroItem
+	for i := 1; i <= maxRetroItemsPerMessage+1; i++ {
+		many = append(many, models.RetroItem{Position: i, Message: "ok"})
+	}
+	parts = splitRetroColumn("Went well", many)
+	require.Len(t, parts, 2)
+	assert.Len(t, parts[0], maxRetroItemsPerMessage)
+
+	assert.Len(t, splitRetroColumn("Went well", nil), 1)
+}
+
+func TestRetro_RevealRetried(t *testing.T) {
+	setupTestDB(t)
+	calls := fakeTelegramForms(t, true)
+	_, group := createSelfServiceFixture(t, true)
+	now := time.Now()
+	s := models.RetroSession{
+		TenantID: group.TenantID, GroupID: group.ID, Title: "Sprint 13", Columns: []string{"Went well"},
+		StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Minute), AnnouncedAt: &now, DotsPerMember: 3,
+	}
+	require.NoError(t, models.DB.Create(&s).Error)
+	models.DB.Create(&models.RetroItem{TenantID: s.TenantID, SessionID: s.ID, Message: "Pairing"})
+	reload := func() models.RetroSession {
+		var got models.RetroSession
+		require.NoError(t, models.DB.First(&got, s.ID).Error)
+		return got
+	}
+
+	// The bot left the group: closed, but nothing to post to
+	models.DB.Model(&group).Update("is_active", false)
+	runRetros(now)
+	got := reload()
+	require.NotNil(t, got.ClosedAt)
+	assert.Nil(t, got.RevealedAt)
+	assert.Empty(t, *calls)
+
+	// Back in the group, but Telegram fails the header
+	models.DB.Model(&group).Update("is_active", true)
+	old := apiBaseURL
+	apiBaseURL = "http://127.0.0.1:0"
+	runRetros(now)
+	apiBaseURL = old
+	assert.Nil(t, reload().RevealedAt)
+
+	runRetros(now)
+	got = reload()
+	require.NotNil(t, got.RevealedAt)
+	require.Len(t, *calls, 2, "the header and the column")
+	assert.Contains(t, (*calls)[1].Get("text"), "1. Pairing")
+
+	runRetros(now)
+	assert.Len(t, *calls, 2, "revealed once")
+}
@@ -0,0 +1,113 @@
+package tgbot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/identity"
+	"gorm.io/gorm"
+)
+
+// Votes of the "vt:<feedback>:<up|down>" buttons under posted ideas.
+const (
+	voteUp   = "up"
+	voteDown = "down"
+)
+
+// voteKeyboard returns the vote buttons for the group post of fb, with the
+// current counts, or nil when fb isn't an idea.
+func voteKeyboard(fb models.Feedback) [][]inlineButton {
+	if fb.Type != models.FeedbackIdea {
+		return nil
+	}
+	return [][]inlineButton{{
+		{Text: fmt.Sprintf("👍 %d", fb.Upvotes), CallbackData: fmt.Sprintf("vt:%d:%s", fb.ID, voteUp)},
+		{Text: fmt.Sprintf("👎 %d", fb.Downvotes), CallbackData: fmt.Sprintf("vt:%d:%s", fb.ID, voteDown)},
+	}}
+}
+
+func voterHash(feedbackID uint, pseudonym string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("vote:%d:%s", feedbackID, pseudonym)))
+	return hex.EncodeToString(sum[:])
+}
+
+// handleVoteCallback records a vote pressed under a posted idea. Each member
+// has one vote per idea: pressing the same button again takes it back, and
+// pressing the other one changes it. The counts on the buttons are updated.
+func handleVoteCallback(bot models.Bot, cq *CallbackQuery) {
+	parts := strings.Split(strings.TrimPrefix(cq.Data, "vt:"), ":")
+	if len(parts) != 2 || cq.Message == nil {
+		return
+	}
+	feedbackID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil || (parts[1] != voteUp && parts[1] != voteDown) {
+		log.Printf("[tgbot] Invalid vote callback data: %s", cq.Data)
+		return
+	}
+	value := 1
+	if parts[1] == voteDown {
+		value = -1
+	}
+
+	// Only the idea's own post takes votes
+	var fb models.Feedback
+	if err := models.DB.Where("type = ? AND posted = ? AND in_digest = ? AND post_chat_id = ? AND post_message_id = ?",
+		models.FeedbackIdea, true, false, cq.Message.Chat.ID, cq.Message.MessageID).
+		Where("group_id IN (SELECT id FROM groups WHERE bot_id = ?)", bot.ID).
+		First(&fb, feedbackID).Error; err != nil {
+		return
+	}
+
+	var hashes []string
+	for _, p := range identity.Pseudonyms(cq.From.ID) {
+		hashes = append(hashes, voterHash(fb.ID, p))
+	}
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		var vote models.IdeaVote
+		switch err := tx.Where("feedback_id = ? AND voter_hash IN ?", fb.ID, hashes).First(&vote).Error; {
+		case err == gorm.ErrRecordNotFound:
+			vote = models.IdeaVote{TenantID: fb.TenantID, FeedbackID: fb.ID, VoterHash: hashes[0], Value: value}
+			if err := tx.Create(&vote).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case vote.Value == value:
+			if err := tx.Unscoped().Delete(&vote).Error; err != nil {
+				return err
+			}
+		default:
+			if err := tx.Model(&vote).Updates(map[string]interface{}{"value": value, "voter_hash": hashes[0]}).Error; err != nil {
+				return err
+			}
+		}
+		return countVotes(tx, &fb)
+	})
+	if err != nil {
+		log.Printf("[tgbot] Failed to record vote on feedback %d: %v", fb.ID, err)
+		return
+	}
+
+	keyboard, _ := json.Marshal(map[string]interface{}{"inline_keyboard": postKeyboard(bot, fb)})
+	callAPI(bot.Token, "editMessageReplyMarkup", url.Values{
+		"chat_id":      {fmt.Sprintf("%d", fb.PostChatID)},
+		"message_id":   {fmt.Sprintf("%d", fb.PostMessageID)},
+		"reply_markup": {string(keyboard)},
+	})
+}
+
+// countVotes stores the up and down votes of fb on it.
+func countVotes(tx *gorm.DB, fb *models.Feedback) error {
+	var up, down int64
+	tx.Model(&models.IdeaVote{}).Where("feedback_id = ? AND value > 0", fb.ID).Count(&up)
+	tx.Model(&models.IdeaVote{}).Where("feedback_id = ? AND value < 0", fb.ID).Count(&down)
+	fb.Upvotes, fb.Downvotes = int(up), int(down)
+	return tx.Model(fb).Updates(map[string]interface{}{"upvotes": fb.Upvotes, "downvotes": fb.Downvotes}).Error
+}
@@ -6,7 +6,17 @@ import (
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/auth"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_feedback"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_group"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_import"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_moderation"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_notification"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_rating"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_report"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_retention"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_retro"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_survey"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_template"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_tenant"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_tenantdata"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -25,6 +35,16 @@ func setRoutes(router *gin.Engine) {
 	tenants := router.Group("/tenants", auth.Auth)
 	tenants.POST("", svc_tenant.CreateTenant)
 	tenants.GET("/:id", svc_tenant.GetTenant)
+	tenants.PATCH("/:id", services.TenantMiddleware, svc_tenant.UpdateTenant)
+
+	// The caller's own tenant
+	tenant := router.Group("/tenant", auth.Auth, services.TenantMiddleware)
+	tenant.POST("/export", svc_tenantdata.StartExport)
+	tenant.GET("/exports", svc_tenantdata.GetExports)
+	tenant.GET("/exports/:id", svc_tenantdata.GetExport)
+	tenant.GET("/exports/:id/download", svc_tenantdata.DownloadExport)
+	tenant.POST("/delete", svc_tenantdata.RequestDeletion)
+	tenant.POST("/delete/cancel", svc_tenantdata.CancelDeletion)
 
 	bots := router.Group("/bots", auth.Auth, services.TenantMiddleware)
 	bots.GET("", svc_tenant.GetBots)
@@ -40,7 +60,83 @@ func setRoutes(router *gin.Engine) {
 
 	feedbacks := router.Group("/feedbacks", auth.Auth, services.TenantMiddleware)
 	feedbacks.GET("", svc_feedback.GetFeedbacks)
-	feedbacks.GET("/export", svc_feedback.ExportCSV)
+	feedbacks.GET("/export", svc_feedback.Export)
+	feedbacks.GET("/ideas", svc_feedback.GetIdeas)
+	feedbacks.PATCH("/:id", svc_feedback.UpdateFeedback)
+	feedbacks.POST("/:id/unpost", svc_feedback.UnpostFeedback)
+	feedbacks.POST("/:id/pin", svc_feedback.PinFeedback)
+	feedbacks.POST("/:id/unpin", svc_feedback.UnpinFeedback)
+	feedbacks.POST("/:id/response", svc_feedback.PublishResponse)
+
+	// "You said, we did" changelog
+	router.GET("/responses", auth.Auth, services.TenantMiddleware, svc_feedback.GetResponses)
+
+	// Downloads are authorized by the signed link instead of a token
+	router.GET("/exports/:id/download", svc_feedback.DownloadExport)
+	exports := router.Group("/exports", auth.Auth, services.TenantMiddleware)
+	exports.GET("", svc_feedback.GetExports)
+	exports.POST("", svc_feedback.StartExport)
+	exports.GET("/:id", svc_feedback.GetExport)
+
+	imports := router.Group("/imports", auth.Auth, services.TenantMiddleware)
+	imports.GET("", svc_import.GetImports)
+	imports.POST("", svc_import.CreateImport)
+	imports.GET("/:id", svc_import.GetImport)
+	imports.POST("/:id/resume", svc_import.ResumeImport)
+
+	moderation := router.Group("/moderation", auth.Auth, services.TenantMiddleware)
+	moderation.GET("", svc_moderation.GetQueue)
+	moderation.PATCH("/:id", svc_moderation.UpdatePending)
+	moderation.POST("/:id/approve", svc_moderation.Approve)
+	moderation.POST("/:id/reject", svc_moderation.Reject)
+
+	notifications := router.Group("/notifications", auth.Auth, services.TenantMiddleware)
+	notifications.GET("", svc_notification.GetNotifications)
+	notifications.POST("/read", svc_notification.MarkAllRead)
+	notifications.POST("/:id/read", svc_notification.MarkRead)
+
+	ratings := router.Group("/ratings", auth.Auth, services.TenantMiddleware)
+	ratings.GET("/summary", svc_rating.Summary)
+	ratings.GET("/trend", svc_rating.Trend)
+
+	reports := router.Group("/reports", auth.Auth, services.TenantMiddleware)
+	reports.GET("/preview", svc_report.Preview)
+	reports.GET("/schedules", svc_report.GetSchedules)
+	reports.POST("/schedules", svc_report.CreateSchedule)
+	reports.PATCH("/schedules/:id", svc_report.UpdateSchedule)
+	reports.DELETE("/schedules/:id", svc_report.DeleteSchedule)
+	reports.POST("/schedules/:id/send", svc_report.SendNow)
+
+	retention := router.Group("/retention", auth.Auth, services.TenantMiddleware)
+	retention.GET("/preview", svc_retention.Preview)
+	retention.GET("/logs", svc_retention.GetPurgeLogs)
+
+	surveys := router.Group("/surveys", auth.Auth, services.TenantMiddleware)
+	surveys.GET("", svc_survey.GetSurveys)
+	surveys.POST("", svc_survey.CreateSurvey)
+	surveys.GET("/:id", svc_survey.GetSurvey)
+	surveys.PATCH("/:id", svc_survey.UpdateSurvey)
+	surveys.DELETE("/:id", svc_survey.DeleteSurvey)
+	surveys.POST("/:id/send", svc_survey.SendSurvey)
+	surveys.GET("/:id/results", svc_survey.GetResults)
+
+	retros := router.Group("/retros", auth.Auth, services.TenantMiddleware)
+	retros.GET("", svc_retro.GetRetros)
+	retros.POST("", svc_retro.CreateRetro)
+	retros.GET("/:id", svc_retro.GetRetro)
+	retros.PATCH("/:id", svc_retro.UpdateRetro)
+	retros.DELETE("/:id", svc_retro.DeleteRetro)
+	retros.POST("/:id/close", svc_retro.CloseRetro)
+	retros.GET("/:id/export", svc_retro.ExportRetro)
+
+	templates := router.Group("/templates", auth.Auth, services.TenantMiddleware)
+	templates.GET("", svc_template.GetTemplates)
+	templates.GET("/defaults", svc_template.GetDefaults)
+	templates.POST("", svc_template.CreateTemplate)
+	templates.POST("/preview", svc_template.PreviewTemplate)
+	templates.GET("/:id", svc_template.GetTemplate)
+	templates.PATCH("/:id", svc_template.UpdateTemplate)
+	templates.DELETE("/:id", svc_template.DeleteTemplate)
 }
 
 func Listen() {
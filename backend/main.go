@@ -2,10 +2,17 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/config"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/db/models"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/importer"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/mailer"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/reports"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/retention"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/services/svc_feedback"
+	"github.com/Lavina-Tech-LLC/feedbackbot/internal/tenantdata"
 	"github.com/Lavina-Tech-LLC/feedbackbot/internal/tgbot"
 	webServer "github.com/Lavina-Tech-LLC/feedbackbot/internal/webserver"
 	lvn "github.com/Lavina-Tech-LLC/lavinagopackage/v2"
@@ -13,19 +20,51 @@ import (
 
 func main() {
 	config.Init()
+	if err := db.ConfigureKeys(); err != nil {
+		log.Fatalf("[main] %v", err)
+	}
 	db.Init()
+	if err := mailer.Configure(config.Confs.Mail); err != nil {
+		log.Fatalf("[main] %v", err)
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "rotate-identity-keys":
+			if err := db.RotateIdentityKeys(); err != nil {
+				log.Fatalf("[main] Identity key rotation failed: %v", err)
+			}
+		case "rotate-bot-token-keys":
+			if err := db.RotateBotTokenKeys(); err != nil {
+				log.Fatalf("[main] Bot token key rotation failed: %v", err)
+			}
+		default:
+			log.Fatalf("[main] Unknown command %q", os.Args[1])
+		}
+		return
+	}
 
 	go webServer.Listen()
 
 	// Start polling for all verified bots
 	var bots []models.Bot
-	models.DB.Where("verified = ?", true).Find(&bots)
+	models.DB.Where("verified = ? AND tenant_id NOT IN (SELECT id FROM tenants WHERE deletion_scheduled_at IS NOT NULL)", true).Find(&bots)
 	for _, bot := range bots {
 		log.Printf("[main] Starting bot polling for @%s", bot.BotUsername)
 		go tgbot.StartPolling(bot)
 	}
 
+	go tgbot.StartScheduler()
+	// Resumed before the retention sweep, which fails stale exports
+	svc_feedback.ResumeExports()
+	tenantdata.ResumeExports()
+	go retention.Start()
+	go reports.Start()
+	importer.ResumeAll()
+
 	lvn.WaitExitSignal()
-	log.Println("[main] Shutting down bot polling...")
+	log.Println("[main] Shutting down bot polling and scheduler...")
 	tgbot.StopAll()
+	retention.Stop()
+	reports.Stop()
 }
@@ -17,7 +17,7 @@ import {
 } from '@mantine/core';
 import { useDebouncedValue } from '@mantine/hooks';
 import { DatePickerInput } from '@mantine/dates';
-import { IconLock, IconWorld, IconCalendar, IconSearch, IconDownload } from '@tabler/icons-react';
+import { IconLock, IconWorld, IconCalendar, IconSearch, IconDownload, IconKey } from '@tabler/icons-react';
 import { useGetFeedbacks, getExportCsvUrl } from '@/service/feedback';
 import { useGetGroups } from '@/service/group';
 import type { Feedback } from '@/types';
@@ -156,7 +156,16 @@ export function FeedbacksPage() {
                   {new Date(fb.createdAt).toLocaleString()}
                 </Text>
               </Group>
-              <Text>{fb.message}</Text>
+              {fb.sealed ? (
+                <Group gap="xs">
+                  <IconKey size={16} />
+                  <Text c="dimmed" fs="italic">
+                    {t('feedbacks.sealed')}
+                  </Text>
+                </Group>
+              ) : (
+                <Text>{fb.message}</Text>
+              )}
             </Card>
           ))}
 
@@ -41,6 +41,7 @@ export interface Feedback {
   group_id: number;
   message: string;
   admin_only: boolean;
+  sealed: boolean;
   posted: boolean;
   createdAt: string;
 }